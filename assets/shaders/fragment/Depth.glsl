@@ -0,0 +1,21 @@
+#version 120
+
+// Writes the depth from Depth.glsl, packed into RGBA as well when the
+// driver can't draw into depth textures
+
+varying float v_depth;
+
+uniform int uShadowPacked;
+
+void main() {
+  // 1.0 exactly would pack to 0
+  float depth = clamp(v_depth, 0.0, 0.9999999);
+  gl_FragDepth = depth;
+  if (uShadowPacked != 0) {
+    vec4 packed = fract(depth * vec4(1.0, 255.0, 65025.0, 16581375.0));
+    packed -= packed.yzww * vec4(1.0 / 255.0, 1.0 / 255.0, 1.0 / 255.0, 0.0);
+    gl_FragColor = packed;
+  } else {
+    gl_FragColor = vec4(1.0);
+  }
+}
@@ -0,0 +1,228 @@
+#version 120
+
+// Blinn-Phong lighting. render.Shade does the same on the CPU, so keep
+// the two in step
+
+#define MAX_LIGHTS 8
+#define MAX_SHADOWS 4
+#define MAX_CASCADES 4
+
+#define LIGHT_DIRECTIONAL 1
+#define LIGHT_POINT 2
+#define LIGHT_SPOT 3
+
+#define ILLUM_COLOR_ON_AMBIENT_OFF 0
+#define ILLUM_HIGHLIGHT_ON 2
+#define ILLUM_CASTS_SHADOWS 10
+
+varying vec3 v_color;
+varying vec2 v_texcoord;
+varying vec3 v_normal;
+varying vec3 v_position;
+varying vec3 v_tangent;
+varying float v_depth;
+
+uniform vec3 uEye;                        // camera position
+uniform vec3 uAmbient;                    // sum of the ambient lights
+uniform int uLightCount;
+uniform int uLightType[MAX_LIGHTS];
+uniform vec3 uLightPosition[MAX_LIGHTS];
+uniform vec3 uLightDirection[MAX_LIGHTS];
+uniform vec3 uLightColor[MAX_LIGHTS];     // color * intensity
+uniform float uLightRange[MAX_LIGHTS];    // 0 for no limit
+uniform vec2 uLightCone[MAX_LIGHTS];      // cos inner, cos outer
+
+uniform vec3 uAmbientColor;               // Ka
+uniform vec3 uDiffuseColor;               // Kd
+uniform vec3 uSpecularColor;              // Ks
+uniform float uShininess;                 // Ns
+uniform float uAlpha;                     // d
+uniform int uIllum;
+uniform float uBumpScale;                 // -bm
+uniform vec2 uTextureOrigin;              // -o
+uniform vec2 uTextureScale;               // -s
+
+// Missing maps are bound to white (or a flat normal for uBumpMap)
+uniform sampler2D uAmbientMap;            // map_Ka
+uniform sampler2D uDiffuseMap;            // map_Kd
+uniform sampler2D uSpecularMap;           // map_Ks
+uniform sampler2D uShininessMap;          // map_Ns
+uniform sampler2D uAlphaMap;              // map_d
+uniform sampler2D uBumpMap;               // map_bump, tangent space normals
+
+// Shadow maps, see render.ShadowMap. A directional light's cascades sit
+// side by side in its map
+uniform int uLightShadow[MAX_LIGHTS];     // shadow map, -1 for none
+uniform int uShadowPacked;                // depth packed into RGBA
+uniform int uShadowCascades[MAX_SHADOWS];
+uniform vec3 uShadowSettings[MAX_SHADOWS];               // size, bias, normal bias
+uniform mat4 uShadowMatrix[MAX_SHADOWS * MAX_CASCADES];  // world to light clip space
+uniform vec4 uShadowCascade[MAX_SHADOWS * MAX_CASCADES]; // near, far, split, texel
+uniform sampler2D uShadowMap0;
+uniform sampler2D uShadowMap1;
+uniform sampler2D uShadowMap2;
+uniform sampler2D uShadowMap3;
+
+// shadowDepth the depth in shadow map s at uv
+float shadowDepth(int s, vec2 uv) {
+  vec4 texel;
+  if (s == 0) {
+    texel = texture2D(uShadowMap0, uv);
+  } else if (s == 1) {
+    texel = texture2D(uShadowMap1, uv);
+  } else if (s == 2) {
+    texel = texture2D(uShadowMap2, uv);
+  } else {
+    texel = texture2D(uShadowMap3, uv);
+  }
+  if (uShadowPacked != 0) {
+    return dot(texel, vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+  }
+  return texel.r;
+}
+
+// visibility how much of light i (shining along l) reaches the point, 0
+// in full shadow to 1, from a 3x3 block of shadow map texels so the
+// edges are soft. render.Light.visibility does the same on the CPU
+float visibility(int i, vec3 n, vec3 l) {
+  int s = uLightShadow[i];
+  if (s < 0) {
+    return 1.0;
+  }
+  int count = uShadowCascades[s];
+  int c = -1;
+  for (int k = MAX_CASCADES - 1; k >= 0; k--) {
+    if (k < count && v_depth <= uShadowCascade[s * MAX_CASCADES + k].z) {
+      c = k;
+    }
+  }
+  if (c < 0) {
+    return 1.0;
+  }
+  int tile = s * MAX_CASCADES + c;
+  vec4 cascade = uShadowCascade[tile];
+  vec3 settings = uShadowSettings[s];
+  bool perspective = uLightType[i] == LIGHT_SPOT;
+
+  // Pushed out along the normal by about a texel, more where the surface
+  // is edge on to the light
+  float texel = cascade.w;
+  if (perspective) {
+    texel *= distance(uLightPosition[i], v_position);
+  }
+  vec3 p = v_position + n * settings.z * texel * (1.0 - max(0.0, dot(n, l)) * 0.5);
+
+  vec4 clip = uShadowMatrix[tile] * vec4(p, 1.0);
+  vec2 uv = clip.xy / clip.w * 0.5 + 0.5;
+  float depth = clip.z / clip.w * 0.5 + 0.5;
+  if (perspective) {
+    depth = (clip.w - cascade.x) / (cascade.y - cascade.x);
+  }
+  if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0 || depth > 1.0) {
+    return 1.0;
+  }
+  float bias = settings.y / (cascade.y - cascade.x);
+  float size = settings.x;
+  float lit = 0.0;
+  for (int y = -1; y <= 1; y++) {
+    for (int x = -1; x <= 1; x++) {
+      // Texel centers, kept inside the cascade
+      vec2 t = clamp(floor(uv * size) + vec2(float(x), float(y)), 0.0, size - 1.0) + 0.5;
+      vec2 at = vec2((float(c) * size + t.x) / (size * float(count)), t.y / size);
+      if (depth - bias <= shadowDepth(s, at)) {
+        lit += 1.0;
+      }
+    }
+  }
+  return lit / 9.0;
+}
+
+// incoming how much of light i reaches the point (range and cone
+// falloff), and l the direction towards it
+float incoming(int i, out vec3 l) {
+  if (uLightType[i] == LIGHT_DIRECTIONAL) {
+    l = -uLightDirection[i];
+    return 1.0;
+  }
+  vec3 toLight = uLightPosition[i] - v_position;
+  l = normalize(toLight);
+  float strength = 1.0;
+  if (uLightRange[i] > 0.0) {
+    float f = max(0.0, 1.0 - length(toLight) / uLightRange[i]);
+    strength = f * f;
+  }
+  if (uLightType[i] == LIGHT_SPOT) {
+    float cosAngle = dot(-l, uLightDirection[i]);
+    strength *= smoothstep(uLightCone[i].y, uLightCone[i].x, cosAngle);
+  }
+  return strength;
+}
+
+// bumped the surface normal tilted by the bump map
+vec3 bumped(vec3 n, vec2 uv) {
+  if (dot(v_tangent, v_tangent) < 1e-8) {
+    return n;
+  }
+  vec3 t = normalize(v_tangent - n * dot(n, v_tangent));
+  vec3 b = cross(n, t);
+  vec3 m = texture2D(uBumpMap, uv).xyz * 2.0 - 1.0;
+  m.xy *= uBumpScale;
+  return normalize(t * m.x + b * m.y + n * m.z);
+}
+
+void main() {
+  vec2 uv = v_texcoord * uTextureScale + uTextureOrigin;
+  vec3 albedo = v_color * uDiffuseColor * texture2D(uDiffuseMap, uv).rgb;
+  float alpha = uAlpha * texture2D(uAlphaMap, uv).r;
+  if (uIllum == ILLUM_COLOR_ON_AMBIENT_OFF) {
+    gl_FragColor = vec4(albedo, alpha);
+    return;
+  }
+  if (uIllum == ILLUM_CASTS_SHADOWS) {
+    // Invisible apart from the shadows falling on it
+    float shade = 0.0;
+    for (int i = 0; i < MAX_LIGHTS; i++) {
+      if (i >= uLightCount) {
+        break;
+      }
+      if (uLightShadow[i] >= 0) {
+        vec3 l;
+        float strength = min(incoming(i, l), 1.0);
+        shade = max(shade, (1.0 - visibility(i, normalize(v_normal), l)) * strength);
+      }
+    }
+    gl_FragColor = vec4(0.0, 0.0, 0.0, alpha * shade);
+    return;
+  }
+
+  vec3 ambient = v_color * uAmbientColor * texture2D(uAmbientMap, uv).rgb;
+  vec3 specular = uSpecularColor * texture2D(uSpecularMap, uv).rgb;
+  float shininess = max(1.0, uShininess * texture2D(uShininessMap, uv).r);
+
+  vec3 n = bumped(normalize(v_normal), uv);
+  vec3 v = normalize(uEye - v_position);
+  vec3 color = ambient * uAmbient;
+
+  for (int i = 0; i < MAX_LIGHTS; i++) {
+    if (i >= uLightCount) {
+      break;
+    }
+
+    vec3 l;
+    float strength = incoming(i, l);
+    float nDotL = dot(n, l);
+    if (strength <= 0.0 || nDotL <= 0.0) {
+      continue;
+    }
+    strength *= visibility(i, normalize(v_normal), l);
+    color += albedo * uLightColor[i] * nDotL * strength;
+
+    if (uIllum >= ILLUM_HIGHLIGHT_ON) {
+      vec3 h = normalize(l + v);
+      float spec = pow(max(dot(n, h), 0.0), shininess) * strength;
+      color += specular * uLightColor[i] * spec;
+    }
+  }
+
+  gl_FragColor = vec4(color, alpha);
+}
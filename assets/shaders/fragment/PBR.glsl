@@ -0,0 +1,244 @@
+#version 120
+
+// Cook-Torrance (GGX, Smith and Schlick) metal/roughness shading with
+// image based ambient light. render.ShadePBR does the same on the CPU,
+// so keep the two in step
+
+#define MAX_LIGHTS 8
+#define MAX_SHADOWS 4
+#define MAX_CASCADES 4
+
+#define LIGHT_DIRECTIONAL 1
+#define LIGHT_POINT 2
+#define LIGHT_SPOT 3
+
+#define PI 3.14159265
+
+varying vec3 v_color;
+varying vec2 v_texcoord;
+varying vec3 v_normal;
+varying vec3 v_position;
+varying vec3 v_tangent;
+varying float v_depth;
+
+uniform vec3 uEye;                        // camera position
+uniform vec3 uAmbient;                    // sum of the ambient lights
+uniform int uLightCount;
+uniform int uLightType[MAX_LIGHTS];
+uniform vec3 uLightPosition[MAX_LIGHTS];
+uniform vec3 uLightDirection[MAX_LIGHTS];
+uniform vec3 uLightColor[MAX_LIGHTS];     // color * intensity
+uniform float uLightRange[MAX_LIGHTS];    // 0 for no limit
+uniform vec2 uLightCone[MAX_LIGHTS];      // cos inner, cos outer
+
+uniform vec3 uBaseColor;
+uniform float uAlpha;
+uniform float uMetallic;
+uniform float uRoughness;
+uniform vec3 uEmissive;
+uniform float uNormalScale;
+uniform float uOcclusionStrength;
+uniform vec2 uTextureOrigin;
+uniform vec2 uTextureScale;
+
+// Missing maps are bound to white (or a flat normal for uNormalMap)
+uniform sampler2D uBaseColorMap;
+uniform sampler2D uMetallicMap;           // blue
+uniform sampler2D uRoughnessMap;          // green
+uniform sampler2D uNormalMap;             // tangent space normals
+uniform sampler2D uOcclusionMap;          // red
+uniform sampler2D uEmissiveMap;
+
+// Black with no irradiance when there's no environment
+uniform samplerCube uEnvironment;
+uniform vec3 uEnvironmentColor;
+uniform float uEnvironmentLevels;         // mipmaps past the first
+uniform vec3 uIrradiance[9];              // spherical harmonics
+
+// Shadow maps, see render.ShadowMap. A directional light's cascades sit
+// side by side in its map
+uniform int uLightShadow[MAX_LIGHTS];     // shadow map, -1 for none
+uniform int uShadowPacked;                // depth packed into RGBA
+uniform int uShadowCascades[MAX_SHADOWS];
+uniform vec3 uShadowSettings[MAX_SHADOWS];               // size, bias, normal bias
+uniform mat4 uShadowMatrix[MAX_SHADOWS * MAX_CASCADES];  // world to light clip space
+uniform vec4 uShadowCascade[MAX_SHADOWS * MAX_CASCADES]; // near, far, split, texel
+uniform sampler2D uShadowMap0;
+uniform sampler2D uShadowMap1;
+uniform sampler2D uShadowMap2;
+uniform sampler2D uShadowMap3;
+
+// shadowDepth the depth in shadow map s at uv
+float shadowDepth(int s, vec2 uv) {
+  vec4 texel;
+  if (s == 0) {
+    texel = texture2D(uShadowMap0, uv);
+  } else if (s == 1) {
+    texel = texture2D(uShadowMap1, uv);
+  } else if (s == 2) {
+    texel = texture2D(uShadowMap2, uv);
+  } else {
+    texel = texture2D(uShadowMap3, uv);
+  }
+  if (uShadowPacked != 0) {
+    return dot(texel, vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+  }
+  return texel.r;
+}
+
+// visibility how much of light i (shining along l) reaches the point, 0
+// in full shadow to 1, from a 3x3 block of shadow map texels so the
+// edges are soft. render.Light.visibility does the same on the CPU
+float visibility(int i, vec3 n, vec3 l) {
+  int s = uLightShadow[i];
+  if (s < 0) {
+    return 1.0;
+  }
+  int count = uShadowCascades[s];
+  int c = -1;
+  for (int k = MAX_CASCADES - 1; k >= 0; k--) {
+    if (k < count && v_depth <= uShadowCascade[s * MAX_CASCADES + k].z) {
+      c = k;
+    }
+  }
+  if (c < 0) {
+    return 1.0;
+  }
+  int tile = s * MAX_CASCADES + c;
+  vec4 cascade = uShadowCascade[tile];
+  vec3 settings = uShadowSettings[s];
+  bool perspective = uLightType[i] == LIGHT_SPOT;
+
+  // Pushed out along the normal by about a texel, more where the surface
+  // is edge on to the light
+  float texel = cascade.w;
+  if (perspective) {
+    texel *= distance(uLightPosition[i], v_position);
+  }
+  vec3 p = v_position + n * settings.z * texel * (1.0 - max(0.0, dot(n, l)) * 0.5);
+
+  vec4 clip = uShadowMatrix[tile] * vec4(p, 1.0);
+  vec2 uv = clip.xy / clip.w * 0.5 + 0.5;
+  float depth = clip.z / clip.w * 0.5 + 0.5;
+  if (perspective) {
+    depth = (clip.w - cascade.x) / (cascade.y - cascade.x);
+  }
+  if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0 || depth > 1.0) {
+    return 1.0;
+  }
+  float bias = settings.y / (cascade.y - cascade.x);
+  float size = settings.x;
+  float lit = 0.0;
+  for (int y = -1; y <= 1; y++) {
+    for (int x = -1; x <= 1; x++) {
+      // Texel centers, kept inside the cascade
+      vec2 t = clamp(floor(uv * size) + vec2(float(x), float(y)), 0.0, size - 1.0) + 0.5;
+      vec2 at = vec2((float(c) * size + t.x) / (size * float(count)), t.y / size);
+      if (depth - bias <= shadowDepth(s, at)) {
+        lit += 1.0;
+      }
+    }
+  }
+  return lit / 9.0;
+}
+
+// bumped the surface normal tilted by the normal map
+vec3 bumped(vec3 n, vec2 uv) {
+  if (dot(v_tangent, v_tangent) < 1e-8) {
+    return n;
+  }
+  vec3 t = normalize(v_tangent - n * dot(n, v_tangent));
+  vec3 b = cross(n, t);
+  vec3 m = texture2D(uNormalMap, uv).xyz * 2.0 - 1.0;
+  m.xy *= uNormalScale;
+  return normalize(t * m.x + b * m.y + n * m.z);
+}
+
+vec3 irradiance(vec3 n) {
+  vec3 e = uIrradiance[0]
+    + uIrradiance[1] * n.y
+    + uIrradiance[2] * n.z
+    + uIrradiance[3] * n.x
+    + uIrradiance[4] * n.x * n.y
+    + uIrradiance[5] * n.y * n.z
+    + uIrradiance[6] * (3.0 * n.z * n.z - 1.0)
+    + uIrradiance[7] * n.x * n.z
+    + uIrradiance[8] * (n.x * n.x - n.y * n.y);
+  return max(e, vec3(0.0));
+}
+
+// envBRDF Karis' fit of the split sum lookup table
+vec2 envBRDF(float roughness, float nDotV) {
+  vec4 r = roughness * vec4(-1.0, -0.0275, -0.572, 0.022) + vec4(1.0, 0.0425, 1.04, -0.04);
+  float a004 = min(r.x * r.x, exp2(-9.28 * nDotV)) * r.x + r.y;
+  return vec2(-1.04, 1.04) * a004 + r.zw;
+}
+
+void main() {
+  vec2 uv = v_texcoord * uTextureScale + uTextureOrigin;
+  vec3 base = v_color * uBaseColor * texture2D(uBaseColorMap, uv).rgb;
+  float metallic = uMetallic * texture2D(uMetallicMap, uv).b;
+  float roughness = clamp(uRoughness * texture2D(uRoughnessMap, uv).g, 0.04, 1.0);
+  float ao = 1.0 + uOcclusionStrength * (texture2D(uOcclusionMap, uv).r - 1.0);
+  vec3 emissive = uEmissive * texture2D(uEmissiveMap, uv).rgb;
+
+  vec3 n = bumped(normalize(v_normal), uv);
+  vec3 v = normalize(uEye - v_position);
+  float nDotV = max(dot(n, v), 1e-4);
+
+  float alpha2 = roughness * roughness * roughness * roughness;
+  float k = (roughness + 1.0) * (roughness + 1.0) / 8.0;
+  vec3 diffuse = base * (1.0 - metallic);
+  vec3 f0 = mix(vec3(0.04), base, metallic);
+
+  vec3 color = vec3(0.0);
+  for (int i = 0; i < MAX_LIGHTS; i++) {
+    if (i >= uLightCount) {
+      break;
+    }
+
+    vec3 l;
+    float strength = 1.0;
+    if (uLightType[i] == LIGHT_DIRECTIONAL) {
+      l = -uLightDirection[i];
+    } else {
+      vec3 toLight = uLightPosition[i] - v_position;
+      l = normalize(toLight);
+      if (uLightRange[i] > 0.0) {
+        float f = max(0.0, 1.0 - length(toLight) / uLightRange[i]);
+        strength = f * f;
+      }
+      if (uLightType[i] == LIGHT_SPOT) {
+        float cosAngle = dot(-l, uLightDirection[i]);
+        strength *= smoothstep(uLightCone[i].y, uLightCone[i].x, cosAngle);
+      }
+    }
+
+    float nDotL = dot(n, l);
+    if (strength <= 0.0 || nDotL <= 0.0) {
+      continue;
+    }
+    strength *= visibility(i, normalize(v_normal), l);
+    vec3 h = normalize(l + v);
+    float nDotH = max(dot(n, h), 0.0);
+    float vDotH = max(dot(v, h), 0.0);
+
+    float d = nDotH * nDotH * (alpha2 - 1.0) + 1.0;
+    float distribution = alpha2 / (PI * d * d);
+    float geometry = nDotV / (nDotV * (1.0 - k) + k) * nDotL / (nDotL * (1.0 - k) + k);
+    vec3 f = f0 + (1.0 - f0) * pow(1.0 - vDotH, 5.0);
+    float spec = distribution * geometry / (4.0 * nDotL * nDotV);
+
+    // Times pi so lights are as bright as in the Lit shader
+    color += ((1.0 - f) * diffuse + PI * spec * f) * uLightColor[i] * nDotL * strength;
+  }
+
+  vec3 r = reflect(-v, n);
+  vec3 env = irradiance(n) * uEnvironmentColor;
+  vec3 sharp = textureCube(uEnvironment, r, roughness * uEnvironmentLevels).rgb;
+  vec3 reflected = mix(sharp, irradiance(r), roughness) * uEnvironmentColor;
+  vec2 ab = envBRDF(roughness, nDotV);
+  color += (diffuse * (uAmbient + env) + (f0 * ab.x + ab.y) * (uAmbient + reflected)) * ao;
+
+  gl_FragColor = vec4(color + emissive, uAlpha);
+}
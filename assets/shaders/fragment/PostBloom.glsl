@@ -0,0 +1,16 @@
+#version 120
+
+// Bloom's last pass, the blurred glow added to the frame. See
+// render.Bloom
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;    // the glow
+uniform sampler2D uOriginal;  // the frame
+uniform float uIntensity;
+
+void main() {
+  vec4 c = texture2D(uOriginal, v_texcoord);
+  vec3 glow = texture2D(uSource, v_texcoord).rgb;
+  gl_FragColor = vec4(c.rgb + glow * uIntensity, c.a);
+}
@@ -0,0 +1,27 @@
+#version 120
+
+// 9 tap Gaussian blur along one axis, see render.Bloom
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform vec2 uTexel;
+uniform vec2 uDirection;  // in pixels per tap
+
+void main() {
+  float weights[5];
+  weights[0] = 0.227027;
+  weights[1] = 0.1945946;
+  weights[2] = 0.1216216;
+  weights[3] = 0.054054;
+  weights[4] = 0.016216;
+
+  vec2 stride = uDirection * uTexel;
+  vec3 sum = texture2D(uSource, v_texcoord).rgb * weights[0];
+  for (int i = 1; i < 5; i++) {
+    vec2 offset = stride * float(i);
+    sum += texture2D(uSource, v_texcoord + offset).rgb * weights[i];
+    sum += texture2D(uSource, v_texcoord - offset).rgb * weights[i];
+  }
+  gl_FragColor = vec4(sum, 1.0);
+}
@@ -0,0 +1,19 @@
+#version 120
+
+// Bloom's first pass, the light past the threshold at half size. See
+// render.Bloom
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform float uThreshold;
+
+void main() {
+  vec3 c = texture2D(uSource, v_texcoord).rgb;
+  float brightest = max(c.r, max(c.g, c.b));
+  if (brightest <= uThreshold) {
+    gl_FragColor = vec4(0.0, 0.0, 0.0, 1.0);
+  } else {
+    gl_FragColor = vec4(c * (brightest - uThreshold) / brightest, 1.0);
+  }
+}
@@ -0,0 +1,31 @@
+#version 120
+
+// Color grading with a lookup table, see render.ColorGrade
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform sampler2D uLUT;       // size slices of size x size side by side
+uniform float uLUTSize;
+uniform float uStrength;      // 0 when there's no table
+
+vec3 lookup(float slice, vec3 c) {
+  vec2 uv = vec2(
+    (slice * uLUTSize + c.r * (uLUTSize - 1.0) + 0.5) / (uLUTSize * uLUTSize),
+    (c.g * (uLUTSize - 1.0) + 0.5) / uLUTSize);
+  // Biased to the full size table, neighbouring colors can be far apart
+  return texture2D(uLUT, uv, -16.0).rgb;
+}
+
+void main() {
+  vec4 c = texture2D(uSource, v_texcoord);
+  if (uStrength <= 0.0) {
+    gl_FragColor = c;
+    return;
+  }
+  vec3 x = clamp(c.rgb, 0.0, 1.0);
+  float blue = x.b * (uLUTSize - 1.0);
+  float slice = floor(blue);
+  vec3 graded = mix(lookup(slice, x), lookup(min(slice + 1.0, uLUTSize - 1.0), x), blue - slice);
+  gl_FragColor = vec4(mix(c.rgb, graded, uStrength), c.a);
+}
@@ -0,0 +1,46 @@
+#version 120
+
+// Fast approximate anti-aliasing, see render.FXAA
+
+#define FXAA_REDUCE_MUL (1.0 / 8.0)
+#define FXAA_REDUCE_MIN (1.0 / 128.0)
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform vec2 uTexel;
+uniform float uSpanMax;
+
+float luma(vec3 c) {
+  return dot(c, vec3(0.299, 0.587, 0.114));
+}
+
+void main() {
+  float nw = luma(texture2D(uSource, v_texcoord + vec2(-1.0, -1.0) * uTexel).rgb);
+  float ne = luma(texture2D(uSource, v_texcoord + vec2(1.0, -1.0) * uTexel).rgb);
+  float sw = luma(texture2D(uSource, v_texcoord + vec2(-1.0, 1.0) * uTexel).rgb);
+  float se = luma(texture2D(uSource, v_texcoord + vec2(1.0, 1.0) * uTexel).rgb);
+  vec4 middle = texture2D(uSource, v_texcoord);
+  float m = luma(middle.rgb);
+  float lumaMin = min(m, min(min(nw, ne), min(sw, se)));
+  float lumaMax = max(m, max(max(nw, ne), max(sw, se)));
+
+  vec2 dir = vec2(-((nw + ne) - (sw + se)), (nw + sw) - (ne + se));
+  float reduce = max((nw + ne + sw + se) * 0.25 * FXAA_REDUCE_MUL, FXAA_REDUCE_MIN);
+  float scale = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+  dir = clamp(dir * scale, -uSpanMax, uSpanMax) * uTexel;
+
+  vec3 inner = 0.5 * (
+    texture2D(uSource, v_texcoord + dir * (1.0 / 3.0 - 0.5)).rgb +
+    texture2D(uSource, v_texcoord + dir * (2.0 / 3.0 - 0.5)).rgb);
+  vec3 wide = inner * 0.5 + 0.25 * (
+    texture2D(uSource, v_texcoord + dir * -0.5).rgb +
+    texture2D(uSource, v_texcoord + dir * 0.5).rgb);
+
+  float l = luma(wide);
+  if (l < lumaMin || l > lumaMax) {
+    gl_FragColor = vec4(inner, middle.a);
+  } else {
+    gl_FragColor = vec4(wide, middle.a);
+  }
+}
@@ -0,0 +1,13 @@
+#version 120
+
+// Gamma correction, see render.Gamma
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform float uGamma;
+
+void main() {
+  vec4 c = texture2D(uSource, v_texcoord);
+  gl_FragColor = vec4(pow(max(c.rgb, vec3(0.0)), vec3(1.0 / uGamma)), c.a);
+}
@@ -0,0 +1,27 @@
+#version 120
+
+// Exposure and tonemapping, see render.Tonemap
+
+#define TONEMAP_ACES 0
+#define TONEMAP_REINHARD 1
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform float uExposure;
+uniform int uOperator;
+
+vec3 aces(vec3 x) {
+  return clamp(x * (2.51 * x + 0.03) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+  vec4 c = texture2D(uSource, v_texcoord);
+  vec3 x = max(c.rgb * uExposure, vec3(0.0));
+  if (uOperator == TONEMAP_REINHARD) {
+    x = x / (1.0 + x);
+  } else {
+    x = aces(x);
+  }
+  gl_FragColor = vec4(x, c.a);
+}
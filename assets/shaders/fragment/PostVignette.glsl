@@ -0,0 +1,16 @@
+#version 120
+
+// Darkened edges, see render.Vignette
+
+varying vec2 v_texcoord;
+
+uniform sampler2D uSource;
+uniform vec3 uVignette;       // intensity, radius, softness
+uniform vec3 uVignetteColor;
+
+void main() {
+  vec4 c = texture2D(uSource, v_texcoord);
+  float d = length(v_texcoord - 0.5);
+  float amount = 1.0 - uVignette.x * (1.0 - smoothstep(uVignette.y, uVignette.y - uVignette.z, d));
+  gl_FragColor = vec4(mix(uVignetteColor, c.rgb, amount), c.a);
+}
@@ -0,0 +1,176 @@
+#version 120
+
+// Ray marches render.ComponentSDF's shape. sceneDistance, generated from
+// its sdf tree, goes in place of the SDF_SCENE line. Lit like Lit.glsl,
+// without maps or shadows, which render.Software does the same way
+
+#define MAX_LIGHTS 8
+#define MAX_STEPS 100
+#define SURF_DIST 0.001
+
+#define LIGHT_DIRECTIONAL 1
+#define LIGHT_POINT 2
+#define LIGHT_SPOT 3
+
+#define ILLUM_COLOR_ON_AMBIENT_OFF 0
+#define ILLUM_HIGHLIGHT_ON 2
+#define ILLUM_CASTS_SHADOWS 10
+
+// SDF_SCENE
+
+uniform mat4 uWorld;                      // model to world
+uniform mat4 uView;                       // view
+uniform mat4 uProj;                       // projection
+uniform mat4 uInverseWorld;               // world to model
+uniform mat4 uInverseViewProjection;      // clip space to world
+uniform vec4 uViewport;                   // x, y, width, height
+uniform vec3 uBounds;                     // half the box's size, zero for none
+
+uniform vec3 uEye;                        // camera position
+uniform vec3 uAmbient;                    // sum of the ambient lights
+uniform int uLightCount;
+uniform int uLightType[MAX_LIGHTS];
+uniform vec3 uLightPosition[MAX_LIGHTS];
+uniform vec3 uLightDirection[MAX_LIGHTS];
+uniform vec3 uLightColor[MAX_LIGHTS];     // color * intensity
+uniform float uLightRange[MAX_LIGHTS];    // 0 for no limit
+uniform vec2 uLightCone[MAX_LIGHTS];      // cos inner, cos outer
+
+uniform vec3 uAmbientColor;               // Ka
+uniform vec3 uDiffuseColor;               // Kd
+uniform vec3 uSpecularColor;              // Ks
+uniform float uShininess;                 // Ns
+uniform float uAlpha;                     // d
+uniform int uIllum;
+
+// unproject a point at clip space depth z under the pixel, in model space
+vec3 unproject(vec2 ndc, float z) {
+  vec4 world = uInverseViewProjection * vec4(ndc, z, 1.0);
+  return (uInverseWorld * vec4(world.xyz / world.w, 1.0)).xyz;
+}
+
+// boxSpan how far along the ray it enters and leaves the bounds, like
+// render.boxSpan
+bool boxSpan(vec3 o, vec3 d, out float near, out float far) {
+  near = -1e10;
+  far = 1e10;
+  for (int i = 0; i < 3; i++) {
+    if (abs(d[i]) < 1e-8) {
+      if (abs(o[i]) > uBounds[i]) {
+        return false;
+      }
+      continue;
+    }
+    float t1 = (-uBounds[i] - o[i]) / d[i];
+    float t2 = (uBounds[i] - o[i]) / d[i];
+    near = max(near, min(t1, t2));
+    far = min(far, max(t1, t2));
+  }
+  return near <= far && far >= 0.0;
+}
+
+// sceneNormal which way is out at p, like sdf.Normal
+vec3 sceneNormal(vec3 p) {
+  vec2 e = vec2(0.001, 0.0);
+  return normalize(vec3(
+    sceneDistance(p + e.xyy) - sceneDistance(p - e.xyy),
+    sceneDistance(p + e.yxy) - sceneDistance(p - e.yxy),
+    sceneDistance(p + e.yyx) - sceneDistance(p - e.yyx)));
+}
+
+// incoming how much of light i reaches position (range and cone
+// falloff), and l the direction towards it
+float incoming(int i, vec3 position, out vec3 l) {
+  if (uLightType[i] == LIGHT_DIRECTIONAL) {
+    l = -uLightDirection[i];
+    return 1.0;
+  }
+  vec3 toLight = uLightPosition[i] - position;
+  l = normalize(toLight);
+  float strength = 1.0;
+  if (uLightRange[i] > 0.0) {
+    float f = max(0.0, 1.0 - length(toLight) / uLightRange[i]);
+    strength = f * f;
+  }
+  if (uLightType[i] == LIGHT_SPOT) {
+    float cosAngle = dot(-l, uLightDirection[i]);
+    strength *= smoothstep(uLightCone[i].y, uLightCone[i].x, cosAngle);
+  }
+  return strength;
+}
+
+void main() {
+  if (uIllum == ILLUM_CASTS_SHADOWS) {
+    // Only shows shadows, and these shapes don't get any
+    discard;
+  }
+
+  // The ray under the pixel from the near plane to the far plane, in
+  // model space, like sdf.March
+  vec2 ndc = (gl_FragCoord.xy - uViewport.xy) / uViewport.zw * 2.0 - 1.0;
+  vec3 start = unproject(ndc, -1.0);
+  vec3 line = unproject(ndc, 1.0) - start;
+  float end = length(line);
+  vec3 d = line / end;
+  float t = 0.0;
+  if (dot(uBounds, uBounds) > 0.0) {
+    float near;
+    float far;
+    if (!boxSpan(start, d, near, far)) {
+      discard;
+    }
+    t = max(near, 0.0);
+    end = min(far, end);
+  }
+  bool hit = false;
+  for (int i = 0; i < MAX_STEPS; i++) {
+    float dist = sceneDistance(start + d * t);
+    if (abs(dist) < SURF_DIST) {
+      hit = true;
+      break;
+    }
+    t += dist;
+    if (t > end || t < 0.0) {
+      break;
+    }
+  }
+  if (!hit) {
+    discard;
+  }
+
+  vec3 local = start + d * t;
+  vec4 world = uWorld * vec4(local, 1.0);
+  vec4 clip = uProj * uView * world;
+  gl_FragDepth = clip.z / clip.w * 0.5 + 0.5;
+
+  if (uIllum == ILLUM_COLOR_ON_AMBIENT_OFF) {
+    gl_FragColor = vec4(uDiffuseColor, uAlpha);
+    return;
+  }
+
+  vec3 position = world.xyz;
+  vec3 n = normalize((uWorld * vec4(sceneNormal(local), 0.0)).xyz);
+  vec3 v = normalize(uEye - position);
+  vec3 color = uAmbientColor * uAmbient;
+  for (int i = 0; i < MAX_LIGHTS; i++) {
+    if (i >= uLightCount) {
+      break;
+    }
+
+    vec3 l;
+    float strength = incoming(i, position, l);
+    float nDotL = dot(n, l);
+    if (strength <= 0.0 || nDotL <= 0.0) {
+      continue;
+    }
+    color += uDiffuseColor * uLightColor[i] * nDotL * strength;
+
+    if (uIllum >= ILLUM_HIGHLIGHT_ON) {
+      vec3 h = normalize(l + v);
+      float spec = pow(max(dot(n, h), 0.0), max(1.0, uShininess)) * strength;
+      color += uSpecularColor * uLightColor[i] * spec;
+    }
+  }
+
+  gl_FragColor = vec4(color, uAlpha);
+}
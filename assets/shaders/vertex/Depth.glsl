@@ -0,0 +1,23 @@
+#version 120
+
+// Depth only pass from a light into its shadow map
+
+attribute vec3 Pos;       // verts
+
+varying float v_depth;    // 0 at the light's near plane, 1 at its far
+
+uniform mat4 uWorld;        // model to world
+uniform mat4 uShadowMatrix; // world to the light's clip space
+uniform vec3 uShadowRange;  // near, far, 1 for spot lights
+
+void main() {
+  vec4 clip = uShadowMatrix * uWorld * vec4(Pos, 1.0);
+  // Even across the light's view, spot lights too, so one bias works
+  // all the way out. render.ShadowMap's software copy does the same
+  if (uShadowRange.z > 0.0) {
+    v_depth = (clip.w - uShadowRange.x) / (uShadowRange.y - uShadowRange.x);
+  } else {
+    v_depth = clip.z / clip.w * 0.5 + 0.5;
+  }
+  gl_Position = clip;
+}
@@ -0,0 +1,37 @@
+#version 120
+
+// Depth only pass from a light into its shadow map, for skinned meshes.
+// Moves the vertices like Skinned.glsl, then does what Depth.glsl does
+
+attribute vec3 Pos;       // verts
+attribute vec4 Joints;    // up to four joints that move the vert
+attribute vec4 Weights;   // how much each joint moves it, all 0 for none
+
+varying float v_depth;    // 0 at the light's near plane, 1 at its far
+
+uniform mat4 uWorld;        // model to world
+uniform mat4 uShadowMatrix; // world to the light's clip space
+uniform vec3 uShadowRange;  // near, far, 1 for spot lights
+uniform mat4 uJoints[60];   // skinning matrices, render.MaxJoints of them
+
+mat4 skin() {
+  float total = Weights.x + Weights.y + Weights.z + Weights.w;
+  if (total <= 0.0) {
+    return mat4(1.0);
+  }
+  mat4 m = uJoints[int(Joints.x)] * Weights.x
+         + uJoints[int(Joints.y)] * Weights.y
+         + uJoints[int(Joints.z)] * Weights.z
+         + uJoints[int(Joints.w)] * Weights.w;
+  return m / total;
+}
+
+void main() {
+  vec4 clip = uShadowMatrix * uWorld * skin() * vec4(Pos, 1.0);
+  if (uShadowRange.z > 0.0) {
+    v_depth = (clip.w - uShadowRange.x) / (uShadowRange.y - uShadowRange.x);
+  } else {
+    v_depth = clip.z / clip.w * 0.5 + 0.5;
+  }
+  gl_Position = clip;
+}
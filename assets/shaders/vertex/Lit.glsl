@@ -0,0 +1,32 @@
+#version 120
+
+attribute vec3 Pos;       // verts
+attribute vec3 Color;     // color
+attribute vec2 TexCoord;  // texture coords (on the vert)
+attribute vec3 Normal;    // which way is out (on the vert)
+attribute vec3 Tangent;   // has to do with light refraction
+
+varying vec3 v_color;
+varying vec2 v_texcoord;
+varying vec3 v_normal;    // world space
+varying vec3 v_position;  // world space
+varying vec3 v_tangent;   // world space, for bump maps
+varying float v_depth;    // how far down the camera's view
+
+uniform mat4 uWorld;      // model to world
+uniform mat4 uView;       // view
+uniform mat4 uProj;       // projection
+
+void main() {
+  vec4 world = uWorld * vec4(Pos, 1.0);
+
+  v_color = Color;
+  v_texcoord = TexCoord;
+  // Right for uniform scale, which is all the engine uses for now
+  v_normal = (uWorld * vec4(Normal, 0.0)).xyz;
+  v_position = world.xyz;
+  v_tangent = (uWorld * vec4(Tangent, 0.0)).xyz;
+  v_depth = -(uView * world).z;
+
+  gl_Position = uProj * uView * world;
+}
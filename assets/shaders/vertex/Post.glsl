@@ -0,0 +1,13 @@
+#version 120
+
+// Full screen pass for post processing effects, see render.Effect
+
+attribute vec3 Pos;       // clip space corners
+attribute vec2 TexCoord;
+
+varying vec2 v_texcoord;
+
+void main() {
+  v_texcoord = TexCoord;
+  gl_Position = vec4(Pos.xy, 0.0, 1.0);
+}
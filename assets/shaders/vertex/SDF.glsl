@@ -0,0 +1,19 @@
+#version 120
+
+// Covers the pixels render.ComponentSDF marches: the box around the
+// shape, or the whole screen when it has no bounds
+
+attribute vec3 Pos;       // box corners, or clip space corners
+
+uniform mat4 uWorld;      // model to world
+uniform mat4 uView;       // view
+uniform mat4 uProj;       // projection
+uniform vec3 uBounds;     // half the box's size, zero for none
+
+void main() {
+  if (dot(uBounds, uBounds) > 0.0) {
+    gl_Position = uProj * uView * uWorld * vec4(Pos * uBounds, 1.0);
+  } else {
+    gl_Position = vec4(Pos.xy, 0.0, 1.0);
+  }
+}
@@ -0,0 +1,53 @@
+#version 120
+
+// Lit's vertex shader with the vertices moved by a skeleton first.
+// geometry.Vertex.Skin does the same on the CPU
+
+attribute vec3 Pos;       // verts
+attribute vec3 Color;     // color
+attribute vec2 TexCoord;  // texture coords (on the vert)
+attribute vec3 Normal;    // which way is out (on the vert)
+attribute vec3 Tangent;   // has to do with light refraction
+attribute vec4 Joints;    // up to four joints that move the vert
+attribute vec4 Weights;   // how much each joint moves it, all 0 for none
+
+varying vec3 v_color;
+varying vec2 v_texcoord;
+varying vec3 v_normal;    // world space
+varying vec3 v_position;  // world space
+varying vec3 v_tangent;   // world space, for bump maps
+varying float v_depth;    // how far down the camera's view
+
+uniform mat4 uWorld;      // model to world
+uniform mat4 uView;       // view
+uniform mat4 uProj;       // projection
+uniform mat4 uJoints[60]; // skinning matrices, render.MaxJoints of them
+
+// skin the joints' matrices blended by the weights, the identity for a
+// vertex without any
+mat4 skin() {
+  float total = Weights.x + Weights.y + Weights.z + Weights.w;
+  if (total <= 0.0) {
+    return mat4(1.0);
+  }
+  mat4 m = uJoints[int(Joints.x)] * Weights.x
+         + uJoints[int(Joints.y)] * Weights.y
+         + uJoints[int(Joints.z)] * Weights.z
+         + uJoints[int(Joints.w)] * Weights.w;
+  return m / total;
+}
+
+void main() {
+  mat4 model = uWorld * skin();
+  vec4 world = model * vec4(Pos, 1.0);
+
+  v_color = Color;
+  v_texcoord = TexCoord;
+  // Right for uniform scale, which is all the engine uses for now
+  v_normal = (model * vec4(Normal, 0.0)).xyz;
+  v_position = world.xyz;
+  v_tangent = (model * vec4(Tangent, 0.0)).xyz;
+  v_depth = -(uView * world).z;
+
+  gl_Position = uProj * uView * world;
+}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"flag"
+	"image/png"
 	"log"
 	"math"
 	"os"
 	"runtime"
 
 	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
 	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/engine"
+	"github.com/robrohan/mesh/internal/input"
 	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/platform"
+	"github.com/robrohan/mesh/internal/platform/sdlplatform"
 	"github.com/robrohan/mesh/internal/render"
-	"github.com/veandco/go-sdl2/sdl"
+	"github.com/robrohan/mesh/internal/replay"
+	"github.com/robrohan/mesh/internal/sdf"
 )
 
 const (
@@ -19,7 +27,20 @@ const (
 	winHeight = 600
 )
 
+var (
+	recordPath = flag.String("record", "", "record the input to this file")
+	replayPath = flag.String("replay", "", "replay the input in this file, checking it plays out the same")
+	headless   = flag.Bool("headless", false, "run without a window, drawing on the CPU")
+	frames     = flag.Int("frames", 60, "frames to run when headless")
+	outPath    = flag.String("out", "", "save the last headless frame to this PNG file")
+	pbr        = flag.Bool("pbr", false, "draw the test model with the PBR (metal/roughness) material")
+	post       = flag.Bool("post", false, "run the view through bloom, tonemapping, FXAA and a vignette")
+	shapes     = flag.Bool("sdf", false, "add a ray marched SDF shape next to the test model")
+	skinned    = flag.Bool("skin", false, "add a skinned, animated stalk next to the test model")
+)
+
 func main() {
+	flag.Parse()
 	if err := run(); err != nil {
 		log.Printf("error: %s", err)
 		os.Exit(1)
@@ -27,126 +48,138 @@ func main() {
 }
 
 func run() error {
-	var window *sdl.Window
-	var context sdl.GLContext
+	if *headless {
+		return runHeadless()
+	}
 
-	var err error
 	runtime.LockOSThread()
-	if err = sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		panic(err)
+	p, err := sdlplatform.New()
+	if err != nil {
+		return err
 	}
-	defer sdl.Quit()
-
-	sdl.GLSetAttribute(sdl.GL_RED_SIZE, 5)
-	sdl.GLSetAttribute(sdl.GL_GREEN_SIZE, 5)
-	sdl.GLSetAttribute(sdl.GL_BLUE_SIZE, 5)
-	sdl.GLSetAttribute(sdl.GL_DEPTH_SIZE, 16)
-	sdl.GLSetAttribute(sdl.GL_DOUBLEBUFFER, 1)
+	defer p.Close()
 
-	window, err = sdl.CreateWindow(
-		winTitle,
-		sdl.WINDOWPOS_UNDEFINED,
-		sdl.WINDOWPOS_UNDEFINED,
-		winWidth, winHeight,
-		sdl.WINDOW_OPENGL|sdl.WINDOW_RESIZABLE) // |sdl.WINDOW_FULLSCREEN)
+	window, err := p.Open(winTitle, winWidth, winHeight)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer window.Destroy()
+	defer window.Close()
+
+	e := engine.New(p, window, &render.System{})
+	log.Printf("W: %v H: %v Pixel Ratio: %v\n", e.Settings.Width, e.Settings.Height, e.Settings.PixelRatio)
 
-	context, err = window.GLCreateContext()
+	return GameLoop(e, true)
+}
+
+// runHeadless run a set number of frames with the software renderer
+func runHeadless() error {
+	p := platform.NewHeadless()
+	window, err := p.Open(winTitle, winWidth, winHeight)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	renderer := &render.Software{}
+	e := engine.New(p, window, renderer)
+
+	count := 0
+	e.Update = func(dt float64) error {
+		count++
+		if count >= *frames {
+			e.Stop()
+		}
+		return nil
+	}
+	if err := GameLoop(e, false); err != nil {
+		return err
 	}
-	defer sdl.GLDeleteContext(context)
 
-	return GameLoop(window)
+	if *outPath == "" {
+		return nil
+	}
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, renderer.Image); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // GameLoop main game loop
-func GameLoop(window *sdl.Window) error {
-	var running bool
-	var event sdl.Event
+func GameLoop(e *engine.Engine, gpu bool) error {
 	///////////////////////////////////
-	lastTime := sdl.GetTicks()
-	nbFrames := 0
+	scene, camera := buildTestScene(&e.Settings, gpu)
+	e.Scene = scene
 	///////////////////////////////////
 
-	w := int32(winWidth)
-	h := int32(winHeight)
-
-	// Much cooler...
-	// mode, err := sdl.GetCurrentDisplayMode(0)
-	// if err == nil {
-	// 	log.Printf("Using CurrentDisplayMode\n")
-	// 	w = mode.W
-	// 	h = mode.H
-	// }
-	log.Printf("W: %v H: %v\n", w, h)
-
-	settings := core.Settings{
-		Width:  w,
-		Height: h,
+	inputMap, err := input.LoadMapFile("assets/input.json")
+	if err != nil {
+		return err
 	}
 
-	renderSystem := render.System{}
-	renderSystem.Initialize(settings)
+	// Recording and replaying run at a fixed step so the replay
+	// simulates exactly the same thing
+	var frames input.Source = e.Platform.Input()
+	var recorder *replay.Recorder
+	var player *replay.Player
+	switch {
+	case *replayPath != "":
+		rec, err := replay.LoadFile(*replayPath)
+		if err != nil {
+			return err
+		}
+		player = replay.NewPlayer(rec)
+		frames = player
+		e.FixedStep = rec.FixedStep
+	case *recordPath != "":
+		e.FixedStep = 1.0 / 60.0
+		recorder = replay.NewRecorder(frames, e.FixedStep)
+		frames = recorder
+	}
+	e.Input = input.NewSystem(frames, inputMap)
 
-	///////////////////////////////////
-	scene, camera := buildTestScene(&settings)
-	///////////////////////////////////
+	update := e.Update
+	e.Update = func(dt float64) error {
+		if dx, dy := e.Input.MouseDelta(); dx != 0 || dy != 0 {
+			x, y := e.Input.MousePosition()
+			ray := camera.ScreenPointToRay(x, y, e.Settings.Width, e.Settings.Height)
+			if hit, ok := scene.Raycast(ray, 1000); ok {
+				log.Printf("over: %v tri: %v at: %v", hit.Entity.Name, hit.Triangle, hit.Point)
+			}
+		}
 
-	tempQ := algebra.Quaternion{}
-
-	running = true
-	for running {
-		///////////////////////////////////
-		// Get input
-		for event = sdl.PollEvent(); event != nil; event =
-			sdl.PollEvent() {
-			switch t := event.(type) {
-			// switch event.(type) {
-			case *sdl.QuitEvent:
-				running = false
-			case *sdl.MouseMotionEvent:
-				xrot := float32(t.X) / 2
-				yrot := float32(t.Y) / 2
-				log.Printf("x: %v y: %v", xrot, yrot)
-				// camera.Parent.Transform.Position.X += float64(xrot)
-				// camera.Parent.Transform.Position.Y += float64(yrot)
+		if recorder != nil {
+			recorder.Checkpoint(scene)
+		}
+		if player != nil {
+			if err := player.Check(scene); err != nil {
+				return err
+			}
+			if player.Done() {
+				log.Printf("replay matched all %v frames", len(player.Recording.Frames))
+				e.Stop()
 			}
 		}
 
-		tempQ.SetFromVector(&algebra.AxisZ, algebra.DegToRad((float64(lastTime))))
-		cameraEntity := camera.GetParent()
-		cameraEntity.Transform.Position.Y = 3 * math.Sin(float64(lastTime)/10)
-		cameraEntity.Transform.Position.Z = 3 * math.Sin(float64(lastTime)/10)
-		// TODO: bad name.
-		cameraEntity.Transform.RotationMatrix(&tempQ)
-
-		///////////////////////////////////
-		// Render
-		camera.UpdateViewMatrix()
-		renderSystem.RenderScene(scene)
-		window.GLSwap()
-
-		///////////////////////////////////
-		// Measure speed
-		currentTime := sdl.GetTicks()
-		nbFrames++
-		if currentTime-lastTime >= 1.0 {
-			// log.Printf("%v ms / Frame\n", 1000.0/nbFrames)
-			nbFrames = 0
-			lastTime += 1.0
+		if update != nil {
+			return update(dt)
 		}
-		///////////////////////////////////
-		// time.Sleep(50 * time.Millisecond)
+		return nil
 	}
 
+	if err := e.Run(); err != nil {
+		return err
+	}
+
+	if recorder != nil {
+		return recorder.Recording.SaveFile(*recordPath)
+	}
 	return nil
 }
 
-func buildTestScene(s *core.Settings) (*core.Scene, *core.ComponentCamera) {
+func buildTestScene(s *core.Settings, gpu bool) (*core.Scene, *core.ComponentCamera) {
 	///////////////////////////////////
 	scene := core.Scene{}
 
@@ -154,14 +187,24 @@ func buildTestScene(s *core.Settings) (*core.Scene, *core.ComponentCamera) {
 	if err != nil {
 		panic("Can't load test object")
 	}
-	// Send the object the GPU (create buffers)
-	mesh := render.CreateMesh(poly)
-	shader := render.Shader{
-		Name:    "default",
-		Program: render.UseProgram(),
-	}
+	mesh := render.Mesh{Poly: poly}
 	material := render.Material{
-		Shader: shader,
+		Illumination:  render.IllumHighlightOn,
+		SpecularColor: algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5},
+	}
+	if *pbr {
+		material.PBR = &render.PBRMaterial{Roughness: 0.4}
+	}
+	if gpu {
+		// Send the object the GPU (create buffers)
+		mesh = render.CreateMesh(poly)
+		material.Shader = render.Shader{
+			Name:    "default",
+			Program: render.UseLitProgram(),
+		}
+		if *pbr {
+			material.Shader.Program = render.UsePBRProgram()
+		}
 	}
 
 	entity := core.Entity{
@@ -169,29 +212,139 @@ func buildTestScene(s *core.Settings) (*core.Scene, *core.ComponentCamera) {
 	}
 	entity.Transform.Position.Z = -8
 	entity.Name = "Test Model"
-	render := render.NewComponentRender()
-	render.Mesh = mesh
-	render.Material = material
-	entity.Attach(&render)
+	renderComp := render.NewComponentRender()
+	renderComp.Mesh = mesh
+	renderComp.Material = material
+	entity.Attach(&renderComp)
 
 	camera := core.Entity{
 		Transform: core.NewTransform(),
 	}
-	camera.Transform.Position.Y = 2
 	camera.Name = "Test Camera"
 	cameraComp := core.NewComponentCamera()
 	cameraComp.UpdatePerspective(s.Width, s.Height, algebra.PerspectiveOptions{
 		Fov:        120,
 		Near:       0.1,
 		Far:        1000,
-		PixelRatio: 1,
+		PixelRatio: s.GetPixelRatio(),
 	})
 	camera.Attach(&cameraComp)
+	orbit := core.NewComponentOrbitController(entity.Transform.Position, 8)
+	orbit.Pitch = -0.25
+	camera.Attach(&orbit)
+	if *post {
+		effects := render.NewComponentPostProcess(
+			render.Bloom{},
+			render.Tonemap{},
+			render.FXAA{},
+			render.Vignette{Intensity: 0.5})
+		camera.Attach(&effects)
+	}
+
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	sun := core.Entity{
+		Name:      "Sun",
+		Transform: core.NewTransform(),
+	}
+	// Down and away from the camera's starting point
+	sun.Transform.Rotation.SetFromEuler(&algebra.Vector{X: -0.8, Y: 0.5}, algebra.EulerYXZ)
+	sunLight := render.NewComponentDirectionalLight(white, 0.8)
+	sunLight.CastShadows = true
+	sun.Attach(&sunLight)
+	ambient := render.NewComponentAmbientLight(white, 0.3)
+	sun.Attach(&ambient)
 
 	scene.Add(&camera)
+	scene.Add(&sun)
 	scene.Add(&entity)
+	if *shapes {
+		// A ring with a ball melted into it, cut through by a box
+		blob := core.Entity{
+			Name:      "Test SDF",
+			Transform: core.NewTransform(),
+		}
+		blob.Transform.Position = algebra.Vector{X: 3, Z: -8}
+		sc := render.NewComponentSDF(sdf.Subtract{
+			From: sdf.Union{
+				Nodes: []sdf.Node{
+					sdf.Torus{Major: 1.2, Minor: 0.3},
+					sdf.Transform{Position: algebra.Vector{Y: 0.6}, Node: sdf.Sphere{Radius: 0.7}},
+				},
+				Smooth: 0.4,
+			},
+			Cut:    sdf.Box{HalfExtents: algebra.Vector{X: 2, Y: 2, Z: 0.15}},
+			Smooth: 0.05,
+		})
+		sc.Material = render.Material{
+			Illumination:  render.IllumHighlightOn,
+			DiffuseColor:  algebra.Vector{X: 0.9, Y: 0.4, Z: 0.2},
+			SpecularColor: algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5},
+		}
+		sc.Bounds = algebra.Vector{X: 1.6, Y: 1.4, Z: 1.6}
+		blob.Attach(&sc)
+		scene.Add(&blob)
+	}
+	if *skinned {
+		scene.Add(buildStalk(gpu))
+	}
 	scene.ActiveCamera = &camera
 	///////////////////////////////////
 
 	return &scene, &cameraComp
 }
+
+// buildStalk a capsule bound to two joints, swaying from side to side
+func buildStalk(gpu bool) *core.Entity {
+	poly, err := sdf.Mesh(sdf.Capsule{B: algebra.Vector{Y: 2}, Radius: 0.3},
+		algebra.Vector{X: -0.5, Y: -0.5, Z: -0.5}, algebra.Vector{X: 0.5, Y: 2.5, Z: 0.5}, 0.1)
+	if err != nil {
+		panic(err)
+	}
+	// The bottom half follows the base, blending into the tip above
+	for i := 0; i < len(poly.Vertices); i++ {
+		v := &poly.Vertices[i]
+		tip := math.Max(0, math.Min(1, v.Pos.Y-0.5))
+		v.Joints = algebra.Vector{X: 0, Y: 1}
+		v.Weights = algebra.Vector{X: 1 - tip, Y: tip}
+	}
+
+	skeleton := &anim.Skeleton{Joints: []anim.Joint{
+		{Name: "base", Parent: -1},
+		{Name: "tip", Parent: 0, Rest: anim.Transform{Translation: algebra.Vector{Y: 1}}},
+	}}
+	world := skeleton.World(skeleton.RestPose(), nil)
+	for i := 0; i < len(world); i++ {
+		world[i].Inverse(&skeleton.Joints[i].InverseBind)
+	}
+	sway := func(angle float64) algebra.Vector {
+		q := algebra.Quaternion{}
+		q.SetFromVector(&algebra.AxisZ, angle)
+		return algebra.Vector(q)
+	}
+	clip := &anim.Clip{Name: "sway", Tracks: []anim.Track{
+		{Joint: 0, Property: anim.Rotation, Times: []float64{0, 1, 2}, Values: []algebra.Vector{sway(-0.3), sway(0.3), sway(-0.3)}},
+		{Joint: 1, Property: anim.Rotation, Times: []float64{0, 1, 2}, Values: []algebra.Vector{sway(-0.6), sway(0.6), sway(-0.6)}},
+	}}
+
+	stalk := &core.Entity{
+		Name:      "Test Stalk",
+		Transform: core.NewTransform(),
+	}
+	stalk.Transform.Position = algebra.Vector{X: -3, Y: -1, Z: -8}
+	rc := render.NewComponentRender()
+	rc.Mesh = render.Mesh{Poly: poly}
+	rc.Material = render.Material{
+		Illumination:  render.IllumHighlightOn,
+		DiffuseColor:  algebra.Vector{X: 0.3, Y: 0.7, Z: 0.3},
+		SpecularColor: algebra.Vector{X: 0.3, Y: 0.3, Z: 0.3},
+	}
+	if gpu {
+		rc.Mesh = render.CreateMesh(poly)
+		rc.Material.Shader = render.Shader{Name: "skinned", Program: render.UseSkinnedProgram()}
+	}
+	stalk.Attach(&rc)
+	animator := anim.NewComponentAnimator(skeleton)
+	animator.Play(clip)
+	stalk.Attach(&animator)
+	return stalk
+}
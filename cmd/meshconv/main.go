@@ -0,0 +1,64 @@
+// Command meshconv converts OBJ and glTF models into the binary mesh
+// format read by the meshfile package
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/meshfile"
+	"github.com/robrohan/mesh/internal/model"
+)
+
+var (
+	outPath  = flag.String("out", "", "where to write the mesh (default: the input with a .meshb extension)")
+	compress = flag.Bool("compress", false, "compress the vertex and index data")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: meshconv [flags] model.(obj|gltf|glb)\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0)); err != nil {
+		log.Printf("error: %s", err)
+		os.Exit(1)
+	}
+}
+
+func run(in string) error {
+	poly, err := load(in)
+	if err != nil {
+		return err
+	}
+	out := *outPath
+	if out == "" {
+		out = strings.TrimSuffix(in, filepath.Ext(in)) + ".meshb"
+	}
+	d := meshfile.FromPolyhedron(poly)
+	if err := meshfile.WriteFile(out, d, meshfile.Options{Compress: *compress}); err != nil {
+		return err
+	}
+	log.Printf("%v: %v vertices, %v triangles\n", out, d.VertexCount(), len(d.Indices)/3)
+	return nil
+}
+
+func load(path string) (geometry.Polyhedron, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".obj":
+		return model.LoadOBJFile(path)
+	case ".gltf", ".glb":
+		return model.LoadGLTFFile(path)
+	}
+	return geometry.Polyhedron{}, fmt.Errorf("don't know how to read %v", path)
+}
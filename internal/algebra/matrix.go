@@ -16,6 +16,16 @@ type PerspectiveOptions struct {
 	Far         float64
 }
 
+// OrthographicOptions used with InitOrthographic
+type OrthographicOptions struct {
+	// Size half the height of the view in world units
+	Size        float64
+	AspectRatio float64
+	PixelRatio  float64
+	Near        float64
+	Far         float64
+}
+
 // InitIdentity create an identity matrix
 //
 //     0 1 2 3
@@ -212,6 +222,84 @@ func (m *Matrix) InitPerspective(o PerspectiveOptions) {
 	m[3][3] = 0
 }
 
+// InitOrthographic init the matrix to an orthographic projection matrix
+// centered on the view axis
+func (m *Matrix) InitOrthographic(o OrthographicOptions) {
+	top := o.Size
+	right := o.Size * o.AspectRatio
+	zRange := 1 / (o.Near - o.Far)
+
+	// row 0
+	m[0][0] = 1 / right
+	m[0][1] = 0
+	m[0][2] = 0
+	m[0][3] = 0
+
+	// row 1
+	m[1][0] = 0
+	m[1][1] = 1 / top
+	m[1][2] = 0
+	m[1][3] = 0
+
+	// row 2
+	m[2][0] = 0
+	m[2][1] = 0
+	m[2][2] = 2 * zRange
+	m[2][3] = 0
+
+	// row 3
+	m[3][0] = 0
+	m[3][1] = 0
+	m[3][2] = (o.Far + o.Near) * zRange
+	m[3][3] = 1
+}
+
+// InitOrthographicBox init the matrix to an orthographic projection of
+// the box left..right, bottom..top and near..far (distances down -Z),
+// which doesn't have to be centered on the view axis
+func (m *Matrix) InitOrthographicBox(left, right, bottom, top, near, far float64) {
+	m.InitIdentity()
+	m[0][0] = 2 / (right - left)
+	m[1][1] = 2 / (top - bottom)
+	m[2][2] = -2 / (far - near)
+	m[3][0] = -(right + left) / (right - left)
+	m[3][1] = -(top + bottom) / (top - bottom)
+	m[3][2] = -(far + near) / (far - near)
+}
+
+// InitLookAt init the matrix to a view matrix for an eye looking at
+// target. Like OpenGL the view looks down -Z
+func (m *Matrix) InitLookAt(eye *Vector, target *Vector, up *Vector) {
+	z := Vector{}
+	eye.SubV(*target, &z)
+	z.Normalized(&z)
+	x := Vector{}
+	up.Cross(z, &x)
+	x.Normalized(&x)
+	y := Vector{}
+	z.Cross(x, &y)
+
+	m[0][0] = x.X
+	m[0][1] = y.X
+	m[0][2] = z.X
+	m[0][3] = 0
+
+	m[1][0] = x.Y
+	m[1][1] = y.Y
+	m[1][2] = z.Y
+	m[1][3] = 0
+
+	m[2][0] = x.Z
+	m[2][1] = y.Z
+	m[2][2] = z.Z
+	m[2][3] = 0
+
+	m[3][0] = -x.Dot(*eye)
+	m[3][1] = -y.Dot(*eye)
+	m[3][2] = -z.Dot(*eye)
+	m[3][3] = 1
+}
+
 // InitFUR initialize with forward, up, and right vectors
 func (m *Matrix) InitFUR(f *Vector, u *Vector, r *Vector) {
 	m[0][0] = r.X
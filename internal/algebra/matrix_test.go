@@ -241,3 +241,69 @@ func TestMatrixClone(t *testing.T) {
 		t.Errorf("Modified both %v and %v", m1, m2)
 	}
 }
+
+func TestMatrixInitOrthographic(t *testing.T) {
+	m := algebra.Matrix{}
+	m.InitOrthographic(algebra.OrthographicOptions{
+		Size:        5,
+		AspectRatio: 2,
+		Near:        1,
+		Far:         11,
+	})
+
+	// Top right corner of the near plane
+	actual := algebra.Vector{}
+	m.Transform(algebra.Vector{X: 10, Y: 5, Z: -1, W: 1}, &actual)
+	expected := algebra.Vector{X: 1, Y: 1, Z: -1, W: 1}
+	if !actual.AlmostEquals(&expected) || actual.W != 1 {
+		t.Errorf("Orthographic near: %v should be %v", actual, expected)
+	}
+
+	m.Transform(algebra.Vector{X: -10, Y: -5, Z: -11, W: 1}, &actual)
+	expected = algebra.Vector{X: -1, Y: -1, Z: 1, W: 1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("Orthographic far: %v should be %v", actual, expected)
+	}
+}
+
+func TestMatrixInitOrthographicBox(t *testing.T) {
+	m := algebra.Matrix{}
+	m.InitOrthographicBox(2, 6, -1, 3, 1, 11)
+
+	actual := algebra.Vector{}
+	m.Transform(algebra.Vector{X: 6, Y: 3, Z: -1, W: 1}, &actual)
+	expected := algebra.Vector{X: 1, Y: 1, Z: -1}
+	if !actual.AlmostEquals(&expected) || actual.W != 1 {
+		t.Errorf("OrthographicBox near: %v should be %v", actual, expected)
+	}
+
+	m.Transform(algebra.Vector{X: 2, Y: -1, Z: -11, W: 1}, &actual)
+	expected = algebra.Vector{X: -1, Y: -1, Z: 1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("OrthographicBox far: %v should be %v", actual, expected)
+	}
+}
+
+func TestMatrixInitLookAt(t *testing.T) {
+	eye := algebra.Vector{X: 0, Y: 0, Z: 10}
+	target := algebra.Vector{}
+
+	m := algebra.Matrix{}
+	m.InitLookAt(&eye, &target, &algebra.Up)
+
+	// The target ends up straight ahead, down -Z
+	actual := algebra.Vector{}
+	m.Transform(algebra.Vector{W: 1}, &actual)
+	expected := algebra.Vector{Z: -10}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("LookAt: target %v should be %v", actual, expected)
+	}
+
+	eye = algebra.Vector{X: 5, Y: 0, Z: 0}
+	m.InitLookAt(&eye, &target, &algebra.Up)
+	m.Transform(algebra.Vector{Z: -1, W: 1}, &actual)
+	// Looking down -X, so -Z is on the right
+	if actual.X <= 0 || actual.Z >= 0 {
+		t.Errorf("LookAt: -Z should be to the right and ahead %v", actual)
+	}
+}
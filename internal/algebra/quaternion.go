@@ -85,3 +85,346 @@ func (q *Quaternion) AddQ(r Quaternion, out *Quaternion) {
 	out.Z = q.Z + r.Z
 	out.W = q.W + r.W
 }
+
+// EulerOrder the order rotations about each axis are applied in
+type EulerOrder uint8
+
+const (
+	// EulerXYZ rotate about X, then the new Y, then the new Z
+	EulerXYZ EulerOrder = iota
+	// EulerXZY rotate about X, then the new Z, then the new Y
+	EulerXZY
+	// EulerYXZ rotate about Y, then the new X, then the new Z
+	EulerYXZ
+	// EulerYZX rotate about Y, then the new Z, then the new X
+	EulerYZX
+	// EulerZXY rotate about Z, then the new X, then the new Y
+	EulerZXY
+	// EulerZYX rotate about Z, then the new Y, then the new X
+	EulerZYX
+)
+
+var (
+	// QuaternionIdentity a rotation that does nothing
+	QuaternionIdentity = Quaternion{W: 1}
+)
+
+// Inverse the rotation that undoes this one
+func (q *Quaternion) Inverse(out *Quaternion) {
+	lenSq := q.Dot(*q)
+	if lenSq == 0 {
+		*out = QuaternionIdentity
+		return
+	}
+	out.X = -q.X / lenSq
+	out.Y = -q.Y / lenSq
+	out.Z = -q.Z / lenSq
+	out.W = q.W / lenSq
+}
+
+// Rotate rotate a vector by this (unit) quaternion, q·v·q*
+func (q *Quaternion) Rotate(v Vector, out *Vector) {
+	// Expanded form of q·v·q* which also treats a zero quaternion
+	// as no rotation
+	u := Vector{X: q.X, Y: q.Y, Z: q.Z}
+	t := Vector{}
+	u.Cross(v, &t)
+	t.Mul(2, &t)
+	c := Vector{}
+	u.Cross(t, &c)
+
+	x := v.X + q.W*t.X + c.X
+	y := v.Y + q.W*t.Y + c.Y
+	z := v.Z + q.W*t.Z + c.Z
+	out.X = x
+	out.Y = y
+	out.Z = z
+	out.W = v.W
+}
+
+// ToMatrix the rotation matrix for this (unit) quaternion, laid out so
+// that Matrix.Transform(v) matches Rotate(v)
+func (q *Quaternion) ToMatrix(out *Matrix) {
+	x, y, z, w := q.X, q.Y, q.Z, q.W
+
+	// Each row is where an axis ends up: right, up, forward
+	right := Vector{
+		X: 1 - 2*(y*y+z*z),
+		Y: 2 * (x*y + w*z),
+		Z: 2 * (x*z - w*y),
+	}
+	up := Vector{
+		X: 2 * (x*y - w*z),
+		Y: 1 - 2*(x*x+z*z),
+		Z: 2 * (y*z + w*x),
+	}
+	forward := Vector{
+		X: 2 * (x*z + w*y),
+		Y: 2 * (y*z - w*x),
+		Z: 1 - 2*(x*x+y*y),
+	}
+	out.InitFUR(&forward, &up, &right)
+}
+
+// SetFromMatrix set the quaternion from the rotation part of a matrix
+// laid out like ToMatrix
+func (q *Quaternion) SetFromMatrix(m *Matrix) {
+	// r[i][j] is the usual (column vector) rotation matrix
+	r00, r01, r02 := m[0][0], m[1][0], m[2][0]
+	r10, r11, r12 := m[0][1], m[1][1], m[2][1]
+	r20, r21, r22 := m[0][2], m[1][2], m[2][2]
+
+	trace := r00 + r11 + r22
+	switch {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q.W = 0.25 / s
+		q.X = (r21 - r12) * s
+		q.Y = (r02 - r20) * s
+		q.Z = (r10 - r01) * s
+	case r00 > r11 && r00 > r22:
+		s := 2 * math.Sqrt(1+r00-r11-r22)
+		q.W = (r21 - r12) / s
+		q.X = 0.25 * s
+		q.Y = (r01 + r10) / s
+		q.Z = (r02 + r20) / s
+	case r11 > r22:
+		s := 2 * math.Sqrt(1+r11-r00-r22)
+		q.W = (r02 - r20) / s
+		q.X = (r01 + r10) / s
+		q.Y = 0.25 * s
+		q.Z = (r12 + r21) / s
+	default:
+		s := 2 * math.Sqrt(1+r22-r00-r11)
+		q.W = (r10 - r01) / s
+		q.X = (r02 + r20) / s
+		q.Y = (r12 + r21) / s
+		q.Z = 0.25 * s
+	}
+	q.Normalized(q)
+}
+
+// Nlerp normalized linear interpolation towards r. Cheaper than Slerp
+// but does not move at a constant speed
+func (q *Quaternion) Nlerp(r Quaternion, t float64, out *Quaternion) {
+	if q.Dot(r) < 0 {
+		r.Mul(-1, &r)
+	}
+	out.X = q.X + (r.X-q.X)*t
+	out.Y = q.Y + (r.Y-q.Y)*t
+	out.Z = q.Z + (r.Z-q.Z)*t
+	out.W = q.W + (r.W-q.W)*t
+	out.Normalized(out)
+}
+
+// Slerp spherical linear interpolation towards r, always taking the
+// shortest way round
+func (q *Quaternion) Slerp(r Quaternion, t float64, out *Quaternion) {
+	cosTheta := q.Dot(r)
+	// q and -q are the same rotation, go the short way
+	if cosTheta < 0 {
+		r.Mul(-1, &r)
+		cosTheta = -cosTheta
+	}
+	// Nearly the same, sin(theta) is too small to divide by
+	if cosTheta > 1-Precision {
+		q.Nlerp(r, t, out)
+		return
+	}
+
+	theta := math.Acos(cosTheta)
+	sinTheta := math.Sin(theta)
+	a := math.Sin((1-t)*theta) / sinTheta
+	b := math.Sin(t*theta) / sinTheta
+
+	out.X = q.X*a + r.X*b
+	out.Y = q.Y*a + r.Y*b
+	out.Z = q.Z*a + r.Z*b
+	out.W = q.W*a + r.W*b
+}
+
+// SetFromEuler set the quaternion from rotations (rads) about each axis,
+// applied in the given order
+func (q *Quaternion) SetFromEuler(v *Vector, order EulerOrder) {
+	x := Quaternion{}
+	y := Quaternion{}
+	z := Quaternion{}
+	x.SetFromVector(&AxisX, v.X)
+	y.SetFromVector(&AxisY, v.Y)
+	z.SetFromVector(&AxisZ, v.Z)
+
+	var first, second, third Quaternion
+	switch order {
+	case EulerXZY:
+		first, second, third = x, z, y
+	case EulerYXZ:
+		first, second, third = y, x, z
+	case EulerYZX:
+		first, second, third = y, z, x
+	case EulerZXY:
+		first, second, third = z, x, y
+	case EulerZYX:
+		first, second, third = z, y, x
+	default:
+		first, second, third = x, y, z
+	}
+
+	// Rotating about the already rotated axes is the same as
+	// multiplying on the right
+	tmp := Quaternion{}
+	first.MulQ(second, &tmp)
+	tmp.MulQ(third, q)
+}
+
+// ToEuler get the rotations (rads) about each axis that, applied in the
+// given order, give this rotation. At gimbal lock the last rotation
+// is folded into the first
+func (q *Quaternion) ToEuler(order EulerOrder, out *Vector) {
+	m := Matrix{}
+	q.ToMatrix(&m)
+	// Usual (column vector) rotation matrix elements
+	m11, m12, m13 := m[0][0], m[1][0], m[2][0]
+	m21, m22, m23 := m[0][1], m[1][1], m[2][1]
+	m31, m32, m33 := m[0][2], m[1][2], m[2][2]
+
+	const lock = 0.9999999
+	switch order {
+	case EulerXYZ:
+		out.Y = math.Asin(clamp(m13))
+		if math.Abs(m13) < lock {
+			out.X = math.Atan2(-m23, m33)
+			out.Z = math.Atan2(-m12, m11)
+		} else {
+			out.X = math.Atan2(m32, m22)
+			out.Z = 0
+		}
+	case EulerYXZ:
+		out.X = math.Asin(-clamp(m23))
+		if math.Abs(m23) < lock {
+			out.Y = math.Atan2(m13, m33)
+			out.Z = math.Atan2(m21, m22)
+		} else {
+			out.Y = math.Atan2(-m31, m11)
+			out.Z = 0
+		}
+	case EulerZXY:
+		out.X = math.Asin(clamp(m32))
+		if math.Abs(m32) < lock {
+			out.Y = math.Atan2(-m31, m33)
+			out.Z = math.Atan2(-m12, m22)
+		} else {
+			out.Y = 0
+			out.Z = math.Atan2(m21, m11)
+		}
+	case EulerZYX:
+		out.Y = math.Asin(-clamp(m31))
+		if math.Abs(m31) < lock {
+			out.X = math.Atan2(m32, m33)
+			out.Z = math.Atan2(m21, m11)
+		} else {
+			out.X = 0
+			out.Z = math.Atan2(-m12, m22)
+		}
+	case EulerYZX:
+		out.Z = math.Asin(clamp(m21))
+		if math.Abs(m21) < lock {
+			out.X = math.Atan2(-m23, m22)
+			out.Y = math.Atan2(-m31, m11)
+		} else {
+			out.X = 0
+			out.Y = math.Atan2(m13, m33)
+		}
+	case EulerXZY:
+		out.Z = math.Asin(-clamp(m12))
+		if math.Abs(m12) < lock {
+			out.X = math.Atan2(m32, m22)
+			out.Y = math.Atan2(m13, m11)
+		} else {
+			out.X = math.Atan2(-m23, m33)
+			out.Y = 0
+		}
+	}
+	out.W = 0
+}
+
+func clamp(f float64) float64 {
+	return math.Max(-1, math.Min(1, f))
+}
+
+// LookRotation the rotation that turns Forward to point along forward
+// with Forward's up as close to up as possible
+func (q *Quaternion) LookRotation(forward Vector, up Vector) {
+	f := Vector{}
+	forward.Normalized(&f)
+	if f.IsZero() {
+		*q = QuaternionIdentity
+		return
+	}
+
+	r := Vector{}
+	up.Cross(f, &r)
+	if r.Length() < Precision {
+		// Looking straight along up, any right will do
+		other := AxisX
+		if math.Abs(f.X) > 0.9 {
+			other = AxisY
+		}
+		other.Cross(f, &r)
+	}
+	r.Normalized(&r)
+	u := Vector{}
+	f.Cross(r, &u)
+
+	m := Matrix{}
+	m.InitFUR(&f, &u, &r)
+	q.SetFromMatrix(&m)
+}
+
+// FromToRotation the shortest rotation that turns from to point along to
+func (q *Quaternion) FromToRotation(from Vector, to Vector) {
+	f := Vector{}
+	t := Vector{}
+	from.Normalized(&f)
+	to.Normalized(&t)
+
+	d := f.Dot(t)
+	if d < -1+Precision {
+		// Opposite directions: half a turn about anything perpendicular
+		axis := Vector{}
+		AxisX.Cross(f, &axis)
+		if axis.Length() < Precision {
+			AxisY.Cross(f, &axis)
+		}
+		axis.Normalized(&axis)
+		q.SetFromVector(&axis, math.Pi)
+		return
+	}
+
+	c := Vector{}
+	f.Cross(t, &c)
+	q.X = c.X
+	q.Y = c.Y
+	q.Z = c.Z
+	q.W = 1 + d
+	q.Normalized(q)
+}
+
+// ToAxisAngle the axis and angle (rads) this rotation turns about
+func (q *Quaternion) ToAxisAngle() (Vector, float64) {
+	n := *q
+	if n.Length() == 0 {
+		return AxisX, 0
+	}
+	n.Normalized(&n)
+	// Keep the angle in [0, pi]
+	if n.W < 0 {
+		n.Mul(-1, &n)
+	}
+
+	angle := 2 * math.Acos(clamp(n.W))
+	s := math.Sqrt(1 - n.W*n.W)
+	if s < Precision {
+		return AxisX, angle
+	}
+	return Vector{X: n.X / s, Y: n.Y / s, Z: n.Z / s}, angle
+}
@@ -1,6 +1,7 @@
 package algebra_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/robrohan/mesh/internal/algebra"
@@ -166,3 +167,262 @@ func TestQuatAddQ(t *testing.T) {
 		t.Errorf("AddQ: %v should be %v", actual, expected)
 	}
 }
+
+func quatAlmostEquals(a, b algebra.Quaternion) bool {
+	// q and -q are the same rotation
+	d := math.Abs(a.Dot(b))
+	return math.Abs(d-a.Length()*b.Length()) < 1e-6
+}
+
+func TestQuatInverse(t *testing.T) {
+	q := algebra.Quaternion{}
+	q.SetFromVector(&algebra.AxisY, algebra.DegToRad(70))
+	inv := algebra.Quaternion{}
+	q.Inverse(&inv)
+
+	actual := algebra.Quaternion{}
+	q.MulQ(inv, &actual)
+	if !quatAlmostEquals(actual, algebra.QuaternionIdentity) {
+		t.Errorf("Inverse: q * inv(q) should be identity got %v", actual)
+	}
+
+	zero := algebra.Quaternion{}
+	zero.Inverse(&actual)
+	if actual != algebra.QuaternionIdentity {
+		t.Errorf("Inverse: zero should give identity got %v", actual)
+	}
+}
+
+func TestQuatRotate(t *testing.T) {
+	q := algebra.Quaternion{}
+	q.SetFromVector(&algebra.AxisY, algebra.DegToRad(90))
+
+	actual := algebra.Vector{}
+	q.Rotate(algebra.AxisX, &actual)
+	expected := algebra.Vector{Z: -1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("Rotate: %v should be %v", actual, expected)
+	}
+
+	// Zero quaternion (what NewTransform starts with) leaves it alone
+	zero := algebra.Quaternion{}
+	zero.Rotate(algebra.AxisX, &actual)
+	if !actual.AlmostEquals(&algebra.AxisX) {
+		t.Errorf("Rotate: zero quaternion moved %v", actual)
+	}
+}
+
+func TestQuatToMatrix(t *testing.T) {
+	q := algebra.Quaternion{}
+	axis := algebra.Vector{X: 1, Y: 2, Z: -3}
+	axis.Normalized(&axis)
+	q.SetFromVector(&axis, algebra.DegToRad(33))
+
+	m := algebra.Matrix{}
+	q.ToMatrix(&m)
+
+	v := algebra.Vector{X: 0.3, Y: -4, Z: 2}
+	expected := algebra.Vector{}
+	q.Rotate(v, &expected)
+	actual := algebra.Vector{}
+	m.Transform(v, &actual)
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("ToMatrix: %v should rotate like Rotate %v", actual, expected)
+	}
+
+	back := algebra.Quaternion{}
+	back.SetFromMatrix(&m)
+	if !quatAlmostEquals(back, q) {
+		t.Errorf("SetFromMatrix: %v should be %v", back, q)
+	}
+
+	identity := algebra.Matrix{}
+	identity.InitIdentity()
+	zero := algebra.Quaternion{}
+	zero.ToMatrix(&m)
+	if m != identity {
+		t.Errorf("ToMatrix: zero quaternion should be identity %v", m)
+	}
+}
+
+func TestQuatSlerp(t *testing.T) {
+	a := algebra.QuaternionIdentity
+	b := algebra.Quaternion{}
+	b.SetFromVector(&algebra.AxisZ, algebra.DegToRad(90))
+
+	actual := algebra.Quaternion{}
+	a.Slerp(b, 0, &actual)
+	if !quatAlmostEquals(actual, a) {
+		t.Errorf("Slerp 0: %v should be %v", actual, a)
+	}
+	a.Slerp(b, 1, &actual)
+	if !quatAlmostEquals(actual, b) {
+		t.Errorf("Slerp 1: %v should be %v", actual, b)
+	}
+
+	expected := algebra.Quaternion{}
+	expected.SetFromVector(&algebra.AxisZ, algebra.DegToRad(45))
+	a.Slerp(b, 0.5, &actual)
+	if !quatAlmostEquals(actual, expected) {
+		t.Errorf("Slerp 0.5: %v should be %v", actual, expected)
+	}
+	a.Nlerp(b, 0.5, &actual)
+	if !quatAlmostEquals(actual, expected) {
+		t.Errorf("Nlerp 0.5: %v should be %v", actual, expected)
+	}
+}
+
+func TestQuatSlerpAntipodal(t *testing.T) {
+	a := algebra.Quaternion{}
+	a.SetFromVector(&algebra.AxisY, algebra.DegToRad(10))
+	b := algebra.Quaternion{}
+	b.SetFromVector(&algebra.AxisY, algebra.DegToRad(30))
+	// Same rotation as b but on the other side of the hypersphere
+	negB := algebra.Quaternion{}
+	b.Mul(-1, &negB)
+
+	expected := algebra.Quaternion{}
+	expected.SetFromVector(&algebra.AxisY, algebra.DegToRad(20))
+
+	actual := algebra.Quaternion{}
+	a.Slerp(negB, 0.5, &actual)
+	if !quatAlmostEquals(actual, expected) {
+		t.Errorf("Slerp should take the short way round %v %v", actual, expected)
+	}
+
+	// Identical inputs should not divide by zero
+	a.Slerp(a, 0.5, &actual)
+	if !quatAlmostEquals(actual, a) || math.IsNaN(actual.W) {
+		t.Errorf("Slerp of equal quaternions %v", actual)
+	}
+}
+
+func TestQuatEulerRoundTrip(t *testing.T) {
+	orders := []algebra.EulerOrder{
+		algebra.EulerXYZ, algebra.EulerXZY, algebra.EulerYXZ,
+		algebra.EulerYZX, algebra.EulerZXY, algebra.EulerZYX,
+	}
+	angles := algebra.Vector{X: 0.3, Y: -0.7, Z: 1.1}
+
+	for _, order := range orders {
+		q := algebra.Quaternion{}
+		q.SetFromEuler(&angles, order)
+
+		actual := algebra.Vector{}
+		q.ToEuler(order, &actual)
+		if !actual.AlmostEquals(&angles) {
+			t.Errorf("Euler order %v: %v should be %v", order, actual, angles)
+		}
+	}
+}
+
+func TestQuatEulerOrder(t *testing.T) {
+	angles := algebra.Vector{X: algebra.DegToRad(90), Y: algebra.DegToRad(90)}
+
+	// X then Y about the new axes
+	q := algebra.Quaternion{}
+	q.SetFromEuler(&angles, algebra.EulerXYZ)
+	actual := algebra.Vector{}
+	q.Rotate(algebra.AxisZ, &actual)
+	expected := algebra.Vector{X: 1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("XYZ: Z went to %v expected %v", actual, expected)
+	}
+
+	q.SetFromEuler(&angles, algebra.EulerYXZ)
+	q.Rotate(algebra.AxisZ, &actual)
+	expected = algebra.Vector{Y: -1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("YXZ: Z went to %v expected %v", actual, expected)
+	}
+}
+
+func TestQuatEulerGimbalLock(t *testing.T) {
+	angles := algebra.Vector{X: 0.4, Y: math.Pi / 2, Z: 0.2}
+	q := algebra.Quaternion{}
+	q.SetFromEuler(&angles, algebra.EulerXYZ)
+
+	euler := algebra.Vector{}
+	q.ToEuler(algebra.EulerXYZ, &euler)
+	if math.IsNaN(euler.X) || math.IsNaN(euler.Y) || math.IsNaN(euler.Z) {
+		t.Fatalf("ToEuler at gimbal lock gave NaN %v", euler)
+	}
+
+	// Different angles but the same rotation
+	back := algebra.Quaternion{}
+	back.SetFromEuler(&euler, algebra.EulerXYZ)
+	if !quatAlmostEquals(back, q) {
+		t.Errorf("ToEuler at gimbal lock %v does not rebuild %v", euler, q)
+	}
+}
+
+func TestQuatLookRotation(t *testing.T) {
+	q := algebra.Quaternion{}
+	forward := algebra.Vector{X: 1, Y: 0, Z: 1}
+	q.LookRotation(forward, algebra.Up)
+
+	actual := algebra.Vector{}
+	q.Rotate(algebra.Forward, &actual)
+	expected := algebra.Vector{}
+	forward.Normalized(&expected)
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("LookRotation: forward %v should be %v", actual, expected)
+	}
+	q.Rotate(algebra.Up, &actual)
+	if !actual.AlmostEquals(&algebra.Up) {
+		t.Errorf("LookRotation: up %v should stay up", actual)
+	}
+
+	// Looking straight up still gives a valid rotation
+	q.LookRotation(algebra.Up, algebra.Up)
+	q.Rotate(algebra.Forward, &actual)
+	if !actual.AlmostEquals(&algebra.Up) {
+		t.Errorf("LookRotation: looking up gave %v", actual)
+	}
+}
+
+func TestQuatFromToRotation(t *testing.T) {
+	from := algebra.Vector{X: 1}
+	to := algebra.Vector{Y: 2}
+
+	q := algebra.Quaternion{}
+	q.FromToRotation(from, to)
+	actual := algebra.Vector{}
+	q.Rotate(from, &actual)
+	expected := algebra.Vector{Y: 1}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("FromToRotation: %v should be %v", actual, expected)
+	}
+
+	// Opposite directions
+	to = algebra.Vector{X: -1}
+	q.FromToRotation(from, to)
+	q.Rotate(from, &actual)
+	if !actual.AlmostEquals(&to) || math.IsNaN(q.W) {
+		t.Errorf("FromToRotation antipodal: %v should be %v", actual, to)
+	}
+}
+
+func TestQuatToAxisAngle(t *testing.T) {
+	axis := algebra.Vector{X: 1, Y: 1}
+	axis.Normalized(&axis)
+	q := algebra.Quaternion{}
+	q.SetFromVector(&axis, 1.2)
+
+	actualAxis, actualAngle := q.ToAxisAngle()
+	if !actualAxis.AlmostEquals(&axis) || math.Abs(actualAngle-1.2) > 1e-9 {
+		t.Errorf("ToAxisAngle: %v %v should be %v 1.2", actualAxis, actualAngle, axis)
+	}
+
+	// The negated quaternion is the same rotation
+	q.Mul(-1, &q)
+	actualAxis, actualAngle = q.ToAxisAngle()
+	if !actualAxis.AlmostEquals(&axis) || math.Abs(actualAngle-1.2) > 1e-9 {
+		t.Errorf("ToAxisAngle negated: %v %v", actualAxis, actualAngle)
+	}
+
+	_, angle := algebra.QuaternionIdentity.ToAxisAngle()
+	if angle != 0 {
+		t.Errorf("ToAxisAngle identity: angle %v should be 0", angle)
+	}
+}
@@ -0,0 +1,150 @@
+package algebra
+
+import "math"
+
+// Ray a half line starting at Origin heading off in Direction
+type Ray struct {
+	Origin    Vector
+	Direction Vector
+}
+
+// NewRay create a ray from an origin towards a direction. The
+// direction is normalized so the t values returned by the
+// intersection tests are distances
+func NewRay(origin Vector, direction Vector) Ray {
+	r := Ray{Origin: origin}
+	direction.Normalized(&r.Direction)
+	return r
+}
+
+// At get the point along the ray at t
+func (r *Ray) At(t float64, out *Vector) {
+	out.X = r.Origin.X + r.Direction.X*t
+	out.Y = r.Origin.Y + r.Direction.Y*t
+	out.Z = r.Origin.Z + r.Direction.Z*t
+}
+
+// IntersectAABB test the ray against an axis aligned box (slab method).
+// Returns the distance to the nearest intersection in front of the
+// origin (0 if the origin is inside the box)
+func (r *Ray) IntersectAABB(min Vector, max Vector) (float64, bool) {
+	tmin := math.Inf(-1)
+	tmax := math.Inf(1)
+
+	origin := [3]float64{r.Origin.X, r.Origin.Y, r.Origin.Z}
+	dir := [3]float64{r.Direction.X, r.Direction.Y, r.Direction.Z}
+	lo := [3]float64{min.X, min.Y, min.Z}
+	hi := [3]float64{max.X, max.Y, max.Z}
+
+	for i := 0; i < 3; i++ {
+		if dir[i] == 0 {
+			// Parallel to the slab, so it has to start inside it
+			if origin[i] < lo[i] || origin[i] > hi[i] {
+				return 0, false
+			}
+			continue
+		}
+		inv := 1 / dir[i]
+		t1 := (lo[i] - origin[i]) * inv
+		t2 := (hi[i] - origin[i]) * inv
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tmin = math.Max(tmin, t1)
+		tmax = math.Min(tmax, t2)
+		if tmin > tmax {
+			return 0, false
+		}
+	}
+
+	if tmax < 0 {
+		return 0, false
+	}
+	if tmin < 0 {
+		return 0, true
+	}
+	return tmin, true
+}
+
+// IntersectSphere test the ray against a sphere. Returns the distance
+// to the nearest intersection in front of the origin
+func (r *Ray) IntersectSphere(center Vector, radius float64) (float64, bool) {
+	oc := Vector{}
+	r.Origin.SubV(center, &oc)
+
+	a := r.Direction.Dot(r.Direction)
+	b := oc.Dot(r.Direction)
+	c := oc.Dot(oc) - radius*radius
+
+	disc := b*b - a*c
+	if disc < 0 || a == 0 {
+		return 0, false
+	}
+	sq := math.Sqrt(disc)
+
+	t := (-b - sq) / a
+	if t < 0 {
+		// Origin is inside the sphere
+		t = (-b + sq) / a
+	}
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// IntersectPlane test the ray against the plane passing through point
+// with the given normal. Hits from either side count
+func (r *Ray) IntersectPlane(point Vector, normal Vector) (float64, bool) {
+	denom := normal.Dot(r.Direction)
+	if math.Abs(denom) < Precision {
+		return 0, false
+	}
+	diff := Vector{}
+	point.SubV(r.Origin, &diff)
+
+	t := diff.Dot(normal) / denom
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// IntersectTriangle test the ray against the triangle a, b, c
+// (Möller–Trumbore). Both faces are hit. Returns the distance along the
+// ray and the barycentric weights u and v of b and c
+// (the point is a*(1-u-v) + b*u + c*v)
+func (r *Ray) IntersectTriangle(a, b, c Vector) (t, u, v float64, ok bool) {
+	edge1 := Vector{}
+	edge2 := Vector{}
+	b.SubV(a, &edge1)
+	c.SubV(a, &edge2)
+
+	pvec := Vector{}
+	r.Direction.Cross(edge2, &pvec)
+	det := edge1.Dot(pvec)
+	if math.Abs(det) < Precision {
+		return 0, 0, 0, false
+	}
+	invDet := 1 / det
+
+	tvec := Vector{}
+	r.Origin.SubV(a, &tvec)
+	u = tvec.Dot(pvec) * invDet
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	qvec := Vector{}
+	tvec.Cross(edge1, &qvec)
+	v = r.Direction.Dot(qvec) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+
+	t = edge2.Dot(qvec) * invDet
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
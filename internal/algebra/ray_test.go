@@ -0,0 +1,113 @@
+package algebra_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+func TestNewRay(t *testing.T) {
+	r := algebra.NewRay(algebra.Vector{}, algebra.Vector{Z: -5})
+
+	expected := algebra.Vector{Z: -1}
+	if !r.Direction.AlmostEquals(&expected) {
+		t.Errorf("NewRay: direction %v should be %v", r.Direction, expected)
+	}
+
+	actual := algebra.Vector{}
+	r.At(3, &actual)
+	expected = algebra.Vector{Z: -3}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("At: %v should be %v", actual, expected)
+	}
+}
+
+func TestRayIntersectAABB(t *testing.T) {
+	min := algebra.Vector{X: -1, Y: -1, Z: -1}
+	max := algebra.Vector{X: 1, Y: 1, Z: 1}
+
+	r := algebra.NewRay(algebra.Vector{Z: 5}, algebra.Vector{Z: -1})
+	d, ok := r.IntersectAABB(min, max)
+	if !ok || math.Abs(d-4) > algebra.Precision {
+		t.Errorf("IntersectAABB: expected hit at 4 got %v %v", d, ok)
+	}
+
+	r = algebra.NewRay(algebra.Vector{}, algebra.Vector{X: 1})
+	d, ok = r.IntersectAABB(min, max)
+	if !ok || d != 0 {
+		t.Errorf("IntersectAABB: expected inside hit got %v %v", d, ok)
+	}
+
+	r = algebra.NewRay(algebra.Vector{Y: 3, Z: 5}, algebra.Vector{Z: -1})
+	if _, ok = r.IntersectAABB(min, max); ok {
+		t.Errorf("IntersectAABB: expected miss")
+	}
+
+	r = algebra.NewRay(algebra.Vector{Z: 5}, algebra.Vector{Z: 1})
+	if _, ok = r.IntersectAABB(min, max); ok {
+		t.Errorf("IntersectAABB: box behind the ray should miss")
+	}
+}
+
+func TestRayIntersectSphere(t *testing.T) {
+	r := algebra.NewRay(algebra.Vector{X: -10}, algebra.Vector{X: 1})
+
+	d, ok := r.IntersectSphere(algebra.Vector{}, 2)
+	if !ok || math.Abs(d-8) > algebra.Precision {
+		t.Errorf("IntersectSphere: expected hit at 8 got %v %v", d, ok)
+	}
+
+	r = algebra.NewRay(algebra.Vector{}, algebra.Vector{X: 1})
+	d, ok = r.IntersectSphere(algebra.Vector{}, 2)
+	if !ok || math.Abs(d-2) > algebra.Precision {
+		t.Errorf("IntersectSphere: expected exit hit at 2 got %v %v", d, ok)
+	}
+
+	r = algebra.NewRay(algebra.Vector{X: -10, Y: 3}, algebra.Vector{X: 1})
+	if _, ok = r.IntersectSphere(algebra.Vector{}, 2); ok {
+		t.Errorf("IntersectSphere: expected miss")
+	}
+}
+
+func TestRayIntersectPlane(t *testing.T) {
+	r := algebra.NewRay(algebra.Vector{Y: 10}, algebra.Vector{Y: -1})
+
+	d, ok := r.IntersectPlane(algebra.Vector{Y: 2}, algebra.Up)
+	if !ok || math.Abs(d-8) > algebra.Precision {
+		t.Errorf("IntersectPlane: expected hit at 8 got %v %v", d, ok)
+	}
+
+	r = algebra.NewRay(algebra.Vector{Y: 10}, algebra.Vector{X: 1})
+	if _, ok = r.IntersectPlane(algebra.Vector{}, algebra.Up); ok {
+		t.Errorf("IntersectPlane: parallel ray should miss")
+	}
+}
+
+func TestRayIntersectTriangle(t *testing.T) {
+	a := algebra.Vector{X: 0, Y: 0}
+	b := algebra.Vector{X: 1, Y: 0}
+	c := algebra.Vector{X: 0, Y: 1}
+
+	r := algebra.NewRay(algebra.Vector{X: .25, Y: .5, Z: 2}, algebra.Vector{Z: -1})
+	d, u, v, ok := r.IntersectTriangle(a, b, c)
+	if !ok {
+		t.Fatalf("IntersectTriangle: expected a hit")
+	}
+	if math.Abs(d-2) > algebra.Precision ||
+		math.Abs(u-.25) > algebra.Precision ||
+		math.Abs(v-.5) > algebra.Precision {
+		t.Errorf("IntersectTriangle: got t=%v u=%v v=%v", d, u, v)
+	}
+
+	// Back face
+	r = algebra.NewRay(algebra.Vector{X: .25, Y: .25, Z: -2}, algebra.Vector{Z: 1})
+	if _, _, _, ok = r.IntersectTriangle(a, b, c); !ok {
+		t.Errorf("IntersectTriangle: back face should hit")
+	}
+
+	r = algebra.NewRay(algebra.Vector{X: .75, Y: .75, Z: 2}, algebra.Vector{Z: -1})
+	if _, _, _, ok = r.IntersectTriangle(a, b, c); ok {
+		t.Errorf("IntersectTriangle: expected miss")
+	}
+}
@@ -0,0 +1,119 @@
+package anim
+
+import (
+	"errors"
+	"math"
+)
+
+// BlendPoint a clip placed in a blend space
+type BlendPoint struct {
+	// Clip the name of the clip
+	Clip string  `json:"clip"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y,omitempty"`
+}
+
+// BlendSpace clips placed by the values of parameters, like a walk at
+// speed 1 and a run at speed 3, blended by where the parameters are now.
+// The clips play in step, each the same fraction of the way through, so
+// the feet land together whatever their lengths
+type BlendSpace struct {
+	// X the parameter along x
+	X string `json:"x"`
+	// Y the parameter along y, empty for a 1D space along x
+	Y      string       `json:"y,omitempty"`
+	Points []BlendPoint `json:"points"`
+}
+
+// Weights how much each point shows for the parameters, adding up to 1,
+// into out. In 1D it's the two points either side of the value (the end
+// one past either end). In 2D each point counts one over the square of
+// how far it is, so exactly on a point is all that point. Missing
+// parameters are 0
+func (b *BlendSpace) Weights(params map[string]float64, out []float64) []float64 {
+	if cap(out) < len(b.Points) {
+		out = make([]float64, len(b.Points))
+	}
+	out = out[:len(b.Points)]
+	for i := 0; i < len(out); i++ {
+		out[i] = 0
+	}
+	if len(out) == 0 {
+		return out
+	}
+	x := params[b.X]
+	if b.Y == "" {
+		b.weights1D(x, out)
+		return out
+	}
+
+	y := params[b.Y]
+	total := 0.0
+	for i := 0; i < len(b.Points); i++ {
+		dx, dy := b.Points[i].X-x, b.Points[i].Y-y
+		d := dx*dx + dy*dy
+		if d < 1e-12 {
+			for j := 0; j < len(out); j++ {
+				out[j] = 0
+			}
+			out[i] = 1
+			return out
+		}
+		out[i] = 1 / d
+		total += out[i]
+	}
+	for i := 0; i < len(out); i++ {
+		out[i] /= total
+	}
+	return out
+}
+
+// weights1D the weights of the points either side of x
+func (b *BlendSpace) weights1D(x float64, out []float64) {
+	below, above := -1, -1
+	for i := 0; i < len(b.Points); i++ {
+		p := b.Points[i].X
+		if p <= x && (below < 0 || p > b.Points[below].X) {
+			below = i
+		}
+		if p >= x && (above < 0 || p < b.Points[above].X) {
+			above = i
+		}
+	}
+	switch {
+	case below < 0:
+		out[above] = 1
+	case above < 0 || below == above:
+		out[below] = 1
+	default:
+		from, to := b.Points[below].X, b.Points[above].X
+		t := (x - from) / (to - from)
+		out[below] = 1 - t
+		out[above] = t
+	}
+}
+
+// Validate check the space has points, and in 1D no two share a value
+func (b *BlendSpace) Validate() error {
+	if len(b.Points) == 0 {
+		return errors.New("blend space has no points")
+	}
+	if b.X == "" {
+		return errors.New("blend space has no x parameter")
+	}
+	for i := 0; i < len(b.Points); i++ {
+		if b.Points[i].Clip == "" {
+			return errors.New("blend point without a clip")
+		}
+		for j := 0; j < i; j++ {
+			dx, dy := b.Points[i].X-b.Points[j].X, b.Points[i].Y-b.Points[j].Y
+			if b.Y == "" {
+				dy = 0
+			}
+			if math.Abs(dx) < 1e-12 && math.Abs(dy) < 1e-12 {
+				return errors.New("two blend points in the same place")
+			}
+		}
+	}
+	return nil
+}
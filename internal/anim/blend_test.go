@@ -0,0 +1,71 @@
+package anim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/anim"
+)
+
+func TestBlendSpace1D(t *testing.T) {
+	b := anim.BlendSpace{
+		X: "speed",
+		Points: []anim.BlendPoint{
+			{Clip: "run", X: 3},
+			{Clip: "idle", X: 0},
+			{Clip: "walk", X: 1},
+		},
+	}
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		speed float64
+		want  []float64
+	}{
+		{-1, []float64{0, 1, 0}},
+		{0, []float64{0, 1, 0}},
+		{0.25, []float64{0, 0.75, 0.25}},
+		{1, []float64{0, 0, 1}},
+		{2.5, []float64{0.75, 0, 0.25}},
+		{9, []float64{1, 0, 0}},
+	}
+	var w []float64
+	for _, c := range cases {
+		w = b.Weights(map[string]float64{"speed": c.speed}, w)
+		for i := 0; i < len(c.want); i++ {
+			if math.Abs(w[i]-c.want[i]) > 1e-9 {
+				t.Errorf("speed %v: got %v, want %v", c.speed, w, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestBlendSpace2D(t *testing.T) {
+	b := anim.BlendSpace{
+		X: "x",
+		Y: "y",
+		Points: []anim.BlendPoint{
+			{Clip: "left", X: -1},
+			{Clip: "right", X: 1},
+			{Clip: "forward", Y: 1},
+		},
+	}
+	w := b.Weights(map[string]float64{"x": 1}, nil)
+	if w[0] != 0 || w[1] != 1 || w[2] != 0 {
+		t.Errorf("on a point: got %v", w)
+	}
+	w = b.Weights(map[string]float64{"y": -1}, w)
+	if math.Abs(w[0]+w[1]+w[2]-1) > 1e-9 || w[0] != w[1] || !(w[0] > w[2]) {
+		t.Errorf("between: got %v", w)
+	}
+
+	b.Points[2].X, b.Points[2].Y = 1, 0
+	if err := b.Validate(); err == nil {
+		t.Errorf("expected an error for two points in the same place")
+	}
+	if err := (&anim.BlendSpace{X: "x"}).Validate(); err == nil {
+		t.Errorf("expected an error without points")
+	}
+}
@@ -0,0 +1,263 @@
+package anim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Property which part of a joint's transform a track moves
+type Property string
+
+const (
+	// Translation the joint's Translation, values in X, Y, Z
+	Translation Property = "translation"
+	// Rotation the joint's Rotation, values are quaternions in X, Y, Z, W
+	Rotation Property = "rotation"
+	// Scale the joint's Scale, values in X, Y, Z
+	Scale Property = "scale"
+)
+
+// Interpolation how a track gets from one key to the next
+type Interpolation string
+
+const (
+	// Linear blend between the keys (slerp for rotations). Unset is
+	// the same
+	Linear Interpolation = "linear"
+	// Step hold each key until the next one
+	Step Interpolation = "step"
+)
+
+// Track keyframes for one property of one joint
+type Track struct {
+	// Joint the index of the joint in the skeleton the clip is for
+	Joint         int           `json:"joint"`
+	Property      Property      `json:"property"`
+	Interpolation Interpolation `json:"interpolation,omitempty"`
+	// Times when each key is, in seconds from the start of the clip,
+	// in order
+	Times []float64 `json:"times"`
+	// Values the key at each time
+	Values []algebra.Vector `json:"values"`
+}
+
+// Sample the track's value at a time. Before the first key it holds the
+// first, after the last it holds the last
+func (t *Track) Sample(time float64) algebra.Vector {
+	count := len(t.Times)
+	if count == 0 || len(t.Values) < count {
+		return algebra.Vector{}
+	}
+	// The first key after time
+	next := sort.SearchFloat64s(t.Times, time)
+	for next < count && t.Times[next] <= time {
+		next++
+	}
+	switch {
+	case next == 0:
+		return t.Values[0]
+	case next == count:
+		return t.Values[count-1]
+	case t.Interpolation == Step:
+		return t.Values[next-1]
+	}
+
+	from, to := t.Times[next-1], t.Times[next]
+	f := (time - from) / (to - from)
+	a, b := t.Values[next-1], t.Values[next]
+	if t.Property == Rotation {
+		qa, qb := algebra.Quaternion(a), algebra.Quaternion(b)
+		out := algebra.Quaternion{}
+		qa.Slerp(qb, f, &out)
+		return algebra.Vector(out)
+	}
+	return algebra.Vector{
+		X: a.X + (b.X-a.X)*f,
+		Y: a.Y + (b.Y-a.Y)*f,
+		Z: a.Z + (b.Z-a.Z)*f,
+		W: a.W + (b.W-a.W)*f,
+	}
+}
+
+// Validate check the keys are in order and there's a value for each
+func (t *Track) Validate() error {
+	switch t.Property {
+	case Translation, Rotation, Scale:
+	default:
+		return fmt.Errorf("unknown property %q", t.Property)
+	}
+	switch t.Interpolation {
+	case "", Linear, Step:
+	default:
+		return fmt.Errorf("unknown interpolation %q", t.Interpolation)
+	}
+	if len(t.Values) != len(t.Times) {
+		return fmt.Errorf("%v times but %v values", len(t.Times), len(t.Values))
+	}
+	for i := 1; i < len(t.Times); i++ {
+		if !(t.Times[i] > t.Times[i-1]) {
+			return fmt.Errorf("key %v at %v is not after %v", i, t.Times[i], t.Times[i-1])
+		}
+	}
+	return nil
+}
+
+// end when the last key is
+func (t *Track) end() float64 {
+	if len(t.Times) == 0 {
+		return 0
+	}
+	return t.Times[len(t.Times)-1]
+}
+
+// Event a named moment in a clip, like a foot landing or a sword
+// connecting. ComponentAnimator tells the components on its entity that
+// are a core.AnimationListener when playback passes it
+type Event struct {
+	Name string `json:"name"`
+	// Time when it happens, in seconds from the start of the clip
+	Time float64 `json:"time"`
+}
+
+// Clip an animation of a skeleton, like a walk cycle, as tracks of
+// keyframes
+type Clip struct {
+	Name string `json:"name"`
+	// Duration how long the clip is in seconds. 0 is until the last key
+	Duration float64 `json:"duration,omitempty"`
+	Tracks   []Track `json:"tracks"`
+	Events   []Event `json:"events,omitempty"`
+}
+
+// Length how long the clip is in seconds
+func (c *Clip) Length() float64 {
+	if c.Duration > 0 {
+		return c.Duration
+	}
+	length := 0.0
+	for i := 0; i < len(c.Tracks); i++ {
+		length = math.Max(length, c.Tracks[i].end())
+	}
+	return length
+}
+
+// Sample set the joints the clip moves to where they are at a time,
+// leaving the rest of the pose as it is. Tracks for joints the pose
+// doesn't have are skipped
+func (c *Clip) Sample(time float64, pose Pose) {
+	for i := 0; i < len(c.Tracks); i++ {
+		t := &c.Tracks[i]
+		if t.Joint < 0 || t.Joint >= len(pose) {
+			continue
+		}
+		v := t.Sample(time)
+		switch t.Property {
+		case Translation:
+			pose[t.Joint].Translation = algebra.Vector{X: v.X, Y: v.Y, Z: v.Z}
+		case Rotation:
+			pose[t.Joint].Rotation = algebra.Quaternion(v)
+		case Scale:
+			pose[t.Joint].Scale = algebra.Vector{X: v.X, Y: v.Y, Z: v.Z}
+		}
+	}
+}
+
+// EventsBetween call fn with each event from time from up to (not including)
+// to. When to is before from playback has gone round the end of the
+// clip, so the events from from to the end fire and then the ones from
+// the start up to to
+func (c *Clip) EventsBetween(from, to float64, fn func(Event)) {
+	if to < from {
+		c.EventsBetween(from, math.Inf(1), fn)
+		c.EventsBetween(0, to, fn)
+		return
+	}
+	for i := 0; i < len(c.Events); i++ {
+		if t := c.Events[i].Time; t >= from && t < to {
+			fn(c.Events[i])
+		}
+	}
+}
+
+// EventsLooped call fn with each event passed playing forwards distance
+// seconds from time from round a looping clip. Every loop the distance
+// covers fires all the events once, so a long step on a short clip
+// doesn't miss any
+func (c *Clip) EventsLooped(from, distance float64, fn func(Event)) {
+	length := c.Length()
+	if !(distance > 0) || math.IsInf(distance, 1) || length <= 0 {
+		return
+	}
+	loops := math.Floor(distance / length)
+	for i := 0.0; i < loops; i++ {
+		c.EventsBetween(from, math.Inf(1), fn)
+		c.EventsBetween(0, from, fn)
+	}
+	if rest := distance - loops*length; rest > 0 {
+		c.EventsBetween(from, math.Mod(from+rest, length), fn)
+	}
+}
+
+// Validate check every track
+func (c *Clip) Validate() error {
+	for i := 0; i < len(c.Tracks); i++ {
+		if err := c.Tracks[i].Validate(); err != nil {
+			return fmt.Errorf("clip %q track %v: %v", c.Name, i, err)
+		}
+	}
+	for i := 0; i < len(c.Events); i++ {
+		if c.Events[i].Name == "" || c.Events[i].Time < 0 {
+			return fmt.Errorf("clip %q event %v needs a name and a time from 0", c.Name, i)
+		}
+	}
+	return nil
+}
+
+// ReadClip load a clip saved by WriteClip, which is JSON
+func ReadClip(r io.Reader) (*Clip, error) {
+	c := &Clip{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteClip save a clip as JSON
+func WriteClip(w io.Writer, c *Clip) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(c)
+}
+
+// ReadClipFile load a clip from a file
+func ReadClipFile(path string) (*Clip, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadClip(bufio.NewReader(f))
+}
+
+// WriteClipFile save a clip to a file
+func WriteClipFile(path string, c *Clip) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteClip(f, c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,182 @@
+package anim_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+)
+
+// mockWave the shoulder swinging a quarter turn about z and back over
+// two seconds while the elbow steps out
+func mockWave() *anim.Clip {
+	quarter := turn(algebra.Vector{Z: 1}, math.Pi/2)
+	return &anim.Clip{
+		Name: "wave",
+		Tracks: []anim.Track{
+			{
+				Joint:    0,
+				Property: anim.Rotation,
+				Times:    []float64{0, 1, 2},
+				Values:   []algebra.Vector{{W: 1}, algebra.Vector(quarter), {W: 1}},
+			},
+			{
+				Joint:         1,
+				Property:      anim.Translation,
+				Interpolation: anim.Step,
+				Times:         []float64{0, 1},
+				Values:        []algebra.Vector{{Y: 1}, {Y: 2}},
+			},
+		},
+	}
+}
+
+func TestTrackSample(t *testing.T) {
+	track := anim.Track{
+		Property: anim.Translation,
+		Times:    []float64{1, 3},
+		Values:   []algebra.Vector{{X: 0}, {X: 10}},
+	}
+	cases := []struct{ time, x float64 }{
+		{0, 0}, {1, 0}, {1.5, 2.5}, {2, 5}, {3, 10}, {4, 10},
+	}
+	for _, c := range cases {
+		if got := track.Sample(c.time).X; math.Abs(got-c.x) > 1e-9 {
+			t.Errorf("Sample(%v): got %v, want %v", c.time, got, c.x)
+		}
+	}
+
+	track.Interpolation = anim.Step
+	if got := track.Sample(2.9).X; got != 0 {
+		t.Errorf("Step: should hold the first key, got %v", got)
+	}
+	if got := track.Sample(3).X; got != 10 {
+		t.Errorf("Step: should reach the second key on time, got %v", got)
+	}
+}
+
+func TestTrackSlerp(t *testing.T) {
+	track := anim.Track{
+		Property: anim.Rotation,
+		Times:    []float64{0, 1},
+		Values: []algebra.Vector{
+			algebra.Vector(turn(algebra.Vector{Y: 1}, 0)),
+			algebra.Vector(turn(algebra.Vector{Y: 1}, math.Pi)),
+		},
+	}
+	// Half way is a quarter turn, which linear blending of the
+	// components wouldn't keep unit length
+	q := algebra.Quaternion(track.Sample(0.5))
+	expected := turn(algebra.Vector{Y: 1}, math.Pi/2)
+	if math.Abs(math.Abs(q.Dot(expected))-1) > 1e-9 {
+		t.Errorf("Slerp: got %v, want %v", q, expected)
+	}
+}
+
+func TestClipSample(t *testing.T) {
+	s := mockArm()
+	clip := mockWave()
+	if err := clip.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if clip.Length() != 2 {
+		t.Errorf("Length: got %v, want the last key 2", clip.Length())
+	}
+	clip.Duration = 3
+	if clip.Length() != 3 {
+		t.Errorf("Length: got %v, want Duration 3", clip.Length())
+	}
+
+	pose := s.RestPose()
+	clip.Sample(1, pose)
+	elbow := algebra.Vector{}
+	world := s.World(pose, nil)
+	world[1].Transform(algebra.Vector{W: 1}, &elbow)
+	if math.Abs(elbow.X+2) > 1e-9 || math.Abs(elbow.Y) > 1e-9 {
+		t.Errorf("elbow at 1s: got %v, want (-2, 0, 0)", elbow)
+	}
+
+	// Tracks for joints the pose doesn't have are left out
+	clip.Tracks[0].Joint = 5
+	clip.Sample(1, pose)
+}
+
+func TestClipReadWrite(t *testing.T) {
+	clip := mockWave()
+	buf := bytes.Buffer{}
+	if err := anim.WriteClip(&buf, clip); err != nil {
+		t.Fatalf("WriteClip: %v", err)
+	}
+	read, err := anim.ReadClip(&buf)
+	if err != nil {
+		t.Fatalf("ReadClip: %v", err)
+	}
+	if read.Name != "wave" || len(read.Tracks) != 2 {
+		t.Fatalf("ReadClip: got %+v", read)
+	}
+	if read.Tracks[1].Interpolation != anim.Step || read.Tracks[1].Values[1].Y != 2 {
+		t.Errorf("ReadClip: second track is %+v", read.Tracks[1])
+	}
+
+	bad := []string{
+		`{"tracks": [{"property": "wobble", "times": [0], "values": [{}]}]}`,
+		`{"tracks": [{"property": "scale", "times": [0, 1], "values": [{}]}]}`,
+		`{"tracks": [{"property": "scale", "times": [1, 0], "values": [{}, {}]}]}`,
+		`{"tracks": [{"property": "scale", "interpolation": "cubic", "times": [0], "values": [{}]}]}`,
+	}
+	for i := 0; i < len(bad); i++ {
+		if _, err := anim.ReadClip(strings.NewReader(bad[i])); err == nil {
+			t.Errorf("ReadClip: %v should fail", bad[i])
+		}
+	}
+}
+
+func TestClipEvents(t *testing.T) {
+	c := &anim.Clip{Events: []anim.Event{{Name: "left", Time: 0}, {Name: "right", Time: 1}}}
+	cases := []struct {
+		from, to float64
+		want     string
+	}{
+		{0, 0.5, "left"},
+		{0.5, 1, ""},
+		{0.5, 1.5, "right"},
+		{1.5, 0.5, "left"},
+		{0.5, 0.25, "rightleft"},
+		{1, math.Inf(1), "right"},
+	}
+	for _, tc := range cases {
+		got := ""
+		c.EventsBetween(tc.from, tc.to, func(e anim.Event) { got += e.Name })
+		if got != tc.want {
+			t.Errorf("from %v to %v: got %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+
+	// Round the loop, as many times as the distance covers
+	c.Tracks = []anim.Track{{Times: []float64{0, 2}, Values: []algebra.Vector{{}, {}}}}
+	looped := []struct {
+		from, distance float64
+		want           string
+	}{
+		{0.5, 1, "right"},
+		{1.5, 1, "left"},
+		{0.5, 2, "rightleft"},
+		{0, 4.5, "leftrightleftrightleft"},
+		{0, 0, ""},
+	}
+	for _, tc := range looped {
+		got := ""
+		c.EventsLooped(tc.from, tc.distance, func(e anim.Event) { got += e.Name })
+		if got != tc.want {
+			t.Errorf("looped from %v by %v: got %q, want %q", tc.from, tc.distance, got, tc.want)
+		}
+	}
+
+	c.Events[0].Name = ""
+	if err := c.Validate(); err == nil {
+		t.Errorf("expected an error for an event without a name")
+	}
+}
@@ -0,0 +1,248 @@
+package anim
+
+import (
+	"errors"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// NewComponentAnimator create an animator holding skeleton at rest
+func NewComponentAnimator(skeleton *Skeleton) ComponentAnimator {
+	c := ComponentAnimator{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Skeleton: skeleton,
+		Speed:    1,
+		Loop:     true,
+	}
+	if skeleton != nil {
+		c.Pose = skeleton.RestPose()
+	}
+	return c
+}
+
+// ComponentAnimator play a clip, or run a state machine, on a skeleton.
+// Skinned meshes on the same entity follow it (it's a core.Skinned),
+// drawn on the GPU with render.UseSkinnedProgram, and components on it
+// that are a core.AnimationListener hear the clips' events
+type ComponentAnimator struct {
+	*core.Component
+	Skeleton *Skeleton
+	// Clip what's playing, nil holds the rest pose
+	Clip *Clip
+	// Time how far into the clip playback is, in seconds
+	Time float64
+	// Speed how fast the clip plays: 1 as made, 0 frozen and negative
+	// backwards
+	Speed float64
+	// Loop start the clip over when it ends, or hold the last frame
+	Loop bool
+	// Pose where the joints are as of the last update
+	Pose Pose
+	// Parameters the values a state machine's conditions and blend
+	// spaces read, see Run
+	Parameters map[string]float64
+
+	joints   []algebra.Matrix
+	machine  *machine
+	triggers map[string]bool
+	// The clip being faded out of
+	from         *Clip
+	fromTime     float64
+	fade         float64
+	fadeDuration float64
+	fromPose     Pose
+}
+
+// Play start a clip from the beginning, stopping any state machine
+func (c *ComponentAnimator) Play(clip *Clip) {
+	c.machine = nil
+	c.from = nil
+	c.Clip = clip
+	c.Time = 0
+	if c.Speed < 0 && clip != nil {
+		c.Time = clip.Length()
+	}
+}
+
+// CrossFade start a clip from the beginning, fading over from what's
+// playing for duration seconds
+func (c *ComponentAnimator) CrossFade(clip *Clip, duration float64) {
+	from, fromTime := c.Clip, c.Time
+	c.Play(clip)
+	if from == nil || duration <= 0 {
+		return
+	}
+	c.from, c.fromTime = from, fromTime
+	c.fade, c.fadeDuration = 0, duration
+}
+
+// Run play a state machine in place of Clip, with the clips its states
+// name
+func (c *ComponentAnimator) Run(m *StateMachine, clips []*Clip) error {
+	if c.Skeleton == nil {
+		return errors.New("animator has no skeleton")
+	}
+	r, err := newMachine(m, c.Skeleton, clips)
+	if err != nil {
+		return err
+	}
+	c.Play(nil)
+	c.machine = r
+	if c.Parameters == nil {
+		c.Parameters = map[string]float64{}
+	}
+	c.triggers = map[string]bool{}
+	return nil
+}
+
+// SetParameter set one of the state machine's parameters
+func (c *ComponentAnimator) SetParameter(name string, value float64) {
+	if c.Parameters == nil {
+		c.Parameters = map[string]float64{}
+	}
+	c.Parameters[name] = value
+}
+
+// Trigger set a trigger, which stays set until a transition that wants
+// it is taken
+func (c *ComponentAnimator) Trigger(name string) {
+	if c.triggers == nil {
+		c.triggers = map[string]bool{}
+	}
+	c.triggers[name] = true
+}
+
+// State the name of the state a layer of the state machine is in, empty
+// when none is running
+func (c *ComponentAnimator) State(layer int) string {
+	if c.machine == nil || layer < 0 || layer >= len(c.machine.layers) {
+		return ""
+	}
+	return c.machine.layers[layer].current.state.Name
+}
+
+// SetLayerWeight how strongly a layer of the state machine plays over
+// the ones before it, 0 to 1
+func (c *ComponentAnimator) SetLayerWeight(layer int, weight float64) {
+	if c.machine == nil || layer < 0 || layer >= len(c.machine.layers) {
+		return
+	}
+	c.machine.layers[layer].weight = math.Max(0, math.Min(weight, 1))
+}
+
+// Done whether a clip that doesn't loop has played to its end
+func (c *ComponentAnimator) Done() bool {
+	if c.Clip == nil || c.Loop {
+		return false
+	}
+	if c.Speed < 0 {
+		return c.Time <= 0
+	}
+	return c.Time >= c.Clip.Length()
+}
+
+// Update move the clip or state machine on and pose the skeleton
+func (c *ComponentAnimator) Update(dt float64) {
+	if c.Skeleton == nil {
+		return
+	}
+	if len(c.Pose) != len(c.Skeleton.Joints) {
+		c.Pose = c.Skeleton.RestPose()
+	} else {
+		c.Skeleton.Reset(c.Pose)
+	}
+	switch {
+	case c.machine != nil:
+		c.machine.update(dt, c.Parameters, c.triggers, c.fire)
+		c.machine.pose(c.Pose)
+	case c.Clip != nil:
+		from := c.Time
+		c.Time = c.advance(c.Clip, c.Time, dt)
+		c.events(from, dt)
+		c.Clip.Sample(c.Time, c.Pose)
+		if c.from == nil {
+			break
+		}
+		c.fromTime = c.advance(c.from, c.fromTime, dt)
+		c.fade += dt
+		if c.fade >= c.fadeDuration {
+			c.from = nil
+			break
+		}
+		if len(c.fromPose) != len(c.Pose) {
+			c.fromPose = c.Skeleton.RestPose()
+		} else {
+			c.Skeleton.Reset(c.fromPose)
+		}
+		c.from.Sample(c.fromTime, c.fromPose)
+		Blend(c.fromPose, c.Pose, c.fade/c.fadeDuration, nil, c.Pose)
+	}
+	c.joints = c.Skeleton.Skin(c.Pose, c.joints)
+}
+
+// advance a time in a clip on by dt, round the loop or held at the ends
+func (c *ComponentAnimator) advance(clip *Clip, time float64, dt float64) float64 {
+	time += dt * c.Speed
+	length := clip.Length()
+	switch {
+	case length <= 0:
+		return 0
+	case c.Loop:
+		time = math.Mod(time, length)
+		if time < 0 {
+			time += length
+		}
+		return time
+	}
+	return math.Max(0, math.Min(time, length))
+}
+
+// events fire the clip's events passed moving on dt from from, once for
+// every time round a loop. Events fire playing forwards
+func (c *ComponentAnimator) events(from float64, dt float64) {
+	if c.Speed <= 0 || dt <= 0 {
+		return
+	}
+	if c.Loop {
+		c.Clip.EventsLooped(from, dt*c.Speed, c.fire)
+		return
+	}
+	if from == c.Time {
+		return
+	}
+	to := c.Time
+	if !c.Loop && c.Time >= c.Clip.Length() {
+		to = math.Inf(1)
+	}
+	c.Clip.EventsBetween(from, to, c.fire)
+}
+
+// fire tell the listeners on the entity about an event
+func (c *ComponentAnimator) fire(e Event) {
+	parent := c.GetParent()
+	if parent == nil {
+		return
+	}
+	components := parent.Components()
+	for i := 0; i < len(components); i++ {
+		if l, ok := components[i].(core.AnimationListener); ok {
+			l.AnimationEvent(e.Name)
+		}
+	}
+}
+
+// JointMatrices the skinning matrices of the current pose, see
+// core.Skinned
+func (c *ComponentAnimator) JointMatrices() []algebra.Matrix {
+	if c.Skeleton == nil {
+		return nil
+	}
+	if len(c.joints) != len(c.Skeleton.Joints) {
+		c.joints = c.Skeleton.Skin(c.Pose, c.joints)
+	}
+	return c.joints
+}
@@ -0,0 +1,135 @@
+package anim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// listener a component that hears animation events
+type listener struct {
+	*core.Component
+	heard []string
+}
+
+func (l *listener) AnimationEvent(name string) {
+	l.heard = append(l.heard, name)
+}
+
+func TestComponentAnimator(t *testing.T) {
+	animator := anim.NewComponentAnimator(mockArm())
+	entity := core.Entity{Transform: core.NewTransform()}
+	entity.Attach(&animator)
+
+	skinned, ok := entity.GetComponent(core.ComponentTypeAnimator).(core.Skinned)
+	if !ok {
+		t.Fatalf("animator should be found as core.Skinned")
+	}
+	if len(skinned.JointMatrices()) != 2 {
+		t.Fatalf("JointMatrices: want one per joint before any update")
+	}
+
+	animator.Play(mockWave())
+	animator.Update(1)
+	if animator.Time != 1 {
+		t.Errorf("Time: got %v, want 1", animator.Time)
+	}
+	hand := algebra.Vector{}
+	m := skinned.JointMatrices()[1]
+	m.Transform(algebra.Vector{Y: 2, W: 1}, &hand)
+	// Shoulder a quarter turn round and the elbow stepped out by one
+	if math.Abs(hand.X+3) > 1e-9 || math.Abs(hand.Y) > 1e-9 {
+		t.Errorf("hand at 1s: got %v, want (-3, 0, 0)", hand)
+	}
+
+	// Loops back round
+	animator.Update(1.5)
+	if math.Abs(animator.Time-0.5) > 1e-9 {
+		t.Errorf("Loop: time got %v, want 0.5", animator.Time)
+	}
+	if animator.Done() {
+		t.Errorf("Done: a looping clip is never done")
+	}
+
+	// Holds the end
+	animator.Loop = false
+	animator.Update(5)
+	if animator.Time != 2 || !animator.Done() {
+		t.Errorf("no loop: time %v done %v, want 2 and done", animator.Time, animator.Done())
+	}
+
+	// Backwards from the end
+	animator.Speed = -1
+	animator.Play(animator.Clip)
+	animator.Update(0.5)
+	if animator.Time != 1.5 {
+		t.Errorf("backwards: time got %v, want 1.5", animator.Time)
+	}
+
+	// Without a clip the skeleton rests
+	animator.Play(nil)
+	animator.Update(1)
+	m = animator.JointMatrices()[1]
+	m.Transform(algebra.Vector{Y: 2, W: 1}, &hand)
+	if math.Abs(hand.X) > 1e-9 || math.Abs(hand.Y-2) > 1e-9 {
+		t.Errorf("rest: got %v, want (0, 2, 0)", hand)
+	}
+}
+
+func TestComponentAnimatorLongStep(t *testing.T) {
+	animator := anim.NewComponentAnimator(mockArm())
+	entity := core.Entity{Transform: core.NewTransform()}
+	entity.Attach(&animator)
+	l := &listener{Component: &core.Component{}}
+	entity.Attach(l)
+
+	walk := mockMoves()[1]
+	walk.Events = []anim.Event{{Name: "step", Time: 0.5}}
+	animator.Play(walk)
+
+	// More than two loops in one update
+	animator.Update(2.25 * walk.Length())
+	if len(l.heard) != 2 {
+		t.Errorf("events: got %v, want a step every loop", l.heard)
+	}
+	// Exactly one loop, ending where it started
+	l.heard = nil
+	animator.Update(walk.Length())
+	if len(l.heard) != 1 {
+		t.Errorf("events: got %v, want a step", l.heard)
+	}
+}
+
+func TestComponentAnimatorCrossFade(t *testing.T) {
+	animator := anim.NewComponentAnimator(mockArm())
+	entity := core.Entity{Transform: core.NewTransform()}
+	entity.Attach(&animator)
+	l := &listener{Component: &core.Component{}}
+	entity.Attach(l)
+
+	moves := mockMoves()
+	walk, run := moves[1], moves[2]
+	walk.Events = []anim.Event{{Name: "step", Time: 0.5}}
+	animator.Play(walk)
+	animator.Update(0.75)
+	if len(l.heard) != 1 || l.heard[0] != "step" {
+		t.Errorf("events: got %v, want a step", l.heard)
+	}
+
+	animator.CrossFade(run, 1)
+	animator.Update(0.25)
+	if x := animator.Pose[0].Translation.X; math.Abs(x-1.5) > 1e-9 {
+		t.Errorf("a quarter of the way from walk to run: got %v, want 1.5", x)
+	}
+	// Walk kept playing as it faded, round its loop past the step
+	if len(l.heard) != 1 {
+		t.Errorf("only the clip fading in fires events, got %v", l.heard)
+	}
+	animator.Update(1)
+	if x := animator.Pose[0].Translation.X; x != 3 {
+		t.Errorf("faded over: got %v, want 3", x)
+	}
+}
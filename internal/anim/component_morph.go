@@ -0,0 +1,54 @@
+package anim
+
+import (
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// NewComponentMorph create a holder for the weights of a mesh's morph
+// targets, starting at each target's own Weight
+func NewComponentMorph(p *geometry.Polyhedron) ComponentMorph {
+	c := ComponentMorph{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Names:   make([]string, len(p.Targets)),
+		Weights: make([]float64, len(p.Targets)),
+	}
+	for i := 0; i < len(p.Targets); i++ {
+		c.Names[i] = p.Targets[i].Name
+		c.Weights[i] = p.Targets[i].Weight
+	}
+	return c
+}
+
+// ComponentMorph how far the mesh on the same entity is blended toward
+// each of its morph targets (it's a core.Morphed). The renderers blend
+// the mesh on the CPU before drawing it, and skinning, when there's an
+// animator, happens after. Weights can be animated like any other
+// number, with tween.Float
+type ComponentMorph struct {
+	*core.Component
+	// Names the name of each target
+	Names []string
+	// Weights how far toward each target, by target index: 0 is the
+	// mesh as modeled and 1 all the way
+	Weights []float64
+}
+
+// SetWeight set the weight of the target with a name, false when there
+// isn't one
+func (c *ComponentMorph) SetWeight(name string, weight float64) bool {
+	for i := 0; i < len(c.Names) && i < len(c.Weights); i++ {
+		if c.Names[i] == name {
+			c.Weights[i] = weight
+			return true
+		}
+	}
+	return false
+}
+
+// MorphWeights the weights, see core.Morphed
+func (c *ComponentMorph) MorphWeights() []float64 {
+	return c.Weights
+}
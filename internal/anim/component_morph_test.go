@@ -0,0 +1,30 @@
+package anim_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+func TestComponentMorph(t *testing.T) {
+	p := geometry.Polyhedron{Targets: []geometry.MorphTarget{{Name: "smile", Weight: 0.25}, {Name: "blink"}}}
+	morph := anim.NewComponentMorph(&p)
+	entity := core.Entity{Transform: core.NewTransform()}
+	entity.Attach(&morph)
+
+	m, ok := entity.GetComponent(core.ComponentTypeMorph).(core.Morphed)
+	if !ok {
+		t.Fatalf("morph component should be found as core.Morphed")
+	}
+	if w := m.MorphWeights(); len(w) != 2 || w[0] != 0.25 || w[1] != 0 {
+		t.Errorf("should start at the targets' weights, got %v", w)
+	}
+	if !morph.SetWeight("blink", 1) || morph.SetWeight("frown", 1) {
+		t.Errorf("SetWeight should find targets by name")
+	}
+	if m.MorphWeights()[1] != 1 {
+		t.Errorf("blink: got %v", m.MorphWeights())
+	}
+}
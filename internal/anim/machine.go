@@ -0,0 +1,426 @@
+package anim
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// StateMachine which clips play when, as data (see ReadStateMachine) and
+// run on an entity by ComponentAnimator.Run. Each layer has states and
+// transitions of its own, and the layers blend over the rest pose in
+// order, so a later one with a mask plays over part of the body
+type StateMachine struct {
+	Layers []Layer `json:"layers"`
+}
+
+// Layer states, and the transitions between them, for the joints the
+// mask picks
+type Layer struct {
+	Name string `json:"name"`
+	// Mask the joints the layer moves, with everything under them.
+	// Empty is every joint
+	Mask []string `json:"mask,omitempty"`
+	// Weight how strongly the layer plays over the ones before it, 0
+	// to 1. Unset is 1, see ComponentAnimator.SetLayerWeight to fade it
+	// in and out
+	Weight float64 `json:"weight,omitempty"`
+	// Start the state the layer starts in, empty for the first
+	Start       string       `json:"start,omitempty"`
+	States      []State      `json:"states"`
+	Transitions []Transition `json:"transitions,omitempty"`
+}
+
+// State something a layer plays: a clip, or a blend space of them
+type State struct {
+	Name string `json:"name"`
+	// Clip the name of the clip to play
+	Clip string `json:"clip,omitempty"`
+	// Blend clips to blend by parameters, in place of Clip
+	Blend *BlendSpace `json:"blend,omitempty"`
+	// Speed how fast it plays. Unset is 1
+	Speed float64 `json:"speed,omitempty"`
+	// Once play through once and hold the end, rather than loop
+	Once bool `json:"once,omitempty"`
+}
+
+// Transition a way out of a state, taken as soon as everything it asks
+// for holds. A layer takes the first that does, in order
+type Transition struct {
+	// From the state it leaves, empty for any state but To
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	// Duration how long to crossfade in seconds, 0 cuts straight over
+	Duration float64 `json:"duration,omitempty"`
+	// Trigger one that has to be set (ComponentAnimator.Trigger), which
+	// taking the transition uses up
+	Trigger string `json:"trigger,omitempty"`
+	// Conditions parameter tests that all have to pass
+	Conditions []Condition `json:"conditions,omitempty"`
+	// AtEnd wait for From to play to its end, or round its loop
+	AtEnd bool `json:"atEnd,omitempty"`
+}
+
+// Condition a test of a parameter
+type Condition struct {
+	Parameter string `json:"parameter"`
+	// Test how the parameter compares to Value: >, >=, <, <=, == or !=
+	Test  string  `json:"test"`
+	Value float64 `json:"value"`
+}
+
+// Holds whether the test passes. Missing parameters are 0
+func (c Condition) Holds(params map[string]float64) bool {
+	v := params[c.Parameter]
+	switch c.Test {
+	case ">":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+// Validate check every layer's states and transitions refer to each
+// other properly. Clips and mask joints are checked when it's run
+func (m *StateMachine) Validate() error {
+	if len(m.Layers) == 0 {
+		return errors.New("state machine has no layers")
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		if err := m.Layers[i].validate(); err != nil {
+			return fmt.Errorf("layer %v (%v): %v", i, m.Layers[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) validate() error {
+	if len(l.States) == 0 {
+		return errors.New("no states")
+	}
+	states := map[string]bool{}
+	for i := 0; i < len(l.States); i++ {
+		s := &l.States[i]
+		if states[s.Name] {
+			return fmt.Errorf("two states called %q", s.Name)
+		}
+		states[s.Name] = true
+		if (s.Clip == "") == (s.Blend == nil) {
+			return fmt.Errorf("state %q needs a clip or a blend space", s.Name)
+		}
+		if s.Blend != nil {
+			if err := s.Blend.Validate(); err != nil {
+				return fmt.Errorf("state %q: %v", s.Name, err)
+			}
+		}
+	}
+	if l.Start != "" && !states[l.Start] {
+		return fmt.Errorf("no start state %q", l.Start)
+	}
+	for i := 0; i < len(l.Transitions); i++ {
+		t := &l.Transitions[i]
+		if !states[t.To] || t.From != "" && !states[t.From] {
+			return fmt.Errorf("transition %v from %q to %q: no such state", i, t.From, t.To)
+		}
+		if t.Duration < 0 {
+			return fmt.Errorf("transition %v has a negative duration", i)
+		}
+		for j := 0; j < len(t.Conditions); j++ {
+			switch t.Conditions[j].Test {
+			case ">", ">=", "<", "<=", "==", "!=":
+			default:
+				return fmt.Errorf("transition %v: unknown test %q", i, t.Conditions[j].Test)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadStateMachine load a state machine from JSON
+func ReadStateMachine(r io.Reader) (*StateMachine, error) {
+	m := &StateMachine{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReadStateMachineFile load a state machine from a file
+func ReadStateMachineFile(path string) (*StateMachine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadStateMachine(bufio.NewReader(f))
+}
+
+//////////////////////////////////////////////////
+
+// machine a state machine as it runs on a skeleton
+type machine struct {
+	skeleton *Skeleton
+	clips    map[string]*Clip
+	layers   []layerPlayer
+	scratch  Pose
+}
+
+// layerPlayer a layer as it runs: the state playing, and the one it's
+// fading from
+type layerPlayer struct {
+	layer    *Layer
+	states   map[string]*State
+	mask     Mask
+	weight   float64
+	current  playing
+	previous playing
+	fading   bool
+	fade     float64
+	duration float64
+	pose     Pose
+	from     Pose
+}
+
+// playing a state as it plays
+type playing struct {
+	state   *State
+	clips   []*Clip
+	weights []float64
+	// phase how far through the state is, 0 to 1
+	phase float64
+	// ended whether it reached its end, or went round its loop, in the
+	// last update
+	ended bool
+}
+
+func newMachine(m *StateMachine, s *Skeleton, clips []*Clip) (*machine, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	r := &machine{
+		skeleton: s,
+		clips:    map[string]*Clip{},
+		layers:   make([]layerPlayer, len(m.Layers)),
+		scratch:  s.RestPose(),
+	}
+	for i := 0; i < len(clips); i++ {
+		r.clips[clips[i].Name] = clips[i]
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		layer := &m.Layers[i]
+		l := &r.layers[i]
+		l.layer = layer
+		l.states = map[string]*State{}
+		l.weight = layer.Weight
+		if l.weight == 0 {
+			l.weight = 1
+		}
+		l.pose, l.from = s.RestPose(), s.RestPose()
+		if len(layer.Mask) > 0 {
+			mask, err := s.Mask(layer.Mask...)
+			if err != nil {
+				return nil, fmt.Errorf("layer %v (%v) mask: %v", i, layer.Name, err)
+			}
+			l.mask = mask
+		}
+		for j := 0; j < len(layer.States); j++ {
+			state := &layer.States[j]
+			if err := r.check(state); err != nil {
+				return nil, fmt.Errorf("layer %v (%v): %v", i, layer.Name, err)
+			}
+			l.states[state.Name] = state
+		}
+		start := &layer.States[0]
+		if layer.Start != "" {
+			start = l.states[layer.Start]
+		}
+		l.current = r.play(start)
+	}
+	return r, nil
+}
+
+// check every clip the state plays is there
+func (r *machine) check(s *State) error {
+	names := []string{s.Clip}
+	if s.Blend != nil {
+		names = names[:0]
+		for i := 0; i < len(s.Blend.Points); i++ {
+			names = append(names, s.Blend.Points[i].Clip)
+		}
+	}
+	for i := 0; i < len(names); i++ {
+		if r.clips[names[i]] == nil {
+			return fmt.Errorf("state %q: no clip %q", s.Name, names[i])
+		}
+	}
+	return nil
+}
+
+// play start a state from the beginning
+func (r *machine) play(s *State) playing {
+	p := playing{state: s}
+	if s.Blend == nil {
+		p.clips = []*Clip{r.clips[s.Clip]}
+		p.weights = []float64{1}
+		return p
+	}
+	p.clips = make([]*Clip, len(s.Blend.Points))
+	for i := 0; i < len(p.clips); i++ {
+		p.clips[i] = r.clips[s.Blend.Points[i].Clip]
+	}
+	return p
+}
+
+// update move every layer on, and take any transitions that are ready
+func (r *machine) update(dt float64, params map[string]float64, triggers map[string]bool, fire func(Event)) {
+	for i := 0; i < len(r.layers); i++ {
+		l := &r.layers[i]
+		l.current.advance(dt, params, fire)
+		if l.fading {
+			l.previous.advance(dt, params, nil)
+			l.fade += dt
+			l.fading = l.fade < l.duration
+		}
+		t := l.transition(params, triggers)
+		if t == nil {
+			continue
+		}
+		if t.Trigger != "" {
+			delete(triggers, t.Trigger)
+		}
+		l.fading = t.Duration > 0
+		l.previous = l.current
+		l.fade, l.duration = 0, t.Duration
+		l.current = r.play(l.states[t.To])
+		l.current.advance(0, params, nil)
+	}
+}
+
+// pose blend every layer over the rest pose into out
+func (r *machine) pose(out Pose) {
+	r.skeleton.Reset(out)
+	for i := 0; i < len(r.layers); i++ {
+		l := &r.layers[i]
+		l.current.sample(r.skeleton, l.pose, r.scratch)
+		if l.fading {
+			l.previous.sample(r.skeleton, l.from, r.scratch)
+			Blend(l.from, l.pose, l.fade/l.duration, nil, l.pose)
+		}
+		Blend(out, l.pose, l.weight, l.mask, out)
+	}
+}
+
+// transition the first transition out of the current state that's
+// ready, nil when none are
+func (l *layerPlayer) transition(params map[string]float64, triggers map[string]bool) *Transition {
+	name := l.current.state.Name
+	for i := 0; i < len(l.layer.Transitions); i++ {
+		t := &l.layer.Transitions[i]
+		switch {
+		case t.From == "" && t.To == name,
+			t.From != "" && t.From != name,
+			t.AtEnd && !l.current.ended,
+			t.Trigger != "" && !triggers[t.Trigger]:
+			continue
+		}
+		holds := true
+		for j := 0; j < len(t.Conditions) && holds; j++ {
+			holds = t.Conditions[j].Holds(params)
+		}
+		if holds {
+			return t
+		}
+	}
+	return nil
+}
+
+// advance move the state on by dt seconds, calling fire with the events
+// of its strongest clip on the way
+func (p *playing) advance(dt float64, params map[string]float64, fire func(Event)) {
+	if p.state.Blend != nil {
+		p.weights = p.state.Blend.Weights(params, p.weights)
+	}
+	length := 0.0
+	for i := 0; i < len(p.clips); i++ {
+		length += p.weights[i] * p.clips[i].Length()
+	}
+	if length <= 0 {
+		p.phase, p.ended = 0, true
+		return
+	}
+	speed := p.state.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	from := p.phase
+	p.phase += dt * speed / length
+	p.ended = false
+	switch {
+	case p.state.Once:
+		p.phase = math.Max(0, math.Min(p.phase, 1))
+		p.ended = p.phase == 1
+	case p.phase >= 1 || p.phase < 0:
+		p.phase -= math.Floor(p.phase)
+		p.ended = true
+	}
+
+	// Events fire playing forwards, once through for states that end
+	if fire == nil || dt*speed <= 0 || p.state.Once && from >= 1 {
+		return
+	}
+	strongest := 0
+	for i := 1; i < len(p.weights); i++ {
+		if p.weights[i] > p.weights[strongest] {
+			strongest = i
+		}
+	}
+	c := p.clips[strongest]
+	if !p.state.Once {
+		c.EventsLooped(from*c.Length(), dt*speed/length*c.Length(), fire)
+		return
+	}
+	to := p.phase * c.Length()
+	if p.ended {
+		to = math.Inf(1)
+	}
+	c.EventsBetween(from*c.Length(), to, fire)
+}
+
+// sample pose the skeleton as the state is now into out, using scratch
+// to blend its clips
+func (p *playing) sample(s *Skeleton, out Pose, scratch Pose) {
+	s.Reset(out)
+	total := 0.0
+	for i := 0; i < len(p.clips); i++ {
+		w := p.weights[i]
+		if w <= 0 {
+			continue
+		}
+		time := p.phase * p.clips[i].Length()
+		if total == 0 {
+			p.clips[i].Sample(time, out)
+			total = w
+			continue
+		}
+		s.Reset(scratch)
+		p.clips[i].Sample(time, scratch)
+		total += w
+		Blend(out, scratch, w/total, nil, out)
+	}
+}
@@ -0,0 +1,153 @@
+package anim_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+const mockMachine = `{
+	"layers": [
+		{
+			"name": "base",
+			"states": [
+				{"name": "idle", "clip": "idle"},
+				{"name": "move", "blend": {"x": "speed", "points": [{"clip": "walk", "x": 1}, {"clip": "run", "x": 3}]}},
+				{"name": "swing", "clip": "swing", "once": true}
+			],
+			"transitions": [
+				{"from": "idle", "to": "move", "conditions": [{"parameter": "speed", "test": ">", "value": 0}]},
+				{"from": "move", "to": "idle", "duration": 1, "conditions": [{"parameter": "speed", "test": "<=", "value": 0}]},
+				{"to": "swing", "trigger": "attack"},
+				{"from": "swing", "to": "idle", "atEnd": true}
+			]
+		},
+		{
+			"name": "arms",
+			"mask": ["elbow"],
+			"states": [{"name": "wave", "clip": "wave"}]
+		}
+	]
+}`
+
+// mockMoves clips that each hold a joint somewhere for a second
+func mockMoves() []*anim.Clip {
+	hold := func(name string, joint int, x float64) *anim.Clip {
+		return &anim.Clip{
+			Name:     name,
+			Duration: 1,
+			Tracks: []anim.Track{{
+				Joint:    joint,
+				Property: anim.Translation,
+				Times:    []float64{0},
+				Values:   []algebra.Vector{{X: x}},
+			}},
+		}
+	}
+	swing := hold("swing", 0, 10)
+	swing.Events = []anim.Event{{Name: "hit", Time: 0.5}}
+	return []*anim.Clip{
+		hold("idle", 0, 0), hold("walk", 0, 1), hold("run", 0, 3), hold("wave", 1, 5), swing,
+	}
+}
+
+func TestStateMachine(t *testing.T) {
+	m, err := anim.ReadStateMachine(strings.NewReader(mockMachine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	animator := anim.NewComponentAnimator(mockArm())
+	entity := core.Entity{Transform: core.NewTransform()}
+	entity.Attach(&animator)
+	l := &listener{Component: &core.Component{}}
+	entity.Attach(l)
+	if err := animator.Run(m, mockMoves()); err != nil {
+		t.Fatal(err)
+	}
+
+	shoulder := func() float64 { return animator.Pose[0].Translation.X }
+	animator.Update(0.1)
+	if animator.State(0) != "idle" || shoulder() != 0 {
+		t.Errorf("start: state %q at %v", animator.State(0), shoulder())
+	}
+	// The arms layer only moves the elbow
+	if animator.State(1) != "wave" || animator.Pose[1].Translation.X != 5 {
+		t.Errorf("arms layer: elbow at %v", animator.Pose[1].Translation)
+	}
+
+	// Half way between walk and run
+	animator.SetParameter("speed", 2)
+	animator.Update(0.1)
+	if animator.State(0) != "move" || math.Abs(shoulder()-2) > 1e-9 {
+		t.Errorf("move: state %q at %v, want 2", animator.State(0), shoulder())
+	}
+
+	// Fades back to idle over a second, from walking as speed is now 0
+	animator.SetParameter("speed", 0)
+	animator.Update(0.1)
+	animator.Update(0.5)
+	if animator.State(0) != "idle" || math.Abs(shoulder()-0.5) > 1e-9 {
+		t.Errorf("fade: state %q at %v, want 0.5", animator.State(0), shoulder())
+	}
+
+	// Swings from anywhere, hits half way through and goes back to idle
+	animator.Trigger("attack")
+	animator.Update(0.1)
+	if animator.State(0) != "swing" || shoulder() != 10 {
+		t.Errorf("swing: state %q at %v", animator.State(0), shoulder())
+	}
+	animator.Update(0.25)
+	if len(l.heard) != 0 {
+		t.Errorf("hit too early: %v", l.heard)
+	}
+	animator.Update(0.5)
+	animator.Update(0.5)
+	if len(l.heard) != 1 || l.heard[0] != "hit" {
+		t.Errorf("events: got %v, want one hit", l.heard)
+	}
+	if animator.State(0) != "idle" {
+		t.Errorf("after the swing: state %q, want idle", animator.State(0))
+	}
+
+	// The trigger was used up
+	animator.Update(0.1)
+	if animator.State(0) != "idle" {
+		t.Errorf("trigger should be used up, state %q", animator.State(0))
+	}
+
+	animator.SetLayerWeight(1, 0)
+	animator.Update(0.1)
+	if animator.Pose[1].Translation.X != 0 {
+		t.Errorf("layer weight 0: elbow at %v", animator.Pose[1].Translation)
+	}
+}
+
+func TestStateMachineErrors(t *testing.T) {
+	bad := map[string]string{
+		"no layers":     `{"layers": []}`,
+		"no states":     `{"layers": [{"name": "base"}]}`,
+		"no clip":       `{"layers": [{"states": [{"name": "idle"}]}]}`,
+		"unknown state": `{"layers": [{"states": [{"name": "idle", "clip": "idle"}], "transitions": [{"to": "walk"}]}]}`,
+		"unknown test":  `{"layers": [{"states": [{"name": "idle", "clip": "idle"}], "transitions": [{"to": "idle", "conditions": [{"test": "~"}]}]}]}`,
+		"bad start":     `{"layers": [{"start": "run", "states": [{"name": "idle", "clip": "idle"}]}]}`,
+	}
+	for name, json := range bad {
+		if _, err := anim.ReadStateMachine(strings.NewReader(json)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+
+	animator := anim.NewComponentAnimator(mockArm())
+	missing := &anim.StateMachine{Layers: []anim.Layer{{States: []anim.State{{Name: "idle", Clip: "sleep"}}}}}
+	if err := animator.Run(missing, mockMoves()); err == nil {
+		t.Errorf("expected an error for a clip that isn't there")
+	}
+	mask := &anim.StateMachine{Layers: []anim.Layer{{Mask: []string{"knee"}, States: []anim.State{{Name: "idle", Clip: "idle"}}}}}
+	if err := animator.Run(mask, mockMoves()); err == nil {
+		t.Errorf("expected an error for a mask joint that isn't there")
+	}
+}
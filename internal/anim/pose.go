@@ -0,0 +1,98 @@
+package anim
+
+import (
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Mask how much each joint of a skeleton takes part in a blend, from 0
+// to 1, by joint index. Layers use them to move only some of the body,
+// like the arms swinging a sword while the legs walk
+type Mask []float64
+
+// Mask the joints with the given names and everything under them, for
+// a layer that moves part of the body (the spine for the upper body)
+func (s *Skeleton) Mask(names ...string) (Mask, error) {
+	m := make(Mask, len(s.Joints))
+	for i := 0; i < len(names); i++ {
+		j := s.Find(names[i])
+		if j < 0 {
+			return nil, fmt.Errorf("no joint %q", names[i])
+		}
+		m[j] = 1
+	}
+	// Parents come first, so one pass carries it down
+	for i := 0; i < len(s.Joints); i++ {
+		if p := s.Joints[i].Parent; p >= 0 && p < i && m[p] > 0 {
+			m[i] = m[p]
+		}
+	}
+	return m, nil
+}
+
+// Blend a pose t of the way from a to b into out (which may be a or b):
+// translations and scales blended linearly, rotations slerped. A mask
+// scales t for each joint, nil moves every joint. Joints past the end
+// of any of the poses are left alone
+func Blend(a, b Pose, t float64, mask Mask, out Pose) {
+	count := len(out)
+	if len(a) < count {
+		count = len(a)
+	}
+	if len(b) < count {
+		count = len(b)
+	}
+	for i := 0; i < count; i++ {
+		f := t
+		if mask != nil {
+			if i >= len(mask) {
+				f = 0
+			} else {
+				f *= mask[i]
+			}
+		}
+		out[i] = blendTransform(a[i], b[i], f)
+	}
+}
+
+// blendTransform a transform t of the way from a to b
+func blendTransform(a, b Transform, t float64) Transform {
+	switch {
+	case t <= 0:
+		return a
+	case t >= 1:
+		return b
+	}
+	ra, rb := rotation(a.Rotation), rotation(b.Rotation)
+	out := Transform{
+		Translation: lerp(a.Translation, b.Translation, t),
+		Scale:       lerp(scale(a.Scale), scale(b.Scale), t),
+	}
+	ra.Slerp(rb, t, &out.Rotation)
+	return out
+}
+
+// rotation a transform's rotation with unset as no rotation
+func rotation(q algebra.Quaternion) algebra.Quaternion {
+	if q == (algebra.Quaternion{}) {
+		return algebra.QuaternionIdentity
+	}
+	return q
+}
+
+// scale a transform's scale with unset as 1
+func scale(v algebra.Vector) algebra.Vector {
+	if v.IsZero() {
+		return algebra.Vector{X: 1, Y: 1, Z: 1}
+	}
+	return v
+}
+
+func lerp(a, b algebra.Vector, t float64) algebra.Vector {
+	return algebra.Vector{
+		X: a.X + (b.X-a.X)*t,
+		Y: a.Y + (b.Y-a.Y)*t,
+		Z: a.Z + (b.Z-a.Z)*t,
+	}
+}
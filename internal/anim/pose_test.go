@@ -0,0 +1,65 @@
+package anim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+)
+
+func TestSkeletonMask(t *testing.T) {
+	s := mockArm()
+	m, err := s.Mask("elbow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m[0] != 0 || m[1] != 1 {
+		t.Errorf("elbow only: got %v", m)
+	}
+	m, _ = s.Mask("shoulder")
+	if m[0] != 1 || m[1] != 1 {
+		t.Errorf("the shoulder takes the elbow with it: got %v", m)
+	}
+	if _, err := s.Mask("knee"); err == nil {
+		t.Errorf("expected an error for a joint that isn't there")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	a := anim.Pose{
+		{Translation: algebra.Vector{X: 0}},
+		{Translation: algebra.Vector{Y: 1}},
+	}
+	b := anim.Pose{
+		{Translation: algebra.Vector{X: 2}, Rotation: turn(algebra.Vector{Z: 1}, math.Pi/2)},
+		{Translation: algebra.Vector{Y: 3}, Scale: algebra.Vector{X: 3, Y: 3, Z: 3}},
+	}
+	out := make(anim.Pose, 2)
+	anim.Blend(a, b, 0.5, nil, out)
+
+	if out[0].Translation.X != 1 || out[1].Translation.Y != 2 {
+		t.Errorf("translations: got %v and %v", out[0].Translation, out[1].Translation)
+	}
+	// Unset scale is 1, so half way to 3 is 2
+	if out[1].Scale.X != 2 {
+		t.Errorf("scale: got %v, want 2", out[1].Scale)
+	}
+	// Unset rotation is none, so half a quarter turn
+	want := turn(algebra.Vector{Z: 1}, math.Pi/4)
+	if math.Abs(math.Abs(out[0].Rotation.Dot(want))-1) > 1e-9 {
+		t.Errorf("rotation: got %v, want %v", out[0].Rotation, want)
+	}
+
+	// Only the elbow
+	anim.Blend(a, b, 1, anim.Mask{0, 1}, out)
+	if out[0] != a[0] || out[1] != b[1] {
+		t.Errorf("mask: got %v", out)
+	}
+
+	// In place
+	anim.Blend(a, b, 0.25, nil, a)
+	if a[0].Translation.X != 0.5 {
+		t.Errorf("in place: got %v, want 0.5", a[0].Translation.X)
+	}
+}
@@ -0,0 +1,141 @@
+// Package anim skeletal animation: skeletons of joints that skinned
+// meshes are bound to, clips of keyframes that move the joints, and
+// ComponentAnimator which plays clips on an entity.
+//
+// Matrices follow the rest of the engine, row vectors (v * M), so a
+// joint's world matrix is its local matrix times its parent's
+package anim
+
+import (
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Transform where a joint is relative to its parent
+type Transform struct {
+	Translation algebra.Vector
+	// Rotation unset (all 0) is no rotation
+	Rotation algebra.Quaternion
+	// Scale unset (all 0) is 1
+	Scale algebra.Vector
+}
+
+// Matrix the transform as a matrix: scale, then rotate, then move to
+// Translation, the same order as core.Transform
+func (t Transform) Matrix() algebra.Matrix {
+	scale := t.Scale
+	if scale.IsZero() {
+		scale = algebra.Vector{X: 1, Y: 1, Z: 1}
+	}
+	s := algebra.Matrix{}
+	s.InitScale(&scale)
+	r := algebra.Matrix{}
+	t.Rotation.ToMatrix(&r)
+	tr := algebra.Matrix{}
+	tr.InitTranslation(&t.Translation)
+
+	sr := algebra.Matrix{}
+	s.Mul(r, &sr)
+	out := algebra.Matrix{}
+	sr.Mul(tr, &out)
+	return out
+}
+
+// Pose a transform for every joint of a skeleton, by joint index
+type Pose []Transform
+
+// Joint one bone of a skeleton
+type Joint struct {
+	Name string
+	// Parent the index of the joint this one hangs off, -1 for a root
+	Parent int
+	// Rest where the joint is when no clip moves it
+	Rest Transform
+	// InverseBind takes the mesh from its own space into the joint's, as
+	// the joint was when the mesh was bound to it. Unset (all 0) is the
+	// identity
+	InverseBind algebra.Matrix
+}
+
+// Skeleton a hierarchy of joints. Each joint's parent comes before it,
+// so a pose can be worked out in one pass
+type Skeleton struct {
+	// Name the skeleton's asset ID, which scene files refer to it by
+	Name   string
+	Joints []Joint
+}
+
+// Validate check every parent is a joint that comes earlier
+func (s *Skeleton) Validate() error {
+	for i := 0; i < len(s.Joints); i++ {
+		p := s.Joints[i].Parent
+		if p < -1 || p >= i {
+			return fmt.Errorf("joint %v (%v) has parent %v, parents have to come first", i, s.Joints[i].Name, p)
+		}
+	}
+	return nil
+}
+
+// Find the index of the joint with a name, -1 when there isn't one
+func (s *Skeleton) Find(name string) int {
+	for i := 0; i < len(s.Joints); i++ {
+		if s.Joints[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// RestPose a new pose with every joint at rest
+func (s *Skeleton) RestPose() Pose {
+	p := make(Pose, len(s.Joints))
+	s.Reset(p)
+	return p
+}
+
+// Reset put every joint of a pose back to rest
+func (s *Skeleton) Reset(p Pose) {
+	for i := 0; i < len(p) && i < len(s.Joints); i++ {
+		p[i] = s.Joints[i].Rest
+	}
+}
+
+// World where each joint of a pose is in the skeleton's space, reusing
+// out when it's big enough. Joints the pose is too short for are at rest
+func (s *Skeleton) World(p Pose, out []algebra.Matrix) []algebra.Matrix {
+	if cap(out) < len(s.Joints) {
+		out = make([]algebra.Matrix, len(s.Joints))
+	}
+	out = out[:len(s.Joints)]
+	for i := 0; i < len(s.Joints); i++ {
+		local := s.Joints[i].Rest
+		if i < len(p) {
+			local = p[i]
+		}
+		m := local.Matrix()
+		if parent := s.Joints[i].Parent; parent >= 0 && parent < i {
+			m.Mul(out[parent], &out[i])
+		} else {
+			out[i] = m
+		}
+	}
+	return out
+}
+
+// Skin the skinning matrix of each joint of a pose, which takes a
+// vertex bound to the joint from where the mesh was modeled to where the
+// pose puts it. These are what the Skinned vertex shader and
+// geometry.Polyhedron.Skin want
+func (s *Skeleton) Skin(p Pose, out []algebra.Matrix) []algebra.Matrix {
+	out = s.World(p, out)
+	for i := 0; i < len(out); i++ {
+		bind := s.Joints[i].InverseBind
+		if bind == (algebra.Matrix{}) {
+			continue
+		}
+		world := out[i]
+		bind.Mul(world, &out[i])
+	}
+	return out
+}
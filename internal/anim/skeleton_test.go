@@ -0,0 +1,120 @@
+package anim_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// mockArm two joints, an elbow one up from the shoulder, bound where
+// they rest
+func mockArm() *anim.Skeleton {
+	s := &anim.Skeleton{
+		Joints: []anim.Joint{
+			{Name: "shoulder", Parent: -1},
+			{Name: "elbow", Parent: 0, Rest: anim.Transform{Translation: algebra.Vector{Y: 1}}},
+		},
+	}
+	world := s.World(s.RestPose(), nil)
+	for i := 0; i < len(world); i++ {
+		world[i].Inverse(&s.Joints[i].InverseBind)
+	}
+	return s
+}
+
+func turn(axis algebra.Vector, angle float64) algebra.Quaternion {
+	q := algebra.Quaternion{}
+	q.SetFromVector(&axis, angle)
+	return q
+}
+
+func TestSkeleton(t *testing.T) {
+	s := mockArm()
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if s.Find("elbow") != 1 || s.Find("knee") != -1 {
+		t.Errorf("Find: got %v and %v", s.Find("elbow"), s.Find("knee"))
+	}
+
+	// At rest the mesh stays where it was modeled
+	rest := s.Skin(s.RestPose(), nil)
+	identity := algebra.Matrix{}
+	identity.InitIdentity()
+	for i := 0; i < len(rest); i++ {
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				if math.Abs(rest[i][r][c]-identity[r][c]) > 1e-9 {
+					t.Fatalf("joint %v at rest should skin with the identity, got %v", i, rest[i])
+				}
+			}
+		}
+	}
+
+	// Turning the shoulder swings the elbow, and what hangs off it
+	pose := s.RestPose()
+	pose[0].Rotation = turn(algebra.Vector{Z: 1}, math.Pi/2)
+	world := s.World(pose, nil)
+	elbow := algebra.Vector{}
+	world[1].Transform(algebra.Vector{W: 1}, &elbow)
+	if math.Abs(elbow.X+1) > 1e-9 || math.Abs(elbow.Y) > 1e-9 {
+		t.Errorf("elbow should swing to -x, got %v", elbow)
+	}
+
+	hand := geometry.Vertex{
+		Pos:     algebra.Vector{Y: 2},
+		Joints:  algebra.Vector{X: 1},
+		Weights: algebra.Vector{X: 1},
+	}.Skin(s.Skin(pose, nil))
+	if math.Abs(hand.Pos.X+2) > 1e-9 || math.Abs(hand.Pos.Y) > 1e-9 {
+		t.Errorf("hand should swing to -2x, got %v", hand.Pos)
+	}
+
+	// Bending the elbow on top only moves what's past it
+	pose[1].Rotation = turn(algebra.Vector{Z: 1}, math.Pi/2)
+	hand = geometry.Vertex{
+		Pos:     algebra.Vector{Y: 2},
+		Joints:  algebra.Vector{X: 1},
+		Weights: algebra.Vector{X: 1},
+	}.Skin(s.Skin(pose, nil))
+	if math.Abs(hand.Pos.X+1) > 1e-9 || math.Abs(hand.Pos.Y+1) > 1e-9 {
+		t.Errorf("hand should bend down to (-1, -1), got %v", hand.Pos)
+	}
+}
+
+func TestSkeletonValidate(t *testing.T) {
+	s := anim.Skeleton{
+		Joints: []anim.Joint{
+			{Name: "child", Parent: 1},
+			{Name: "parent", Parent: -1},
+		},
+	}
+	if err := s.Validate(); err == nil {
+		t.Errorf("Validate: a parent after its child should fail")
+	}
+}
+
+func TestTransformMatrix(t *testing.T) {
+	tr := anim.Transform{
+		Translation: algebra.Vector{X: 1},
+		Rotation:    turn(algebra.Vector{Y: 1}, math.Pi/2),
+		Scale:       algebra.Vector{X: 2, Y: 2, Z: 2},
+	}
+	m := tr.Matrix()
+	out := algebra.Vector{}
+	m.Transform(algebra.Vector{Z: 1, W: 1}, &out)
+	// Scaled to 2, turned about y onto +x, then moved along
+	if math.Abs(out.X-3) > 1e-9 || math.Abs(out.Z) > 1e-9 {
+		t.Errorf("Matrix: got %v, want (3, 0, 0)", out)
+	}
+
+	// Unset scale and rotation change nothing
+	m = anim.Transform{}.Matrix()
+	m.Transform(algebra.Vector{X: 1, Y: 2, Z: 3, W: 1}, &out)
+	if out.X != 1 || out.Y != 2 || out.Z != 3 {
+		t.Errorf("zero Transform should be the identity, got %v", out)
+	}
+}
@@ -0,0 +1,29 @@
+package core
+
+// The action and axis names the built in components listen for
+const (
+	// ActionOrbit held while dragging to orbit
+	ActionOrbit = "orbit"
+	// ActionPan held while dragging to pan
+	ActionPan = "pan"
+	// ActionLook held while the mouse should turn the view
+	ActionLook = "look"
+
+	// AxisLookX turning left and right (e.g. mouse movement)
+	AxisLookX = "look_x"
+	// AxisLookY turning up and down (e.g. mouse movement)
+	AxisLookY = "look_y"
+	// AxisLookXPad turning left and right with a stick, as a rate: the
+	// same units as AxisLookX but per second, and with no button held
+	AxisLookXPad = "look_x_pad"
+	// AxisLookYPad turning up and down with a stick, like AxisLookXPad
+	AxisLookYPad = "look_y_pad"
+	// AxisZoom in (positive) and out
+	AxisZoom = "zoom"
+	// AxisMoveForward forward (positive) and back
+	AxisMoveForward = "move_forward"
+	// AxisMoveRight right (positive) and left
+	AxisMoveRight = "move_right"
+	// AxisMoveUp up (positive) and down
+	AxisMoveUp = "move_up"
+)
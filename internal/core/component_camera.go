@@ -23,6 +23,14 @@ type ComponentCamera struct {
 	View       *algebra.Matrix
 	Projection *algebra.Matrix
 	PixelRatio float32
+	// Target draws the camera's view into a texture instead of the
+	// window, nil for the window
+	Target RenderTarget
+
+	// The options the projection was last built from, so it can be
+	// rebuilt when the window changes size
+	perspective  *algebra.PerspectiveOptions
+	orthographic *algebra.OrthographicOptions
 }
 
 // GetView get the current view matrix
@@ -37,31 +45,29 @@ func (c *ComponentCamera) GetProjection() *algebra.Matrix {
 
 // UpdateViewMatrix update the view model based on the parents transform
 func (c *ComponentCamera) UpdateViewMatrix() {
-	transform := c.GetParent().Transform
-
-	translation := algebra.Matrix{}
-	translation.InitTranslation(&transform.Position)
-
-	rotation := algebra.Matrix{}
-	rotation.InitFUR(
-		&transform.Forward,
-		&transform.Up,
-		&transform.Right)
-
-	scale := algebra.Matrix{}
-	scale.InitScale(&transform.Scale)
-
-	tr := algebra.Matrix{}
-	trs := algebra.Matrix{}
+	c.GetParent().Transform.GetTransformation().Inverse(c.View)
+}
 
-	translation.Mul(rotation, &tr)
-	tr.Mul(scale, &trs)
+// LookAt turn the parent entity to face target. The camera looks down
+// -Z so the entity's Forward ends up pointing away from target
+func (c *ComponentCamera) LookAt(target algebra.Vector, up algebra.Vector) {
+	lookFrom(c.GetParent().Transform, target, up)
+}
 
-	trs.Inverse(c.View)
+// lookFrom turn a camera transform so it looks at target
+func lookFrom(t *Transform, target algebra.Vector, up algebra.Vector) {
+	back := algebra.Vector{}
+	t.Position.SubV(target, &back)
+	back.W = 0
+	t.Rotation.LookRotation(back, up)
+	t.RotationMatrix(&t.Rotation)
 }
 
 // UpdatePerspective Update the perspective
 func (c *ComponentCamera) UpdatePerspective(width, height int32, o algebra.PerspectiveOptions) {
+	c.perspective = &o
+	c.orthographic = nil
+	c.PixelRatio = float32(o.PixelRatio)
 
 	c.Projection.InitPerspective(algebra.PerspectiveOptions{
 		Fov:         algebra.DegToRad(o.Fov),
@@ -70,3 +76,80 @@ func (c *ComponentCamera) UpdatePerspective(width, height int32, o algebra.Persp
 		Far:         o.Far,
 	})
 }
+
+// UpdateOrthographic use an orthographic projection, o.Size is half the
+// height of the view in world units
+func (c *ComponentCamera) UpdateOrthographic(width, height int32, o algebra.OrthographicOptions) {
+	c.orthographic = &o
+	c.perspective = nil
+	c.PixelRatio = float32(o.PixelRatio)
+	c.Projection.InitOrthographic(algebra.OrthographicOptions{
+		Size:        o.Size,
+		AspectRatio: (math.Floor(float64(width)*o.PixelRatio) / math.Floor(float64(height)*o.PixelRatio)),
+		Near:        o.Near,
+		Far:         o.Far,
+	})
+}
+
+// PerspectiveOptions the options of the current projection, false when
+// it isn't a perspective one
+func (c *ComponentCamera) PerspectiveOptions() (algebra.PerspectiveOptions, bool) {
+	if c.perspective == nil {
+		return algebra.PerspectiveOptions{}, false
+	}
+	return *c.perspective, true
+}
+
+// OrthographicOptions the options of the current projection, false when
+// it isn't an orthographic one
+func (c *ComponentCamera) OrthographicOptions() (algebra.OrthographicOptions, bool) {
+	if c.orthographic == nil {
+		return algebra.OrthographicOptions{}, false
+	}
+	return *c.orthographic, true
+}
+
+// Resize rebuild the projection for a new window size or pixel ratio.
+// Cameras drawing into a Target keep the target's shape
+func (c *ComponentCamera) Resize(s Settings) {
+	if s.Width <= 0 || s.Height <= 0 || c.Target != nil {
+		return
+	}
+	switch {
+	case c.perspective != nil:
+		o := *c.perspective
+		o.PixelRatio = s.GetPixelRatio()
+		c.UpdatePerspective(s.Width, s.Height, o)
+	case c.orthographic != nil:
+		o := *c.orthographic
+		o.PixelRatio = s.GetPixelRatio()
+		c.UpdateOrthographic(s.Width, s.Height, o)
+	}
+}
+
+// ScreenPointToRay turn a point on the screen (in pixels, top left is 0,0)
+// into a world space ray leaving the camera's near plane
+func (c *ComponentCamera) ScreenPointToRay(x, y float64, width, height int32) algebra.Ray {
+	ndcX := 2*x/float64(width) - 1
+	ndcY := 1 - 2*y/float64(height)
+
+	viewProj := algebra.Matrix{}
+	c.View.Mul(*c.Projection, &viewProj)
+	inv := algebra.Matrix{}
+	viewProj.Inverse(&inv)
+
+	near := unproject(&inv, algebra.Vector{X: ndcX, Y: ndcY, Z: -1, W: 1})
+	far := unproject(&inv, algebra.Vector{X: ndcX, Y: ndcY, Z: 1, W: 1})
+
+	direction := algebra.Vector{}
+	far.SubV(near, &direction)
+	return algebra.NewRay(near, direction)
+}
+
+func unproject(inv *algebra.Matrix, ndc algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	inv.Transform(ndc, &out)
+	out.Div(out.W, &out)
+	out.W = 1
+	return out
+}
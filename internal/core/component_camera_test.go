@@ -0,0 +1,108 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+func mockCamera() (*core.Entity, *core.ComponentCamera) {
+	entity := core.Entity{
+		Name:      "camera",
+		Transform: core.NewTransform(),
+	}
+	camera := core.NewComponentCamera()
+	entity.Attach(&camera)
+	return &entity, &camera
+}
+
+func TestCameraLookAt(t *testing.T) {
+	entity, camera := mockCamera()
+	entity.Transform.Position = algebra.Vector{X: 4, Y: 3, Z: 5}
+	target := algebra.Vector{X: 1, Y: 0, Z: -2}
+
+	camera.LookAt(target, algebra.Up)
+	camera.UpdateViewMatrix()
+
+	expected := algebra.Matrix{}
+	expected.InitLookAt(&entity.Transform.Position, &target, &algebra.Up)
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			if math.Abs(camera.View[r][c]-expected[r][c]) > algebra.Precision {
+				t.Fatalf("LookAt: view %v should be %v", camera.View, expected)
+			}
+		}
+	}
+}
+
+func TestCameraOrthographicRay(t *testing.T) {
+	_, camera := mockCamera()
+	camera.UpdateOrthographic(800, 600, algebra.OrthographicOptions{
+		Size:       3,
+		Near:       0.1,
+		Far:        100,
+		PixelRatio: 1,
+	})
+	camera.UpdateViewMatrix()
+
+	// Every ray points straight ahead, just from a different place
+	ray := camera.ScreenPointToRay(800, 0, 800, 600)
+	expected := algebra.Vector{Z: -1}
+	if !ray.Direction.AlmostEquals(&expected) {
+		t.Errorf("Orthographic: direction %v should be %v", ray.Direction, expected)
+	}
+	if math.Abs(ray.Origin.X-4) > algebra.Precision || math.Abs(ray.Origin.Y-3) > algebra.Precision {
+		t.Errorf("Orthographic: top right origin %v should be at 4, 3", ray.Origin)
+	}
+}
+
+func TestCameraResize(t *testing.T) {
+	scene := core.Scene{}
+	entity, camera := mockCamera()
+	scene.Add(entity)
+	camera.UpdatePerspective(800, 600, algebra.PerspectiveOptions{
+		Fov:        60,
+		Near:       0.1,
+		Far:        100,
+		PixelRatio: 1,
+	})
+	before := *camera.Projection
+
+	// Twice as wide: the horizontal scale halves, the vertical stays
+	settings := core.Settings{}
+	settings.SetWindowSize(1600, 600, 3200, 1200)
+	scene.Resize(settings)
+
+	if math.Abs(camera.Projection[0][0]-before[0][0]/2) > algebra.Precision {
+		t.Errorf("Resize: x scale %v should be %v", camera.Projection[0][0], before[0][0]/2)
+	}
+	if math.Abs(camera.Projection[1][1]-before[1][1]) > algebra.Precision {
+		t.Errorf("Resize: y scale %v should be %v", camera.Projection[1][1], before[1][1])
+	}
+	if camera.PixelRatio != 2 {
+		t.Errorf("Resize: pixel ratio %v should be 2", camera.PixelRatio)
+	}
+
+	// Minimized windows are ignored
+	scene.Resize(core.Settings{})
+	if math.Abs(camera.Projection[0][0]-before[0][0]/2) > algebra.Precision {
+		t.Errorf("Resize: a zero size should leave the projection alone")
+	}
+
+	// So are cameras drawing into a render target
+	camera.Target = mockTarget{}
+	settings.SetWindowSize(800, 800, 800, 800)
+	scene.Resize(settings)
+	if math.Abs(camera.Projection[0][0]-before[0][0]/2) > algebra.Precision || camera.PixelRatio != 2 {
+		t.Errorf("Resize: a camera with a target should keep its projection")
+	}
+}
+
+// mockTarget a render target 256 pixels square
+type mockTarget struct{}
+
+func (mockTarget) TargetSize() (int32, int32) {
+	return 256, 256
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// NewComponentFlyController create a first person fly controller
+func NewComponentFlyController() ComponentFlyController {
+	return ComponentFlyController{
+		Component: &Component{
+			Parent: &Entity{},
+		},
+		LookSpeed: 0.003,
+		MoveSpeed: 5,
+	}
+}
+
+// ComponentFlyController first person free flying camera, mouse to look
+// and keys to move
+type ComponentFlyController struct {
+	*Component
+	// Yaw turn about world up (rads)
+	Yaw float64
+	// Pitch look up (positive) and down (rads)
+	Pitch float64
+	// LookSpeed rads turned per unit passed to Look (e.g. pixels)
+	LookSpeed float64
+	// MoveSpeed world units per second
+	MoveSpeed float64
+
+	move algebra.Vector
+}
+
+// Look turn the view, dx and dy are usually mouse movement
+func (c *ComponentFlyController) Look(dx, dy float64) {
+	const limit = math.Pi/2 - 0.01
+	c.Yaw -= dx * c.LookSpeed
+	c.Pitch -= dy * c.LookSpeed
+	c.Pitch = math.Max(-limit, math.Min(limit, c.Pitch))
+}
+
+// Move set which way to fly (each -1 to 1) relative to where the view
+// is facing. It keeps flying that way until Move is called again
+func (c *ComponentFlyController) Move(forward, right, up float64) {
+	c.move = algebra.Vector{X: right, Y: up, Z: -forward}
+}
+
+// Input look around using AxisLookX and AxisLookY while ActionLook is
+// held, or AxisLookXPad and AxisLookYPad, and fly with AxisMoveForward,
+// AxisMoveRight and AxisMoveUp
+func (c *ComponentFlyController) Input(in InputState, dt float64) {
+	if in.Held(ActionLook) {
+		c.Look(in.Axis(AxisLookX), in.Axis(AxisLookY))
+	}
+	if px, py := in.Axis(AxisLookXPad), in.Axis(AxisLookYPad); px != 0 || py != 0 {
+		c.Look(px*dt, py*dt)
+	}
+	c.Move(in.Axis(AxisMoveForward), in.Axis(AxisMoveRight), in.Axis(AxisMoveUp))
+}
+
+// Update turn and move the parent
+func (c *ComponentFlyController) Update(dt float64) {
+	transform := c.GetParent().Transform
+	q := algebra.Quaternion{}
+	q.SetFromEuler(&algebra.Vector{X: c.Pitch, Y: c.Yaw}, algebra.EulerYXZ)
+
+	if !c.move.IsZero() {
+		step := algebra.Vector{}
+		q.Rotate(c.move, &step)
+		if step.Length() > 1 {
+			step.Normalized(&step)
+		}
+		step.Mul(c.MoveSpeed*dt, &step)
+		transform.Position.AddV(step, &transform.Position)
+	}
+
+	transform.Rotation = q
+	transform.RotationMatrix(&transform.Rotation)
+}
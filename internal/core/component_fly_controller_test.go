@@ -0,0 +1,36 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+func TestFlyController(t *testing.T) {
+	entity, _ := mockCamera()
+	fly := core.NewComponentFlyController()
+	entity.Attach(&fly)
+
+	fly.Move(1, 0, 0)
+	fly.Update(1)
+	expected := algebra.Vector{Z: -fly.MoveSpeed}
+	if !entity.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("Fly: forward %v should be %v", entity.Transform.Position, expected)
+	}
+
+	// Turn left a quarter, forward is now -X
+	entity.Transform.Position = algebra.Vector{}
+	fly.Look(-math.Pi/2/fly.LookSpeed, 0)
+	fly.Update(1)
+	expected = algebra.Vector{X: -fly.MoveSpeed}
+	if !entity.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("Fly: turned forward %v should be %v", entity.Transform.Position, expected)
+	}
+
+	fly.Look(0, 100000)
+	if fly.Pitch < -math.Pi/2 {
+		t.Errorf("Fly: pitch %v should not flip over", fly.Pitch)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// NewComponentFollowController create a controller that trails target
+func NewComponentFollowController(target *Entity, offset algebra.Vector) ComponentFollowController {
+	return ComponentFollowController{
+		Component: &Component{
+			Parent: &Entity{},
+		},
+		Target:       target,
+		Offset:       offset,
+		Damping:      5,
+		LookAtTarget: true,
+	}
+}
+
+// ComponentFollowController smoothly keeps the parent near another
+// entity, like a chase camera
+type ComponentFollowController struct {
+	*Component
+	Target *Entity
+	// Offset where to sit relative to the target, in the target's space
+	// so it stays behind the target as it turns
+	Offset algebra.Vector
+	// Damping how quickly to catch up, higher is snappier and 0 snaps
+	// straight into place
+	Damping float64
+	// LookAtTarget keep the parent (as a camera) facing the target
+	LookAtTarget bool
+}
+
+// Update move the parent toward its spot behind the target
+func (c *ComponentFollowController) Update(dt float64) {
+	if c.Target == nil || c.Target.Transform == nil {
+		return
+	}
+	transform := c.GetParent().Transform
+	target := c.Target.Transform.Position
+
+	desired := algebra.Vector{}
+	c.Target.Transform.Rotation.Rotate(c.Offset, &desired)
+	desired.AddV(target, &desired)
+
+	if c.Damping <= 0 {
+		transform.Position = desired
+	} else {
+		// Frame rate independent exponential smoothing
+		t := 1 - math.Exp(-c.Damping*dt)
+		delta := algebra.Vector{}
+		desired.SubV(transform.Position, &delta)
+		delta.Mul(t, &delta)
+		transform.Position.AddV(delta, &transform.Position)
+	}
+
+	if c.LookAtTarget {
+		lookFrom(transform, target, algebra.Up)
+	}
+}
@@ -0,0 +1,55 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+func TestFollowController(t *testing.T) {
+	player := core.Entity{
+		Name:      "player",
+		Transform: core.NewTransform(),
+	}
+	player.Transform.Position = algebra.Vector{X: 10}
+
+	entity, _ := mockCamera()
+	follow := core.NewComponentFollowController(&player, algebra.Vector{Y: 2, Z: 5})
+	entity.Attach(&follow)
+
+	// Damped, so it only gets part of the way there
+	follow.Update(0.1)
+	x := entity.Transform.Position.X
+	fraction := 1 - math.Exp(-follow.Damping*0.1)
+	if math.Abs(x-10*fraction) > algebra.Precision {
+		t.Errorf("Follow: x %v should be %v", x, 10*fraction)
+	}
+
+	for i := 0; i < 200; i++ {
+		follow.Update(0.1)
+	}
+	expected := algebra.Vector{X: 10, Y: 2, Z: 5}
+	if !entity.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("Follow: settled at %v should be %v", entity.Transform.Position, expected)
+	}
+
+	// Offset turns with the target
+	follow.Damping = 0
+	player.Transform.Rotation.SetFromVector(&algebra.AxisY, math.Pi/2)
+	follow.Update(0.1)
+	expected = algebra.Vector{X: 15, Y: 2}
+	if !entity.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("Follow: turned %v should be %v", entity.Transform.Position, expected)
+	}
+
+	// Looking at the player, so -Z points at them
+	look := algebra.Vector{}
+	entity.Transform.Rotation.Rotate(algebra.Vector{Z: -1}, &look)
+	toPlayer := algebra.Vector{X: -5, Y: -2}
+	toPlayer.Normalized(&toPlayer)
+	if !look.AlmostEquals(&toPlayer) {
+		t.Errorf("Follow: looking %v should be %v", look, toPlayer)
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// NewComponentOrbitController create a controller circling target at
+// distance
+func NewComponentOrbitController(target algebra.Vector, distance float64) ComponentOrbitController {
+	return ComponentOrbitController{
+		Component: &Component{
+			Parent: &Entity{},
+		},
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 0.5,
+		MaxDistance: 500,
+		MinPitch:    -math.Pi/2 + 0.01,
+		MaxPitch:    math.Pi/2 - 0.01,
+		RotateSpeed: 0.01,
+		ZoomSpeed:   0.1,
+		PanSpeed:    0.002,
+	}
+}
+
+// ComponentOrbitController keeps the parent (usually a camera) circling
+// and looking at a target point
+type ComponentOrbitController struct {
+	*Component
+	Target   algebra.Vector
+	Distance float64
+	// Yaw turn about world up (rads)
+	Yaw float64
+	// Pitch tilt up and down (rads), negative looks down on the target
+	Pitch       float64
+	MinDistance float64
+	MaxDistance float64
+	MinPitch    float64
+	MaxPitch    float64
+	// RotateSpeed rads turned per unit passed to Rotate (e.g. pixels)
+	RotateSpeed float64
+	// ZoomSpeed fraction of the distance moved per unit passed to Zoom
+	ZoomSpeed float64
+	// PanSpeed world units moved per unit passed to Pan, per unit of
+	// distance so panning feels the same close up and far away
+	PanSpeed float64
+}
+
+// Rotate swing around the target, dx and dy are usually mouse movement
+func (c *ComponentOrbitController) Rotate(dx, dy float64) {
+	c.Yaw -= dx * c.RotateSpeed
+	c.Pitch -= dy * c.RotateSpeed
+	c.Pitch = math.Max(c.MinPitch, math.Min(c.MaxPitch, c.Pitch))
+}
+
+// Zoom move toward (positive) or away from (negative) the target,
+// amount is usually mouse wheel clicks
+func (c *ComponentOrbitController) Zoom(amount float64) {
+	c.Distance *= math.Pow(1-c.ZoomSpeed, amount)
+	c.Distance = math.Max(c.MinDistance, math.Min(c.MaxDistance, c.Distance))
+}
+
+// Pan slide the target across the view, dx and dy are usually mouse
+// movement
+func (c *ComponentOrbitController) Pan(dx, dy float64) {
+	q := c.rotation()
+	right := algebra.Vector{}
+	up := algebra.Vector{}
+	q.Rotate(algebra.Right, &right)
+	q.Rotate(algebra.Up, &up)
+
+	step := c.PanSpeed * c.Distance
+	right.Mul(-dx*step, &right)
+	up.Mul(dy*step, &up)
+	c.Target.AddV(right, &c.Target)
+	c.Target.AddV(up, &c.Target)
+}
+
+// Input drag with ActionOrbit or ActionPan held to turn or pan using
+// AxisLookX and AxisLookY, turn with AxisLookXPad and AxisLookYPad, and
+// AxisZoom to zoom
+func (c *ComponentOrbitController) Input(in InputState, dt float64) {
+	dx, dy := in.Axis(AxisLookX), in.Axis(AxisLookY)
+	if in.Held(ActionOrbit) {
+		c.Rotate(dx, dy)
+	}
+	if px, py := in.Axis(AxisLookXPad), in.Axis(AxisLookYPad); px != 0 || py != 0 {
+		c.Rotate(px*dt, py*dt)
+	}
+	if in.Held(ActionPan) {
+		c.Pan(dx, dy)
+	}
+	if zoom := in.Axis(AxisZoom); zoom != 0 {
+		c.Zoom(zoom)
+	}
+}
+
+// Update move the parent to its place on the orbit
+func (c *ComponentOrbitController) Update(dt float64) {
+	transform := c.GetParent().Transform
+	q := c.rotation()
+
+	// The camera looks down -Z so it sits out along +Z
+	offset := algebra.Vector{}
+	q.Rotate(algebra.Vector{Z: c.Distance}, &offset)
+	c.Target.AddV(offset, &transform.Position)
+
+	transform.Rotation = q
+	transform.RotationMatrix(&transform.Rotation)
+}
+
+func (c *ComponentOrbitController) rotation() algebra.Quaternion {
+	q := algebra.Quaternion{}
+	q.SetFromEuler(&algebra.Vector{X: c.Pitch, Y: c.Yaw}, algebra.EulerYXZ)
+	return q
+}
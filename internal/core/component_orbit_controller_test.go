@@ -0,0 +1,140 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+)
+
+func TestOrbitController(t *testing.T) {
+	scene := core.Scene{}
+	entity, camera := mockCamera()
+	target := algebra.Vector{X: 1, Y: 2, Z: 3}
+	orbit := core.NewComponentOrbitController(target, 10)
+	entity.Attach(&orbit)
+	scene.Add(entity)
+
+	scene.Update(0.016)
+	expected := algebra.Vector{X: 1, Y: 2, Z: 13}
+	if !entity.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("Orbit: position %v should be %v", entity.Transform.Position, expected)
+	}
+
+	// A quarter turn and looking down
+	orbit.Yaw = math.Pi / 2
+	orbit.Pitch = -math.Pi / 4
+	scene.Update(0.016)
+	pos := entity.Transform.Position
+	if math.Abs(pos.Y-(2+10*math.Sin(math.Pi/4))) > algebra.Precision || pos.X <= 1 {
+		t.Errorf("Orbit: should be above and to the side of the target %v", pos)
+	}
+
+	// The target should be straight ahead of the camera
+	camera.UpdateViewMatrix()
+	view := algebra.Vector{}
+	camera.View.Transform(algebra.Vector{X: target.X, Y: target.Y, Z: target.Z, W: 1}, &view)
+	ahead := algebra.Vector{Z: -10}
+	if !view.AlmostEquals(&ahead) {
+		t.Errorf("Orbit: target in view space %v should be %v", view, ahead)
+	}
+}
+
+func TestOrbitControllerLimits(t *testing.T) {
+	orbit := core.NewComponentOrbitController(algebra.Vector{}, 10)
+	orbit.Rotate(0, -100000)
+	if orbit.Pitch > orbit.MaxPitch+algebra.Precision {
+		t.Errorf("Orbit: pitch %v should be clamped to %v", orbit.Pitch, orbit.MaxPitch)
+	}
+
+	orbit.Zoom(1)
+	if math.Abs(orbit.Distance-9) > algebra.Precision {
+		t.Errorf("Orbit: zoom should close 10%% of the distance got %v", orbit.Distance)
+	}
+	orbit.Zoom(-1000)
+	if orbit.Distance != orbit.MaxDistance {
+		t.Errorf("Orbit: distance %v should be clamped to %v", orbit.Distance, orbit.MaxDistance)
+	}
+
+	orbit.Pitch = 0
+	orbit.Yaw = 0
+	orbit.Pan(100, 0)
+	if orbit.Target.X >= 0 || orbit.Target.Y != 0 {
+		t.Errorf("Orbit: dragging right should move the target left %v", orbit.Target)
+	}
+}
+
+func TestOrbitControllerInput(t *testing.T) {
+	m := &input.Map{
+		Actions: map[string][]input.Button{core.ActionOrbit: {"mouse:left"}},
+		Axes: map[string]input.AxisBinding{
+			core.AxisLookX: {Axes: []input.Axis{input.MouseDX}},
+			core.AxisLookY: {Axes: []input.Axis{input.MouseDY}},
+			core.AxisZoom:  {Axes: []input.Axis{input.WheelY}},
+		},
+	}
+	source := input.NewFakeSource(
+		// Moving without the button held does nothing
+		input.Frame{Axes: map[input.Axis]float64{input.MouseDX: 50}},
+		input.Frame{
+			Held: []input.Button{"mouse:left"},
+			Axes: map[input.Axis]float64{input.MouseDX: 10, input.WheelY: 1},
+		},
+	)
+	in := input.NewSystem(source, m)
+
+	scene := core.Scene{}
+	entity, _ := mockCamera()
+	orbit := core.NewComponentOrbitController(algebra.Vector{}, 10)
+	entity.Attach(&orbit)
+	scene.Add(entity)
+
+	in.Update()
+	scene.Input(in, 0.016)
+	if orbit.Yaw != 0 {
+		t.Errorf("Orbit: should not turn without the button held %v", orbit.Yaw)
+	}
+
+	in.Update()
+	scene.Input(in, 0.016)
+	if math.Abs(orbit.Yaw+10*orbit.RotateSpeed) > algebra.Precision {
+		t.Errorf("Orbit: yaw %v should be %v", orbit.Yaw, -10*orbit.RotateSpeed)
+	}
+	if math.Abs(orbit.Distance-9) > algebra.Precision {
+		t.Errorf("Orbit: should have zoomed in to 9 got %v", orbit.Distance)
+	}
+}
+
+func TestOrbitControllerStick(t *testing.T) {
+	m := &input.Map{
+		Axes: map[string]input.AxisBinding{
+			core.AxisLookXPad: {Axes: []input.Axis{"pad:rightx"}, DeadZone: 0.2, Scale: 100},
+		},
+	}
+	source := input.NewFakeSource(
+		input.Frame{Axes: map[input.Axis]float64{"pad:rightx": 0.1}},
+		input.Frame{Axes: map[input.Axis]float64{"pad:rightx": 1}},
+	)
+	in := input.NewSystem(source, m)
+
+	scene := core.Scene{}
+	entity, _ := mockCamera()
+	orbit := core.NewComponentOrbitController(algebra.Vector{}, 10)
+	entity.Attach(&orbit)
+	scene.Add(entity)
+
+	in.Update()
+	scene.Input(in, 0.5)
+	if orbit.Yaw != 0 {
+		t.Errorf("Orbit: stick drift should not turn %v", orbit.Yaw)
+	}
+
+	// Turns without a button, at a rate
+	in.Update()
+	scene.Input(in, 0.5)
+	if math.Abs(orbit.Yaw+50*orbit.RotateSpeed) > algebra.Precision {
+		t.Errorf("Orbit: yaw %v should be %v", orbit.Yaw, -50*orbit.RotateSpeed)
+	}
+}
@@ -1,6 +1,19 @@
 package core
 
 const (
-	ComponentTypeCamera = "*core.ComponentCamera"
-	ComponentTypeRender = "*render.ComponentRender"
+	ComponentTypeCamera           = "*core.ComponentCamera"
+	ComponentTypeOrbitController  = "*core.ComponentOrbitController"
+	ComponentTypeFlyController    = "*core.ComponentFlyController"
+	ComponentTypeFollowController = "*core.ComponentFollowController"
+	ComponentTypeRender           = "*render.ComponentRender"
+	ComponentTypeLight            = "*render.ComponentLight"
+	ComponentTypePostProcess      = "*render.ComponentPostProcess"
+	ComponentTypeSDF              = "*render.ComponentSDF"
+	ComponentTypeRigidBody        = "*physics.ComponentRigidBody"
+	ComponentTypeCollider         = "*physics.ComponentCollider"
+	ComponentTypeCharacter        = "*physics.ComponentCharacterController"
+	ComponentTypePrefab           = "*scenefile.ComponentPrefab"
+	ComponentTypeAnimator         = "*anim.ComponentAnimator"
+	ComponentTypeMorph            = "*anim.ComponentMorph"
+	ComponentTypeTween            = "*tween.ComponentTween"
 )
@@ -19,8 +19,19 @@ func (ge *Entity) Add(e *Entity) {
 	ge.children = append(ge.children, e)
 }
 
+// Children the sub entities of this entity
+func (ge *Entity) Children() []*Entity {
+	return ge.children
+}
+
+// Remove take a sub entity away from this entity
 func (ge *Entity) Remove(e *Entity) {
-	panic("Not implemented")
+	for i := 0; i < len(ge.children); i++ {
+		if ge.children[i] == e {
+			ge.children = append(ge.children[:i], ge.children[i+1:]...)
+			return
+		}
+	}
 }
 
 // Attach add a component to this entity
@@ -31,7 +42,17 @@ func (ge *Entity) Attach(cmp Componenter) {
 
 // Detach a component from this entity
 func (ge *Entity) Detach(cmp Componenter) {
-	panic("Not implemented")
+	for i := 0; i < len(ge.components); i++ {
+		if ge.components[i] == cmp {
+			ge.components = append(ge.components[:i], ge.components[i+1:]...)
+			return
+		}
+	}
+}
+
+// Components all the components attached to this entity
+func (ge *Entity) Components() []Componenter {
+	return ge.components
 }
 
 // GetComponent get component by name
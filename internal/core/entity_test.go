@@ -40,3 +40,30 @@ func TestGetComponentNil(t *testing.T) {
 		t.Fatalf("expected comp to not be found")
 	}
 }
+
+func TestDetach(t *testing.T) {
+	e := mockEntity()
+	comp := e.GetComponent(core.ComponentTypeRender)
+	e.Detach(comp)
+
+	if e.GetComponent(core.ComponentTypeRender) != nil {
+		t.Errorf("component should have been detached")
+	}
+	if e.GetComponent(core.ComponentTypeCamera) == nil {
+		t.Errorf("other components should be kept")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	e := mockEntity()
+	a := &core.Entity{Name: "a"}
+	b := &core.Entity{Name: "b"}
+	e.Add(a)
+	e.Add(b)
+	e.Remove(a)
+
+	children := e.Children()
+	if len(children) != 1 || children[0] != b {
+		t.Errorf("only b should be left %v", children)
+	}
+}
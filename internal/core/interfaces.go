@@ -1,5 +1,10 @@
 package core
 
+import (
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
 // Initializer can be initialized
 type Initializer interface {
 	Initialize()
@@ -41,6 +46,43 @@ type Componenter interface {
 	SetParent(*Entity)
 }
 
+// RenderTarget somewhere other than the window a camera can draw into,
+// see render.RenderTarget
+type RenderTarget interface {
+	// TargetSize the size in pixels
+	TargetSize() (width int32, height int32)
+}
+
+// Meshed a component that has geometry which can be hit tested
+type Meshed interface {
+	GetPolyhedron() *geometry.Polyhedron
+}
+
+// Raycaster a component that hit tests itself, for shapes that aren't
+// triangles (see render.ComponentSDF). The ray is in world space
+type Raycaster interface {
+	Raycast(ray algebra.Ray, maxDistance float64) (RaycastHit, bool)
+}
+
+// Skinned a component that poses a skeleton which skinned meshes on
+// the same entity follow (see anim.ComponentAnimator). The matrices take
+// each joint from the bind pose to where it is now, in the entity's space
+type Skinned interface {
+	JointMatrices() []algebra.Matrix
+}
+
+// Morphed a component that blends the mesh on the same entity toward
+// its morph targets (see anim.ComponentMorph), by target index
+type Morphed interface {
+	MorphWeights() []float64
+}
+
+// AnimationListener a component told when the animation playing on its
+// entity passes a named event, like a footstep (see anim.Event)
+type AnimationListener interface {
+	AnimationEvent(name string)
+}
+
 //////////////////////////////////////////////////
 
 // Updater a component that can update itself
@@ -48,7 +90,24 @@ type Updater interface {
 	Update(float64)
 }
 
+// Resizer a component that depends on the size of the window
+type Resizer interface {
+	Resize(Settings)
+}
+
+// InputState the named actions and axes for the current frame
+type InputState interface {
+	// Pressed whether the action started this frame
+	Pressed(action string) bool
+	// Held whether the action is active this frame
+	Held(action string) bool
+	// Released whether the action stopped this frame
+	Released(action string) bool
+	// Axis the value of a named axis this frame
+	Axis(name string) float64
+}
+
 // Inputter a component that can take user input
 type Inputter interface {
-	Input(float64)
+	Input(InputState, float64)
 }
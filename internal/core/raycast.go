@@ -0,0 +1,129 @@
+package core
+
+import "github.com/robrohan/mesh/internal/algebra"
+
+// RaycastHit what a ray ran into
+type RaycastHit struct {
+	Entity *Entity
+	// Triangle index of the triangle in the entity's Polyhedron, -1 for
+	// hits on a Raycaster
+	Triangle int
+	// U, V barycentric weights of the triangle's second and third vertex
+	U float64
+	V float64
+	// Distance from the ray origin to the hit in world units
+	Distance float64
+	Point    algebra.Vector
+	// Normal world space face normal, facing back towards the ray
+	Normal algebra.Vector
+}
+
+// Raycast find the nearest entity with geometry (see Meshed and
+// Raycaster) that the ray hits within maxDistance. The ray direction is
+// expected to be normalized
+func (s *Scene) Raycast(ray algebra.Ray, maxDistance float64) (RaycastHit, bool) {
+	best := RaycastHit{Distance: maxDistance}
+	found := false
+
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		hit, ok := raycastEntity(entities[i], ray, best.Distance)
+		if ok {
+			best = hit
+			found = true
+		}
+	}
+	return best, found
+}
+
+func raycastEntity(e *Entity, ray algebra.Ray, maxDistance float64) (RaycastHit, bool) {
+	best := RaycastHit{Distance: maxDistance}
+	found := false
+
+	if e.Transform == nil {
+		return best, false
+	}
+
+	for c := 0; c < len(e.components); c++ {
+		if caster, ok := e.components[c].(Raycaster); ok {
+			if hit, ok := caster.Raycast(ray, best.Distance); ok {
+				best = hit
+				best.Entity = e
+				best.Triangle = -1
+				found = true
+			}
+			continue
+		}
+		meshed, ok := e.components[c].(Meshed)
+		if !ok {
+			continue
+		}
+		poly := meshed.GetPolyhedron()
+		if poly == nil || poly.TriangleCount() == 0 {
+			continue
+		}
+
+		world := e.Transform.GetTransformation()
+		inv := algebra.Matrix{}
+		world.Inverse(&inv)
+
+		// Move the ray into model space. The direction is not
+		// renormalized so t stays a world space distance
+		local := algebra.Ray{}
+		origin := ray.Origin
+		origin.W = 1
+		inv.Transform(origin, &local.Origin)
+		direction := ray.Direction
+		direction.W = 0
+		inv.Transform(direction, &local.Direction)
+
+		min, max := poly.Bounds()
+		if d, ok := local.IntersectAABB(min, max); !ok || d > best.Distance {
+			continue
+		}
+
+		for tri := 0; tri < poly.TriangleCount(); tri++ {
+			a, b, cc := poly.Triangle(tri)
+			t, u, v, ok := local.IntersectTriangle(a, b, cc)
+			if !ok || t > best.Distance {
+				continue
+			}
+
+			best.Entity = e
+			best.Triangle = tri
+			best.U = u
+			best.V = v
+			best.Distance = t
+			best.Normal = worldNormal(&inv, a, b, cc)
+			found = true
+		}
+	}
+
+	if found {
+		ray.At(best.Distance, &best.Point)
+		if best.Normal.Dot(ray.Direction) > 0 {
+			best.Normal.Negate(&best.Normal)
+		}
+		best.Normal.W = 0
+	}
+	return best, found
+}
+
+// worldNormal takes a model space triangle's normal into world space
+// using the inverse transpose of the model matrix
+func worldNormal(inv *algebra.Matrix, a, b, c algebra.Vector) algebra.Vector {
+	e1 := algebra.Vector{}
+	e2 := algebra.Vector{}
+	b.SubV(a, &e1)
+	c.SubV(a, &e2)
+	n := algebra.Vector{}
+	e1.Cross(e2, &n)
+
+	invT := algebra.Matrix{}
+	inv.Transpose(&invT)
+	out := algebra.Vector{}
+	invT.Transform(n, &out)
+	out.W = 0
+	out.Normalized(&out)
+	return out
+}
@@ -0,0 +1,143 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func mockCubeEntity(t *testing.T, name string, z float64) *core.Entity {
+	poly, err := model.CreateTestPoly()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	entity := core.Entity{
+		Transform: core.NewTransform(),
+		Name:      name,
+	}
+	entity.Transform.Position.Z = z
+	rc := render.NewComponentRender()
+	rc.Mesh = render.Mesh{Poly: poly}
+	entity.Attach(&rc)
+	return &entity
+}
+
+func TestSceneRaycast(t *testing.T) {
+	scene := core.Scene{}
+	far := mockCubeEntity(t, "far", -20)
+	near := mockCubeEntity(t, "near", -8)
+	scene.Add(far)
+	scene.Add(near)
+
+	ray := algebra.NewRay(algebra.Vector{}, algebra.Vector{Z: -1})
+	hit, ok := scene.Raycast(ray, 1000)
+	if !ok {
+		t.Fatalf("Raycast: expected a hit")
+	}
+	if hit.Entity != near {
+		t.Errorf("Raycast: expected the near cube got %v", hit.Entity.Name)
+	}
+	if math.Abs(hit.Distance-7) > algebra.Precision {
+		t.Errorf("Raycast: distance %v should be 7", hit.Distance)
+	}
+	expectedPoint := algebra.Vector{Z: -7}
+	if !hit.Point.AlmostEquals(&expectedPoint) {
+		t.Errorf("Raycast: point %v should be %v", hit.Point, expectedPoint)
+	}
+	expectedNormal := algebra.Vector{Z: 1}
+	if !hit.Normal.AlmostEquals(&expectedNormal) {
+		t.Errorf("Raycast: normal %v should be %v", hit.Normal, expectedNormal)
+	}
+	if hit.U < 0 || hit.V < 0 || hit.U+hit.V > 1 {
+		t.Errorf("Raycast: bad barycentrics %v %v", hit.U, hit.V)
+	}
+}
+
+func TestSceneRaycastMiss(t *testing.T) {
+	scene := core.Scene{}
+	scene.Add(mockCubeEntity(t, "cube", -8))
+
+	ray := algebra.NewRay(algebra.Vector{Y: 5}, algebra.Vector{Z: -1})
+	if _, ok := scene.Raycast(ray, 1000); ok {
+		t.Errorf("Raycast: expected a miss")
+	}
+
+	ray = algebra.NewRay(algebra.Vector{}, algebra.Vector{Z: -1})
+	if _, ok := scene.Raycast(ray, 5); ok {
+		t.Errorf("Raycast: hit beyond max distance")
+	}
+}
+
+// mockWall a Raycaster that's a wall across the view at Z
+type mockWall struct {
+	*core.Component
+	Z float64
+}
+
+func (w *mockWall) Raycast(ray algebra.Ray, maxDistance float64) (core.RaycastHit, bool) {
+	d, ok := ray.IntersectPlane(algebra.Vector{Z: w.Z}, algebra.Vector{Z: 1})
+	if !ok || d > maxDistance {
+		return core.RaycastHit{}, false
+	}
+	return core.RaycastHit{Distance: d, Normal: algebra.Vector{Z: 1}}, true
+}
+
+func TestSceneRaycastRaycaster(t *testing.T) {
+	scene := core.Scene{}
+	cube := mockCubeEntity(t, "cube", -8)
+	scene.Add(cube)
+	wall := core.Entity{Transform: core.NewTransform(), Name: "wall"}
+	caster := mockWall{Component: &core.Component{}, Z: -4}
+	wall.Attach(&caster)
+	scene.Add(&wall)
+
+	ray := algebra.NewRay(algebra.Vector{}, algebra.Vector{Z: -1})
+	hit, ok := scene.Raycast(ray, 1000)
+	if !ok || hit.Entity != &wall {
+		t.Fatalf("Raycast: expected to hit the wall got %v %v", hit.Entity, ok)
+	}
+	expectedPoint := algebra.Vector{Z: -4}
+	if hit.Triangle != -1 || !hit.Point.AlmostEquals(&expectedPoint) {
+		t.Errorf("Raycast: triangle %v point %v should be -1 %v", hit.Triangle, hit.Point, expectedPoint)
+	}
+
+	// Behind the cube now
+	caster.Z = -12
+	if hit, _ := scene.Raycast(ray, 1000); hit.Entity != cube {
+		t.Errorf("Raycast: expected the cube in front of the wall got %v", hit.Entity.Name)
+	}
+}
+
+func TestScreenPointToRay(t *testing.T) {
+	camera := core.NewComponentCamera()
+	camera.View.InitIdentity()
+	camera.UpdatePerspective(800, 600, algebra.PerspectiveOptions{
+		Fov:        90,
+		Near:       0.1,
+		Far:        100,
+		PixelRatio: 1,
+	})
+
+	ray := camera.ScreenPointToRay(400, 300, 800, 600)
+
+	expected := algebra.Vector{Z: -1}
+	if !ray.Direction.AlmostEquals(&expected) {
+		t.Errorf("ScreenPointToRay: direction %v should be %v", ray.Direction, expected)
+	}
+	if math.Abs(ray.Origin.Z+0.1) > algebra.Precision {
+		t.Errorf("ScreenPointToRay: origin %v should be on the near plane", ray.Origin)
+	}
+
+	left := camera.ScreenPointToRay(0, 300, 800, 600)
+	if left.Direction.X >= 0 {
+		t.Errorf("ScreenPointToRay: left edge should point left %v", left.Direction)
+	}
+	top := camera.ScreenPointToRay(400, 0, 800, 600)
+	if top.Direction.Y <= 0 {
+		t.Errorf("ScreenPointToRay: top edge should point up %v", top.Direction)
+	}
+}
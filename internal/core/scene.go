@@ -19,3 +19,65 @@ func (s *Scene) Remove(e *Entity) {
 func (s *Scene) All() []*Entity {
 	return s.children
 }
+
+// Update update every component in the scene that is an Updater
+func (s *Scene) Update(dt float64) {
+	for i := 0; i < len(s.children); i++ {
+		updateTree(s.children[i], dt)
+	}
+}
+
+func updateTree(e *Entity, dt float64) {
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		if u, ok := components[i].(Updater); ok {
+			u.Update(dt)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		updateTree(children[i], dt)
+	}
+}
+
+// Resize tell every component in the scene that is a Resizer about a
+// new window size
+func (s *Scene) Resize(settings Settings) {
+	for i := 0; i < len(s.children); i++ {
+		resizeTree(s.children[i], settings)
+	}
+}
+
+func resizeTree(e *Entity, settings Settings) {
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		if r, ok := components[i].(Resizer); ok {
+			r.Resize(settings)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		resizeTree(children[i], settings)
+	}
+}
+
+// Input hand the frame's input to every component in the scene that is
+// an Inputter
+func (s *Scene) Input(state InputState, dt float64) {
+	for i := 0; i < len(s.children); i++ {
+		inputTree(s.children[i], state, dt)
+	}
+}
+
+func inputTree(e *Entity, state InputState, dt float64) {
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		if in, ok := components[i].(Inputter); ok {
+			in.Input(state, dt)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		inputTree(children[i], state, dt)
+	}
+}
@@ -1,7 +1,39 @@
 package core
 
+import "math"
+
 // Settings global object of current settings
 type Settings struct {
-	Width  int32
+	// Width of the window in screen units
+	Width int32
+	// Height of the window in screen units
 	Height int32
+	// PixelRatio drawable pixels per screen unit, 2 on most high-DPI
+	// displays. Zero is treated as 1
+	PixelRatio float64
+}
+
+// SetWindowSize update the settings from the window size and the size of
+// its drawable (GL) surface
+func (s *Settings) SetWindowSize(width, height, drawableWidth, drawableHeight int32) {
+	s.Width = width
+	s.Height = height
+	s.PixelRatio = 1
+	if width > 0 && drawableWidth > 0 {
+		s.PixelRatio = float64(drawableWidth) / float64(width)
+	}
+}
+
+// GetPixelRatio the pixel ratio, defaulting to 1
+func (s *Settings) GetPixelRatio() float64 {
+	if s.PixelRatio <= 0 {
+		return 1
+	}
+	return s.PixelRatio
+}
+
+// DrawableSize the size of the drawable surface in pixels
+func (s *Settings) DrawableSize() (int32, int32) {
+	pr := s.GetPixelRatio()
+	return int32(math.Floor(float64(s.Width) * pr)), int32(math.Floor(float64(s.Height) * pr))
 }
@@ -0,0 +1,27 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/core"
+)
+
+func TestSettingsHighDPI(t *testing.T) {
+	s := core.Settings{}
+	s.SetWindowSize(800, 600, 1600, 1200)
+	if s.PixelRatio != 2 {
+		t.Errorf("PixelRatio %v should be 2", s.PixelRatio)
+	}
+	w, h := s.DrawableSize()
+	if w != 1600 || h != 1200 {
+		t.Errorf("DrawableSize %v x %v should be 1600 x 1200", w, h)
+	}
+}
+
+func TestSettingsDefaultPixelRatio(t *testing.T) {
+	s := core.Settings{Width: 300, Height: 200}
+	w, h := s.DrawableSize()
+	if w != 300 || h != 200 {
+		t.Errorf("DrawableSize %v x %v should be 300 x 200", w, h)
+	}
+}
@@ -25,45 +25,35 @@ func NewTransform() *Transform {
 	return &t
 }
 
+// GetTransformation the model to world matrix: scale, then rotate in
+// place, then move to Position
 func (t *Transform) GetTransformation() *algebra.Matrix {
 	translationMatrix := algebra.Matrix{}
 	translationMatrix.InitTranslation(&t.Position)
 	rotationMatrix := t.RotationMatrix(&t.Rotation)
 	scaleMatrix := algebra.Matrix{}
 	scaleMatrix.InitScale(&t.Scale)
-	// scaleMatrix := new Matrix4().initScale(this.scale);
 
-	// “first translate, then rotate, then scale”.
-	// This first one will rotate about the world space
-	// return this.getParentMatrix().mul(translationMatrix.mul(rotationMatrix.mul(scaleMatrix)));
-	// Where as this one rotates at the object space
-	tr := algebra.Matrix{}
-	translationMatrix.Mul(*rotationMatrix, &tr)
-	trs := algebra.Matrix{}
-	scaleMatrix.Mul(tr, &trs)
+	// Row vectors (v * M) so the first thing done to the vertex is on
+	// the left: scale * rotation * translation. Translating before
+	// rotating would swing the entity's Position around the world origin
+	// rather than turning the entity where it stands
+	sr := algebra.Matrix{}
+	scaleMatrix.Mul(*rotationMatrix, &sr)
+	srt := algebra.Matrix{}
+	sr.Mul(translationMatrix, &srt)
 
-	// return this.getParentMatrix().mul(scaleMatrix.mul(rotationMatrix.mul(translationMatrix)));
-	return &trs
+	return &srt
 }
 
+// RotationMatrix the matrix for rot. Also points Forward, Up and Right
+// along the rotated axes
 func (t *Transform) RotationMatrix(rot *algebra.Quaternion) *algebra.Matrix {
-	t.Forward = algebra.Vector{
-		X: 2.0 * (rot.X*rot.Z - rot.W*rot.Y),
-		Y: 2.0 * (rot.Y*rot.Z + rot.W*rot.X),
-		Z: 1.0 - 2.0*(rot.X*rot.X+rot.Y*rot.Y),
-	}
-	t.Up = algebra.Vector{
-		X: 2.0 * (rot.X*rot.Y + rot.W*rot.Z),
-		Y: 1.0 - 2.0*(rot.X*rot.X+rot.Z*rot.Z),
-		Z: 2.0 * (rot.Y*rot.Z - rot.W*rot.X),
-	}
-	t.Right = algebra.Vector{
-		X: 1.0 - 2.0*(rot.Y*rot.Y+rot.Z*rot.Z),
-		Y: 2.0 * (rot.X*rot.Y - rot.W*rot.Z),
-		Z: 2.0 * (rot.X*rot.Z + rot.W*rot.Y),
-	}
+	rot.Rotate(algebra.Forward, &t.Forward)
+	rot.Rotate(algebra.Up, &t.Up)
+	rot.Rotate(algebra.Right, &t.Right)
+
 	mat := algebra.Matrix{}
-	mat.InitFUR(&t.Forward, &t.Up, &t.Right)
-	// return new Matrix4().initRotationFUR(forward, up, right);
+	rot.ToMatrix(&mat)
 	return &mat
 }
@@ -0,0 +1,66 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+func TestTransformRotatesInPlace(t *testing.T) {
+	tr := core.NewTransform()
+	tr.Position = algebra.Vector{X: 10}
+	tr.Scale = algebra.Vector{X: 2, Y: 2, Z: 2}
+	tr.Rotation.SetFromVector(&algebra.AxisY, algebra.DegToRad(90))
+
+	m := tr.GetTransformation()
+
+	actual := algebra.Vector{}
+	m.Transform(algebra.Vector{X: 1, W: 1}, &actual)
+	// Scaled to 2, turned to -Z, then moved out to X 10
+	expected := algebra.Vector{X: 10, Z: -2}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("GetTransformation: %v should be %v", actual, expected)
+	}
+}
+
+func TestTransformPositionPlusRotated(t *testing.T) {
+	tr := core.NewTransform()
+	tr.Position = algebra.Vector{X: 3, Y: -2, Z: 5}
+	tr.Scale = algebra.Vector{X: 1, Y: 2, Z: 3}
+	axis := algebra.Vector{}
+	(&algebra.Vector{X: 1, Y: 1, Z: 0.5}).Normalized(&axis)
+	tr.Rotation.SetFromVector(&axis, algebra.DegToRad(70))
+
+	v := algebra.Vector{X: 0.5, Y: -1, Z: 2}
+	actual := algebra.Vector{}
+	tr.GetTransformation().Transform(algebra.Vector{X: v.X, Y: v.Y, Z: v.Z, W: 1}, &actual)
+
+	// Position + R * (S * v)
+	rotated := algebra.Vector{}
+	tr.Rotation.Rotate(algebra.Vector{X: v.X * tr.Scale.X, Y: v.Y * tr.Scale.Y, Z: v.Z * tr.Scale.Z}, &rotated)
+	expected := algebra.Vector{X: tr.Position.X + rotated.X, Y: tr.Position.Y + rotated.Y, Z: tr.Position.Z + rotated.Z}
+	if !actual.AlmostEquals(&expected) {
+		t.Errorf("GetTransformation: %v should be %v", actual, expected)
+	}
+}
+
+func TestTransformRotationMatrix(t *testing.T) {
+	tr := core.NewTransform()
+	q := algebra.Quaternion{}
+	q.SetFromVector(&algebra.AxisY, algebra.DegToRad(90))
+
+	tr.RotationMatrix(&q)
+
+	expected := algebra.Vector{X: 1}
+	if !tr.Forward.AlmostEquals(&expected) {
+		t.Errorf("Forward: %v should be %v", tr.Forward, expected)
+	}
+	if !tr.Up.AlmostEquals(&algebra.Up) {
+		t.Errorf("Up: %v should be %v", tr.Up, algebra.Up)
+	}
+	expected = algebra.Vector{Z: -1}
+	if !tr.Right.AlmostEquals(&expected) {
+		t.Errorf("Right: %v should be %v", tr.Right, expected)
+	}
+}
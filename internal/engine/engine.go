@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+	"github.com/robrohan/mesh/internal/platform"
+)
+
+// Renderer draws a scene, on the GPU or off screen
+type Renderer interface {
+	Initialize(core.Settings)
+	Resize(core.Settings) error
+	RenderScene(*core.Scene) error
+}
+
+// Engine the main loop: pump the platform's events, read input, update
+// the scene and draw it
+type Engine struct {
+	Platform platform.Platform
+	Window   platform.Window
+	Renderer Renderer
+	Scene    *core.Scene
+	Input    *input.System
+	Settings core.Settings
+	// FixedStep when set every frame advances exactly this many seconds,
+	// whatever the clock says
+	FixedStep float64
+	// Update called every frame after the scene has updated
+	Update func(dt float64) error
+
+	running bool
+	last    float64
+}
+
+// New create an engine drawing into window, reading input from the
+// platform with an empty input map
+func New(p platform.Platform, w platform.Window, r Renderer) *Engine {
+	e := &Engine{
+		Platform: p,
+		Window:   w,
+		Renderer: r,
+		Input:    input.NewSystem(p.Input(), nil),
+		Settings: platform.WindowSettings(w),
+		last:     p.Now(),
+	}
+	r.Initialize(e.Settings)
+	return e
+}
+
+// Run frames until Stop is called or the platform quits
+func (e *Engine) Run() error {
+	e.running = true
+	for e.running {
+		if err := e.Frame(); err != nil {
+			e.running = false
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop finish the current frame then return from Run
+func (e *Engine) Stop() {
+	e.running = false
+}
+
+// Frame run a single frame
+func (e *Engine) Frame() error {
+	if e.Scene == nil {
+		return errors.New("engine has no scene")
+	}
+
+	events := e.Platform.Poll()
+	now := e.Platform.Now()
+	dt := now - e.last
+	e.last = now
+	if e.FixedStep > 0 {
+		dt = e.FixedStep
+	}
+
+	for i := 0; i < len(events); i++ {
+		switch t := events[i].(type) {
+		case platform.QuitEvent:
+			e.Stop()
+		case platform.ResizeEvent:
+			e.Settings.SetWindowSize(t.Width, t.Height, t.DrawableWidth, t.DrawableHeight)
+			if err := e.Renderer.Resize(e.Settings); err != nil {
+				return err
+			}
+			e.Scene.Resize(e.Settings)
+		}
+	}
+
+	e.Input.Update()
+	e.Scene.Input(e.Input, dt)
+	e.Scene.Update(dt)
+	if e.Update != nil {
+		if err := e.Update(dt); err != nil {
+			return err
+		}
+	}
+
+	if e.Scene.ActiveCamera != nil {
+		if cc, ok := e.Scene.ActiveCamera.GetComponent(core.ComponentTypeCamera).(*core.ComponentCamera); ok {
+			cc.UpdateViewMatrix()
+		}
+	}
+	if err := e.Renderer.RenderScene(e.Scene); err != nil {
+		return err
+	}
+	e.Window.Swap()
+	return nil
+}
@@ -0,0 +1,132 @@
+package engine_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/engine"
+	"github.com/robrohan/mesh/internal/input"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/platform"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func mockScene(t *testing.T, s core.Settings) (*core.Scene, *core.Entity) {
+	poly, err := model.CreateTestPoly()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	cube := core.Entity{Name: "cube", Transform: core.NewTransform()}
+	cube.Transform.Position.Z = -8
+	rc := render.NewComponentRender()
+	rc.Mesh = render.Mesh{Poly: poly}
+	cube.Attach(&rc)
+
+	camera := core.Entity{Name: "camera", Transform: core.NewTransform()}
+	cc := core.NewComponentCamera()
+	cc.UpdatePerspective(s.Width, s.Height, algebra.PerspectiveOptions{
+		Fov:        60,
+		Near:       0.1,
+		Far:        100,
+		PixelRatio: s.GetPixelRatio(),
+	})
+	camera.Attach(&cc)
+
+	scene := core.Scene{}
+	scene.Add(&camera)
+	scene.Add(&cube)
+	scene.ActiveCamera = &camera
+	return &scene, &camera
+}
+
+func boot(t *testing.T) (*engine.Engine, *platform.Headless, *render.Software) {
+	p := platform.NewHeadless()
+	w, _ := p.Open("test", 64, 48)
+	r := &render.Software{}
+	e := engine.New(p, w, r)
+	e.Scene, _ = mockScene(t, e.Settings)
+	return e, p, r
+}
+
+func TestHeadlessBoot(t *testing.T) {
+	e, p, r := boot(t)
+
+	frames := 0
+	e.Update = func(dt float64) error {
+		if math.Abs(dt-p.Step) > 1e-9 {
+			t.Errorf("dt %v should be the headless step %v", dt, p.Step)
+		}
+		frames++
+		if frames == 3 {
+			p.Push(platform.QuitEvent{})
+		}
+		return nil
+	}
+	if err := e.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// The quit is seen at the start of the next frame, which still
+	// finishes
+	if frames != 4 || e.Window.(*platform.HeadlessWindow).Frames != 4 {
+		t.Errorf("expected 4 frames got %v", frames)
+	}
+
+	// The cube is in the middle of the view, the corners are clear
+	size := r.Image.Bounds().Size()
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if r.Image.RGBAAt(size.X/2, size.Y/2) == white {
+		t.Errorf("the cube should have been drawn in the middle")
+	}
+	if r.Image.RGBAAt(0, 0) != white {
+		t.Errorf("the corner should be the clear color got %v", r.Image.RGBAAt(0, 0))
+	}
+}
+
+func TestHeadlessResize(t *testing.T) {
+	e, p, r := boot(t)
+	camera := e.Scene.ActiveCamera.GetComponent(core.ComponentTypeCamera).(*core.ComponentCamera)
+	before := camera.Projection[0][0]
+
+	p.PixelRatio = 2
+	p.Resize(64, 24)
+	if err := e.Frame(); err != nil {
+		t.Fatalf("Frame: %v", err)
+	}
+	if size := r.Image.Bounds().Size(); size.X != 128 || size.Y != 48 {
+		t.Errorf("image %v should have been resized to the 128x48 drawable", size)
+	}
+	if camera.Projection[0][0] == before {
+		t.Errorf("the camera should have a new aspect ratio")
+	}
+}
+
+func TestHeadlessInput(t *testing.T) {
+	e, p, _ := boot(t)
+	e.Input.Map = &input.Map{Actions: map[string][]input.Button{"jump": {"key:space"}}}
+	p.PushInput(input.Frame{}, input.Frame{Held: []input.Button{"key:space"}})
+
+	pressed := []bool{}
+	e.Update = func(dt float64) error {
+		pressed = append(pressed, e.Input.Pressed("jump"))
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if err := e.Frame(); err != nil {
+			t.Fatalf("Frame: %v", err)
+		}
+	}
+	if pressed[0] || !pressed[1] || pressed[2] {
+		t.Errorf("jump should only be pressed on the second frame %v", pressed)
+	}
+}
+
+func TestNoScene(t *testing.T) {
+	e, _, _ := boot(t)
+	e.Scene = nil
+	if err := e.Run(); err == nil {
+		t.Errorf("expected an error without a scene")
+	}
+}
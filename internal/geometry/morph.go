@@ -0,0 +1,86 @@
+package geometry
+
+import "github.com/robrohan/mesh/internal/algebra"
+
+// MorphTarget a named shape a mesh can blend toward, like a smile or a
+// blink, as how far each of its vertices moves
+type MorphTarget struct {
+	Name string
+	// Positions how far each vertex moves at full weight, by vertex
+	// index
+	Positions []algebra.Vector
+	// Normals how much each vertex's normal changes at full weight, empty
+	// when the target leaves them alone
+	Normals []algebra.Vector
+	// Weight how much the target shows until something changes it
+	Weight float64
+}
+
+// FindTarget the index of the morph target with a name, -1 when there
+// isn't one
+func (p *Polyhedron) FindTarget(name string) int {
+	for i := 0; i < len(p.Targets); i++ {
+		if p.Targets[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Morphed whether any of weights would move the mesh
+func (p *Polyhedron) Morphed(weights []float64) bool {
+	for i := 0; i < len(weights) && i < len(p.Targets); i++ {
+		if weights[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Morph blend the mesh toward its targets, each by its weight (by target
+// index), into out. Normals that change are brought back to unit length.
+// Targets without a delta for every vertex are skipped. out gets its own
+// vertices but shares p's indices and targets
+func (p *Polyhedron) Morph(weights []float64, out *Polyhedron) {
+	if len(out.Vertices) != len(p.Vertices) {
+		out.Vertices = make([]Vertex, len(p.Vertices))
+	}
+	copy(out.Vertices, p.Vertices)
+	out.Indices = p.Indices
+	out.Targets = p.Targets
+
+	normals := false
+	for t := 0; t < len(weights) && t < len(p.Targets); t++ {
+		w := weights[t]
+		target := &p.Targets[t]
+		if w == 0 || len(target.Positions) != len(p.Vertices) {
+			continue
+		}
+		for i := 0; i < len(out.Vertices); i++ {
+			d := target.Positions[i]
+			v := &out.Vertices[i]
+			v.Pos.X += d.X * w
+			v.Pos.Y += d.Y * w
+			v.Pos.Z += d.Z * w
+		}
+		if len(target.Normals) != len(p.Vertices) {
+			continue
+		}
+		normals = true
+		for i := 0; i < len(out.Vertices); i++ {
+			d := target.Normals[i]
+			v := &out.Vertices[i]
+			v.Normal.X += d.X * w
+			v.Normal.Y += d.Y * w
+			v.Normal.Z += d.Z * w
+		}
+	}
+	if !normals {
+		return
+	}
+	for i := 0; i < len(out.Vertices); i++ {
+		if n := out.Vertices[i].Normal; !n.IsZero() {
+			n.Normalized(&out.Vertices[i].Normal)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package geometry_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+func TestMorph(t *testing.T) {
+	p := geometry.Polyhedron{
+		Vertices: []geometry.Vertex{
+			{Pos: algebra.Vector{X: 0}, Normal: algebra.Vector{Z: 1}},
+			{Pos: algebra.Vector{X: 1}, Normal: algebra.Vector{Z: 1}},
+		},
+		Indices: []uint16{0, 1, 0},
+		Targets: []geometry.MorphTarget{
+			{
+				Name:      "up",
+				Positions: []algebra.Vector{{Y: 1}, {Y: 2}},
+				Normals:   []algebra.Vector{{Y: 1}, {}},
+			},
+			{Name: "out", Positions: []algebra.Vector{{X: -1}, {X: 1}}},
+			// Not a delta for every vertex
+			{Name: "broken", Positions: []algebra.Vector{{Z: 5}}},
+		},
+	}
+	if p.FindTarget("out") != 1 || p.FindTarget("in") != -1 {
+		t.Errorf("FindTarget: got %v and %v", p.FindTarget("out"), p.FindTarget("in"))
+	}
+	if p.Morphed(nil) || p.Morphed([]float64{0, 0}) || !p.Morphed([]float64{0, 0.5}) {
+		t.Errorf("Morphed should only be true for a weight that isn't 0")
+	}
+
+	out := geometry.Polyhedron{}
+	p.Morph([]float64{0.5, 1, 1}, &out)
+	if a := out.Vertices[0].Pos; a.X != -1 || a.Y != 0.5 || a.Z != 0 {
+		t.Errorf("vertex 0: got %v, want (-1, 0.5, 0)", a)
+	}
+	if b := out.Vertices[1].Pos; b.X != 2 || b.Y != 1 {
+		t.Errorf("vertex 1: got %v, want (2, 1, 0)", b)
+	}
+	n := out.Vertices[0].Normal
+	if math.Abs(n.Length()-1) > 1e-9 || math.Abs(2*n.Y-n.Z) > 1e-9 {
+		t.Errorf("normal should tip half of its change toward y and stay unit length, got %v", n)
+	}
+	if p.Vertices[0].Pos.X != 0 || len(out.Indices) != 3 {
+		t.Errorf("morphing should leave the mesh alone and share its indices")
+	}
+}
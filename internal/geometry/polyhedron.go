@@ -1,9 +1,17 @@
 package geometry
 
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
 // Polyhedron a mesh
 type Polyhedron struct {
 	Vertices []Vertex
 	Indices  []uint16
+	// Targets shapes the mesh can blend toward, see Morph
+	Targets []MorphTarget
 }
 
 // GetVertices get the array of verts for this mesh
@@ -15,3 +23,75 @@ func (p *Polyhedron) GetVertices() []Vertex {
 func (p *Polyhedron) GetIndices() []uint16 {
 	return p.Indices
 }
+
+// Bounds get the axis aligned box that contains every vertex
+func (p *Polyhedron) Bounds() (min algebra.Vector, max algebra.Vector) {
+	if len(p.Vertices) == 0 {
+		return
+	}
+	min = p.Vertices[0].Pos
+	max = p.Vertices[0].Pos
+	for i := 1; i < len(p.Vertices); i++ {
+		pos := p.Vertices[i].Pos
+		min.X = math.Min(min.X, pos.X)
+		min.Y = math.Min(min.Y, pos.Y)
+		min.Z = math.Min(min.Z, pos.Z)
+		max.X = math.Max(max.X, pos.X)
+		max.Y = math.Max(max.Y, pos.Y)
+		max.Z = math.Max(max.Z, pos.Z)
+	}
+	return
+}
+
+// TriangleCount number of triangles described by the indices
+func (p *Polyhedron) TriangleCount() int {
+	return len(p.Indices) / 3
+}
+
+// Triangle get the vertex positions of the i'th triangle
+func (p *Polyhedron) Triangle(i int) (a, b, c algebra.Vector) {
+	a = p.Vertices[p.Indices[i*3]].Pos
+	b = p.Vertices[p.Indices[i*3+1]].Pos
+	c = p.Vertices[p.Indices[i*3+2]].Pos
+	return
+}
+
+// ComputeTangents set each vertex's Tangent to the direction texture U
+// runs across the triangles around it, at right angles to its normal.
+// Bump maps need these. Vertices whose triangles have no texture
+// coordinates are left alone
+func (p *Polyhedron) ComputeTangents() {
+	sums := make([]algebra.Vector, len(p.Vertices))
+	for t := 0; t < p.TriangleCount(); t++ {
+		i0, i1, i2 := p.Indices[t*3], p.Indices[t*3+1], p.Indices[t*3+2]
+		a, b, c := p.Vertices[i0], p.Vertices[i1], p.Vertices[i2]
+		var e1, e2 algebra.Vector
+		b.Pos.SubV(a.Pos, &e1)
+		c.Pos.SubV(a.Pos, &e2)
+		du1, dv1 := b.TexCoord.X-a.TexCoord.X, b.TexCoord.Y-a.TexCoord.Y
+		du2, dv2 := c.TexCoord.X-a.TexCoord.X, c.TexCoord.Y-a.TexCoord.Y
+		det := du1*dv2 - du2*dv1
+		if det == 0 {
+			continue
+		}
+		r := 1 / det
+		tangent := algebra.Vector{
+			X: (e1.X*dv2 - e2.X*dv1) * r,
+			Y: (e1.Y*dv2 - e2.Y*dv1) * r,
+			Z: (e1.Z*dv2 - e2.Z*dv1) * r,
+		}
+		sums[i0].AddV(tangent, &sums[i0])
+		sums[i1].AddV(tangent, &sums[i1])
+		sums[i2].AddV(tangent, &sums[i2])
+	}
+	for i := 0; i < len(p.Vertices); i++ {
+		n := p.Vertices[i].Normal
+		t := sums[i]
+		// Gram-Schmidt, take out any part along the normal
+		t.SubV(n.Scale(n.Dot(t)), &t)
+		if t.Length() < 1e-12 {
+			continue
+		}
+		t.Normalized(&p.Vertices[i].Tangent)
+	}
+}
@@ -1,5 +1,13 @@
 package geometry_test
 
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
 // func TestPolyhedron(t *testing.T) {
 // 	ph := geometry.Polyhedron{}
 
@@ -9,3 +17,67 @@ package geometry_test
 // 		t.Errorf("Polyhedron: should be not be nil")
 // 	}
 // }
+
+func makeTriangle() geometry.Polyhedron {
+	return geometry.Polyhedron{
+		Vertices: []geometry.Vertex{
+			{Pos: algebra.Vector{X: -1, Y: 2, Z: 3}},
+			{Pos: algebra.Vector{X: 4, Y: -5, Z: 6}},
+			{Pos: algebra.Vector{X: 7, Y: 8, Z: -9}},
+		},
+		Indices: []uint16{0, 1, 2},
+	}
+}
+
+func TestPolyhedronBounds(t *testing.T) {
+	p := makeTriangle()
+
+	min, max := p.Bounds()
+
+	expectedMin := algebra.Vector{X: -1, Y: -5, Z: -9}
+	expectedMax := algebra.Vector{X: 7, Y: 8, Z: 6}
+	if min != expectedMin || max != expectedMax {
+		t.Errorf("Bounds: got %v %v", min, max)
+	}
+}
+
+func TestPolyhedronTriangle(t *testing.T) {
+	p := makeTriangle()
+
+	if p.TriangleCount() != 1 {
+		t.Fatalf("TriangleCount: expected 1 got %v", p.TriangleCount())
+	}
+
+	a, b, c := p.Triangle(0)
+	if a != p.Vertices[0].Pos || b != p.Vertices[1].Pos || c != p.Vertices[2].Pos {
+		t.Errorf("Triangle: got %v %v %v", a, b, c)
+	}
+}
+
+func TestComputeTangents(t *testing.T) {
+	// A quad in the XY plane with U along X, tilted normals included
+	p := geometry.Polyhedron{
+		Vertices: []geometry.Vertex{
+			{Pos: algebra.Vector{X: 0, Y: 0}, TexCoord: algebra.Vector{X: 0, Y: 0}, Normal: algebra.Vector{Z: 1}},
+			{Pos: algebra.Vector{X: 2, Y: 0}, TexCoord: algebra.Vector{X: 1, Y: 0}, Normal: algebra.Vector{X: 0.6, Z: 0.8}},
+			{Pos: algebra.Vector{X: 2, Y: 2}, TexCoord: algebra.Vector{X: 1, Y: 1}, Normal: algebra.Vector{Z: 1}},
+			{Pos: algebra.Vector{X: 0, Y: 2}, TexCoord: algebra.Vector{X: 0, Y: 1}, Normal: algebra.Vector{Z: 1}},
+			// Not used by any triangle
+			{Pos: algebra.Vector{X: 5}},
+		},
+		Indices: []uint16{0, 1, 2, 0, 2, 3},
+	}
+	p.ComputeTangents()
+
+	expected := algebra.Vector{X: 1}
+	if !p.Vertices[0].Tangent.AlmostEquals(&expected) {
+		t.Errorf("tangent should follow U got %v", p.Vertices[0].Tangent)
+	}
+	tilted := p.Vertices[1]
+	if math.Abs(tilted.Tangent.Dot(tilted.Normal)) > 1e-9 || math.Abs(tilted.Tangent.Length()-1) > 1e-9 {
+		t.Errorf("tangent should be unit length and at right angles to the normal got %v", tilted.Tangent)
+	}
+	if p.Vertices[4].Tangent != (algebra.Vector{}) {
+		t.Errorf("unused vertex should be left alone got %v", p.Vertices[4].Tangent)
+	}
+}
@@ -0,0 +1,77 @@
+package geometry
+
+import "github.com/robrohan/mesh/internal/algebra"
+
+// Skinned whether any vertex is moved by a skeleton
+func (p *Polyhedron) Skinned() bool {
+	for i := 0; i < len(p.Vertices); i++ {
+		if !p.Vertices[i].Weights.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Skin move the vertices by the skinning matrices of a skeleton's
+// joints (model space in bind pose to model space as posed), blended by
+// each vertex's Weights. This is what the Skinned vertex shader does, for
+// drawing and hit testing on the CPU. Vertices without weights, and
+// joints past the end of joints, stay where they are. out gets its own
+// vertices but shares p's indices
+func (p *Polyhedron) Skin(joints []algebra.Matrix, out *Polyhedron) {
+	if len(out.Vertices) != len(p.Vertices) {
+		out.Vertices = make([]Vertex, len(p.Vertices))
+	}
+	out.Indices = p.Indices
+	for i := 0; i < len(p.Vertices); i++ {
+		out.Vertices[i] = p.Vertices[i].Skin(joints)
+	}
+}
+
+// Skin the vertex moved by the skinning matrices of the joints it's
+// weighted to
+func (v Vertex) Skin(joints []algebra.Matrix) Vertex {
+	m := algebra.Matrix{}
+	total := 0.0
+	indices := [4]float64{v.Joints.X, v.Joints.Y, v.Joints.Z, v.Joints.W}
+	weights := [4]float64{v.Weights.X, v.Weights.Y, v.Weights.Z, v.Weights.W}
+	for k := 0; k < 4; k++ {
+		j := int(indices[k])
+		if weights[k] == 0 || j < 0 || j >= len(joints) {
+			continue
+		}
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				m[r][c] += joints[j][r][c] * weights[k]
+			}
+		}
+		total += weights[k]
+	}
+	if total == 0 {
+		return v
+	}
+
+	out := v
+	pos := algebra.Vector{}
+	m.Transform(algebra.Vector{X: v.Pos.X, Y: v.Pos.Y, Z: v.Pos.Z, W: 1}, &pos)
+	out.Pos = algebra.Vector{X: pos.X / total, Y: pos.Y / total, Z: pos.Z / total, W: v.Pos.W}
+	out.Normal = skinDirection(&m, v.Normal)
+	out.Tangent = skinDirection(&m, v.Tangent)
+	return out
+}
+
+// skinDirection a direction moved by a blended joint matrix, back to
+// unit length (right for uniform scale, like the shaders)
+func skinDirection(m *algebra.Matrix, d algebra.Vector) algebra.Vector {
+	if d.IsZero() {
+		return d
+	}
+	out := algebra.Vector{}
+	m.Transform(algebra.Vector{X: d.X, Y: d.Y, Z: d.Z}, &out)
+	out.W = 0
+	if out.IsZero() {
+		return d
+	}
+	out.Normalized(&out)
+	return out
+}
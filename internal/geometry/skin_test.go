@@ -0,0 +1,75 @@
+package geometry_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+func TestSkin(t *testing.T) {
+	still := algebra.Matrix{}
+	still.InitIdentity()
+	moved := algebra.Matrix{}
+	moved.InitTranslation(&algebra.Vector{X: 2})
+	joints := []algebra.Matrix{still, moved}
+
+	p := geometry.Polyhedron{
+		Vertices: []geometry.Vertex{
+			{Pos: algebra.Vector{X: 1}},
+			{Pos: algebra.Vector{X: 1}, Joints: algebra.Vector{X: 1}, Weights: algebra.Vector{X: 1}},
+			{
+				Pos:     algebra.Vector{X: 1},
+				Normal:  algebra.Vector{Y: 1},
+				Joints:  algebra.Vector{X: 0, Y: 1},
+				Weights: algebra.Vector{X: 0.5, Y: 0.5},
+			},
+			{Pos: algebra.Vector{X: 1}, Joints: algebra.Vector{X: 7}, Weights: algebra.Vector{X: 1}},
+		},
+		Indices: []uint16{0, 1, 2},
+	}
+	if !p.Skinned() {
+		t.Errorf("Skinned: should be true with weights")
+	}
+
+	out := geometry.Polyhedron{}
+	p.Skin(joints, &out)
+	want := []float64{1, 3, 2, 1}
+	for i := 0; i < len(want); i++ {
+		if math.Abs(out.Vertices[i].Pos.X-want[i]) > 1e-9 {
+			t.Errorf("vertex %v: got x %v, want %v", i, out.Vertices[i].Pos.X, want[i])
+		}
+	}
+	if n := out.Vertices[2].Normal; math.Abs(n.Y-1) > 1e-9 {
+		t.Errorf("translation should not move normals, got %v", n)
+	}
+	if len(out.Indices) != 3 {
+		t.Errorf("indices should be shared, got %v", out.Indices)
+	}
+	if p.Vertices[1].Pos.X != 1 {
+		t.Errorf("skinning should leave the source alone")
+	}
+
+	turn := algebra.Quaternion{}
+	turn.SetFromVector(&algebra.Vector{Z: 1}, math.Pi/2)
+	rotated := algebra.Matrix{}
+	turn.ToMatrix(&rotated)
+	v := geometry.Vertex{
+		Pos:     algebra.Vector{X: 1},
+		Normal:  algebra.Vector{X: 1},
+		Weights: algebra.Vector{X: 1},
+	}.Skin([]algebra.Matrix{rotated})
+	expected := algebra.Vector{}
+	turn.Rotate(algebra.Vector{X: 1}, &expected)
+	if math.Abs(v.Pos.X-expected.X) > 1e-9 || math.Abs(v.Pos.Y-expected.Y) > 1e-9 {
+		t.Errorf("rotated position: got %v, want %v", v.Pos, expected)
+	}
+	if math.Abs(v.Normal.X-expected.X) > 1e-9 || math.Abs(v.Normal.Y-expected.Y) > 1e-9 {
+		t.Errorf("rotated normal: got %v, want %v", v.Normal, expected)
+	}
+
+	if (&geometry.Polyhedron{Vertices: p.Vertices[:1]}).Skinned() {
+		t.Errorf("Skinned: should be false without weights")
+	}
+}
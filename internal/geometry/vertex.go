@@ -4,7 +4,7 @@ import "github.com/robrohan/mesh/internal/algebra"
 
 const (
 	// VertexSize number of elements in a vertex
-	VertexSize uint8 = 14
+	VertexSize uint8 = 22
 )
 
 // Vertex an element of some 3D geometry which has a position and some other attributes
@@ -14,4 +14,10 @@ type Vertex struct {
 	TexCoord algebra.Vector
 	Normal   algebra.Vector
 	Tangent  algebra.Vector
+	// Joints the indices of up to four skeleton joints that move the
+	// vertex, in X, Y, Z and W
+	Joints algebra.Vector
+	// Weights how much each of Joints moves the vertex, adding up to 1.
+	// All 0 for a vertex that isn't skinned
+	Weights algebra.Vector
 }
@@ -15,7 +15,7 @@ func TestVertex(t *testing.T) {
 		t.Errorf("Vertex: should be not be nil")
 	}
 
-	if geometry.VertexSize != 14 {
-		t.Errorf("Vertex Size: should be 14")
+	if geometry.VertexSize != 22 {
+		t.Errorf("Vertex Size: should be 22")
 	}
 }
@@ -0,0 +1,95 @@
+package input
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+)
+
+// Map names actions and axes and binds them to buttons and raw axes
+type Map struct {
+	// Actions each action is triggered by any of its buttons
+	Actions map[string][]Button `json:"actions"`
+	// Axes each axis adds up its bindings
+	Axes map[string]AxisBinding `json:"axes"`
+}
+
+// AxisBinding the inputs that make up a named axis
+type AxisBinding struct {
+	// Positive buttons that push the axis to +1
+	Positive []Button `json:"positive,omitempty"`
+	// Negative buttons that push the axis to -1
+	Negative []Button `json:"negative,omitempty"`
+	// Axes raw axes added to the value
+	Axes []Axis `json:"axes,omitempty"`
+	// DeadZone raw values closer to 0 than this are ignored, and what is
+	// left is rescaled to start from 0. Meant for gamepad sticks
+	DeadZone float64 `json:"deadZone,omitempty"`
+	// Scale multiplies the raw axes, 0 is treated as 1
+	Scale float64 `json:"scale,omitempty"`
+	// Invert flips the sign of the whole axis
+	Invert bool `json:"invert,omitempty"`
+}
+
+// LoadMap read a Map from JSON
+func LoadMap(r io.Reader) (*Map, error) {
+	m := &Map{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadMapFile read a Map from a JSON file
+func LoadMapFile(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadMap(f)
+}
+
+// value the axis for the given frame
+func (a *AxisBinding) value(f *Frame) float64 {
+	v := 0.0
+	for i := 0; i < len(a.Positive); i++ {
+		if f.IsHeld(a.Positive[i]) {
+			v++
+			break
+		}
+	}
+	for i := 0; i < len(a.Negative); i++ {
+		if f.IsHeld(a.Negative[i]) {
+			v--
+			break
+		}
+	}
+
+	scale := a.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	for i := 0; i < len(a.Axes); i++ {
+		v += DeadZone(f.Value(a.Axes[i]), a.DeadZone) * scale
+	}
+
+	if a.Invert {
+		v = -v
+	}
+	return v
+}
+
+// DeadZone zero out v when it is within dz of 0, and rescale the rest
+// so it still runs smoothly from 0 to 1
+func DeadZone(v, dz float64) float64 {
+	if dz <= 0 {
+		return v
+	}
+	mag := math.Abs(v)
+	if mag <= dz {
+		return 0
+	}
+	return math.Copysign(math.Min((mag-dz)/(1-dz), 1), v)
+}
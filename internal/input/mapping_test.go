@@ -0,0 +1,52 @@
+package input_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/input"
+)
+
+func TestLoadMapFile(t *testing.T) {
+	m, err := input.LoadMapFile("testdata/input.json")
+	if err != nil {
+		t.Fatalf("LoadMapFile: %v", err)
+	}
+	jump := m.Actions["jump"]
+	if len(jump) != 2 || jump[0] != "key:space" || jump[1] != "pad:a" {
+		t.Errorf("LoadMapFile: jump bound to %v", jump)
+	}
+	move := m.Axes["move_x"]
+	if move.DeadZone != 0.25 || len(move.Axes) != 1 || move.Axes[0] != "pad:leftx" {
+		t.Errorf("LoadMapFile: move_x %+v", move)
+	}
+}
+
+func TestLoadMapBadJSON(t *testing.T) {
+	if _, err := input.LoadMap(strings.NewReader("{")); err == nil {
+		t.Errorf("LoadMap: expected an error")
+	}
+	if _, err := input.LoadMapFile("testdata/missing.json"); err == nil {
+		t.Errorf("LoadMapFile: expected an error")
+	}
+}
+
+func TestDeadZone(t *testing.T) {
+	tests := []struct {
+		v, dz, expected float64
+	}{
+		{0.1, 0.2, 0},
+		{-0.2, 0.2, 0},
+		{0.6, 0.2, 0.5},
+		{-1, 0.2, -1},
+		{0.3, 0, 0.3},
+	}
+	for i := 0; i < len(tests); i++ {
+		actual := input.DeadZone(tests[i].v, tests[i].dz)
+		if math.Abs(actual-tests[i].expected) > 1e-9 {
+			t.Errorf("DeadZone(%v, %v): %v should be %v",
+				tests[i].v, tests[i].dz, actual, tests[i].expected)
+		}
+	}
+}
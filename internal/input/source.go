@@ -0,0 +1,90 @@
+package input
+
+import "sort"
+
+// Button a digital input named by device and button, for example
+// "key:space", "mouse:left" or "pad:a"
+type Button string
+
+// Axis an analog input named by device and axis, for example
+// "mouse:dx", "wheel:y" or "pad:leftx"
+type Axis string
+
+// Axes the platform sources are expected to report
+const (
+	// MouseX and MouseY where the pointer is, in window units
+	MouseX Axis = "mouse:x"
+	MouseY Axis = "mouse:y"
+	// MouseDX and MouseDY how far the pointer moved this frame
+	MouseDX Axis = "mouse:dx"
+	MouseDY Axis = "mouse:dy"
+	// WheelX and WheelY how far the wheel scrolled this frame
+	WheelX Axis = "wheel:x"
+	WheelY Axis = "wheel:y"
+)
+
+// Frame the raw state of every device for one frame. It holds only
+// plain data so it can be compared, saved and replayed
+type Frame struct {
+	// Held the buttons down during the frame, sorted
+	Held []Button `json:"held,omitempty"`
+	// Axes the analog values. Gamepad sticks and triggers are -1 to 1,
+	// the mouse is in window units
+	Axes map[Axis]float64 `json:"axes,omitempty"`
+}
+
+// IsHeld whether b was down during the frame
+func (f *Frame) IsHeld(b Button) bool {
+	i := sort.Search(len(f.Held), func(i int) bool { return f.Held[i] >= b })
+	return i < len(f.Held) && f.Held[i] == b
+}
+
+// Value the value of an axis, 0 when it wasn't reported
+func (f *Frame) Value(a Axis) float64 {
+	return f.Axes[a]
+}
+
+// NewFrame create a frame, sorting the held buttons
+func NewFrame(held []Button, axes map[Axis]float64) Frame {
+	sorted := make([]Button, len(held))
+	copy(sorted, held)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return Frame{Held: sorted, Axes: axes}
+}
+
+// Source something that produces raw input: a platform, a script or a
+// recording
+type Source interface {
+	// Next the state of the devices for the coming frame
+	Next() Frame
+}
+
+// FakeSource plays back a scripted list of frames, for tests. Once the
+// script runs out every frame is empty
+type FakeSource struct {
+	frames []Frame
+}
+
+// NewFakeSource create a source that returns frames in order
+func NewFakeSource(frames ...Frame) *FakeSource {
+	f := &FakeSource{}
+	f.Push(frames...)
+	return f
+}
+
+// Push add frames to the end of the script
+func (f *FakeSource) Push(frames ...Frame) {
+	for i := 0; i < len(frames); i++ {
+		f.frames = append(f.frames, NewFrame(frames[i].Held, frames[i].Axes))
+	}
+}
+
+// Next the next scripted frame
+func (f *FakeSource) Next() Frame {
+	if len(f.frames) == 0 {
+		return Frame{}
+	}
+	next := f.frames[0]
+	f.frames = f.frames[1:]
+	return next
+}
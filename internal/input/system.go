@@ -0,0 +1,97 @@
+package input
+
+// System turns the raw frames from a Source into named actions and
+// axes. Call Update once per frame before anything reads from it
+type System struct {
+	Map *Map
+
+	source   Source
+	current  Frame
+	previous Frame
+}
+
+// NewSystem create an input system reading from source
+func NewSystem(source Source, m *Map) *System {
+	if m == nil {
+		m = &Map{}
+	}
+	return &System{
+		Map:    m,
+		source: source,
+	}
+}
+
+// Update move on to the next frame
+func (s *System) Update() {
+	s.previous = s.current
+	s.current = s.source.Next()
+}
+
+// Frame the raw state of the current frame
+func (s *System) Frame() Frame {
+	return s.current
+}
+
+// Pressed whether the action started this frame
+func (s *System) Pressed(action string) bool {
+	return s.actionHeld(action, &s.current) && !s.actionHeld(action, &s.previous)
+}
+
+// Held whether the action is active this frame
+func (s *System) Held(action string) bool {
+	return s.actionHeld(action, &s.current)
+}
+
+// Released whether the action stopped this frame
+func (s *System) Released(action string) bool {
+	return !s.actionHeld(action, &s.current) && s.actionHeld(action, &s.previous)
+}
+
+// Axis the value of a named axis this frame
+func (s *System) Axis(name string) float64 {
+	binding, ok := s.Map.Axes[name]
+	if !ok {
+		return 0
+	}
+	return binding.value(&s.current)
+}
+
+// ButtonPressed whether a raw button went down this frame
+func (s *System) ButtonPressed(b Button) bool {
+	return s.current.IsHeld(b) && !s.previous.IsHeld(b)
+}
+
+// ButtonHeld whether a raw button is down this frame
+func (s *System) ButtonHeld(b Button) bool {
+	return s.current.IsHeld(b)
+}
+
+// ButtonReleased whether a raw button came up this frame
+func (s *System) ButtonReleased(b Button) bool {
+	return !s.current.IsHeld(b) && s.previous.IsHeld(b)
+}
+
+// MousePosition where the pointer is, in window units
+func (s *System) MousePosition() (float64, float64) {
+	return s.current.Value(MouseX), s.current.Value(MouseY)
+}
+
+// MouseDelta how far the pointer moved this frame
+func (s *System) MouseDelta() (float64, float64) {
+	return s.current.Value(MouseDX), s.current.Value(MouseDY)
+}
+
+// Wheel how far the mouse wheel scrolled this frame
+func (s *System) Wheel() (float64, float64) {
+	return s.current.Value(WheelX), s.current.Value(WheelY)
+}
+
+func (s *System) actionHeld(action string, f *Frame) bool {
+	buttons := s.Map.Actions[action]
+	for i := 0; i < len(buttons); i++ {
+		if f.IsHeld(buttons[i]) {
+			return true
+		}
+	}
+	return false
+}
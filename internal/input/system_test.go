@@ -0,0 +1,129 @@
+package input_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+)
+
+var _ core.InputState = (*input.System)(nil)
+
+func mockSystem(t *testing.T, frames ...input.Frame) *input.System {
+	m, err := input.LoadMapFile("testdata/input.json")
+	if err != nil {
+		t.Fatalf("LoadMapFile: %v", err)
+	}
+	return input.NewSystem(input.NewFakeSource(frames...), m)
+}
+
+func TestActionStates(t *testing.T) {
+	s := mockSystem(t,
+		input.Frame{},
+		input.Frame{Held: []input.Button{"key:space"}},
+		input.Frame{Held: []input.Button{"key:space", "pad:a"}},
+		input.Frame{Held: []input.Button{"pad:a"}},
+		input.Frame{},
+	)
+
+	expected := []struct{ pressed, held, released bool }{
+		{false, false, false},
+		{true, true, false},
+		// Another button for the same action doesn't press it again
+		{false, true, false},
+		{false, true, false},
+		{false, false, true},
+		// The script has run out
+		{false, false, false},
+	}
+	for i := 0; i < len(expected); i++ {
+		s.Update()
+		e := expected[i]
+		if s.Pressed("jump") != e.pressed || s.Held("jump") != e.held || s.Released("jump") != e.released {
+			t.Errorf("frame %v: pressed %v held %v released %v should be %+v", i,
+				s.Pressed("jump"), s.Held("jump"), s.Released("jump"), e)
+		}
+	}
+}
+
+func TestRawButtons(t *testing.T) {
+	s := mockSystem(t,
+		input.NewFrame([]input.Button{"key:b", "key:a"}, nil),
+		input.NewFrame([]input.Button{"key:b"}, nil),
+	)
+	s.Update()
+	if !s.ButtonPressed("key:a") || !s.ButtonHeld("key:b") {
+		t.Errorf("expected a and b to go down")
+	}
+	s.Update()
+	if !s.ButtonReleased("key:a") || s.ButtonPressed("key:b") || !s.ButtonHeld("key:b") {
+		t.Errorf("expected a to come up and b to stay down")
+	}
+	if s.Held("unknown") || s.Axis("unknown") != 0 {
+		t.Errorf("unknown actions and axes should be idle")
+	}
+}
+
+func TestAxes(t *testing.T) {
+	s := mockSystem(t,
+		input.Frame{Held: []input.Button{"key:d"}},
+		input.Frame{Held: []input.Button{"key:d", "key:a"}},
+		input.Frame{Axes: map[input.Axis]float64{"pad:leftx": 0.2}},
+		input.Frame{Axes: map[input.Axis]float64{"pad:leftx": -1}},
+		input.Frame{Axes: map[input.Axis]float64{
+			input.MouseDX: 3,
+			input.MouseDY: 4,
+			input.WheelY:  -1,
+		}},
+	)
+
+	expected := []float64{1, 0, 0, -1}
+	for i := 0; i < len(expected); i++ {
+		s.Update()
+		if actual := s.Axis("move_x"); math.Abs(actual-expected[i]) > 1e-9 {
+			t.Errorf("frame %v: move_x %v should be %v", i, actual, expected[i])
+		}
+	}
+
+	s.Update()
+	dx, dy := s.MouseDelta()
+	if dx != 3 || dy != 4 {
+		t.Errorf("MouseDelta %v, %v should be 3, 4", dx, dy)
+	}
+	if _, wy := s.Wheel(); wy != -1 {
+		t.Errorf("Wheel %v should be -1", wy)
+	}
+	// Scaled by a half and inverted
+	if actual := s.Axis("look_y"); actual != -2 {
+		t.Errorf("look_y %v should be -2", actual)
+	}
+}
+
+func TestDefaultStickLook(t *testing.T) {
+	m, err := input.LoadMapFile("../../assets/input.json")
+	if err != nil {
+		t.Fatalf("LoadMapFile: %v", err)
+	}
+	s := input.NewSystem(input.NewFakeSource(
+		// A stick drifting a little off center
+		input.Frame{Axes: map[input.Axis]float64{"pad:rightx": 0.1, "pad:righty": -0.15}},
+		input.Frame{Axes: map[input.Axis]float64{"pad:rightx": 1, input.MouseDX: 5}},
+	), m)
+
+	s.Update()
+	for _, axis := range []string{core.AxisLookX, core.AxisLookY, core.AxisLookXPad, core.AxisLookYPad} {
+		if actual := s.Axis(axis); actual != 0 {
+			t.Errorf("%v %v should be 0 inside the dead zone", axis, actual)
+		}
+	}
+
+	// The stick and the mouse each drive their own axis
+	s.Update()
+	if actual := s.Axis(core.AxisLookX); actual != 5 {
+		t.Errorf("look_x %v should be the mouse's 5", actual)
+	}
+	if actual := s.Axis(core.AxisLookXPad); actual <= 5 {
+		t.Errorf("look_x_pad %v should be scaled up to turn at a usable rate", actual)
+	}
+}
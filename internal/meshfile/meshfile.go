@@ -0,0 +1,346 @@
+// Package meshfile a compact binary mesh format that loads straight into
+// the buffers render.CreateMesh sends to the GPU.
+//
+// A file is, all little endian:
+//
+//	magic           "MSHB"
+//	version         uint16
+//	flags           uint16 (FlagCompressed)
+//	vertex count    uint32
+//	index count     uint32
+//	bounds          min [3]float32, max [3]float32
+//	attribute count uint8, then per attribute semantic uint8 and size uint8
+//	body length     uint32
+//	body            vertices []float32 then indices []uint16, zlib
+//	                compressed when FlagCompressed is set
+package meshfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// Version the format written by this package
+const Version = 1
+
+// FlagCompressed the body is zlib compressed
+const FlagCompressed uint16 = 1
+
+var magic = [4]byte{'M', 'S', 'H', 'B'}
+
+// indexChunk how many indices are read at a time, so a header claiming
+// more than the body holds fails before it allocates them all
+const indexChunk = 1 << 16
+
+// Semantic what a vertex attribute holds
+type Semantic uint8
+
+const (
+	// Position x, y, z
+	Position Semantic = iota + 1
+	// Color r, g, b
+	Color
+	// TexCoord u, v
+	TexCoord
+	// Normal x, y, z
+	Normal
+	// Tangent x, y, z
+	Tangent
+	// Joints the indices of four skeleton joints
+	Joints
+	// Weights how much each of the four joints counts
+	Weights
+)
+
+// Attribute one part of a vertex, Size floats long
+type Attribute struct {
+	Semantic Semantic
+	Size     uint8
+}
+
+// Layout the vertex layout of render.VertexBuffer, which is the only
+// one files are written in for now. Files with the start of it (from
+// before meshes were skinned) are read too, with the rest left 0
+var Layout = []Attribute{
+	{Semantic: Position, Size: 3},
+	{Semantic: Color, Size: 3},
+	{Semantic: TexCoord, Size: 2},
+	{Semantic: Normal, Size: 3},
+	{Semantic: Tangent, Size: 3},
+	{Semantic: Joints, Size: 4},
+	{Semantic: Weights, Size: 4},
+}
+
+// Data a mesh in the layout the GPU wants it
+type Data struct {
+	// Vertices interleaved in the Layout
+	Vertices []float32
+	Indices  []uint16
+	// Min, Max the mesh's bounds
+	Min algebra.Vector
+	Max algebra.Vector
+}
+
+// Options how to write a file
+type Options struct {
+	// Compress the vertex and index data
+	Compress bool
+}
+
+type header struct {
+	Magic       [4]byte
+	Version     uint16
+	Flags       uint16
+	VertexCount uint32
+	IndexCount  uint32
+	Min         [3]float32
+	Max         [3]float32
+}
+
+// FromPolyhedron convert geometry into the file's layout
+func FromPolyhedron(p geometry.Polyhedron) *Data {
+	min, max := p.Bounds()
+	return &Data{
+		Vertices: render.VertexBuffer(p),
+		Indices:  render.IndexBuffer(p),
+		Min:      min,
+		Max:      max,
+	}
+}
+
+// VertexCount number of vertices in the data
+func (d *Data) VertexCount() int {
+	return len(d.Vertices) / int(geometry.VertexSize)
+}
+
+// Polyhedron rebuild the geometry, for things like collision and
+// raycasting that need more than the GPU buffers
+func (d *Data) Polyhedron() geometry.Polyhedron {
+	count := d.VertexCount()
+	p := geometry.Polyhedron{
+		Vertices: make([]geometry.Vertex, count),
+		Indices:  d.Indices,
+	}
+	for i := 0; i < count; i++ {
+		v := d.Vertices[i*int(geometry.VertexSize):]
+		p.Vertices[i] = geometry.Vertex{
+			Pos:      algebra.Vector{X: float64(v[0]), Y: float64(v[1]), Z: float64(v[2])},
+			Color:    algebra.Vector{X: float64(v[3]), Y: float64(v[4]), Z: float64(v[5]), W: 1},
+			TexCoord: algebra.Vector{X: float64(v[6]), Y: float64(v[7])},
+			Normal:   algebra.Vector{X: float64(v[8]), Y: float64(v[9]), Z: float64(v[10])},
+			Tangent:  algebra.Vector{X: float64(v[11]), Y: float64(v[12]), Z: float64(v[13])},
+			Joints:   algebra.Vector{X: float64(v[14]), Y: float64(v[15]), Z: float64(v[16]), W: float64(v[17])},
+			Weights:  algebra.Vector{X: float64(v[18]), Y: float64(v[19]), Z: float64(v[20]), W: float64(v[21])},
+		}
+	}
+	return p
+}
+
+// Mesh send the data to the GPU as is
+func (d *Data) Mesh(name string) render.Mesh {
+	m := render.CreateMeshFromBuffers(d.Polyhedron(), d.Vertices, d.Indices)
+	m.Name = name
+	return m
+}
+
+// Write save mesh data
+func Write(w io.Writer, d *Data, o Options) error {
+	if len(d.Vertices)%int(geometry.VertexSize) != 0 {
+		return errors.New("vertex data is not a whole number of vertices")
+	}
+	h := header{
+		Magic:       magic,
+		Version:     Version,
+		VertexCount: uint32(d.VertexCount()),
+		IndexCount:  uint32(len(d.Indices)),
+		Min:         [3]float32{float32(d.Min.X), float32(d.Min.Y), float32(d.Min.Z)},
+		Max:         [3]float32{float32(d.Max.X), float32(d.Max.Y), float32(d.Max.Z)},
+	}
+	if o.Compress {
+		h.Flags |= FlagCompressed
+	}
+
+	body := bytes.Buffer{}
+	var bw io.Writer = &body
+	var zw *zlib.Writer
+	if o.Compress {
+		zw = zlib.NewWriter(&body)
+		bw = zw
+	}
+	if err := binary.Write(bw, binary.LittleEndian, d.Vertices); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, d.Indices); err != nil {
+		return err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint8(len(Layout))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, Layout); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(body.Len())); err != nil {
+		return err
+	}
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// Read load mesh data written by Write
+func Read(r io.Reader) (*Data, error) {
+	h := header{}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Magic != magic {
+		return nil, errors.New("not a mesh file")
+	}
+	if h.Version != Version {
+		return nil, fmt.Errorf("unsupported mesh file version %v", h.Version)
+	}
+	if h.VertexCount > math.MaxUint16+1 {
+		return nil, fmt.Errorf("too many vertices %v", h.VertexCount)
+	}
+
+	var count uint8
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	layout := make([]Attribute, count)
+	if err := binary.Read(r, binary.LittleEndian, layout); err != nil {
+		return nil, err
+	}
+	if len(layout) == 0 || len(layout) > len(Layout) || !sameLayout(layout, Layout[:len(layout)]) {
+		return nil, fmt.Errorf("unsupported vertex layout %v", layout)
+	}
+	stride := 0
+	for i := 0; i < len(layout); i++ {
+		stride += int(layout[i].Size)
+	}
+
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	size := int64(h.VertexCount)*int64(stride)*4 + int64(h.IndexCount)*2
+	var body io.Reader = io.LimitReader(r, int64(length))
+	if h.Flags&FlagCompressed != 0 {
+		zr, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	} else if int64(length) != size {
+		return nil, fmt.Errorf("body is %v bytes, expected %v", length, size)
+	}
+
+	vertices := make([]float32, int(h.VertexCount)*stride)
+	d := &Data{
+		Vertices: make([]float32, int(h.VertexCount)*int(geometry.VertexSize)),
+		Min:      algebra.Vector{X: float64(h.Min[0]), Y: float64(h.Min[1]), Z: float64(h.Min[2])},
+		Max:      algebra.Vector{X: float64(h.Max[0]), Y: float64(h.Max[1]), Z: float64(h.Max[2])},
+	}
+	if err := binary.Read(body, binary.LittleEndian, vertices); err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(h.VertexCount); i++ {
+		copy(d.Vertices[i*int(geometry.VertexSize):], vertices[i*stride:(i+1)*stride])
+	}
+	indices, err := readIndices(body, h.IndexCount)
+	if err != nil {
+		return nil, err
+	}
+	d.Indices = indices
+	for i := 0; i < len(d.Indices); i++ {
+		if uint32(d.Indices[i]) >= h.VertexCount {
+			return nil, fmt.Errorf("index %v out of range", d.Indices[i])
+		}
+	}
+	return d, nil
+}
+
+// readIndices read count indices a chunk at a time
+func readIndices(r io.Reader, count uint32) ([]uint16, error) {
+	size := count
+	if size > indexChunk {
+		size = indexChunk
+	}
+	indices := make([]uint16, 0, size)
+	chunk := make([]uint16, size)
+	for left := count; left > 0; {
+		n := left
+		if n > indexChunk {
+			n = indexChunk
+		}
+		if err := binary.Read(r, binary.LittleEndian, chunk[:n]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("body ends before all %v indices", count)
+			}
+			return nil, err
+		}
+		indices = append(indices, chunk[:n]...)
+		left -= n
+	}
+	return indices, nil
+}
+
+// WriteFile save mesh data to a file
+func WriteFile(path string, d *Data, o Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err := Write(bw, d, o); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadFile load mesh data from a file
+func ReadFile(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(bufio.NewReader(f))
+}
+
+func sameLayout(a, b []Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
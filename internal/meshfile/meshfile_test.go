@@ -0,0 +1,156 @@
+package meshfile_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/meshfile"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func TestRoundTrip(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	d := meshfile.FromPolyhedron(poly)
+
+	for _, compress := range []bool{false, true} {
+		buf := bytes.Buffer{}
+		if err := meshfile.Write(&buf, d, meshfile.Options{Compress: compress}); err != nil {
+			t.Fatalf("%v", err)
+		}
+		loaded, err := meshfile.Read(&buf)
+		if err != nil {
+			t.Fatalf("compress %v: %v", compress, err)
+		}
+		if !reflect.DeepEqual(loaded.Vertices, d.Vertices) || !reflect.DeepEqual(loaded.Indices, d.Indices) {
+			t.Errorf("compress %v: buffers differ", compress)
+		}
+		if loaded.Min.X != -1 || loaded.Max.Y != 1 {
+			t.Errorf("compress %v: bounds %v %v", compress, loaded.Min, loaded.Max)
+		}
+	}
+}
+
+func TestLoadsIntoVertexBuffer(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	d := meshfile.FromPolyhedron(poly)
+
+	// What CreateMesh would send to the GPU
+	if !reflect.DeepEqual(d.Vertices, render.VertexBuffer(poly)) {
+		t.Errorf("vertices should be in the VertexBuffer layout")
+	}
+	// And back again
+	rebuilt := d.Polyhedron()
+	if !reflect.DeepEqual(render.VertexBuffer(rebuilt), d.Vertices) {
+		t.Errorf("rebuilt polyhedron should give the same buffer")
+	}
+}
+
+func TestCompressionIsSmaller(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	d := meshfile.FromPolyhedron(poly)
+	plain := bytes.Buffer{}
+	packed := bytes.Buffer{}
+	meshfile.Write(&plain, d, meshfile.Options{})
+	meshfile.Write(&packed, d, meshfile.Options{Compress: true})
+	if packed.Len() >= plain.Len() {
+		t.Errorf("compressed %v should be smaller than %v", packed.Len(), plain.Len())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	buf := bytes.Buffer{}
+	meshfile.Write(&buf, meshfile.FromPolyhedron(poly), meshfile.Options{})
+	good := buf.Bytes()
+
+	corrupt := func(at int, b byte) []byte {
+		out := append([]byte{}, good...)
+		out[at] = b
+		return out
+	}
+	tests := map[string][]byte{
+		"empty":     {},
+		"magic":     corrupt(0, 'X'),
+		"version":   corrupt(4, 9),
+		"layout":    corrupt(41, 2),
+		"truncated": good[:len(good)-10],
+	}
+	for name, data := range tests {
+		if _, err := meshfile.Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func TestReadCorruptIndexCount(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	d := meshfile.FromPolyhedron(poly)
+	for _, compress := range []bool{false, true} {
+		buf := bytes.Buffer{}
+		meshfile.Write(&buf, d, meshfile.Options{Compress: compress})
+		data := buf.Bytes()
+		// Claim far more indices than the body holds
+		binary.LittleEndian.PutUint32(data[12:], 0xffffffff)
+		if _, err := meshfile.Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("compress %v: expected an error", compress)
+		}
+	}
+}
+
+func TestReadUnskinned(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	d := meshfile.FromPolyhedron(poly)
+	buf := bytes.Buffer{}
+	meshfile.Write(&buf, d, meshfile.Options{})
+	good := buf.Bytes()
+
+	// The same mesh as written before vertices had joints and weights
+	old := bytes.Buffer{}
+	old.Write(good[:40])
+	old.WriteByte(5)
+	old.Write(good[41 : 41+5*2])
+	size := int(geometry.VertexSize)
+	vertices := []float32{}
+	for i := 0; i < d.VertexCount(); i++ {
+		vertices = append(vertices, d.Vertices[i*size:i*size+14]...)
+	}
+	binary.Write(&old, binary.LittleEndian, uint32(len(vertices)*4+len(d.Indices)*2))
+	binary.Write(&old, binary.LittleEndian, vertices)
+	binary.Write(&old, binary.LittleEndian, d.Indices)
+
+	loaded, err := meshfile.Read(&old)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(loaded.Vertices, d.Vertices) || !reflect.DeepEqual(loaded.Indices, d.Indices) {
+		t.Errorf("an unskinned file should load with no joints or weights")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meshfile")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	poly, _ := model.CreateTestPoly()
+	path := filepath.Join(dir, "cube.meshb")
+	if err := meshfile.WriteFile(path, meshfile.FromPolyhedron(poly), meshfile.Options{Compress: true}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	d, err := meshfile.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if d.VertexCount() != len(poly.Vertices) {
+		t.Errorf("vertex count %v should be %v", d.VertexCount(), len(poly.Vertices))
+	}
+}
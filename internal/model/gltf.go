@@ -0,0 +1,710 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+const (
+	glbMagic     = 0x46546C67
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBin  = 0x004E4942
+
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+type gltfDocument struct {
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes  []gltfNode `json:"nodes"`
+	Meshes []struct {
+		Primitives []gltfPrimitive `json:"primitives"`
+		// Weights the morph targets' default weights
+		Weights []float64 `json:"weights"`
+		Extras  struct {
+			TargetNames []string `json:"targetNames"`
+		} `json:"extras"`
+	} `json:"meshes"`
+	Skins       []gltfSkin       `json:"skins"`
+	Animations  []gltfAnimation  `json:"animations"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name"`
+	Mesh        *int      `json:"mesh"`
+	Skin        *int      `json:"skin"`
+	Children    []int     `json:"children"`
+	Matrix      []float64 `json:"matrix"`
+	Translation []float64 `json:"translation"`
+	Rotation    []float64 `json:"rotation"`
+	Scale       []float64 `json:"scale"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices"`
+	Mode       *int             `json:"mode"`
+	Targets    []map[string]int `json:"targets"`
+}
+
+type gltfSkin struct {
+	InverseBindMatrices *int  `json:"inverseBindMatrices"`
+	Joints              []int `json:"joints"`
+}
+
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+// mat4 a column major 4x4 matrix, as glTF stores them
+type mat4 [16]float64
+
+var identity4 = mat4{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+
+// gltfLoader the state of one load
+type gltfLoader struct {
+	doc     gltfDocument
+	buffers [][]byte
+	poly    geometry.Polyhedron
+	smooth  []bool
+
+	// skeleton the first skin, when loading it, with the skeleton joint
+	// for each node in it and for each of the skin's joints
+	skeleton   *anim.Skeleton
+	nodeJoints map[int]int
+	skinJoints []int
+}
+
+// Animated a model bound to a skeleton, with the clips that move it
+type Animated struct {
+	Poly geometry.Polyhedron
+	// Skeleton the file's first skin, nil when it has none
+	Skeleton *anim.Skeleton
+	// Clips the file's animations, as far as they move the skeleton
+	Clips []*anim.Clip
+}
+
+// LoadGLTF read the triangles of a glTF 2.0 file (.gltf or binary .glb)
+// into one polyhedron, with each mesh placed by the nodes of the default
+// scene. Morph targets come in as the polyhedron's Targets, named by the
+// mesh's extras.targetNames. open is called for buffers stored outside
+// the file and may be nil when there are none
+func LoadGLTF(r io.Reader, open func(uri string) ([]byte, error)) (geometry.Polyhedron, error) {
+	l, err := readGLTF(r, open)
+	if err != nil {
+		return geometry.Polyhedron{}, err
+	}
+	if err := l.loadScene(); err != nil {
+		return geometry.Polyhedron{}, err
+	}
+	smoothNormals(&l.poly, l.smooth)
+	return l.poly, nil
+}
+
+// LoadGLTFFile read a glTF file from disk, with any external buffers
+// relative to it
+func LoadGLTFFile(path string) (geometry.Polyhedron, error) {
+	data, open, err := readGLTFFile(path)
+	if err != nil {
+		return geometry.Polyhedron{}, err
+	}
+	return LoadGLTF(bytes.NewReader(data), open)
+}
+
+// LoadGLTFAnimated read a glTF file like LoadGLTF, along with its first
+// skin as a skeleton and its animations as clips for that skeleton. The
+// skeleton takes in every node above the skin's joints too, so the
+// scene's transforms still apply. Meshes bound to the skin are left in
+// their bind pose with each vertex's Joints and Weights (JOINTS_0 and
+// WEIGHTS_0) set, for anim.ComponentAnimator to move. Other meshes are
+// placed by their nodes as LoadGLTF does, and animations of nodes
+// outside the skeleton, or of morph weights, are left out. Cubic spline
+// keys lose their tangents and are blended linearly
+func LoadGLTFAnimated(r io.Reader, open func(uri string) ([]byte, error)) (Animated, error) {
+	l, err := readGLTF(r, open)
+	if err != nil {
+		return Animated{}, err
+	}
+	if err := l.loadSkin(); err != nil {
+		return Animated{}, err
+	}
+	if err := l.loadScene(); err != nil {
+		return Animated{}, err
+	}
+	smoothNormals(&l.poly, l.smooth)
+	clips, err := l.loadAnimations()
+	if err != nil {
+		return Animated{}, err
+	}
+	return Animated{Poly: l.poly, Skeleton: l.skeleton, Clips: clips}, nil
+}
+
+// LoadGLTFAnimatedFile read an animated glTF file from disk, with any
+// external buffers relative to it
+func LoadGLTFAnimatedFile(path string) (Animated, error) {
+	data, open, err := readGLTFFile(path)
+	if err != nil {
+		return Animated{}, err
+	}
+	return LoadGLTFAnimated(bytes.NewReader(data), open)
+}
+
+// readGLTF parse the document and load its buffers
+func readGLTF(r io.Reader, open func(uri string) ([]byte, error)) (*gltfLoader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var bin []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, bin, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &gltfLoader{}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(l.doc.Buffers); i++ {
+		b, err := loadGLTFBuffer(l.doc.Buffers[i].URI, bin, open)
+		if err != nil {
+			return nil, fmt.Errorf("buffer %v: %v", i, err)
+		}
+		l.buffers = append(l.buffers, b)
+	}
+	return l, nil
+}
+
+// readGLTFFile read a file, and a way to open the buffers beside it
+func readGLTFFile(path string) ([]byte, func(string) ([]byte, error), error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := filepath.Dir(path)
+	return data, func(uri string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(uri)))
+	}, nil
+}
+
+// splitGLB get the JSON and binary chunks of a .glb file
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %v", version)
+	}
+	var js, bin []byte
+	for offset := 12; offset+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		kind := binary.LittleEndian.Uint32(data[offset+4:])
+		start := offset + 8
+		if length < 0 || start+length > len(data) {
+			return nil, nil, errors.New("glb chunk runs past the end of the file")
+		}
+		switch kind {
+		case glbChunkJSON:
+			js = data[start : start+length]
+		case glbChunkBin:
+			bin = data[start : start+length]
+		}
+		offset = start + length
+	}
+	if js == nil {
+		return nil, nil, errors.New("glb has no JSON chunk")
+	}
+	return js, bin, nil
+}
+
+func loadGLTFBuffer(uri string, bin []byte, open func(string) ([]byte, error)) ([]byte, error) {
+	switch {
+	case uri == "":
+		if bin == nil {
+			return nil, errors.New("no uri and no glb binary chunk")
+		}
+		return bin, nil
+	case strings.HasPrefix(uri, "data:"):
+		comma := strings.Index(uri, ",")
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, errors.New("only base64 data uris are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	case open == nil:
+		return nil, fmt.Errorf("no way to open %q", uri)
+	}
+	return open(uri)
+}
+
+// loadScene add every mesh in the default scene, or every mesh as is
+// when the file has no scenes
+func (l *gltfLoader) loadScene() error {
+	if len(l.doc.Scenes) == 0 {
+		for m := 0; m < len(l.doc.Meshes); m++ {
+			if err := l.loadMesh(m, identity4, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	scene := 0
+	if l.doc.Scene != nil {
+		scene = *l.doc.Scene
+	}
+	if scene < 0 || scene >= len(l.doc.Scenes) {
+		return fmt.Errorf("scene %v out of range", scene)
+	}
+	nodes := l.doc.Scenes[scene].Nodes
+	for i := 0; i < len(nodes); i++ {
+		if err := l.loadNode(nodes[i], identity4, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *gltfLoader) loadNode(index int, parent mat4, depth int) error {
+	if index < 0 || index >= len(l.doc.Nodes) {
+		return fmt.Errorf("node %v out of range", index)
+	}
+	if depth > len(l.doc.Nodes) {
+		return errors.New("node hierarchy has a cycle")
+	}
+	n := l.doc.Nodes[index]
+	world := parent.mul(n.local())
+	if n.Mesh != nil {
+		var err error
+		if l.skeleton != nil && n.Skin != nil && *n.Skin == 0 {
+			// The joints place skinned meshes, not the node
+			err = l.loadMesh(*n.Mesh, identity4, true)
+		} else {
+			err = l.loadMesh(*n.Mesh, world, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(n.Children); i++ {
+		if err := l.loadNode(n.Children[i], world, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *gltfLoader) loadMesh(index int, world mat4, skinned bool) error {
+	if index < 0 || index >= len(l.doc.Meshes) {
+		return fmt.Errorf("mesh %v out of range", index)
+	}
+	mesh := l.doc.Meshes[index]
+	for i := 0; i < len(mesh.Primitives); i++ {
+		if err := l.loadPrimitive(mesh.Primitives[i], world, skinned); err != nil {
+			return fmt.Errorf("mesh %v primitive %v: %v", index, i, err)
+		}
+	}
+	l.nameTargets(mesh.Extras.TargetNames, mesh.Weights)
+	return nil
+}
+
+func (l *gltfLoader) loadPrimitive(p gltfPrimitive, world mat4, skinned bool) error {
+	mode := gltfTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+		// Points and lines have no surface to draw
+		return nil
+	}
+
+	position, ok := p.Attributes["POSITION"]
+	if !ok {
+		return errors.New("no POSITION attribute")
+	}
+	positions, err := l.accessor(position, 3)
+	if err != nil {
+		return err
+	}
+	count := len(positions) / 3
+	base := len(l.poly.Vertices)
+	if base+count > math.MaxUint16+1 {
+		return fmt.Errorf("more than %v vertices", math.MaxUint16+1)
+	}
+
+	normals, err := l.optional(p, "NORMAL", 3, count)
+	if err != nil {
+		return err
+	}
+	texCoords, err := l.optional(p, "TEXCOORD_0", 2, count)
+	if err != nil {
+		return err
+	}
+	tangents, err := l.optional(p, "TANGENT", 4, count)
+	if err != nil {
+		return err
+	}
+	colors, colorSize, err := l.colors(p, count)
+	if err != nil {
+		return err
+	}
+	var joints, weights []float64
+	if skinned {
+		if joints, weights, err = l.skinAttributes(p, count); err != nil {
+			return err
+		}
+	}
+
+	normalMatrix := world.normalMatrix()
+	for i := 0; i < count; i++ {
+		v := geometry.Vertex{
+			Pos:   world.point(positions[i*3:]),
+			Color: algebra.Vector{X: 1, Y: 1, Z: 1, W: 1},
+		}
+		if normals != nil {
+			v.Normal = normalMatrix.direction(normals[i*3:])
+		}
+		if texCoords != nil {
+			v.TexCoord = algebra.Vector{X: texCoords[i*2], Y: texCoords[i*2+1]}
+		}
+		if tangents != nil {
+			v.Tangent = world.direction(tangents[i*4:])
+		}
+		if colors != nil {
+			c := colors[i*colorSize:]
+			v.Color = algebra.Vector{X: c[0], Y: c[1], Z: c[2], W: 1}
+		}
+		if joints != nil {
+			j, w := joints[i*4:], weights[i*4:]
+			v.Joints = algebra.Vector{X: j[0], Y: j[1], Z: j[2], W: j[3]}
+			v.Weights = algebra.Vector{X: w[0], Y: w[1], Z: w[2], W: w[3]}
+		}
+		l.poly.Vertices = append(l.poly.Vertices, v)
+		l.smooth = append(l.smooth, normals == nil)
+	}
+	if err := l.loadTargets(p, count, world, normals); err != nil {
+		return err
+	}
+
+	indices := make([]int, count)
+	for i := 0; i < count; i++ {
+		indices[i] = i
+	}
+	if p.Indices != nil {
+		values, err := l.accessor(*p.Indices, 1)
+		if err != nil {
+			return err
+		}
+		indices = make([]int, len(values))
+		for i := 0; i < len(values); i++ {
+			indices[i] = int(values[i])
+			if indices[i] < 0 || indices[i] >= count {
+				return fmt.Errorf("index %v out of range", indices[i])
+			}
+		}
+	}
+
+	// A mirroring transform turns the triangles inside out
+	flip := world.determinant() < 0
+	emit := func(a, b, c int) {
+		if flip {
+			b, c = c, b
+		}
+		l.poly.Indices = append(l.poly.Indices, uint16(base+a), uint16(base+b), uint16(base+c))
+	}
+	switch mode {
+	case gltfTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			emit(indices[i], indices[i+1], indices[i+2])
+		}
+	case gltfTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				emit(indices[i], indices[i+1], indices[i+2])
+			} else {
+				emit(indices[i+1], indices[i], indices[i+2])
+			}
+		}
+	case gltfTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			emit(indices[0], indices[i], indices[i+1])
+		}
+	}
+	return nil
+}
+
+// optional read an attribute the primitive may not have, nil if it
+// doesn't
+func (l *gltfLoader) optional(p gltfPrimitive, name string, size int, count int) ([]float64, error) {
+	index, ok := p.Attributes[name]
+	if !ok {
+		return nil, nil
+	}
+	values, err := l.accessor(index, size)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	if len(values) != count*size {
+		return nil, fmt.Errorf("%v has %v values, expected %v", name, len(values)/size, count)
+	}
+	return values, nil
+}
+
+// colors read COLOR_0, which can be rgb or rgba
+func (l *gltfLoader) colors(p gltfPrimitive, count int) ([]float64, int, error) {
+	index, ok := p.Attributes["COLOR_0"]
+	if !ok {
+		return nil, 0, nil
+	}
+	if index < 0 || index >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %v out of range", index)
+	}
+	size := 3
+	if l.doc.Accessors[index].Type == "VEC4" {
+		size = 4
+	}
+	values, err := l.optional(p, "COLOR_0", size, count)
+	return values, size, err
+}
+
+// accessor read an accessor's values as floats, checking it has size
+// components per element
+func (l *gltfLoader) accessor(index int, size int) ([]float64, error) {
+	if index < 0 || index >= len(l.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %v out of range", index)
+	}
+	a := l.doc.Accessors[index]
+	if len(a.Sparse) > 0 {
+		return nil, errors.New("sparse accessors are not supported")
+	}
+	components := map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT4": 16}[a.Type]
+	if components != size {
+		return nil, fmt.Errorf("accessor %v is %v, expected %v components", index, a.Type, size)
+	}
+	componentSize := map[int]int{
+		gltfByte: 1, gltfUnsignedByte: 1,
+		gltfShort: 2, gltfUnsignedShort: 2,
+		gltfUnsignedInt: 4, gltfFloat: 4,
+	}[a.ComponentType]
+	if componentSize == 0 {
+		return nil, fmt.Errorf("unknown component type %v", a.ComponentType)
+	}
+
+	out := make([]float64, a.Count*components)
+	if a.BufferView == nil {
+		return out, nil
+	}
+	if *a.BufferView < 0 || *a.BufferView >= len(l.doc.BufferViews) {
+		return nil, fmt.Errorf("buffer view %v out of range", *a.BufferView)
+	}
+	view := l.doc.BufferViews[*a.BufferView]
+	if view.Buffer < 0 || view.Buffer >= len(l.buffers) {
+		return nil, fmt.Errorf("buffer %v out of range", view.Buffer)
+	}
+	buffer := l.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(buffer) {
+		return nil, errors.New("buffer view runs past the end of its buffer")
+	}
+	data := buffer[view.ByteOffset : view.ByteOffset+view.ByteLength]
+
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = components * componentSize
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+components*componentSize > len(data) {
+		return nil, errors.New("accessor runs past the end of its buffer view")
+	}
+	for i := 0; i < a.Count; i++ {
+		for c := 0; c < components; c++ {
+			at := a.ByteOffset + i*stride + c*componentSize
+			out[i*components+c] = readComponent(data[at:], a.ComponentType, a.Normalized)
+		}
+	}
+	return out, nil
+}
+
+func readComponent(b []byte, kind int, normalized bool) float64 {
+	switch kind {
+	case gltfByte:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltfShort:
+		v := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	}
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+}
+
+// local the node's transform relative to its parent
+func (n gltfNode) local() mat4 {
+	if len(n.Matrix) == 16 {
+		m := mat4{}
+		copy(m[:], n.Matrix)
+		return m
+	}
+	t := [3]float64{}
+	copy(t[:], n.Translation)
+	r := [4]float64{0, 0, 0, 1}
+	if len(n.Rotation) == 4 {
+		copy(r[:], n.Rotation)
+	}
+	s := [3]float64{1, 1, 1}
+	if len(n.Scale) == 3 {
+		copy(s[:], n.Scale)
+	}
+
+	// T * R * S
+	x, y, z, w := r[0], r[1], r[2], r[3]
+	rot := [9]float64{
+		1 - 2*(y*y+z*z), 2 * (x*y + z*w), 2 * (x*z - y*w),
+		2 * (x*y - z*w), 1 - 2*(x*x+z*z), 2 * (y*z + x*w),
+		2 * (x*z + y*w), 2 * (y*z - x*w), 1 - 2*(x*x+y*y),
+	}
+	m := identity4
+	for c := 0; c < 3; c++ {
+		for row := 0; row < 3; row++ {
+			m[c*4+row] = rot[c*3+row] * s[c]
+		}
+	}
+	m[12], m[13], m[14] = t[0], t[1], t[2]
+	return m
+}
+
+func (m mat4) mul(o mat4) mat4 {
+	out := mat4{}
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			for k := 0; k < 4; k++ {
+				out[c*4+r] += m[k*4+r] * o[c*4+k]
+			}
+		}
+	}
+	return out
+}
+
+func (m mat4) point(p []float64) algebra.Vector {
+	return algebra.Vector{
+		X: m[0]*p[0] + m[4]*p[1] + m[8]*p[2] + m[12],
+		Y: m[1]*p[0] + m[5]*p[1] + m[9]*p[2] + m[13],
+		Z: m[2]*p[0] + m[6]*p[1] + m[10]*p[2] + m[14],
+	}
+}
+
+// direction transform and normalize a direction (ignoring translation)
+func (m mat4) direction(d []float64) algebra.Vector {
+	v := m.vector(d)
+	out := algebra.Vector{}
+	v.Normalized(&out)
+	return out
+}
+
+// vector transform an offset, ignoring translation and keeping its length
+func (m mat4) vector(d []float64) algebra.Vector {
+	return algebra.Vector{
+		X: m[0]*d[0] + m[4]*d[1] + m[8]*d[2],
+		Y: m[1]*d[0] + m[5]*d[1] + m[9]*d[2],
+		Z: m[2]*d[0] + m[6]*d[1] + m[10]*d[2],
+	}
+}
+
+func (m mat4) determinant() float64 {
+	return m[0]*(m[5]*m[10]-m[9]*m[6]) -
+		m[4]*(m[1]*m[10]-m[9]*m[2]) +
+		m[8]*(m[1]*m[6]-m[5]*m[2])
+}
+
+// normalMatrix the cofactors of the upper 3x3, which transform normals
+// correctly under non uniform scale (up to length)
+func (m mat4) normalMatrix() mat4 {
+	a := func(c, r int) float64 { return m[c*4+r] }
+	out := identity4
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			c1, c2 := (c+1)%3, (c+2)%3
+			r1, r2 := (r+1)%3, (r+2)%3
+			out[c*4+r] = a(c1, r1)*a(c2, r2) - a(c1, r2)*a(c2, r1)
+		}
+	}
+	if m.determinant() < 0 {
+		for i := 0; i < 12; i++ {
+			out[i] = -out[i]
+		}
+	}
+	return out
+}
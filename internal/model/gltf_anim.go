@@ -0,0 +1,257 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+)
+
+// loadSkin build a skeleton from the first skin: its joints, and every
+// node above them so the joints end up where the scene puts them,
+// parents first
+func (l *gltfLoader) loadSkin() error {
+	if len(l.doc.Skins) == 0 {
+		return nil
+	}
+	skin := l.doc.Skins[0]
+	nodes := l.doc.Nodes
+
+	parents := make([]int, len(nodes))
+	for i := 0; i < len(parents); i++ {
+		parents[i] = -1
+	}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range nodes[i].Children {
+			if c < 0 || c >= len(nodes) {
+				return fmt.Errorf("node %v out of range", c)
+			}
+			parents[c] = i
+		}
+	}
+
+	s := &anim.Skeleton{}
+	l.nodeJoints = map[int]int{}
+	var add func(node int, depth int) error
+	add = func(node int, depth int) error {
+		if _, ok := l.nodeJoints[node]; ok {
+			return nil
+		}
+		if node < 0 || node >= len(nodes) {
+			return fmt.Errorf("skin joint %v out of range", node)
+		}
+		if depth > len(nodes) {
+			return errors.New("node hierarchy has a cycle")
+		}
+		parent := -1
+		if p := parents[node]; p >= 0 {
+			if err := add(p, depth+1); err != nil {
+				return err
+			}
+			parent = l.nodeJoints[p]
+		}
+		joint := anim.Joint{Name: nodes[node].Name, Parent: parent, Rest: nodes[node].transform()}
+		joint.InverseBind.InitIdentity()
+		l.nodeJoints[node] = len(s.Joints)
+		s.Joints = append(s.Joints, joint)
+		return nil
+	}
+	l.skinJoints = make([]int, len(skin.Joints))
+	for i := 0; i < len(skin.Joints); i++ {
+		if err := add(skin.Joints[i], 0); err != nil {
+			return err
+		}
+		l.skinJoints[i] = l.nodeJoints[skin.Joints[i]]
+	}
+
+	if skin.InverseBindMatrices != nil {
+		values, err := l.accessor(*skin.InverseBindMatrices, 16)
+		if err != nil {
+			return fmt.Errorf("inverse bind matrices: %v", err)
+		}
+		if len(values) != len(skin.Joints)*16 {
+			return fmt.Errorf("%v inverse bind matrices for %v joints", len(values)/16, len(skin.Joints))
+		}
+		for i := 0; i < len(skin.Joints); i++ {
+			m := mat4{}
+			copy(m[:], values[i*16:])
+			s.Joints[l.skinJoints[i]].InverseBind = m.matrix()
+		}
+	}
+	l.skeleton = s
+	return nil
+}
+
+// skinAttributes read JOINTS_0 as skeleton joints and WEIGHTS_0 scaled
+// to add up to 1, nil when the primitive isn't skinned
+func (l *gltfLoader) skinAttributes(p gltfPrimitive, count int) ([]float64, []float64, error) {
+	joints, err := l.optional(p, "JOINTS_0", 4, count)
+	if err != nil {
+		return nil, nil, err
+	}
+	weights, err := l.optional(p, "WEIGHTS_0", 4, count)
+	if err != nil {
+		return nil, nil, err
+	}
+	if joints == nil || weights == nil {
+		return nil, nil, nil
+	}
+	for i := 0; i < len(joints); i++ {
+		j := int(joints[i])
+		if j < 0 || j >= len(l.skinJoints) {
+			return nil, nil, fmt.Errorf("JOINTS_0 has joint %v, the skin has %v", j, len(l.skinJoints))
+		}
+		joints[i] = float64(l.skinJoints[j])
+	}
+	for i := 0; i < count; i++ {
+		w := weights[i*4 : i*4+4]
+		total := w[0] + w[1] + w[2] + w[3]
+		if total <= 0 {
+			continue
+		}
+		for k := 0; k < 4; k++ {
+			w[k] /= total
+		}
+	}
+	return joints, weights, nil
+}
+
+// loadAnimations every animation as a clip of the skeleton, keeping the
+// channels that move its joints
+func (l *gltfLoader) loadAnimations() ([]*anim.Clip, error) {
+	if l.skeleton == nil {
+		return nil, nil
+	}
+	clips := []*anim.Clip{}
+	for a := 0; a < len(l.doc.Animations); a++ {
+		animation := l.doc.Animations[a]
+		clip := &anim.Clip{Name: animation.Name}
+		for c := 0; c < len(animation.Channels); c++ {
+			channel := animation.Channels[c]
+			if channel.Target.Node == nil {
+				continue
+			}
+			joint, ok := l.nodeJoints[*channel.Target.Node]
+			if !ok {
+				continue
+			}
+			property, size := anim.Property(channel.Target.Path), 3
+			switch property {
+			case anim.Translation, anim.Scale:
+			case anim.Rotation:
+				size = 4
+			default:
+				continue
+			}
+			if channel.Sampler < 0 || channel.Sampler >= len(animation.Samplers) {
+				return nil, fmt.Errorf("animation %v channel %v: sampler %v out of range", a, c, channel.Sampler)
+			}
+			sampler := animation.Samplers[channel.Sampler]
+			track, err := l.track(sampler.Input, sampler.Output, sampler.Interpolation, size)
+			if err != nil {
+				return nil, fmt.Errorf("animation %v channel %v: %v", a, c, err)
+			}
+			track.Joint, track.Property = joint, property
+			if err := track.Validate(); err != nil {
+				return nil, fmt.Errorf("animation %v channel %v: %v", a, c, err)
+			}
+			clip.Tracks = append(clip.Tracks, track)
+		}
+		clips = append(clips, clip)
+	}
+	return clips, nil
+}
+
+// track read a sampler's keys, size values each
+func (l *gltfLoader) track(input, output int, interpolation string, size int) (anim.Track, error) {
+	t := anim.Track{}
+	times, err := l.accessor(input, 1)
+	if err != nil {
+		return t, err
+	}
+	values, err := l.accessor(output, size)
+	if err != nil {
+		return t, err
+	}
+	// Cubic splines have an in tangent, the value and an out tangent
+	// for every key
+	stride, offset := 1, 0
+	switch interpolation {
+	case "STEP":
+		t.Interpolation = anim.Step
+	case "CUBICSPLINE":
+		stride, offset = 3, 1
+	}
+	if len(values) != len(times)*stride*size {
+		return t, fmt.Errorf("%v keys but %v values", len(times), len(values)/size)
+	}
+	t.Times = times
+	t.Values = make([]algebra.Vector, len(times))
+	for k := 0; k < len(times); k++ {
+		v := values[(k*stride+offset)*size:]
+		t.Values[k] = algebra.Vector{X: v[0], Y: v[1], Z: v[2]}
+		if size == 4 {
+			t.Values[k].W = v[3]
+		}
+	}
+	return t, nil
+}
+
+// transform the node's translation, rotation and scale, pulled out of
+// its matrix when it has one
+func (n gltfNode) transform() anim.Transform {
+	if len(n.Matrix) != 16 {
+		t := anim.Transform{
+			Rotation: algebra.Quaternion{W: 1},
+			Scale:    algebra.Vector{X: 1, Y: 1, Z: 1},
+		}
+		if len(n.Translation) == 3 {
+			t.Translation = algebra.Vector{X: n.Translation[0], Y: n.Translation[1], Z: n.Translation[2]}
+		}
+		if len(n.Rotation) == 4 {
+			t.Rotation = algebra.Quaternion{X: n.Rotation[0], Y: n.Rotation[1], Z: n.Rotation[2], W: n.Rotation[3]}
+		}
+		if len(n.Scale) == 3 {
+			t.Scale = algebra.Vector{X: n.Scale[0], Y: n.Scale[1], Z: n.Scale[2]}
+		}
+		return t
+	}
+
+	m := n.local()
+	rows := m.matrix()
+	scale := [3]float64{}
+	for i := 0; i < 3; i++ {
+		scale[i] = math.Sqrt(rows[i][0]*rows[i][0] + rows[i][1]*rows[i][1] + rows[i][2]*rows[i][2])
+	}
+	if m.determinant() < 0 {
+		scale[0] = -scale[0]
+	}
+	for i := 0; i < 3; i++ {
+		if scale[i] == 0 {
+			continue
+		}
+		for j := 0; j < 3; j++ {
+			rows[i][j] /= scale[i]
+		}
+	}
+	t := anim.Transform{
+		Translation: algebra.Vector{X: m[12], Y: m[13], Z: m[14]},
+		Scale:       algebra.Vector{X: scale[0], Y: scale[1], Z: scale[2]},
+	}
+	t.Rotation.SetFromMatrix(&rows)
+	return t
+}
+
+// matrix the same transform for row vectors, as algebra.Matrix has them.
+// Each of glTF's columns is one of the rows
+func (m mat4) matrix() algebra.Matrix {
+	out := algebra.Matrix{}
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			out[r][c] = m[r*4+c]
+		}
+	}
+	return out
+}
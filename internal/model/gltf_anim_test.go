@@ -0,0 +1,199 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/model"
+)
+
+// armDocument an arm (armature, shoulder and elbow one up) with a
+// triangle bound to it, listing the elbow first in the skin, and a clip
+// turning the shoulder a quarter turn about z
+func armDocument() map[string]interface{} {
+	buffer := bytes.Buffer{}
+	views := []interface{}{}
+	accessors := []interface{}{}
+	add := func(data interface{}, componentType int, count int, kind string) int {
+		for buffer.Len()%4 != 0 {
+			buffer.WriteByte(0)
+		}
+		start := buffer.Len()
+		binary.Write(&buffer, binary.LittleEndian, data)
+		views = append(views, map[string]interface{}{
+			"buffer": 0, "byteOffset": start, "byteLength": buffer.Len() - start,
+		})
+		accessors = append(accessors, map[string]interface{}{
+			"bufferView": len(views) - 1, "componentType": componentType, "count": count, "type": kind,
+		})
+		return len(accessors) - 1
+	}
+
+	position := add([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, 5126, 3, "VEC3")
+	indices := add([]uint16{0, 1, 2}, 5123, 3, "SCALAR")
+	// Skin joint 1 is the shoulder, 0 the elbow
+	joints := add([]uint8{1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, 5121, 3, "VEC4")
+	weights := add([]float32{1, 0, 0, 0, 1, 0, 0, 0, 0.5, 0, 0, 0}, 5126, 3, "VEC4")
+	inverseBind := add([]float32{
+		1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, -1, 0, 1,
+		1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1,
+	}, 5126, 2, "MAT4")
+	times := add([]float32{0, 1}, 5126, 2, "SCALAR")
+	half := float32(math.Sqrt(0.5))
+	rotations := add([]float32{0, 0, 0, 1, 0, 0, half, half}, 5126, 2, "VEC4")
+
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes())
+	return map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0, 3}}},
+		"nodes": []interface{}{
+			map[string]interface{}{"name": "armature", "children": []int{1}},
+			map[string]interface{}{"name": "shoulder", "children": []int{2}},
+			map[string]interface{}{"name": "elbow", "translation": []float64{0, 1, 0}},
+			map[string]interface{}{"name": "body", "mesh": 0, "skin": 0, "translation": []float64{100, 0, 0}},
+		},
+		"meshes": []interface{}{map[string]interface{}{
+			"primitives": []interface{}{map[string]interface{}{
+				"attributes": map[string]int{"POSITION": position, "JOINTS_0": joints, "WEIGHTS_0": weights},
+				"indices":    indices,
+			}},
+		}},
+		"skins": []interface{}{map[string]interface{}{
+			"joints": []int{2, 1}, "inverseBindMatrices": inverseBind,
+		}},
+		"animations": []interface{}{map[string]interface{}{
+			"name": "raise",
+			"samplers": []interface{}{
+				map[string]interface{}{"input": times, "output": rotations},
+			},
+			"channels": []interface{}{
+				map[string]interface{}{"sampler": 0, "target": map[string]interface{}{"node": 1, "path": "rotation"}},
+				// Not a joint, and not a joint's transform
+				map[string]interface{}{"sampler": 0, "target": map[string]interface{}{"node": 3, "path": "translation"}},
+				map[string]interface{}{"sampler": 0, "target": map[string]interface{}{"node": 2, "path": "weights"}},
+			},
+		}},
+		"accessors":   accessors,
+		"bufferViews": views,
+		"buffers":     []interface{}{map[string]interface{}{"uri": uri, "byteLength": buffer.Len()}},
+	}
+}
+
+func TestLoadGLTFAnimated(t *testing.T) {
+	a, err := model.LoadGLTFAnimated(bytes.NewReader(encodeDocument(armDocument())), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	s := a.Skeleton
+	if s == nil || len(s.Joints) != 3 {
+		t.Fatalf("expected armature, shoulder and elbow got %+v", s)
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("parents should come first: %v", err)
+	}
+	shoulder, elbow := s.Find("shoulder"), s.Find("elbow")
+	if s.Joints[shoulder].Parent != s.Find("armature") || s.Joints[elbow].Parent != shoulder {
+		t.Errorf("hierarchy lost %+v", s.Joints)
+	}
+
+	p := a.Poly
+	if len(p.Vertices) != 3 || p.Vertices[1].Pos.X != 1 {
+		t.Fatalf("skinned meshes should stay in their bind pose got %v", p.Vertices)
+	}
+	if int(p.Vertices[0].Joints.X) != shoulder || int(p.Vertices[2].Joints.X) != elbow {
+		t.Errorf("joints should be the skeleton's got %v and %v", p.Vertices[0].Joints, p.Vertices[2].Joints)
+	}
+	if p.Vertices[2].Weights.X != 1 {
+		t.Errorf("weights should add up to 1 got %v", p.Vertices[2].Weights)
+	}
+
+	if len(a.Clips) != 1 || len(a.Clips[0].Tracks) != 1 {
+		t.Fatalf("expected one clip moving the shoulder got %+v", a.Clips)
+	}
+	clip := a.Clips[0]
+	if clip.Name != "raise" || clip.Length() != 1 || clip.Tracks[0].Joint != shoulder {
+		t.Errorf("clip %+v", clip)
+	}
+
+	// Bound where they rest
+	pose := s.RestPose()
+	rest := s.Skin(pose, nil)
+	for i := 0; i < len(p.Vertices); i++ {
+		v := p.Vertices[i].Skin(rest)
+		if !v.Pos.AlmostEquals(&p.Vertices[i].Pos) {
+			t.Errorf("vertex %v moved at rest to %v", i, v.Pos)
+		}
+	}
+
+	// The shoulder turns +x up to +y, and the elbow's tip over to -x
+	clip.Sample(1, pose)
+	raised := s.Skin(pose, nil)
+	want := []algebra.Vector{{}, {Y: 1}, {X: -1}}
+	for i := 0; i < len(want); i++ {
+		v := p.Vertices[i].Skin(raised)
+		if math.Abs(v.Pos.X-want[i].X) > 1e-6 || math.Abs(v.Pos.Y-want[i].Y) > 1e-6 {
+			t.Errorf("raised vertex %v at %v want %v", i, v.Pos, want[i])
+		}
+	}
+}
+
+func TestLoadGLTFAnimatedStatic(t *testing.T) {
+	// Without a skin it's LoadGLTF with nothing to animate
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(triangleBuffer())
+	doc := triangleDocument(uri, map[string]interface{}{"translation": []float64{5, 0, 0}})
+	a, err := model.LoadGLTFAnimated(bytes.NewReader(encodeDocument(doc)), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if a.Skeleton != nil || len(a.Clips) != 0 || a.Poly.Vertices[1].Pos.X != 6 {
+		t.Errorf("static file %+v", a)
+	}
+
+	// And LoadGLTF places skinned meshes by their node as before
+	p, err := model.LoadGLTF(bytes.NewReader(encodeDocument(armDocument())), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.Vertices[1].Pos.X != 101 || !p.Vertices[1].Weights.IsZero() {
+		t.Errorf("LoadGLTF should ignore the skin got %v", p.Vertices[1])
+	}
+}
+
+func TestLoadGLTFAnimatedErrors(t *testing.T) {
+	tests := map[string]func(doc map[string]interface{}){
+		"joint out of range": func(doc map[string]interface{}) {
+			doc["skins"] = []interface{}{map[string]interface{}{"joints": []int{9}}}
+		},
+		"too few inverse binds": func(doc map[string]interface{}) {
+			doc["skins"] = []interface{}{map[string]interface{}{"joints": []int{2, 1, 0}, "inverseBindMatrices": 4}}
+		},
+		"vertex joint past the skin": func(doc map[string]interface{}) {
+			doc["skins"] = []interface{}{map[string]interface{}{"joints": []int{1}}}
+		},
+		"sampler out of range": func(doc map[string]interface{}) {
+			a := doc["animations"].([]interface{})[0].(map[string]interface{})
+			a["channels"] = []interface{}{
+				map[string]interface{}{"sampler": 3, "target": map[string]interface{}{"node": 1, "path": "rotation"}},
+			}
+		},
+		"wrong key values": func(doc map[string]interface{}) {
+			a := doc["animations"].([]interface{})[0].(map[string]interface{})
+			a["samplers"] = []interface{}{
+				map[string]interface{}{"input": 5, "output": 6, "interpolation": "CUBICSPLINE"},
+			}
+		},
+	}
+	for name, change := range tests {
+		doc := armDocument()
+		change(doc)
+		if _, err := model.LoadGLTFAnimated(bytes.NewReader(encodeDocument(doc)), nil); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// loadTargets add the morph targets of the primitive just loaded (its
+// count vertices are the last ones) to the polyhedron's. Targets are
+// matched by index across primitives, and vertices of primitives without
+// one don't move
+func (l *gltfLoader) loadTargets(p gltfPrimitive, count int, world mat4, normals []float64) error {
+	total := len(l.poly.Vertices)
+	base := total - count
+	for len(l.poly.Targets) < len(p.Targets) {
+		l.poly.Targets = append(l.poly.Targets, geometry.MorphTarget{})
+	}
+
+	normalMatrix := world.normalMatrix()
+	for t := 0; t < len(l.poly.Targets); t++ {
+		target := &l.poly.Targets[t]
+		target.Positions = grow(target.Positions, total)
+		if target.Normals != nil {
+			target.Normals = grow(target.Normals, total)
+		}
+		if t >= len(p.Targets) {
+			continue
+		}
+
+		attributes := gltfPrimitive{Attributes: p.Targets[t]}
+		positions, err := l.optional(attributes, "POSITION", 3, count)
+		if err != nil {
+			return fmt.Errorf("target %v: %v", t, err)
+		}
+		for i := 0; i < len(positions)/3; i++ {
+			// Skinned meshes load with the identity, so this is only ever
+			// the node's transform of a static mesh
+			target.Positions[base+i] = world.vector(positions[i*3:])
+		}
+
+		deltas, err := l.optional(attributes, "NORMAL", 3, count)
+		if err != nil {
+			return fmt.Errorf("target %v: %v", t, err)
+		}
+		if deltas == nil || normals == nil {
+			continue
+		}
+		if target.Normals == nil {
+			target.Normals = grow(nil, total)
+		}
+		for i := 0; i < count; i++ {
+			// The base normal was normalized after transforming, so the
+			// change is scaled the same way
+			n := normalMatrix.vector(normals[i*3:])
+			length := n.Length()
+			if length == 0 {
+				continue
+			}
+			d := normalMatrix.vector(deltas[i*3:])
+			target.Normals[base+i] = algebra.Vector{X: d.X / length, Y: d.Y / length, Z: d.Z / length}
+		}
+	}
+	return nil
+}
+
+// nameTargets give the morph targets the names and default weights of
+// the mesh that has them, when it does
+func (l *gltfLoader) nameTargets(names []string, weights []float64) {
+	for i := 0; i < len(l.poly.Targets); i++ {
+		if i < len(names) && l.poly.Targets[i].Name == "" {
+			l.poly.Targets[i].Name = names[i]
+		}
+		if i < len(weights) && weights[i] != 0 {
+			l.poly.Targets[i].Weight = weights[i]
+		}
+	}
+}
+
+// grow pad deltas with zeros out to n
+func grow(deltas []algebra.Vector, n int) []algebra.Vector {
+	for len(deltas) < n {
+		deltas = append(deltas, algebra.Vector{})
+	}
+	return deltas
+}
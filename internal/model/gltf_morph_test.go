@@ -0,0 +1,106 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/model"
+)
+
+// faceDocument a mesh scaled by 2 with two triangles, the first with
+// two morph targets (one moving its normals too) and the second with none
+func faceDocument() map[string]interface{} {
+	buffer := bytes.Buffer{}
+	views := []interface{}{}
+	accessors := []interface{}{}
+	add := func(data []float32, count int) int {
+		start := buffer.Len()
+		binary.Write(&buffer, binary.LittleEndian, data)
+		views = append(views, map[string]interface{}{
+			"buffer": 0, "byteOffset": start, "byteLength": buffer.Len() - start,
+		})
+		accessors = append(accessors, map[string]interface{}{
+			"bufferView": len(views) - 1, "componentType": 5126, "count": count, "type": "VEC3",
+		})
+		return len(accessors) - 1
+	}
+
+	triangle := add([]float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, 3)
+	normals := add([]float32{0, 0, 1, 0, 0, 1, 0, 0, 1}, 3)
+	smile := add([]float32{0, 0, 1, 0, 0, 0, 0, 0, 0}, 3)
+	smileNormals := add([]float32{1, 0, 0, 0, 0, 0, 0, 0, 0}, 3)
+	blink := add([]float32{0, 1, 0, 0, 1, 0, 0, 1, 0}, 3)
+
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes())
+	return map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0}}},
+		"nodes":  []interface{}{map[string]interface{}{"mesh": 0, "scale": []float64{2, 2, 2}}},
+		"meshes": []interface{}{map[string]interface{}{
+			"primitives": []interface{}{
+				map[string]interface{}{
+					"attributes": map[string]int{"POSITION": triangle, "NORMAL": normals},
+					"targets": []interface{}{
+						map[string]int{"POSITION": smile, "NORMAL": smileNormals},
+						map[string]int{"POSITION": blink},
+					},
+				},
+				map[string]interface{}{
+					"attributes": map[string]int{"POSITION": triangle, "NORMAL": normals},
+				},
+			},
+			"weights": []float64{0.5, 0},
+			"extras":  map[string]interface{}{"targetNames": []string{"smile", "blink"}},
+		}},
+		"accessors":   accessors,
+		"bufferViews": views,
+		"buffers":     []interface{}{map[string]interface{}{"uri": uri, "byteLength": buffer.Len()}},
+	}
+}
+
+func TestLoadGLTFMorphTargets(t *testing.T) {
+	p, err := model.LoadGLTF(bytes.NewReader(encodeDocument(faceDocument())), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(p.Vertices) != 6 || len(p.Targets) != 2 {
+		t.Fatalf("expected 6 vertices and 2 targets got %v and %v", len(p.Vertices), len(p.Targets))
+	}
+	smile, blink := p.Targets[0], p.Targets[1]
+	if smile.Name != "smile" || blink.Name != "blink" || smile.Weight != 0.5 || blink.Weight != 0 {
+		t.Errorf("names and weights: %v %v, %v %v", smile.Name, smile.Weight, blink.Name, blink.Weight)
+	}
+	if len(smile.Positions) != 6 || len(blink.Positions) != 6 {
+		t.Fatalf("every target needs a delta for every vertex")
+	}
+	// Scaled by the node like the vertices, and nothing for the second
+	// triangle
+	if smile.Positions[0].Z != 2 || blink.Positions[2].Y != 2 || !blink.Positions[5].IsZero() {
+		t.Errorf("position deltas %v %v", smile.Positions, blink.Positions)
+	}
+	if len(smile.Normals) != 6 || smile.Normals[0].X != 1 || blink.Normals != nil {
+		t.Errorf("normal deltas %v and %v", smile.Normals, blink.Normals)
+	}
+
+	out := geometry.Polyhedron{}
+	p.Morph([]float64{1}, &out)
+	v := out.Vertices[0]
+	if v.Pos.Z != 2 || math.Abs(v.Normal.X-math.Sqrt(0.5)) > 1e-9 {
+		t.Errorf("smiling: %v normal %v", v.Pos, v.Normal)
+	}
+}
+
+func TestLoadGLTFMorphTargetErrors(t *testing.T) {
+	doc := faceDocument()
+	primitive := doc["meshes"].([]interface{})[0].(map[string]interface{})["primitives"].([]interface{})[0].(map[string]interface{})
+	// A target reading an accessor that isn't there
+	primitive["targets"] = []interface{}{map[string]int{"POSITION": 99}}
+	if _, err := model.LoadGLTF(bytes.NewReader(encodeDocument(doc)), nil); err == nil {
+		t.Errorf("expected an error for a target accessor out of range")
+	}
+}
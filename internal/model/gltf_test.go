@@ -0,0 +1,170 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/model"
+)
+
+// triangleBuffer three float positions followed by three ushort indices
+func triangleBuffer() []byte {
+	b := bytes.Buffer{}
+	binary.Write(&b, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&b, binary.LittleEndian, []uint16{0, 1, 2})
+	return b.Bytes()
+}
+
+func triangleDocument(uri string, node map[string]interface{}) map[string]interface{} {
+	buffer := map[string]interface{}{"byteLength": 42}
+	if uri != "" {
+		buffer["uri"] = uri
+	}
+	node["mesh"] = 0
+	return map[string]interface{}{
+		"asset":  map[string]interface{}{"version": "2.0"},
+		"scene":  0,
+		"scenes": []interface{}{map[string]interface{}{"nodes": []int{0}}},
+		"nodes":  []interface{}{node},
+		"meshes": []interface{}{map[string]interface{}{
+			"primitives": []interface{}{map[string]interface{}{
+				"attributes": map[string]int{"POSITION": 0},
+				"indices":    1,
+			}},
+		}},
+		"accessors": []interface{}{
+			map[string]interface{}{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]interface{}{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		},
+		"bufferViews": []interface{}{
+			map[string]interface{}{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+			map[string]interface{}{"buffer": 0, "byteOffset": 36, "byteLength": 6},
+		},
+		"buffers": []interface{}{buffer},
+	}
+}
+
+func encodeDocument(doc map[string]interface{}) []byte {
+	b, _ := json.Marshal(doc)
+	return b
+}
+
+func TestLoadGLTF(t *testing.T) {
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(triangleBuffer())
+	doc := triangleDocument(uri, map[string]interface{}{"translation": []float64{5, 0, 0}})
+
+	p, err := model.LoadGLTF(bytes.NewReader(encodeDocument(doc)), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(p.Vertices) != 3 || p.TriangleCount() != 1 {
+		t.Fatalf("expected one triangle got %v vertices %v indices", len(p.Vertices), len(p.Indices))
+	}
+	if p.Vertices[1].Pos.X != 6 {
+		t.Errorf("node translation should apply %v", p.Vertices[1].Pos)
+	}
+	if n := p.Vertices[0].Normal; n.Z != 1 {
+		t.Errorf("missing normals should be computed %v", n)
+	}
+	if c := p.Vertices[0].Color; c.X != 1 || c.Y != 1 || c.Z != 1 {
+		t.Errorf("default color should be white %v", c)
+	}
+}
+
+func TestLoadGLTFMirrored(t *testing.T) {
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(triangleBuffer())
+	doc := triangleDocument(uri, map[string]interface{}{"scale": []float64{-1, 1, 1}})
+
+	p, err := model.LoadGLTF(bytes.NewReader(encodeDocument(doc)), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.Vertices[1].Pos.X != -1 {
+		t.Errorf("scale should apply %v", p.Vertices[1].Pos)
+	}
+	// Still facing +Z once the winding is flipped
+	if n := p.Vertices[0].Normal; math.Abs(n.Z-1) > 1e-9 {
+		t.Errorf("mirrored triangle should keep facing +Z %v", n)
+	}
+}
+
+func TestLoadGLB(t *testing.T) {
+	js := encodeDocument(triangleDocument("", map[string]interface{}{}))
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := triangleBuffer()
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	glb := bytes.Buffer{}
+	binary.Write(&glb, binary.LittleEndian, []uint32{0x46546C67, 2, uint32(12 + 8 + len(js) + 8 + len(bin))})
+	binary.Write(&glb, binary.LittleEndian, []uint32{uint32(len(js)), 0x4E4F534A})
+	glb.Write(js)
+	binary.Write(&glb, binary.LittleEndian, []uint32{uint32(len(bin)), 0x004E4942})
+	glb.Write(bin)
+
+	p, err := model.LoadGLTF(&glb, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if p.TriangleCount() != 1 || p.Vertices[2].Pos.Y != 1 {
+		t.Errorf("glb not read %v", p.Vertices)
+	}
+}
+
+func TestLoadGLTFExternalBuffer(t *testing.T) {
+	doc := triangleDocument("triangle.bin", map[string]interface{}{})
+	opened := ""
+	open := func(uri string) ([]byte, error) {
+		opened = uri
+		return triangleBuffer(), nil
+	}
+	if _, err := model.LoadGLTF(bytes.NewReader(encodeDocument(doc)), open); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if opened != "triangle.bin" {
+		t.Errorf("expected the buffer to be opened got %q", opened)
+	}
+
+	failing := func(uri string) ([]byte, error) { return nil, errors.New("nope") }
+	if _, err := model.LoadGLTF(bytes.NewReader(encodeDocument(doc)), failing); err == nil {
+		t.Errorf("expected the open error")
+	}
+}
+
+func TestLoadGLTFErrors(t *testing.T) {
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(triangleBuffer())
+
+	short := triangleDocument(uri, map[string]interface{}{})
+	short["accessors"].([]interface{})[0].(map[string]interface{})["count"] = 30
+
+	wrongType := triangleDocument(uri, map[string]interface{}{})
+	wrongType["accessors"].([]interface{})[0].(map[string]interface{})["type"] = "VEC2"
+
+	badIndex := triangleDocument(uri, map[string]interface{}{})
+	badIndex["meshes"].([]interface{})[0].(map[string]interface{})["primitives"].([]interface{})[0].(map[string]interface{})["indices"] = 7
+
+	tests := map[string][]byte{
+		"not json":       []byte("{"),
+		"short accessor": encodeDocument(short),
+		"wrong type":     encodeDocument(wrongType),
+		"bad index":      encodeDocument(badIndex),
+		"no buffer":      encodeDocument(triangleDocument("", map[string]interface{}{})),
+	}
+	for name, doc := range tests {
+		if _, err := model.LoadGLTF(bytes.NewReader(doc), nil); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+	if _, err := model.LoadGLTF(strings.NewReader(`{"buffers": [{"uri": "data:text/plain,hi"}]}`), nil); err == nil {
+		t.Errorf("expected an error for a non base64 data uri")
+	}
+}
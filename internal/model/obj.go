@@ -0,0 +1,195 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// objCorner the position, texture coordinate and normal indices of a
+// face corner (-1 when missing)
+type objCorner struct {
+	v  int
+	vt int
+	vn int
+}
+
+// LoadOBJ read the geometry of a Wavefront OBJ file. Faces with more
+// than three corners are split into triangle fans, and corners without
+// a normal get one averaged from the faces around them. Vertex colors
+// ("v x y z r g b") are read when present, otherwise vertices are white.
+// Tangents are worked out when there are texture coordinates. Materials
+// (mtllib, usemtl) are not read here, see render.LoadMTL
+func LoadOBJ(r io.Reader) (geometry.Polyhedron, error) {
+	positions := []algebra.Vector{}
+	colors := []algebra.Vector{}
+	texCoords := []algebra.Vector{}
+	normals := []algebra.Vector{}
+
+	poly := geometry.Polyhedron{}
+	corners := map[objCorner]uint16{}
+	smooth := []bool{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			f, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return poly, fmt.Errorf("line %v: %v", line, err)
+			}
+			positions = append(positions, algebra.Vector{X: f[0], Y: f[1], Z: f[2]})
+			color := algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+			if len(f) >= 6 {
+				color = algebra.Vector{X: f[3], Y: f[4], Z: f[5], W: 1}
+			}
+			colors = append(colors, color)
+		case "vt":
+			f, err := parseFloats(fields[1:], 2)
+			if err != nil {
+				return poly, fmt.Errorf("line %v: %v", line, err)
+			}
+			texCoords = append(texCoords, algebra.Vector{X: f[0], Y: f[1]})
+		case "vn":
+			f, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return poly, fmt.Errorf("line %v: %v", line, err)
+			}
+			normals = append(normals, algebra.Vector{X: f[0], Y: f[1], Z: f[2]})
+		case "f":
+			if len(fields) < 4 {
+				return poly, fmt.Errorf("line %v: a face needs at least 3 corners", line)
+			}
+			face := make([]uint16, 0, len(fields)-1)
+			for i := 1; i < len(fields); i++ {
+				c, err := parseCorner(fields[i], len(positions), len(texCoords), len(normals))
+				if err != nil {
+					return poly, fmt.Errorf("line %v: %v", line, err)
+				}
+				index, ok := corners[c]
+				if !ok {
+					if len(poly.Vertices) > math.MaxUint16 {
+						return poly, fmt.Errorf("line %v: more than %v vertices", line, math.MaxUint16+1)
+					}
+					v := geometry.Vertex{Pos: positions[c.v], Color: colors[c.v]}
+					if c.vt >= 0 {
+						v.TexCoord = texCoords[c.vt]
+					}
+					if c.vn >= 0 {
+						v.Normal = normals[c.vn]
+					}
+					index = uint16(len(poly.Vertices))
+					corners[c] = index
+					poly.Vertices = append(poly.Vertices, v)
+					smooth = append(smooth, c.vn < 0)
+				}
+				face = append(face, index)
+			}
+			for i := 2; i < len(face); i++ {
+				poly.Indices = append(poly.Indices, face[0], face[i-1], face[i])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return poly, err
+	}
+
+	smoothNormals(&poly, smooth)
+	if len(texCoords) > 0 {
+		poly.ComputeTangents()
+	}
+	return poly, nil
+}
+
+// LoadOBJFile read an OBJ file from disk
+func LoadOBJFile(path string) (geometry.Polyhedron, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return geometry.Polyhedron{}, err
+	}
+	defer f.Close()
+	return LoadOBJ(f)
+}
+
+// parseCorner read "v", "v/vt", "v//vn" or "v/vt/vn". Indices start at
+// 1, negative ones count back from the latest
+func parseCorner(s string, nv, nvt, nvn int) (objCorner, error) {
+	parts := strings.Split(s, "/")
+	c := objCorner{v: -1, vt: -1, vn: -1}
+	counts := []int{nv, nvt, nvn}
+	out := []*int{&c.v, &c.vt, &c.vn}
+	for i := 0; i < len(parts) && i < 3; i++ {
+		if parts[i] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return c, fmt.Errorf("bad face corner %q", s)
+		}
+		if n < 0 {
+			n = counts[i] + n
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return c, fmt.Errorf("face corner %q out of range", s)
+		}
+		*out[i] = n
+	}
+	if c.v < 0 {
+		return c, fmt.Errorf("face corner %q has no position", s)
+	}
+	return c, nil
+}
+
+func parseFloats(fields []string, min int) ([]float64, error) {
+	if len(fields) < min {
+		return nil, fmt.Errorf("expected %v numbers got %v", min, len(fields))
+	}
+	out := make([]float64, len(fields))
+	for i := 0; i < len(fields); i++ {
+		f, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+// smoothNormals give the vertices marked in which the area weighted
+// average normal of the triangles that use them
+func smoothNormals(p *geometry.Polyhedron, which []bool) {
+	for t := 0; t < p.TriangleCount(); t++ {
+		a, b, c := p.Triangle(t)
+		var ab, ac, n algebra.Vector
+		b.SubV(a, &ab)
+		c.SubV(a, &ac)
+		ab.Cross(ac, &n)
+		for i := 0; i < 3; i++ {
+			index := p.Indices[t*3+i]
+			if which[index] {
+				p.Vertices[index].Normal.AddV(n, &p.Vertices[index].Normal)
+			}
+		}
+	}
+	for i := 0; i < len(p.Vertices); i++ {
+		if which[i] && p.Vertices[i].Normal.Length() > 0 {
+			n := p.Vertices[i].Normal
+			n.Normalized(&p.Vertices[i].Normal)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package model_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/model"
+)
+
+const quadOBJ = `# a unit square
+v 0 0 0
+v 1 0 0 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+f 1/1/1 2/2/1 3/3/1 4/4/1
+`
+
+func TestLoadOBJ(t *testing.T) {
+	p, err := model.LoadOBJ(strings.NewReader(quadOBJ))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(p.Vertices) != 4 || p.TriangleCount() != 2 {
+		t.Fatalf("expected 4 vertices and 2 triangles got %v %v", len(p.Vertices), p.TriangleCount())
+	}
+	expected := []uint16{0, 1, 2, 0, 2, 3}
+	for i := 0; i < len(expected); i++ {
+		if p.Indices[i] != expected[i] {
+			t.Errorf("indices %v should be %v", p.Indices, expected)
+			break
+		}
+	}
+	v := p.Vertices[2]
+	if v.Pos.X != 1 || v.Pos.Y != 1 || v.TexCoord.X != 1 || v.TexCoord.Y != 1 || v.Normal.Z != 1 {
+		t.Errorf("vertex 2 not read %+v", v)
+	}
+	if p.Vertices[1].Color.Y != 0 || p.Vertices[0].Color.Y != 1 {
+		t.Errorf("vertex colors %v %v", p.Vertices[1].Color, p.Vertices[0].Color)
+	}
+	if v.Tangent.X != 1 {
+		t.Errorf("tangent should follow U got %v", v.Tangent)
+	}
+}
+
+func TestLoadOBJSharedAndSmooth(t *testing.T) {
+	// Two triangles folded along a shared edge, no normals
+	src := `v 0 0 0
+v 1 0 0
+v 0 1 0
+v 0 0 1
+f 1 2 3
+f -4 -1 -3
+`
+	p, err := model.LoadOBJ(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(p.Vertices) != 4 {
+		t.Errorf("corners should be shared %v", len(p.Vertices))
+	}
+	// The first vertex is on both faces, so its normal is between them
+	n := p.Vertices[0].Normal
+	if math.Abs(n.Length()-1) > 1e-9 || n.Z <= 0 || n.Y <= 0 || n.X != 0 {
+		t.Errorf("smoothed normal %v", n)
+	}
+	// Only on the first face
+	if n := p.Vertices[2].Normal; n.Z != 1 {
+		t.Errorf("face normal %v should be +Z", n)
+	}
+}
+
+func TestLoadOBJErrors(t *testing.T) {
+	tests := map[string]string{
+		"short vertex":    "v 1 2\n",
+		"bad number":      "v 1 2 x\n",
+		"out of range":    "v 0 0 0\nf 1 2 3\n",
+		"two corners":     "v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"bad corner":      "v 0 0 0\nf a b c\n",
+		"missing texture": "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n",
+	}
+	for name, src := range tests {
+		if _, err := model.LoadOBJ(strings.NewReader(src)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"github.com/robrohan/mesh/internal/geometry"
 )
 
+// phoCubeColumns numbers per vertex in phoCube: position, color, uv,
+// normal and tangent
+const phoCubeColumns = 14
+
 // CreateTestPoly make a default cube for render testing
 func CreateTestPoly() (geometry.Polyhedron, error) {
 	modelData := phoCube
 	vertLen := len(modelData)
-	voffset := vertLen / int(geometry.VertexSize)
+	voffset := vertLen / phoCubeColumns
 
 	verts := make([]geometry.Vertex, voffset, voffset)
 	index := make([]uint16, voffset, voffset)
@@ -19,9 +23,9 @@ func CreateTestPoly() (geometry.Polyhedron, error) {
 		Indices:  index,
 	}
 
-	numRow := vertLen / int(geometry.VertexSize)
+	numRow := vertLen / phoCubeColumns
 	for i := 0; i < numRow; i++ {
-		row := i * int(geometry.VertexSize)
+		row := i * phoCubeColumns
 		poly.Vertices[i] = geometry.Vertex{
 			Pos: algebra.Vector{
 				X: modelData[row+0],
@@ -56,9 +60,30 @@ func CreateTestPoly() (geometry.Polyhedron, error) {
 		}
 		poly.Indices[i] = uint16(i)
 	}
+	outwardNormals(&poly)
 	return poly, nil
 }
 
+// outwardNormals give each triangle of a shape centered on the origin a
+// flat normal pointing away from the center
+func outwardNormals(p *geometry.Polyhedron) {
+	for t := 0; t < p.TriangleCount(); t++ {
+		a, b, c := p.Triangle(t)
+		var ab, ac, n, normal algebra.Vector
+		b.SubV(a, &ab)
+		c.SubV(a, &ac)
+		ab.Cross(ac, &n)
+		if n.Dot(a) < 0 {
+			n.Negate(&n)
+		}
+		n.Normalized(&normal)
+		normal.W = 0
+		for i := 0; i < 3; i++ {
+			p.Vertices[p.Indices[t*3+i]].Normal = normal
+		}
+	}
+}
+
 var phoCube = []float64{
 	//0     1     2     3      4      5    6  7  8  9  A  B  C  D
 	-1.0, -1.0, -1.0, 0.583, 0.771, 0.014, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -0,0 +1,147 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// body the system's working copy of an entity's physical state
+type body struct {
+	entity   *core.Entity
+	collider *ComponentCollider
+	rigid    *ComponentRigidBody
+
+	position        algebra.Vector
+	orientation     algebra.Quaternion
+	velocity        algebra.Vector
+	angularVelocity algebra.Vector
+
+	invMass    float64
+	invInertia algebra.Vector
+
+	min algebra.Vector
+	max algebra.Vector
+}
+
+func newBody(e *core.Entity, c *ComponentCollider, rb *ComponentRigidBody) *body {
+	b := &body{
+		entity:   e,
+		collider: c,
+		rigid:    rb,
+	}
+	if rb != nil && !rb.IsStatic() && !b.isPlane() {
+		b.invMass = 1 / rb.Mass
+		if c != nil {
+			inertia := c.Shape.Inertia(rb.Mass)
+			b.invInertia = algebra.Vector{
+				X: invert(inertia.X),
+				Y: invert(inertia.Y),
+				Z: invert(inertia.Z),
+			}
+		}
+	}
+	return b
+}
+
+func invert(f float64) float64 {
+	if f == 0 {
+		return 0
+	}
+	return 1 / f
+}
+
+func (b *body) isDynamic() bool {
+	return b.invMass > 0
+}
+
+func (b *body) isPlane() bool {
+	if b.collider == nil {
+		return false
+	}
+	_, ok := b.collider.Shape.(*PlaneShape)
+	return ok
+}
+
+// load read the entity's transform and rigid body into the working state
+func (b *body) load() {
+	b.position = b.entity.Transform.Position
+	b.position.W = 0
+	b.orientation = identity(b.entity.Transform.Rotation)
+	b.orientation.Normalized(&b.orientation)
+	if b.rigid != nil {
+		b.velocity = b.rigid.Velocity
+		b.angularVelocity = b.rigid.AngularVelocity
+	}
+}
+
+// store write the working state back to the entity
+func (b *body) store() {
+	if !b.isDynamic() {
+		return
+	}
+	b.entity.Transform.Position.X = b.position.X
+	b.entity.Transform.Position.Y = b.position.Y
+	b.entity.Transform.Position.Z = b.position.Z
+	b.entity.Transform.Rotation = b.orientation
+	b.rigid.Velocity = b.velocity
+	b.rigid.AngularVelocity = b.angularVelocity
+}
+
+// support the furthest world space point of the collider in direction d
+func (b *body) support(d algebra.Vector) algebra.Vector {
+	local := b.collider.Shape.Support(inverseRotate(b.orientation, d))
+	return add(b.position, rotate(b.orientation, local))
+}
+
+// toWorld take a local point into world space
+func (b *body) toWorld(p algebra.Vector) algebra.Vector {
+	return add(b.position, rotate(b.orientation, p))
+}
+
+// plane the world space normal and offset of a plane collider
+func (b *body) plane() (algebra.Vector, float64) {
+	p := b.collider.Shape.(*PlaneShape)
+	n := normalize(rotate(b.orientation, p.Normal))
+	return n, p.Offset + dot(n, b.position)
+}
+
+// updateBounds refresh the world space box around the collider
+func (b *body) updateBounds() {
+	if b.isPlane() {
+		inf := math.Inf(1)
+		b.min = algebra.Vector{X: -inf, Y: -inf, Z: -inf}
+		b.max = algebra.Vector{X: inf, Y: inf, Z: inf}
+		return
+	}
+	b.min = algebra.Vector{
+		X: b.support(algebra.Vector{X: -1}).X,
+		Y: b.support(algebra.Vector{Y: -1}).Y,
+		Z: b.support(algebra.Vector{Z: -1}).Z,
+	}
+	b.max = algebra.Vector{
+		X: b.support(algebra.Vector{X: 1}).X,
+		Y: b.support(algebra.Vector{Y: 1}).Y,
+		Z: b.support(algebra.Vector{Z: 1}).Z,
+	}
+}
+
+// invInertiaWorld apply the world space inverse inertia tensor to v
+func (b *body) invInertiaWorld(v algebra.Vector) algebra.Vector {
+	return rotate(b.orientation, mulV(b.invInertia, inverseRotate(b.orientation, v)))
+}
+
+// velocityAt the velocity of the point r away from the center of mass
+func (b *body) velocityAt(r algebra.Vector) algebra.Vector {
+	return add(b.velocity, cross(b.angularVelocity, r))
+}
+
+// applyImpulse push the body at r away from the center of mass
+func (b *body) applyImpulse(impulse algebra.Vector, r algebra.Vector) {
+	if !b.isDynamic() {
+		return
+	}
+	b.velocity = add(b.velocity, scale(impulse, b.invMass))
+	b.angularVelocity = add(b.angularVelocity, b.invInertiaWorld(cross(r, impulse)))
+}
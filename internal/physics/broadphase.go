@@ -0,0 +1,71 @@
+package physics
+
+import "sort"
+
+// pair two bodies whose bounds overlap
+type pair struct {
+	a *body
+	b *body
+}
+
+// broadphase sort and sweep along X to find the pairs worth testing
+// properly. Planes are unbounded so they are paired with everything.
+// The order of the pairs only depends on the order bodies were added
+// so the simulation stays deterministic
+func broadphase(bodies []*body) []pair {
+	sorted := make([]int, 0, len(bodies))
+	planes := []int{}
+	for i := 0; i < len(bodies); i++ {
+		if bodies[i].collider == nil {
+			continue
+		}
+		if bodies[i].isPlane() {
+			planes = append(planes, i)
+			continue
+		}
+		sorted = append(sorted, i)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bodies[sorted[i]].min.X < bodies[sorted[j]].min.X
+	})
+
+	out := []pair{}
+	for i := 0; i < len(sorted); i++ {
+		a := bodies[sorted[i]]
+		for j := i + 1; j < len(sorted); j++ {
+			b := bodies[sorted[j]]
+			if b.min.X > a.max.X {
+				break
+			}
+			if !interacts(a, b) || !overlaps(a, b) {
+				continue
+			}
+			if sorted[i] < sorted[j] {
+				out = append(out, pair{a: a, b: b})
+			} else {
+				out = append(out, pair{a: b, b: a})
+			}
+		}
+	}
+	for p := 0; p < len(planes); p++ {
+		plane := bodies[planes[p]]
+		for i := 0; i < len(bodies); i++ {
+			b := bodies[i]
+			if b.collider == nil || b.isPlane() || !interacts(b, plane) {
+				continue
+			}
+			out = append(out, pair{a: b, b: plane})
+		}
+	}
+	return out
+}
+
+// interacts whether a pair could ever respond to each other
+func interacts(a, b *body) bool {
+	return a.isDynamic() || b.isDynamic()
+}
+
+func overlaps(a, b *body) bool {
+	return a.min.Y <= b.max.Y && a.max.Y >= b.min.Y &&
+		a.min.Z <= b.max.Z && a.max.Z >= b.min.Z
+}
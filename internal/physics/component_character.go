@@ -0,0 +1,327 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// CollisionFlags what a character bumped into during a Move
+type CollisionFlags uint8
+
+// CollidedNone the move was not blocked
+const CollidedNone CollisionFlags = 0
+
+const (
+	// CollidedSides hit a wall (or a slope too steep to walk up)
+	CollidedSides CollisionFlags = 1 << iota
+	// CollidedAbove hit a ceiling
+	CollidedAbove
+	// CollidedBelow landed on walkable ground
+	CollidedBelow
+)
+
+const (
+	characterSlideIterations = 4
+	characterBisections      = 12
+)
+
+// ComponentCharacterController moves an entity as an upright capsule
+// that slides along walls and walks up steps and gentle slopes rather
+// than being pushed around by the rigid body simulation. The capsule is
+// centered on the entity's position
+type ComponentCharacterController struct {
+	*core.Component
+	Radius     float64
+	HalfHeight float64
+	// StepOffset tallest ledge the character can walk straight up
+	StepOffset float64
+	// MaxSlope steepest walkable slope (degrees)
+	MaxSlope float64
+	// SkinWidth gap kept between the capsule and whatever it touches
+	SkinWidth float64
+	// Layer which collision layer the character moves on
+	Layer uint8
+
+	// Grounded whether the character is standing on walkable ground
+	Grounded bool
+	// GroundNormal the normal of the ground it is standing on
+	GroundNormal algebra.Vector
+
+	shape CapsuleShape
+	// inside the triggers the capsule overlapped after the last Move
+	inside []*body
+}
+
+// NewComponentCharacterController create a controller for a capsule
+func NewComponentCharacterController(radius, halfHeight float64) ComponentCharacterController {
+	return ComponentCharacterController{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Radius:     radius,
+		HalfHeight: halfHeight,
+		StepOffset: 0.3,
+		MaxSlope:   45,
+		SkinWidth:  0.01,
+	}
+}
+
+// walkable whether a surface with normal n can be stood on
+func (cc *ComponentCharacterController) walkable(n algebra.Vector) bool {
+	return n.Y >= math.Cos(algebra.DegToRad(cc.MaxSlope))-algebra.Precision
+}
+
+// Move the character by motion through the system's colliders,
+// updating the entity's Transform and the Grounded state. A capsule
+// with no Radius (or a negative HalfHeight) can't be swept, so it
+// doesn't move at all
+func (s *System) Move(cc *ComponentCharacterController, motion algebra.Vector) CollisionFlags {
+	if !(cc.Radius > 0) || cc.HalfHeight < 0 {
+		return CollidedNone
+	}
+	e := cc.GetParent()
+	cc.shape = CapsuleShape{Radius: cc.Radius, HalfHeight: cc.HalfHeight}
+	s.refresh(e)
+
+	flags := CollidedNone
+	pos := e.Transform.Position
+	pos.W = 0
+
+	horizontal := algebra.Vector{X: motion.X, Z: motion.Z}
+	stepUp := 0.0
+	if cc.Grounded && !horizontal.IsZero() {
+		stepUp = cc.StepOffset
+	}
+
+	// Up: jumping, plus lifting over any ledge in the way
+	rise := math.Max(motion.Y, 0) + stepUp
+	if rise > 0 {
+		var normal algebra.Vector
+		var hit bool
+		before := pos.Y
+		pos, normal, hit = s.sweep(cc, pos, algebra.Vector{Y: rise})
+		if hit && normal.Y < 0 {
+			flags |= CollidedAbove
+		}
+		stepUp = math.Min(stepUp, pos.Y-before)
+	}
+
+	// Across: slide along anything in the way
+	remaining := horizontal
+	for i := 0; i < characterSlideIterations && lengthSq(remaining) > 1e-12; i++ {
+		next, normal, hit := s.sweep(cc, pos, remaining)
+		travelled := sub(next, pos)
+		pos = next
+		if !hit {
+			break
+		}
+		remaining = sub(remaining, travelled)
+		if !cc.walkable(normal) {
+			flags |= CollidedSides
+			// Walls and steep slopes only block, they don't lift
+			normal = normalize(algebra.Vector{X: normal.X, Z: normal.Z})
+		}
+		remaining = sub(remaining, scale(normal, dot(remaining, normal)))
+	}
+
+	// Down: falling, plus putting back what the step lifted
+	drop := math.Max(-motion.Y, 0) + stepUp
+	if drop > 0 {
+		var normal algebra.Vector
+		var hit bool
+		pos, normal, hit = s.sweep(cc, pos, algebra.Vector{Y: -drop})
+		if hit && cc.walkable(normal) {
+			flags |= CollidedBelow
+		}
+	}
+
+	pos = s.depenetrate(cc, pos)
+
+	cc.Grounded, cc.GroundNormal = s.probeGround(cc, pos)
+	if cc.Grounded {
+		flags |= CollidedBelow
+	}
+
+	e.Transform.Position.X = pos.X
+	e.Transform.Position.Y = pos.Y
+	e.Transform.Position.Z = pos.Z
+	s.triggers(cc, pos)
+	return flags
+}
+
+// refresh bring every body up to date with its entity
+func (s *System) refresh(ignore *core.Entity) {
+	for i := 0; i < len(s.bodies); i++ {
+		b := s.bodies[i]
+		if b.collider == nil || b.entity == ignore {
+			continue
+		}
+		b.load()
+		b.updateBounds()
+	}
+}
+
+// deepest the deepest contact between the character at pos and the
+// world, only counting surfaces the character is moving into (dir)
+func (s *System) deepest(cc *ComponentCharacterController, pos algebra.Vector, dir algebra.Vector) (contact, bool) {
+	probe := &body{
+		collider:    &ComponentCollider{Shape: &cc.shape},
+		position:    pos,
+		orientation: algebra.Quaternion{W: 1},
+	}
+	probe.updateBounds()
+	ignore := cc.GetParent()
+
+	best := contact{}
+	found := false
+	for i := 0; i < len(s.bodies); i++ {
+		b := s.bodies[i]
+		if b.collider == nil || b.collider.IsTrigger || b.entity == ignore {
+			continue
+		}
+		if !s.LayersCollide(cc.Layer, b.collider.Layer) {
+			continue
+		}
+		if !near(probe, b) {
+			continue
+		}
+		contacts := collide(probe, b)
+		for c := 0; c < len(contacts); c++ {
+			// Flip so the normal points out of the surface at the character
+			contacts[c].normal = scale(contacts[c].normal, -1)
+			if !dir.IsZero() && dot(contacts[c].normal, dir) >= 0 {
+				continue
+			}
+			if !found || contacts[c].depth > best.depth {
+				best = contacts[c]
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// near whether b could be touching the probe, going by their bounds
+func near(probe *body, b *body) bool {
+	return b.isPlane() || (overlaps(probe, b) &&
+		probe.min.X <= b.max.X && probe.max.X >= b.min.X)
+}
+
+// triggers tell the character and any triggers it has moved into, stayed
+// in or left. The simulation only pairs triggers with moving bodies, so
+// the controller keeps track of its own
+func (s *System) triggers(cc *ComponentCharacterController, pos algebra.Vector) {
+	self := &body{
+		entity:      cc.GetParent(),
+		collider:    &ComponentCollider{Shape: &cc.shape},
+		position:    pos,
+		orientation: algebra.Quaternion{W: 1},
+	}
+	self.updateBounds()
+
+	var inside []*body
+	for i := 0; i < len(s.bodies); i++ {
+		b := s.bodies[i]
+		if b.collider == nil || !b.collider.IsTrigger || b.entity == self.entity {
+			continue
+		}
+		if !s.LayersCollide(cc.Layer, b.collider.Layer) || !near(self, b) {
+			continue
+		}
+		if len(collide(self, b)) == 0 {
+			continue
+		}
+		inside = append(inside, b)
+		t := touch{a: self, b: b, trigger: true}
+		if contains(cc.inside, b) {
+			t.stay()
+		} else {
+			t.enter()
+		}
+	}
+	for i := 0; i < len(cc.inside); i++ {
+		b := cc.inside[i]
+		// Removed triggers go quietly, as they do for rigid bodies
+		if contains(inside, b) || !contains(s.bodies, b) {
+			continue
+		}
+		t := touch{a: self, b: b, trigger: true}
+		t.exit()
+	}
+	cc.inside = inside
+}
+
+// contains whether b is in bodies
+func contains(bodies []*body, b *body) bool {
+	for i := 0; i < len(bodies); i++ {
+		if bodies[i] == b {
+			return true
+		}
+	}
+	return false
+}
+
+// sweep move the capsule from pos along motion until it hits
+// something, stopping SkinWidth short. Returns where it got to and the
+// normal of what it hit
+func (s *System) sweep(cc *ComponentCharacterController, pos algebra.Vector, motion algebra.Vector) (algebra.Vector, algebra.Vector, bool) {
+	length := motion.Length()
+	if length == 0 {
+		return pos, algebra.Vector{}, false
+	}
+
+	// Small enough steps that nothing thinner than the capsule is skipped
+	steps := int(math.Ceil(length / (cc.Radius * 0.5)))
+	lo := 0.0
+	hi := -1.0
+	var hitContact contact
+	for i := 1; i <= steps; i++ {
+		f := float64(i) / float64(steps)
+		if c, ok := s.deepest(cc, add(pos, scale(motion, f)), motion); ok {
+			hi = f
+			hitContact = c
+			break
+		}
+		lo = f
+	}
+	if hi < 0 {
+		return add(pos, motion), algebra.Vector{}, false
+	}
+
+	for i := 0; i < characterBisections; i++ {
+		mid := (lo + hi) * 0.5
+		if c, ok := s.deepest(cc, add(pos, scale(motion, mid)), motion); ok {
+			hi = mid
+			hitContact = c
+		} else {
+			lo = mid
+		}
+	}
+
+	back := math.Max(lo-cc.SkinWidth/length, 0)
+	return add(pos, scale(motion, back)), hitContact.normal, true
+}
+
+// depenetrate push the capsule out of anything it ended up inside
+func (s *System) depenetrate(cc *ComponentCharacterController, pos algebra.Vector) algebra.Vector {
+	for i := 0; i < characterSlideIterations; i++ {
+		c, ok := s.deepest(cc, pos, algebra.Vector{})
+		if !ok || c.depth <= algebra.Precision {
+			break
+		}
+		pos = add(pos, scale(c.normal, c.depth+cc.SkinWidth))
+	}
+	return pos
+}
+
+// probeGround look just below the capsule for walkable ground
+func (s *System) probeGround(cc *ComponentCharacterController, pos algebra.Vector) (bool, algebra.Vector) {
+	down := algebra.Vector{Y: -cc.SkinWidth * 2}
+	c, ok := s.deepest(cc, add(pos, down), down)
+	if !ok || !cc.walkable(c.normal) {
+		return false, algebra.Vector{}
+	}
+	return true, c.normal
+}
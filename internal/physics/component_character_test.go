@@ -0,0 +1,188 @@
+package physics_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/physics"
+)
+
+func mockCharacter(s *physics.System, pos algebra.Vector) (*core.Entity, *physics.ComponentCharacterController) {
+	e := core.Entity{
+		Name:      "player",
+		Transform: core.NewTransform(),
+	}
+	e.Transform.Position = pos
+	cc := physics.NewComponentCharacterController(0.5, 0.5)
+	e.Attach(&cc)
+	return &e, &cc
+}
+
+func mockStatic(s *physics.System, shape physics.Shape, pos algebra.Vector, rot algebra.Quaternion) *core.Entity {
+	e := mockBody("static", shape, 0, pos)
+	e.Transform.Rotation = rot
+	s.Add(e)
+	return e
+}
+
+// walk move the character along motion in small steps with gravity
+func walk(s *physics.System, cc *physics.ComponentCharacterController, motion algebra.Vector, steps int) physics.CollisionFlags {
+	flags := physics.CollidedNone
+	for i := 0; i < steps; i++ {
+		step := motion.Scale(1 / float64(steps))
+		step.Y -= 0.05
+		flags |= s.Move(cc, step)
+	}
+	return flags
+}
+
+func TestCharacterFallsAndGrounds(t *testing.T) {
+	s := physics.NewSystem()
+	s.Add(mockGround())
+	e, cc := mockCharacter(s, algebra.Vector{Y: 3})
+
+	walk(s, cc, algebra.Vector{}, 100)
+
+	if !cc.Grounded {
+		t.Errorf("character should be grounded")
+	}
+	if math.Abs(e.Transform.Position.Y-1) > 0.05 {
+		t.Errorf("character should stand at 1 got %v", e.Transform.Position.Y)
+	}
+}
+
+func TestCharacterWallSlide(t *testing.T) {
+	s := physics.NewSystem()
+	s.Add(mockGround())
+	// Wall across the X axis at x = 3
+	mockStatic(s, &physics.BoxShape{HalfExtents: algebra.Vector{X: .5, Y: 3, Z: 10}},
+		algebra.Vector{X: 3.5, Y: 3}, algebra.Quaternion{W: 1})
+	e, cc := mockCharacter(s, algebra.Vector{Y: 1})
+
+	flags := walk(s, cc, algebra.Vector{X: 5, Z: 5}, 50)
+
+	if flags&physics.CollidedSides == 0 {
+		t.Errorf("character should have hit the wall")
+	}
+	if e.Transform.Position.X > 2.5+0.05 {
+		t.Errorf("character went through the wall %v", e.Transform.Position)
+	}
+	if e.Transform.Position.Z < 4.5 {
+		t.Errorf("character should slide along the wall %v", e.Transform.Position)
+	}
+}
+
+func TestCharacterSteps(t *testing.T) {
+	s := physics.NewSystem()
+	s.Add(mockGround())
+	// A low step at x >= 2 and a high ledge at z >= 2
+	mockStatic(s, &physics.BoxShape{HalfExtents: algebra.Vector{X: 2, Y: .1, Z: 1}},
+		algebra.Vector{X: 4, Y: .1}, algebra.Quaternion{W: 1})
+	mockStatic(s, &physics.BoxShape{HalfExtents: algebra.Vector{X: 1, Y: .5, Z: 2}},
+		algebra.Vector{Y: .5, Z: 4}, algebra.Quaternion{W: 1})
+
+	e, cc := mockCharacter(s, algebra.Vector{Y: 1})
+	walk(s, cc, algebra.Vector{}, 5)
+	walk(s, cc, algebra.Vector{X: 4}, 40)
+	if e.Transform.Position.X < 3.9 || math.Abs(e.Transform.Position.Y-1.2) > 0.05 {
+		t.Errorf("character should have stepped up onto the low step %v", e.Transform.Position)
+	}
+
+	e, cc = mockCharacter(s, algebra.Vector{Y: 1})
+	walk(s, cc, algebra.Vector{}, 5)
+	walk(s, cc, algebra.Vector{Z: 4}, 40)
+	if e.Transform.Position.Z > 1.55 {
+		t.Errorf("character should be blocked by the high ledge %v", e.Transform.Position)
+	}
+}
+
+func TestCharacterSlopes(t *testing.T) {
+	ramp := func(degrees float64) (*core.Entity, *physics.ComponentCharacterController) {
+		s := physics.NewSystem()
+		s.Add(mockGround())
+		rot := algebra.Quaternion{}
+		rot.SetFromVector(&algebra.AxisZ, algebra.DegToRad(degrees))
+		// A long thin box tilted up towards +X, starting at the origin
+		half := algebra.Vector{X: 10, Y: .5, Z: 5}
+		center := algebra.Vector{
+			X: 2 + 10*math.Cos(algebra.DegToRad(degrees)),
+			Y: 10*math.Sin(algebra.DegToRad(degrees)) - .5*math.Cos(algebra.DegToRad(degrees)),
+		}
+		mockStatic(s, &physics.BoxShape{HalfExtents: half}, center, rot)
+
+		e, cc := mockCharacter(s, algebra.Vector{Y: 1})
+		walk(s, cc, algebra.Vector{}, 5)
+		walk(s, cc, algebra.Vector{X: 6}, 60)
+		return e, cc
+	}
+
+	e, cc := ramp(30)
+	if e.Transform.Position.Y < 2 || !cc.Grounded {
+		t.Errorf("character should walk up a gentle slope %v", e.Transform.Position)
+	}
+
+	e, _ = ramp(60)
+	if e.Transform.Position.Y > 1.5 {
+		t.Errorf("character should not walk up a steep slope %v", e.Transform.Position)
+	}
+}
+
+func TestCharacterTriggers(t *testing.T) {
+	s := physics.NewSystem()
+	s.Add(mockGround())
+	zone := mockStatic(s, &physics.BoxShape{HalfExtents: algebra.Vector{X: 1, Y: 2, Z: 1}},
+		algebra.Vector{X: 4, Y: 2}, algebra.Quaternion{W: 1})
+	zone.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider).IsTrigger = true
+	zoneListener := newMockListener()
+	zone.Attach(zoneListener)
+	e, cc := mockCharacter(s, algebra.Vector{Y: 1})
+	playerListener := newMockListener()
+	e.Attach(playerListener)
+
+	walk(s, cc, algebra.Vector{X: 4}, 40)
+
+	// Triggers don't block
+	if math.Abs(e.Transform.Position.X-4) > 0.05 {
+		t.Errorf("character should have walked into the trigger %v", e.Transform.Position)
+	}
+	expected := []string{"trigger enter player", "trigger stay player"}
+	if len(zoneListener.events) != len(expected) ||
+		zoneListener.events[0] != expected[0] || zoneListener.events[1] != expected[1] {
+		t.Fatalf("zone events %v should be %v", zoneListener.events, expected)
+	}
+
+	walk(s, cc, algebra.Vector{X: 4}, 40)
+
+	expected = append(expected, "trigger exit player")
+	if len(zoneListener.events) != len(expected) || zoneListener.events[2] != expected[2] {
+		t.Errorf("zone events %v should be %v", zoneListener.events, expected)
+	}
+	expected = []string{"trigger enter static", "trigger stay static", "trigger exit static"}
+	if len(playerListener.events) != len(expected) {
+		t.Fatalf("player events %v should be %v", playerListener.events, expected)
+	}
+	for i := 0; i < len(expected); i++ {
+		if playerListener.events[i] != expected[i] {
+			t.Errorf("player events %v should be %v", playerListener.events, expected)
+		}
+	}
+}
+
+func TestCharacterNoRadius(t *testing.T) {
+	s := physics.NewSystem()
+	s.Add(mockGround())
+	mockStatic(s, &physics.BoxShape{HalfExtents: algebra.Vector{X: .5, Y: 3, Z: 10}},
+		algebra.Vector{X: 3.5, Y: 3}, algebra.Quaternion{W: 1})
+	e, cc := mockCharacter(s, algebra.Vector{Y: 1})
+	cc.Radius = 0
+
+	if flags := walk(s, cc, algebra.Vector{X: 10}, 10); flags != physics.CollidedNone {
+		t.Errorf("flags %v should be none", flags)
+	}
+	expected := algebra.Vector{Y: 1}
+	if p := e.Transform.Position; p.X != expected.X || p.Y != expected.Y || p.Z != expected.Z {
+		t.Errorf("character %v should not have moved through the wall", p)
+	}
+}
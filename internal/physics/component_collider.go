@@ -0,0 +1,34 @@
+package physics
+
+import (
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// ComponentCollider gives an entity a shape that other colliders
+// bump into. The shape is centered on the entity's position and turns
+// with its rotation (scale is ignored)
+type ComponentCollider struct {
+	*core.Component
+	Shape Shape
+	// Friction coulomb friction coefficient
+	Friction float64
+	// Restitution how bouncy the collider is (0 = no bounce, 1 = perfect)
+	Restitution float64
+	// IsTrigger triggers report overlaps but nothing bounces off them
+	IsTrigger bool
+	// Layer which collision layer (0 - 31) the collider is on. See
+	// System.SetLayerCollision
+	Layer uint8
+}
+
+// NewComponentCollider create a collider for a shape
+func NewComponentCollider(shape Shape) ComponentCollider {
+	return ComponentCollider{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Shape:       shape,
+		Friction:    0.5,
+		Restitution: 0,
+	}
+}
@@ -0,0 +1,60 @@
+package physics
+
+import (
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// ComponentRigidBody makes an entity with a collider move under forces.
+// Entities with a collider but no rigid body (or a Mass of 0) are static
+type ComponentRigidBody struct {
+	*core.Component
+	Mass            float64
+	Velocity        algebra.Vector
+	AngularVelocity algebra.Vector
+	// LinearDamping fraction of velocity lost per second
+	LinearDamping float64
+	// AngularDamping fraction of angular velocity lost per second
+	AngularDamping float64
+	// UseGravity whether the system's gravity applies to this body
+	UseGravity bool
+
+	force  algebra.Vector
+	torque algebra.Vector
+}
+
+// NewComponentRigidBody create a body with the given mass
+func NewComponentRigidBody(mass float64) ComponentRigidBody {
+	return ComponentRigidBody{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Mass:           mass,
+		LinearDamping:  0.01,
+		AngularDamping: 0.05,
+		UseGravity:     true,
+	}
+}
+
+// AddForce push the body through its center of mass until the next step
+func (rb *ComponentRigidBody) AddForce(f algebra.Vector) {
+	rb.force = add(rb.force, f)
+}
+
+// AddTorque spin the body until the next step
+func (rb *ComponentRigidBody) AddTorque(t algebra.Vector) {
+	rb.torque = add(rb.torque, t)
+}
+
+// AddImpulse change the body's velocity immediately
+func (rb *ComponentRigidBody) AddImpulse(i algebra.Vector) {
+	if rb.Mass <= 0 {
+		return
+	}
+	rb.Velocity = add(rb.Velocity, scale(i, 1/rb.Mass))
+}
+
+// IsStatic whether the body is immovable
+func (rb *ComponentRigidBody) IsStatic() bool {
+	return rb.Mass <= 0
+}
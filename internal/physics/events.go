@@ -0,0 +1,152 @@
+package physics
+
+import (
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// Collision details handed to collision listeners
+type Collision struct {
+	// Other the entity that was hit
+	Other *core.Entity
+	Point algebra.Vector
+	// Normal points away from Other, towards the entity being told
+	Normal algebra.Vector
+	Depth  float64
+}
+
+// CollisionEnterer a component told when its entity starts touching another
+type CollisionEnterer interface {
+	OnCollisionEnter(Collision)
+}
+
+// CollisionStayer a component told every step its entity keeps touching another
+type CollisionStayer interface {
+	OnCollisionStay(Collision)
+}
+
+// CollisionExiter a component told when its entity stops touching another
+type CollisionExiter interface {
+	OnCollisionExit(other *core.Entity)
+}
+
+// TriggerEnterer a component told when something enters a trigger
+// (or its entity enters one)
+type TriggerEnterer interface {
+	OnTriggerEnter(other *core.Entity)
+}
+
+// TriggerStayer a component told every step something stays in a trigger
+type TriggerStayer interface {
+	OnTriggerStay(other *core.Entity)
+}
+
+// TriggerExiter a component told when something leaves a trigger
+type TriggerExiter interface {
+	OnTriggerExit(other *core.Entity)
+}
+
+// touch a pair of bodies touching during a step
+type touch struct {
+	a       *body
+	b       *body
+	trigger bool
+	contact contact
+}
+
+type touchKey struct {
+	a *body
+	b *body
+}
+
+// dispatch compare this step's touching pairs with the last step's
+// and tell the components about it. Everything is walked in slice
+// order so events fire in the same order every run
+func (s *System) dispatch(current []touch) {
+	seen := make(map[touchKey]bool, len(s.touching))
+	for i := 0; i < len(s.touching); i++ {
+		seen[touchKey{s.touching[i].a, s.touching[i].b}] = true
+	}
+	now := make(map[touchKey]bool, len(current))
+
+	for i := 0; i < len(current); i++ {
+		t := current[i]
+		key := touchKey{t.a, t.b}
+		now[key] = true
+		if seen[key] {
+			t.stay()
+		} else {
+			t.enter()
+		}
+	}
+	for i := 0; i < len(s.touching); i++ {
+		t := s.touching[i]
+		if !now[touchKey{t.a, t.b}] {
+			t.exit()
+		}
+	}
+	s.touching = current
+}
+
+func (t *touch) collisionFor(self *body) Collision {
+	c := Collision{
+		Point:  t.contact.point,
+		Normal: t.contact.normal,
+		Depth:  t.contact.depth,
+	}
+	if self == t.a {
+		c.Other = t.b.entity
+		c.Normal = scale(c.Normal, -1)
+	} else {
+		c.Other = t.a.entity
+	}
+	return c
+}
+
+func (t *touch) enter() {
+	for _, pair := range [2][2]*body{{t.a, t.b}, {t.b, t.a}} {
+		self, other := pair[0], pair[1]
+		components := self.entity.Components()
+		for i := 0; i < len(components); i++ {
+			if t.trigger {
+				if l, ok := components[i].(TriggerEnterer); ok {
+					l.OnTriggerEnter(other.entity)
+				}
+			} else if l, ok := components[i].(CollisionEnterer); ok {
+				l.OnCollisionEnter(t.collisionFor(self))
+			}
+		}
+	}
+}
+
+func (t *touch) stay() {
+	for _, pair := range [2][2]*body{{t.a, t.b}, {t.b, t.a}} {
+		self, other := pair[0], pair[1]
+		components := self.entity.Components()
+		for i := 0; i < len(components); i++ {
+			if t.trigger {
+				if l, ok := components[i].(TriggerStayer); ok {
+					l.OnTriggerStay(other.entity)
+				}
+			} else if l, ok := components[i].(CollisionStayer); ok {
+				l.OnCollisionStay(t.collisionFor(self))
+			}
+		}
+	}
+}
+
+func (t *touch) exit() {
+	for _, pair := range [2][2]*body{{t.a, t.b}, {t.b, t.a}} {
+		self, other := pair[0], pair[1]
+		components := self.entity.Components()
+		for i := 0; i < len(components); i++ {
+			if t.trigger {
+				if l, ok := components[i].(TriggerExiter); ok {
+					l.OnTriggerExit(other.entity)
+				}
+			} else if l, ok := components[i].(CollisionExiter); ok {
+				l.OnCollisionExit(other.entity)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package physics_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/physics"
+)
+
+type mockListener struct {
+	*core.Component
+	events  []string
+	normals []algebra.Vector
+}
+
+func newMockListener() *mockListener {
+	return &mockListener{Component: &core.Component{}}
+}
+
+func (l *mockListener) OnTriggerEnter(other *core.Entity) {
+	l.events = append(l.events, "trigger enter "+other.Name)
+}
+
+func (l *mockListener) OnTriggerStay(other *core.Entity) {
+	if n := len(l.events); n == 0 || l.events[n-1] != "trigger stay "+other.Name {
+		l.events = append(l.events, "trigger stay "+other.Name)
+	}
+}
+
+func (l *mockListener) OnTriggerExit(other *core.Entity) {
+	l.events = append(l.events, "trigger exit "+other.Name)
+}
+
+func (l *mockListener) OnCollisionEnter(c physics.Collision) {
+	l.events = append(l.events, "collision enter "+c.Other.Name)
+	l.normals = append(l.normals, c.Normal)
+}
+
+func (l *mockListener) OnCollisionExit(other *core.Entity) {
+	l.events = append(l.events, "collision exit "+other.Name)
+}
+
+func TestTriggerEvents(t *testing.T) {
+	s := physics.NewSystem()
+	zone := mockBody("zone", &physics.BoxShape{HalfExtents: algebra.Vector{X: 2, Y: 1, Z: 2}}, 0, algebra.Vector{Y: 5})
+	zoneCollider := zone.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider)
+	zoneCollider.IsTrigger = true
+	zoneListener := newMockListener()
+	zone.Attach(zoneListener)
+
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 10})
+	ballListener := newMockListener()
+	ball.Attach(ballListener)
+
+	s.Add(zone)
+	s.Add(ball)
+	run(s, 120)
+
+	// Triggers don't stop anything
+	if ball.Transform.Position.Y > 3 {
+		t.Errorf("ball should have fallen through the trigger %v", ball.Transform.Position)
+	}
+
+	expected := []string{"trigger enter ball", "trigger stay ball", "trigger exit ball"}
+	if len(zoneListener.events) != len(expected) {
+		t.Fatalf("zone events %v should be %v", zoneListener.events, expected)
+	}
+	for i := 0; i < len(expected); i++ {
+		if zoneListener.events[i] != expected[i] {
+			t.Errorf("zone events %v should be %v", zoneListener.events, expected)
+		}
+	}
+	if len(ballListener.events) != 3 || ballListener.events[0] != "trigger enter zone" {
+		t.Errorf("ball events %v", ballListener.events)
+	}
+}
+
+func TestCollisionEvents(t *testing.T) {
+	s := physics.NewSystem()
+	ground := mockGround()
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 2})
+	listener := newMockListener()
+	ball.Attach(listener)
+	s.Add(ground)
+	s.Add(ball)
+
+	run(s, 120)
+
+	if len(listener.events) != 1 || listener.events[0] != "collision enter ground" {
+		t.Fatalf("expected one enter event got %v", listener.events)
+	}
+	expected := algebra.Vector{Y: 1}
+	if !listener.normals[0].AlmostEquals(&expected) {
+		t.Errorf("normal %v should point away from the ground", listener.normals[0])
+	}
+
+	// Lift it off the ground
+	rb := ball.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	rb.Velocity.Y = 10
+	run(s, 10)
+	if listener.events[len(listener.events)-1] != "collision exit ground" {
+		t.Errorf("expected an exit event got %v", listener.events)
+	}
+}
+
+func TestLayerMask(t *testing.T) {
+	s := physics.NewSystem()
+	ground := mockGround()
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 2})
+	ballCollider := ball.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider)
+	ballCollider.Layer = 3
+	s.SetLayerCollision(0, 3, false)
+	s.Add(ground)
+	s.Add(ball)
+
+	if s.LayersCollide(3, 0) {
+		t.Errorf("layer mask should be symmetric")
+	}
+	if !s.LayersCollide(3, 3) {
+		t.Errorf("other layers should be untouched")
+	}
+
+	run(s, 60)
+	if ball.Transform.Position.Y > 0 {
+		t.Errorf("ball should have fallen through the ground %v", ball.Transform.Position)
+	}
+}
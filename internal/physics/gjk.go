@@ -0,0 +1,285 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+const (
+	gjkMaxIterations = 64
+	epaMaxIterations = 64
+	epaTolerance     = 1e-4
+)
+
+// supportFn the furthest world space point of a convex shape in a direction
+type supportFn func(d algebra.Vector) algebra.Vector
+
+// simplexPoint a point on the Minkowski difference A - B along with
+// the points on A and B it came from
+type simplexPoint struct {
+	p algebra.Vector
+	a algebra.Vector
+	b algebra.Vector
+}
+
+func minkowski(sa, sb supportFn, d algebra.Vector) simplexPoint {
+	a := sa(d)
+	b := sb(scale(d, -1))
+	return simplexPoint{p: sub(a, b), a: a, b: b}
+}
+
+// gjk test whether two convex shapes overlap. When they do the
+// returned simplex encloses the origin and can be handed to epa
+func gjk(sa, sb supportFn, initial algebra.Vector) ([]simplexPoint, bool) {
+	d := initial
+	if lengthSq(d) < 1e-12 {
+		d = algebra.AxisX
+	}
+
+	s := []simplexPoint{minkowski(sa, sb, d)}
+	d = scale(s[0].p, -1)
+
+	for i := 0; i < gjkMaxIterations; i++ {
+		if lengthSq(d) < 1e-12 {
+			// The origin is on the simplex, so the shapes are touching
+			return s, true
+		}
+		p := minkowski(sa, sb, d)
+		if dot(p.p, d) < 0 {
+			return nil, false
+		}
+		s = append([]simplexPoint{p}, s...)
+
+		var contains bool
+		s, d, contains = nextSimplex(s)
+		if contains {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// nextSimplex reduce the simplex to the feature nearest the origin
+// (newest point first) and pick the next search direction
+func nextSimplex(s []simplexPoint) ([]simplexPoint, algebra.Vector, bool) {
+	switch len(s) {
+	case 2:
+		return simplexLine(s)
+	case 3:
+		return simplexTriangle(s)
+	default:
+		return simplexTetrahedron(s)
+	}
+}
+
+func simplexLine(s []simplexPoint) ([]simplexPoint, algebra.Vector, bool) {
+	a, b := s[0], s[1]
+	ab := sub(b.p, a.p)
+	ao := scale(a.p, -1)
+
+	if dot(ab, ao) > 0 {
+		return []simplexPoint{a, b}, cross(cross(ab, ao), ab), false
+	}
+	return []simplexPoint{a}, ao, false
+}
+
+func simplexTriangle(s []simplexPoint) ([]simplexPoint, algebra.Vector, bool) {
+	a, b, c := s[0], s[1], s[2]
+	ab := sub(b.p, a.p)
+	ac := sub(c.p, a.p)
+	ao := scale(a.p, -1)
+	abc := cross(ab, ac)
+
+	if dot(cross(abc, ac), ao) > 0 {
+		if dot(ac, ao) > 0 {
+			return []simplexPoint{a, c}, cross(cross(ac, ao), ac), false
+		}
+		return simplexLine([]simplexPoint{a, b})
+	}
+	if dot(cross(ab, abc), ao) > 0 {
+		return simplexLine([]simplexPoint{a, b})
+	}
+	if dot(abc, ao) > 0 {
+		return []simplexPoint{a, b, c}, abc, false
+	}
+	return []simplexPoint{a, c, b}, scale(abc, -1), false
+}
+
+func simplexTetrahedron(s []simplexPoint) ([]simplexPoint, algebra.Vector, bool) {
+	a, b, c, d := s[0], s[1], s[2], s[3]
+	ab := sub(b.p, a.p)
+	ac := sub(c.p, a.p)
+	ad := sub(d.p, a.p)
+	ao := scale(a.p, -1)
+
+	if dot(cross(ab, ac), ao) > 0 {
+		return simplexTriangle([]simplexPoint{a, b, c})
+	}
+	if dot(cross(ac, ad), ao) > 0 {
+		return simplexTriangle([]simplexPoint{a, c, d})
+	}
+	if dot(cross(ad, ab), ao) > 0 {
+		return simplexTriangle([]simplexPoint{a, d, b})
+	}
+	return s, algebra.Vector{}, true
+}
+
+// penetration result of epa. Normal points from A to B
+type penetration struct {
+	normal algebra.Vector
+	depth  float64
+	pointA algebra.Vector
+	pointB algebra.Vector
+}
+
+type epaFace struct {
+	i, j, k int
+	normal  algebra.Vector
+	dist    float64
+}
+
+type epaEdge struct {
+	i, j int
+}
+
+// epa expand the gjk simplex out to the surface of the Minkowski
+// difference to find the smallest way to push the shapes apart
+func epa(sa, sb supportFn, s []simplexPoint) (penetration, bool) {
+	verts, ok := completeSimplex(sa, sb, s)
+	if !ok {
+		return penetration{}, false
+	}
+
+	faces := []epaFace{}
+	for _, f := range [][3]int{{0, 1, 2}, {0, 3, 1}, {0, 2, 3}, {1, 3, 2}} {
+		faces = append(faces, makeFace(verts, f[0], f[1], f[2]))
+	}
+
+	for iter := 0; iter < epaMaxIterations; iter++ {
+		closest := 0
+		for f := 1; f < len(faces); f++ {
+			if faces[f].dist < faces[closest].dist {
+				closest = f
+			}
+		}
+		face := faces[closest]
+
+		p := minkowski(sa, sb, face.normal)
+		if dot(p.p, face.normal)-face.dist < epaTolerance || iter == epaMaxIterations-1 {
+			return resolveFace(verts, face), true
+		}
+
+		// Knock out every face the new point can see and stitch the
+		// hole closed around it
+		edges := []epaEdge{}
+		kept := faces[:0]
+		for f := 0; f < len(faces); f++ {
+			face := faces[f]
+			if dot(face.normal, sub(p.p, verts[face.i].p)) > 0 {
+				edges = addEdge(edges, face.i, face.j)
+				edges = addEdge(edges, face.j, face.k)
+				edges = addEdge(edges, face.k, face.i)
+				continue
+			}
+			kept = append(kept, face)
+		}
+		faces = kept
+
+		verts = append(verts, p)
+		n := len(verts) - 1
+		for e := 0; e < len(edges); e++ {
+			faces = append(faces, makeFace(verts, edges[e].i, edges[e].j, n))
+		}
+	}
+	return penetration{}, false
+}
+
+// addEdge add an edge to the horizon, unless its twin is already there
+// in which case both faces were visible and the edge goes away
+func addEdge(edges []epaEdge, i, j int) []epaEdge {
+	for e := 0; e < len(edges); e++ {
+		if edges[e].i == j && edges[e].j == i {
+			return append(edges[:e], edges[e+1:]...)
+		}
+	}
+	return append(edges, epaEdge{i: i, j: j})
+}
+
+// makeFace build a face with an outward (away from the origin) normal
+func makeFace(verts []simplexPoint, i, j, k int) epaFace {
+	a, b, c := verts[i].p, verts[j].p, verts[k].p
+	n := cross(sub(b, a), sub(c, a))
+	if lengthSq(n) < 1e-18 {
+		return epaFace{i: i, j: j, k: k, dist: math.Inf(1)}
+	}
+	n = normalize(n)
+	dist := dot(n, a)
+	if dist < 0 {
+		return epaFace{i: i, j: k, k: j, normal: scale(n, -1), dist: -dist}
+	}
+	return epaFace{i: i, j: j, k: k, normal: n, dist: dist}
+}
+
+// completeSimplex grow a degenerate gjk simplex into a tetrahedron
+func completeSimplex(sa, sb supportFn, s []simplexPoint) ([]simplexPoint, bool) {
+	verts := append([]simplexPoint{}, s...)
+	if len(verts) == 4 {
+		return verts, true
+	}
+	directions := []algebra.Vector{
+		{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1},
+		{X: 1, Y: 1, Z: 1}, {X: -1, Y: -1, Z: -1},
+	}
+	for i := 0; i < len(directions) && len(verts) < 4; i++ {
+		p := minkowski(sa, sb, directions[i])
+		if addsDimension(verts, p.p) {
+			verts = append(verts, p)
+		}
+	}
+	return verts, len(verts) == 4
+}
+
+func addsDimension(verts []simplexPoint, p algebra.Vector) bool {
+	const eps = 1e-9
+	switch len(verts) {
+	case 1:
+		return lengthSq(sub(p, verts[0].p)) > eps
+	case 2:
+		return lengthSq(cross(sub(verts[1].p, verts[0].p), sub(p, verts[0].p))) > eps
+	default:
+		n := cross(sub(verts[1].p, verts[0].p), sub(verts[2].p, verts[0].p))
+		return math.Abs(dot(n, sub(p, verts[0].p))) > eps
+	}
+}
+
+// resolveFace turn the closest polytope face into contact information
+func resolveFace(verts []simplexPoint, face epaFace) penetration {
+	a, b, c := verts[face.i], verts[face.j], verts[face.k]
+	u, v, w := barycentric(scale(face.normal, face.dist), a.p, b.p, c.p)
+	return penetration{
+		normal: face.normal,
+		depth:  face.dist,
+		pointA: add(add(scale(a.a, u), scale(b.a, v)), scale(c.a, w)),
+		pointB: add(add(scale(a.b, u), scale(b.b, v)), scale(c.b, w)),
+	}
+}
+
+// barycentric weights of p in the triangle a, b, c
+func barycentric(p, a, b, c algebra.Vector) (float64, float64, float64) {
+	v0 := sub(b, a)
+	v1 := sub(c, a)
+	v2 := sub(p, a)
+	d00 := dot(v0, v0)
+	d01 := dot(v0, v1)
+	d11 := dot(v1, v1)
+	d20 := dot(v2, v0)
+	d21 := dot(v2, v1)
+	denom := d00*d11 - d01*d01
+	if denom == 0 {
+		return 1, 0, 0
+	}
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w
+}
@@ -0,0 +1,93 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Small value helpers. The algebra package works through out pointers
+// which gets noisy in the solver, so these return by value instead.
+
+func add(a, b algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func sub(a, b algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+func scale(a algebra.Vector, s float64) algebra.Vector {
+	return algebra.Vector{X: a.X * s, Y: a.Y * s, Z: a.Z * s}
+}
+
+func mulV(a, b algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: a.X * b.X, Y: a.Y * b.Y, Z: a.Z * b.Z}
+}
+
+func dot(a, b algebra.Vector) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func cross(a, b algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	a.Cross(b, &out)
+	return out
+}
+
+func normalize(a algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	a.Normalized(&out)
+	return out
+}
+
+func lengthSq(a algebra.Vector) float64 {
+	return dot(a, a)
+}
+
+// identity the rotation that does nothing. A zero quaternion (what
+// core.NewTransform starts with) is read as the identity
+func identity(q algebra.Quaternion) algebra.Quaternion {
+	if q.X == 0 && q.Y == 0 && q.Z == 0 && q.W == 0 {
+		return algebra.QuaternionIdentity
+	}
+	return q
+}
+
+// rotate v by q (q·v·q*)
+func rotate(q algebra.Quaternion, v algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	q.Rotate(v, &out)
+	return out
+}
+
+// inverseRotate rotate v by the inverse of the unit quaternion q
+func inverseRotate(q algebra.Quaternion, v algebra.Vector) algebra.Vector {
+	return rotate(algebra.Quaternion{X: -q.X, Y: -q.Y, Z: -q.Z, W: q.W}, v)
+}
+
+// integrateRotation advance q by the angular velocity w over dt
+func integrateRotation(q algebra.Quaternion, w algebra.Vector, dt float64) algebra.Quaternion {
+	spin := algebra.Quaternion{X: w.X, Y: w.Y, Z: w.Z}
+	dq := algebra.Quaternion{}
+	spin.MulQ(q, &dq)
+	out := algebra.Quaternion{
+		X: q.X + dq.X*0.5*dt,
+		Y: q.Y + dq.Y*0.5*dt,
+		Z: q.Z + dq.Z*0.5*dt,
+		W: q.W + dq.W*0.5*dt,
+	}
+	out.Normalized(&out)
+	return out
+}
+
+// tangents build two unit vectors perpendicular to n and each other
+func tangents(n algebra.Vector) (algebra.Vector, algebra.Vector) {
+	var t1 algebra.Vector
+	if math.Abs(n.X) >= 0.57735 {
+		t1 = normalize(algebra.Vector{X: n.Y, Y: -n.X})
+	} else {
+		t1 = normalize(algebra.Vector{Y: n.Z, Z: -n.Y})
+	}
+	return t1, cross(n, t1)
+}
@@ -0,0 +1,162 @@
+package physics
+
+import (
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// contact a point where two bodies touch. Normal points from a to b
+type contact struct {
+	a      *body
+	b      *body
+	point  algebra.Vector
+	normal algebra.Vector
+	depth  float64
+
+	// Solver state
+	ra             algebra.Vector
+	rb             algebra.Vector
+	t1             algebra.Vector
+	t2             algebra.Vector
+	normalMass     float64
+	tangentMass    [2]float64
+	bias           float64
+	friction       float64
+	normalImpulse  float64
+	tangentImpulse [2]float64
+}
+
+// collide find the contacts between two bodies
+func collide(a, b *body) []contact {
+	switch {
+	case a.isPlane() && b.isPlane():
+		return nil
+	case a.isPlane():
+		return collidePlane(b, a)
+	case b.isPlane():
+		return collidePlane(a, b)
+	}
+
+	if sa, ok := a.collider.Shape.(*SphereShape); ok {
+		if sb, ok := b.collider.Shape.(*SphereShape); ok {
+			return collideSpheres(a, sa, b, sb)
+		}
+	}
+	return collideConvex(a, b)
+}
+
+func collideSpheres(a *body, sa *SphereShape, b *body, sb *SphereShape) []contact {
+	d := sub(b.position, a.position)
+	dist2 := lengthSq(d)
+	r := sa.Radius + sb.Radius
+	if dist2 > r*r {
+		return nil
+	}
+
+	normal := algebra.AxisY
+	if dist2 > 1e-12 {
+		normal = normalize(d)
+	}
+	depth := r - d.Length()
+	pa := add(a.position, scale(normal, sa.Radius))
+	pb := sub(b.position, scale(normal, sb.Radius))
+	return []contact{{
+		a:      a,
+		b:      b,
+		point:  scale(add(pa, pb), 0.5),
+		normal: normal,
+		depth:  depth,
+	}}
+}
+
+// collidePlane test every feature point of a convex body against a
+// static plane, which gives a stable multi point manifold for boxes
+// and capsules resting on the ground
+func collidePlane(a *body, plane *body) []contact {
+	n, offset := plane.plane()
+	m := a.collider.Shape.margin()
+	features := a.collider.Shape.features()
+
+	out := []contact{}
+	for i := 0; i < len(features); i++ {
+		p := sub(a.toWorld(features[i]), scale(n, m))
+		depth := offset - dot(n, p)
+		if depth < 0 {
+			continue
+		}
+		out = append(out, contact{
+			a:      a,
+			b:      plane,
+			point:  add(p, scale(n, depth*0.5)),
+			normal: scale(n, -1),
+			depth:  depth,
+		})
+	}
+	return out
+}
+
+// collideConvex general convex vs convex using gjk / epa
+func collideConvex(a, b *body) []contact {
+	simplex, ok := gjk(a.support, b.support, sub(b.position, a.position))
+	if !ok {
+		return nil
+	}
+	pen, ok := epa(a.support, b.support, simplex)
+	if !ok {
+		return nil
+	}
+
+	// epa only finds one point. For sharp shapes (no margin) also use
+	// any corners buried in the other shape so flat faces rest stably
+	out := []contact{}
+	if a.collider.Shape.margin() == 0 && b.collider.Shape.margin() == 0 {
+		minB := dot(pen.normal, b.support(scale(pen.normal, -1)))
+		maxA := dot(pen.normal, a.support(pen.normal))
+
+		features := a.collider.Shape.features()
+		for i := 0; i < len(features); i++ {
+			p := a.toWorld(features[i])
+			if !containsPoint(b, p) {
+				continue
+			}
+			out = append(out, contact{a: a, b: b, point: p, normal: pen.normal,
+				depth: clampDepth(dot(pen.normal, p)-minB, pen.depth)})
+		}
+		features = b.collider.Shape.features()
+		for i := 0; i < len(features); i++ {
+			p := b.toWorld(features[i])
+			if !containsPoint(a, p) {
+				continue
+			}
+			out = append(out, contact{a: a, b: b, point: p, normal: pen.normal,
+				depth: clampDepth(maxA-dot(pen.normal, p), pen.depth)})
+		}
+	}
+
+	if len(out) == 0 {
+		out = append(out, contact{
+			a:      a,
+			b:      b,
+			point:  scale(add(pen.pointA, pen.pointB), 0.5),
+			normal: pen.normal,
+			depth:  pen.depth,
+		})
+	}
+	return out
+}
+
+func clampDepth(d, max float64) float64 {
+	if d < 0 {
+		return 0
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// containsPoint whether a world space point is inside the body's collider
+func containsPoint(b *body, p algebra.Vector) bool {
+	point := func(d algebra.Vector) algebra.Vector { return p }
+	_, ok := gjk(b.support, point, sub(p, b.position))
+	return ok
+}
@@ -0,0 +1,210 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// Shape a convex collision volume in the local space of an entity.
+// Every convex shape here is described as the hull of a few feature
+// points inflated by a margin (a sphere is one point with a radius, a
+// capsule is two points with a radius, a box is eight corners with no
+// radius). That keeps the support function and contact generation the
+// same for all of them.
+type Shape interface {
+	// Support the furthest local point in direction d
+	Support(d algebra.Vector) algebra.Vector
+	// Inertia the diagonal of the local inertia tensor for a mass
+	Inertia(mass float64) algebra.Vector
+	features() []algebra.Vector
+	margin() float64
+}
+
+// SphereShape a ball centered on the entity
+type SphereShape struct {
+	Radius float64
+}
+
+// Support the furthest point in direction d
+func (s *SphereShape) Support(d algebra.Vector) algebra.Vector {
+	return scale(normalize(d), s.Radius)
+}
+
+// Inertia solid sphere
+func (s *SphereShape) Inertia(mass float64) algebra.Vector {
+	i := 0.4 * mass * s.Radius * s.Radius
+	return algebra.Vector{X: i, Y: i, Z: i}
+}
+
+func (s *SphereShape) features() []algebra.Vector {
+	return []algebra.Vector{{}}
+}
+
+func (s *SphereShape) margin() float64 {
+	return s.Radius
+}
+
+// BoxShape a box centered on the entity
+type BoxShape struct {
+	HalfExtents algebra.Vector
+}
+
+// Support the furthest corner in direction d
+func (b *BoxShape) Support(d algebra.Vector) algebra.Vector {
+	return algebra.Vector{
+		X: math.Copysign(b.HalfExtents.X, d.X),
+		Y: math.Copysign(b.HalfExtents.Y, d.Y),
+		Z: math.Copysign(b.HalfExtents.Z, d.Z),
+	}
+}
+
+// Inertia solid cuboid
+func (b *BoxShape) Inertia(mass float64) algebra.Vector {
+	x2 := b.HalfExtents.X * b.HalfExtents.X
+	y2 := b.HalfExtents.Y * b.HalfExtents.Y
+	z2 := b.HalfExtents.Z * b.HalfExtents.Z
+	return algebra.Vector{
+		X: mass / 3 * (y2 + z2),
+		Y: mass / 3 * (x2 + z2),
+		Z: mass / 3 * (x2 + y2),
+	}
+}
+
+func (b *BoxShape) features() []algebra.Vector {
+	h := b.HalfExtents
+	out := make([]algebra.Vector, 0, 8)
+	for _, x := range []float64{-h.X, h.X} {
+		for _, y := range []float64{-h.Y, h.Y} {
+			for _, z := range []float64{-h.Z, h.Z} {
+				out = append(out, algebra.Vector{X: x, Y: y, Z: z})
+			}
+		}
+	}
+	return out
+}
+
+func (b *BoxShape) margin() float64 {
+	return 0
+}
+
+// CapsuleShape a cylinder with hemisphere caps running along the local
+// Y axis. HalfHeight is the distance from the center to a cap's center
+type CapsuleShape struct {
+	Radius     float64
+	HalfHeight float64
+}
+
+// Support the furthest point in direction d
+func (c *CapsuleShape) Support(d algebra.Vector) algebra.Vector {
+	p := algebra.Vector{Y: math.Copysign(c.HalfHeight, d.Y)}
+	return add(p, scale(normalize(d), c.Radius))
+}
+
+// Inertia approximated as a solid cylinder the full length of the capsule
+func (c *CapsuleShape) Inertia(mass float64) algebra.Vector {
+	l := 2 * (c.HalfHeight + c.Radius)
+	r2 := c.Radius * c.Radius
+	side := mass / 12 * (3*r2 + l*l)
+	return algebra.Vector{X: side, Y: 0.5 * mass * r2, Z: side}
+}
+
+func (c *CapsuleShape) features() []algebra.Vector {
+	return []algebra.Vector{{Y: -c.HalfHeight}, {Y: c.HalfHeight}}
+}
+
+func (c *CapsuleShape) margin() float64 {
+	return c.Radius
+}
+
+// HullShape the convex hull of a set of points
+type HullShape struct {
+	Points []algebra.Vector
+}
+
+// NewHullShape build a hull from the vertices of a polyhedron. The
+// polyhedron does not need to be convex, the hull wraps it
+func NewHullShape(p *geometry.Polyhedron) *HullShape {
+	verts := p.GetVertices()
+	points := make([]algebra.Vector, 0, len(verts))
+	for i := 0; i < len(verts); i++ {
+		v := verts[i].Pos
+		v.W = 0
+		duplicate := false
+		for j := 0; j < len(points); j++ {
+			if points[j].AlmostEquals(&v) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			points = append(points, v)
+		}
+	}
+	return &HullShape{Points: points}
+}
+
+// Support the furthest point in direction d
+func (h *HullShape) Support(d algebra.Vector) algebra.Vector {
+	best := algebra.Vector{}
+	bestDot := math.Inf(-1)
+	for i := 0; i < len(h.Points); i++ {
+		if dd := dot(h.Points[i], d); dd > bestDot {
+			bestDot = dd
+			best = h.Points[i]
+		}
+	}
+	return best
+}
+
+// Inertia approximated by the hull's bounding box
+func (h *HullShape) Inertia(mass float64) algebra.Vector {
+	if len(h.Points) == 0 {
+		return algebra.Vector{}
+	}
+	lo := h.Points[0]
+	hi := h.Points[0]
+	for i := 1; i < len(h.Points); i++ {
+		p := h.Points[i]
+		lo = algebra.Vector{X: math.Min(lo.X, p.X), Y: math.Min(lo.Y, p.Y), Z: math.Min(lo.Z, p.Z)}
+		hi = algebra.Vector{X: math.Max(hi.X, p.X), Y: math.Max(hi.Y, p.Y), Z: math.Max(hi.Z, p.Z)}
+	}
+	box := BoxShape{HalfExtents: scale(sub(hi, lo), 0.5)}
+	return box.Inertia(mass)
+}
+
+func (h *HullShape) features() []algebra.Vector {
+	return h.Points
+}
+
+func (h *HullShape) margin() float64 {
+	return 0
+}
+
+// PlaneShape an infinite static plane of points p where
+// dot(Normal, p) = Offset in local space. Planes can only be attached
+// to static entities
+type PlaneShape struct {
+	Normal algebra.Vector
+	Offset float64
+}
+
+// Support planes have no support point, this returns the point on the
+// plane nearest the origin
+func (p *PlaneShape) Support(d algebra.Vector) algebra.Vector {
+	return scale(p.Normal, p.Offset)
+}
+
+// Inertia planes never move
+func (p *PlaneShape) Inertia(mass float64) algebra.Vector {
+	return algebra.Vector{}
+}
+
+func (p *PlaneShape) features() []algebra.Vector {
+	return nil
+}
+
+func (p *PlaneShape) margin() float64 {
+	return 0
+}
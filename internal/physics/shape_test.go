@@ -0,0 +1,59 @@
+package physics_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/physics"
+)
+
+func TestHullShapeFromPolyhedron(t *testing.T) {
+	poly, _ := model.CreateTestPoly()
+	hull := physics.NewHullShape(&poly)
+
+	// 36 verts in the test cube but only 8 corners
+	if len(hull.Points) != 8 {
+		t.Errorf("expected 8 unique points got %v", len(hull.Points))
+	}
+
+	expected := algebra.Vector{X: 1, Y: 1, Z: 1}
+	actual := hull.Support(algebra.Vector{X: 1, Y: 2, Z: 3})
+	if actual != expected {
+		t.Errorf("Support: %v should be %v", actual, expected)
+	}
+}
+
+func TestShapeSupport(t *testing.T) {
+	box := physics.BoxShape{HalfExtents: algebra.Vector{X: 1, Y: 2, Z: 3}}
+	expected := algebra.Vector{X: -1, Y: 2, Z: -3}
+	if actual := box.Support(algebra.Vector{X: -1, Y: 1, Z: -1}); actual != expected {
+		t.Errorf("Box Support: %v should be %v", actual, expected)
+	}
+
+	sphere := physics.SphereShape{Radius: 2}
+	expected = algebra.Vector{Y: 2}
+	if actual := sphere.Support(algebra.Vector{Y: 5}); !actual.AlmostEquals(&expected) {
+		t.Errorf("Sphere Support: %v should be %v", actual, expected)
+	}
+
+	capsule := physics.CapsuleShape{Radius: 1, HalfHeight: 2}
+	expected = algebra.Vector{Y: -3}
+	if actual := capsule.Support(algebra.Vector{Y: -1}); !actual.AlmostEquals(&expected) {
+		t.Errorf("Capsule Support: %v should be %v", actual, expected)
+	}
+}
+
+func TestShapeInertia(t *testing.T) {
+	sphere := physics.SphereShape{Radius: 1}
+	expected := algebra.Vector{X: .4, Y: .4, Z: .4}
+	if actual := sphere.Inertia(1); !actual.AlmostEquals(&expected) {
+		t.Errorf("Sphere Inertia: %v should be %v", actual, expected)
+	}
+
+	box := physics.BoxShape{HalfExtents: algebra.Vector{X: 1, Y: 1, Z: 1}}
+	expected = algebra.Vector{X: 8, Y: 8, Z: 8}
+	if actual := box.Inertia(12); !actual.AlmostEquals(&expected) {
+		t.Errorf("Box Inertia: %v should be %v", actual, expected)
+	}
+}
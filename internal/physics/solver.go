@@ -0,0 +1,75 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+const (
+	// baumgarte how much of the penetration to fix each step
+	baumgarte = 0.2
+	// slop penetration allowed before pushing apart, stops jitter
+	slop = 0.005
+	// bounceThreshold closing speeds below this do not bounce
+	bounceThreshold = 0.5
+)
+
+// prepare work out the parts of the contact constraint that don't
+// change between iterations
+func (c *contact) prepare(dt float64) {
+	a, b := c.a, c.b
+	c.ra = sub(c.point, a.position)
+	c.rb = sub(c.point, b.position)
+
+	c.normalMass = invert(effectiveMass(a, b, c.ra, c.rb, c.normal))
+	c.t1, c.t2 = tangents(c.normal)
+	c.tangentMass[0] = invert(effectiveMass(a, b, c.ra, c.rb, c.t1))
+	c.tangentMass[1] = invert(effectiveMass(a, b, c.ra, c.rb, c.t2))
+
+	c.friction = math.Sqrt(a.collider.Friction * b.collider.Friction)
+	restitution := math.Max(a.collider.Restitution, b.collider.Restitution)
+
+	vn := dot(sub(b.velocityAt(c.rb), a.velocityAt(c.ra)), c.normal)
+	bounce := 0.0
+	if vn < -bounceThreshold {
+		bounce = -restitution * vn
+	}
+	c.bias = math.Max(bounce, baumgarte/dt*math.Max(c.depth-slop, 0))
+}
+
+func effectiveMass(a, b *body, ra, rb, n algebra.Vector) float64 {
+	k := a.invMass + b.invMass
+	k += dot(cross(a.invInertiaWorld(cross(ra, n)), ra), n)
+	k += dot(cross(b.invInertiaWorld(cross(rb, n)), rb), n)
+	return k
+}
+
+// solve one sequential impulse pass over the contact
+func (c *contact) solve() {
+	a, b := c.a, c.b
+
+	// Friction, limited by the normal impulse from the last pass
+	limit := c.friction * c.normalImpulse
+	for i, t := range [2]algebra.Vector{c.t1, c.t2} {
+		vt := dot(sub(b.velocityAt(c.rb), a.velocityAt(c.ra)), t)
+		lambda := -vt * c.tangentMass[i]
+		old := c.tangentImpulse[i]
+		c.tangentImpulse[i] = math.Max(-limit, math.Min(old+lambda, limit))
+		lambda = c.tangentImpulse[i] - old
+		c.apply(scale(t, lambda))
+	}
+
+	// Normal, never pulls the bodies together
+	vn := dot(sub(b.velocityAt(c.rb), a.velocityAt(c.ra)), c.normal)
+	lambda := (c.bias - vn) * c.normalMass
+	old := c.normalImpulse
+	c.normalImpulse = math.Max(old+lambda, 0)
+	lambda = c.normalImpulse - old
+	c.apply(scale(c.normal, lambda))
+}
+
+func (c *contact) apply(impulse algebra.Vector) {
+	c.a.applyImpulse(scale(impulse, -1), c.ra)
+	c.b.applyImpulse(impulse, c.rb)
+}
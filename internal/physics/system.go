@@ -0,0 +1,215 @@
+package physics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// System simulates every entity with a collider and / or rigid body.
+// It always advances in steps of FixedStep, so the same inputs give
+// the same results which keeps it testable without a window
+type System struct {
+	Gravity algebra.Vector
+	// FixedStep seconds simulated per step
+	FixedStep float64
+	// Iterations solver passes per step
+	Iterations int
+	// MaxSubSteps steps allowed per Update before dropping time
+	MaxSubSteps int
+
+	bodies      []*body
+	accumulator float64
+	// layerMask bit j of layerMask[i] is set when layers i and j collide
+	layerMask [32]uint32
+	touching  []touch
+}
+
+// NewSystem create a physics system with earth gravity stepping at 60hz
+func NewSystem() *System {
+	s := &System{
+		Gravity:     algebra.Vector{Y: -9.81},
+		FixedStep:   1.0 / 60.0,
+		Iterations:  10,
+		MaxSubSteps: 8,
+	}
+	for i := 0; i < len(s.layerMask); i++ {
+		s.layerMask[i] = math.MaxUint32
+	}
+	return s
+}
+
+// SetLayerCollision choose whether colliders on layers a and b interact
+// (collide or trigger). Every layer interacts with every other by default
+func (s *System) SetLayerCollision(a, b uint8, enabled bool) {
+	a, b = a%32, b%32
+	if enabled {
+		s.layerMask[a] |= 1 << b
+		s.layerMask[b] |= 1 << a
+		return
+	}
+	s.layerMask[a] &^= 1 << b
+	s.layerMask[b] &^= 1 << a
+}
+
+// LayersCollide whether colliders on layers a and b interact
+func (s *System) LayersCollide(a, b uint8) bool {
+	return s.layerMask[a%32]&(1<<(b%32)) != 0
+}
+
+// Add start simulating an entity. It needs a ComponentCollider, a
+// ComponentRigidBody or both
+func (s *System) Add(e *core.Entity) error {
+	if e.Transform == nil {
+		return errors.New("physics entity has no transform")
+	}
+	c, _ := e.GetComponent(core.ComponentTypeCollider).(*ComponentCollider)
+	rb, _ := e.GetComponent(core.ComponentTypeRigidBody).(*ComponentRigidBody)
+	if c == nil && rb == nil {
+		return errors.New("entity has no collider or rigid body")
+	}
+	if rb != nil && !rb.IsStatic() && c != nil {
+		if _, ok := c.Shape.(*PlaneShape); ok {
+			return errors.New("planes can only be static")
+		}
+	}
+	s.bodies = append(s.bodies, newBody(e, c, rb))
+	return nil
+}
+
+// AddScene add every entity in the scene that has physics components.
+// Entities that can't be added are skipped and the first one's error
+// returned once the rest are in
+func (s *System) AddScene(scene *core.Scene) error {
+	var first error
+	entities := scene.All()
+	for i := 0; i < len(entities); i++ {
+		if err := s.addTree(entities[i]); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *System) addTree(e *core.Entity) error {
+	var first error
+	if e.GetComponent(core.ComponentTypeCollider) != nil ||
+		e.GetComponent(core.ComponentTypeRigidBody) != nil {
+		if err := s.Add(e); err != nil {
+			first = fmt.Errorf("%v: %v", e.Name, err)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		if err := s.addTree(children[i]); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Remove stop simulating an entity
+func (s *System) Remove(e *core.Entity) {
+	for i := 0; i < len(s.bodies); i++ {
+		if s.bodies[i].entity == e {
+			s.bodies = append(s.bodies[:i], s.bodies[i+1:]...)
+			break
+		}
+	}
+	kept := s.touching[:0]
+	for i := 0; i < len(s.touching); i++ {
+		if s.touching[i].a.entity != e && s.touching[i].b.entity != e {
+			kept = append(kept, s.touching[i])
+		}
+	}
+	s.touching = kept
+}
+
+// Update advance the simulation by dt seconds of real time, running as
+// many fixed steps as fit
+func (s *System) Update(dt float64) {
+	s.accumulator += dt
+	steps := 0
+	for s.accumulator >= s.FixedStep && steps < s.MaxSubSteps {
+		s.Step()
+		s.accumulator -= s.FixedStep
+		steps++
+	}
+	// Can't keep up, drop the time rather than spiral
+	s.accumulator = math.Min(s.accumulator, s.FixedStep)
+}
+
+// Step advance the simulation by exactly one FixedStep
+func (s *System) Step() {
+	dt := s.FixedStep
+
+	for i := 0; i < len(s.bodies); i++ {
+		b := s.bodies[i]
+		b.load()
+		if b.isDynamic() {
+			s.integrateForces(b, dt)
+		}
+		if b.collider != nil {
+			b.updateBounds()
+		}
+	}
+
+	contacts := []contact{}
+	touches := []touch{}
+	pairs := broadphase(s.bodies)
+	for p := 0; p < len(pairs); p++ {
+		a, b := pairs[p].a, pairs[p].b
+		if !s.LayersCollide(a.collider.Layer, b.collider.Layer) {
+			continue
+		}
+		found := collide(a, b)
+		if len(found) == 0 {
+			continue
+		}
+		trigger := a.collider.IsTrigger || b.collider.IsTrigger
+		touches = append(touches, touch{a: a, b: b, trigger: trigger, contact: found[0]})
+		if !trigger {
+			contacts = append(contacts, found...)
+		}
+	}
+
+	for c := 0; c < len(contacts); c++ {
+		contacts[c].prepare(dt)
+	}
+	for it := 0; it < s.Iterations; it++ {
+		for c := 0; c < len(contacts); c++ {
+			contacts[c].solve()
+		}
+	}
+
+	for i := 0; i < len(s.bodies); i++ {
+		b := s.bodies[i]
+		if !b.isDynamic() {
+			continue
+		}
+		b.position = add(b.position, scale(b.velocity, dt))
+		b.orientation = integrateRotation(b.orientation, b.angularVelocity, dt)
+		b.store()
+	}
+
+	s.dispatch(touches)
+}
+
+func (s *System) integrateForces(b *body, dt float64) {
+	rb := b.rigid
+	accel := scale(rb.force, b.invMass)
+	if rb.UseGravity {
+		accel = add(accel, s.Gravity)
+	}
+	b.velocity = add(b.velocity, scale(accel, dt))
+	b.angularVelocity = add(b.angularVelocity, scale(b.invInertiaWorld(rb.torque), dt))
+
+	b.velocity = scale(b.velocity, 1/(1+rb.LinearDamping*dt))
+	b.angularVelocity = scale(b.angularVelocity, 1/(1+rb.AngularDamping*dt))
+
+	rb.force = algebra.Vector{}
+	rb.torque = algebra.Vector{}
+}
@@ -0,0 +1,229 @@
+package physics_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/physics"
+)
+
+func mockBody(name string, shape physics.Shape, mass float64, pos algebra.Vector) *core.Entity {
+	e := core.Entity{
+		Name:      name,
+		Transform: core.NewTransform(),
+	}
+	e.Transform.Position = pos
+	collider := physics.NewComponentCollider(shape)
+	e.Attach(&collider)
+	if mass > 0 {
+		rb := physics.NewComponentRigidBody(mass)
+		e.Attach(&rb)
+	}
+	return &e
+}
+
+func mockGround() *core.Entity {
+	return mockBody("ground", &physics.PlaneShape{Normal: algebra.Up}, 0, algebra.Vector{})
+}
+
+func run(s *physics.System, steps int) {
+	for i := 0; i < steps; i++ {
+		s.Step()
+	}
+}
+
+func TestSphereFallsAndRests(t *testing.T) {
+	s := physics.NewSystem()
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 5})
+	s.Add(mockGround())
+	s.Add(ball)
+
+	run(s, 300)
+
+	y := ball.Transform.Position.Y
+	if math.Abs(y-0.5) > 0.02 {
+		t.Errorf("ball should rest on the ground at 0.5 got %v", y)
+	}
+	rb := ball.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	if rb.Velocity.Length() > 0.05 {
+		t.Errorf("ball should be at rest got velocity %v", rb.Velocity)
+	}
+}
+
+func TestBoxRestsFlat(t *testing.T) {
+	s := physics.NewSystem()
+	box := mockBody("box", &physics.BoxShape{HalfExtents: algebra.Vector{X: 1, Y: 0.5, Z: 1}}, 2, algebra.Vector{Y: 3})
+	s.Add(mockGround())
+	s.Add(box)
+
+	run(s, 300)
+
+	if math.Abs(box.Transform.Position.Y-0.5) > 0.02 {
+		t.Errorf("box should rest at 0.5 got %v", box.Transform.Position.Y)
+	}
+	q := box.Transform.Rotation
+	if math.Abs(math.Abs(q.W)-1) > 1e-3 {
+		t.Errorf("box should not have tipped over %v", q)
+	}
+}
+
+func TestRestitution(t *testing.T) {
+	s := physics.NewSystem()
+	shape := &physics.SphereShape{Radius: 0.5}
+	ball := mockBody("ball", shape, 1, algebra.Vector{Y: 3})
+	collider := ball.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider)
+	collider.Restitution = 0.8
+	s.Add(mockGround())
+	s.Add(ball)
+
+	bounced := false
+	rb := ball.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	for i := 0; i < 120; i++ {
+		s.Step()
+		if rb.Velocity.Y > 2 {
+			bounced = true
+			break
+		}
+	}
+	if !bounced {
+		t.Errorf("a bouncy ball should bounce")
+	}
+}
+
+func TestSpheresCollide(t *testing.T) {
+	s := physics.NewSystem()
+	s.Gravity = algebra.Vector{}
+	a := mockBody("a", &physics.SphereShape{Radius: 1}, 1, algebra.Vector{X: -3})
+	b := mockBody("b", &physics.SphereShape{Radius: 1}, 1, algebra.Vector{X: 3})
+	rba := a.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	rbb := b.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	rba.Velocity.X = 5
+	rbb.Velocity.X = -5
+	s.Add(a)
+	s.Add(b)
+
+	run(s, 120)
+
+	if b.Transform.Position.X-a.Transform.Position.X < 2-0.02 {
+		t.Errorf("spheres passed through each other %v %v",
+			a.Transform.Position, b.Transform.Position)
+	}
+	if rba.Velocity.X > 0 || rbb.Velocity.X < 0 {
+		t.Errorf("spheres should not keep moving into each other %v %v",
+			rba.Velocity, rbb.Velocity)
+	}
+}
+
+func TestBoxStack(t *testing.T) {
+	s := physics.NewSystem()
+	half := algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5}
+	bottom := mockBody("bottom", &physics.BoxShape{HalfExtents: half}, 1, algebra.Vector{Y: 0.5})
+	top := mockBody("top", &physics.BoxShape{HalfExtents: half}, 1, algebra.Vector{Y: 1.6})
+	s.Add(mockGround())
+	s.Add(bottom)
+	s.Add(top)
+
+	run(s, 240)
+
+	if math.Abs(top.Transform.Position.Y-1.5) > 0.05 {
+		t.Errorf("top box should rest on the bottom one at 1.5 got %v", top.Transform.Position.Y)
+	}
+}
+
+func TestCapsuleAndHull(t *testing.T) {
+	s := physics.NewSystem()
+	capsule := mockBody("capsule", &physics.CapsuleShape{Radius: 0.5, HalfHeight: 1}, 1, algebra.Vector{Y: 4})
+	poly, _ := model.CreateTestPoly()
+	hull := mockBody("hull", physics.NewHullShape(&poly), 1, algebra.Vector{X: 5, Y: 4})
+	s.Add(mockGround())
+	s.Add(capsule)
+	s.Add(hull)
+
+	run(s, 300)
+
+	// Standing upright on one cap
+	if math.Abs(capsule.Transform.Position.Y-1.5) > 0.05 {
+		t.Errorf("capsule should rest at 1.5 got %v", capsule.Transform.Position.Y)
+	}
+	// The test cube is 2 units across
+	if math.Abs(hull.Transform.Position.Y-1) > 0.05 {
+		t.Errorf("hull should rest at 1 got %v", hull.Transform.Position.Y)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	simulate := func() []algebra.Vector {
+		s := physics.NewSystem()
+		s.Add(mockGround())
+		bodies := []*core.Entity{}
+		for i := 0; i < 5; i++ {
+			e := mockBody("box", &physics.BoxShape{HalfExtents: algebra.Vector{X: .5, Y: .5, Z: .5}}, 1,
+				algebra.Vector{X: float64(i) * 0.3, Y: 1 + float64(i)*1.2, Z: float64(i) * 0.1})
+			s.Add(e)
+			bodies = append(bodies, e)
+		}
+		run(s, 200)
+		out := []algebra.Vector{}
+		for i := 0; i < len(bodies); i++ {
+			out = append(out, bodies[i].Transform.Position)
+		}
+		return out
+	}
+
+	first := simulate()
+	second := simulate()
+	for i := 0; i < len(first); i++ {
+		if first[i] != second[i] {
+			t.Errorf("runs differ at body %v: %v %v", i, first[i], second[i])
+		}
+	}
+}
+
+func TestUpdateFixedStep(t *testing.T) {
+	s := physics.NewSystem()
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 100})
+	s.Add(ball)
+
+	// Half a step shouldn't move anything
+	s.Update(s.FixedStep / 2)
+	if ball.Transform.Position.Y != 100 {
+		t.Errorf("no step should have run yet")
+	}
+	s.Update(s.FixedStep / 2)
+	if ball.Transform.Position.Y == 100 {
+		t.Errorf("a step should have run")
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	s := physics.NewSystem()
+	e := core.Entity{Transform: core.NewTransform()}
+	if err := s.Add(&e); err == nil {
+		t.Errorf("expected an error for an entity without physics")
+	}
+
+	plane := mockBody("plane", &physics.PlaneShape{Normal: algebra.Up}, 1, algebra.Vector{})
+	if err := s.Add(plane); err == nil {
+		t.Errorf("expected an error for a dynamic plane")
+	}
+}
+
+func TestAddSceneErrors(t *testing.T) {
+	s := physics.NewSystem()
+	scene := core.Scene{}
+	scene.Add(mockBody("plane", &physics.PlaneShape{Normal: algebra.Up}, 1, algebra.Vector{}))
+	ball := mockBody("ball", &physics.SphereShape{Radius: 0.5}, 1, algebra.Vector{Y: 5})
+	scene.Add(ball)
+
+	if err := s.AddScene(&scene); err == nil {
+		t.Errorf("expected an error for the dynamic plane")
+	}
+	// Everything else still goes in
+	run(s, 10)
+	if ball.Transform.Position.Y == 5 {
+		t.Errorf("the ball should have been added")
+	}
+}
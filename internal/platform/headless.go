@@ -0,0 +1,127 @@
+package platform
+
+import (
+	"github.com/robrohan/mesh/internal/input"
+)
+
+// Headless a platform with no screen or devices. Events and input are
+// pushed in by the caller and the clock moves on a fixed Step every
+// Poll, so runs are repeatable. Use with a renderer that draws
+// offscreen
+type Headless struct {
+	// Step seconds the clock moves on each Poll
+	Step float64
+	// PixelRatio drawable pixels per window unit for opened windows
+	PixelRatio float64
+
+	events []Event
+	source *input.FakeSource
+	now    float64
+	window *HeadlessWindow
+}
+
+// NewHeadless create a headless platform running at 60hz
+func NewHeadless() *Headless {
+	return &Headless{
+		Step:       1.0 / 60.0,
+		PixelRatio: 1,
+		source:     input.NewFakeSource(),
+	}
+}
+
+// Open create an offscreen window
+func (h *Headless) Open(title string, width, height int32) (Window, error) {
+	h.window = &HeadlessWindow{
+		Title:      title,
+		width:      width,
+		height:     height,
+		pixelRatio: h.PixelRatio,
+	}
+	return h.window, nil
+}
+
+// Push queue events for the next Poll
+func (h *Headless) Push(events ...Event) {
+	h.events = append(h.events, events...)
+}
+
+// PushInput queue input frames, one is used per engine frame
+func (h *Headless) PushInput(frames ...input.Frame) {
+	h.source.Push(frames...)
+}
+
+// Resize change the size of the window, and its pixel ratio to
+// PixelRatio, queueing a ResizeEvent
+func (h *Headless) Resize(width, height int32) {
+	if h.window == nil {
+		return
+	}
+	h.window.width = width
+	h.window.height = height
+	h.window.pixelRatio = h.PixelRatio
+	dw, dh := h.window.DrawableSize()
+	h.Push(ResizeEvent{
+		Width:          width,
+		Height:         height,
+		DrawableWidth:  dw,
+		DrawableHeight: dh,
+	})
+}
+
+// Poll the queued events, moving the clock on a Step
+func (h *Headless) Poll() []Event {
+	h.now += h.Step
+	events := h.events
+	h.events = nil
+	return events
+}
+
+// Input the pushed input frames
+func (h *Headless) Input() input.Source {
+	return h.source
+}
+
+// Now seconds of Steps taken so far
+func (h *Headless) Now() float64 {
+	return h.now
+}
+
+// Close does nothing
+func (h *Headless) Close() {}
+
+// HeadlessWindow a window that is never shown
+type HeadlessWindow struct {
+	Title string
+	// Frames how many times Swap has been called
+	Frames int
+
+	width      int32
+	height     int32
+	pixelRatio float64
+	closed     bool
+}
+
+// Size of the window
+func (w *HeadlessWindow) Size() (int32, int32) {
+	return w.width, w.height
+}
+
+// DrawableSize of the window in pixels
+func (w *HeadlessWindow) DrawableSize() (int32, int32) {
+	return int32(float64(w.width) * w.pixelRatio), int32(float64(w.height) * w.pixelRatio)
+}
+
+// Swap count the frame
+func (w *HeadlessWindow) Swap() {
+	w.Frames++
+}
+
+// Close the window
+func (w *HeadlessWindow) Close() {
+	w.closed = true
+}
+
+// Closed whether Close has been called
+func (w *HeadlessWindow) Closed() bool {
+	return w.closed
+}
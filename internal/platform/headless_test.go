@@ -0,0 +1,38 @@
+package platform_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/platform"
+)
+
+func TestHeadless(t *testing.T) {
+	p := platform.NewHeadless()
+	p.PixelRatio = 2
+	w, err := p.Open("test", 100, 50)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	s := platform.WindowSettings(w)
+	if s.Width != 100 || s.Height != 50 || s.PixelRatio != 2 {
+		t.Errorf("WindowSettings %+v", s)
+	}
+
+	p.Push(platform.QuitEvent{})
+	p.Resize(200, 100)
+	events := p.Poll()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %v", events)
+	}
+	resize, ok := events[1].(platform.ResizeEvent)
+	if !ok || resize.DrawableWidth != 400 || resize.DrawableHeight != 200 {
+		t.Errorf("unexpected resize event %+v", events[1])
+	}
+	if len(p.Poll()) != 0 {
+		t.Errorf("events should only be returned once")
+	}
+	if p.Now() != 2*p.Step {
+		t.Errorf("the clock should move a step per poll %v", p.Now())
+	}
+}
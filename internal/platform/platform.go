@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+)
+
+// Platform everything the engine needs from the operating system: a
+// window to draw into, events, input and the time
+type Platform interface {
+	// Open create a window and make its graphics context current
+	Open(title string, width, height int32) (Window, error)
+	// Poll the events since the last call. Input isn't returned here,
+	// it is read through Input
+	Poll() []Event
+	// Input where the state of the keyboard, mouse and gamepads comes
+	// from each frame
+	Input() input.Source
+	// Now seconds since the platform started
+	Now() float64
+	// Close shut the platform down
+	Close()
+}
+
+// Window a surface the engine draws into
+type Window interface {
+	// Size of the window in screen units
+	Size() (int32, int32)
+	// DrawableSize of the window in pixels
+	DrawableSize() (int32, int32)
+	// Swap show what was drawn this frame
+	Swap()
+	// Close the window and its graphics context
+	Close()
+}
+
+// Event something that happened to the platform or window
+type Event interface{}
+
+// QuitEvent the user asked to quit
+type QuitEvent struct{}
+
+// ResizeEvent the window changed size
+type ResizeEvent struct {
+	Width          int32
+	Height         int32
+	DrawableWidth  int32
+	DrawableHeight int32
+}
+
+// WindowSettings settings matching the window's current size
+func WindowSettings(w Window) core.Settings {
+	s := core.Settings{}
+	width, height := w.Size()
+	dw, dh := w.DrawableSize()
+	s.SetWindowSize(width, height, dw, dh)
+	return s
+}
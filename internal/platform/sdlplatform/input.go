@@ -0,0 +1,128 @@
+package sdlplatform
+
+import (
+	"strings"
+
+	"github.com/robrohan/mesh/internal/input"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// sdlSource collects SDL events into input frames. Hand it every event
+// with Handle, then call Next once per frame
+type sdlSource struct {
+	held        map[input.Button]bool
+	axes        map[input.Axis]float64
+	controllers map[sdl.JoystickID]*sdl.GameController
+}
+
+func newSDLSource() *sdlSource {
+	return &sdlSource{
+		held:        map[input.Button]bool{},
+		axes:        map[input.Axis]float64{},
+		controllers: map[sdl.JoystickID]*sdl.GameController{},
+	}
+}
+
+// Handle record an event, returns false if it wasn't an input event
+func (s *sdlSource) Handle(event sdl.Event) bool {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if t.Repeat != 0 {
+			return true
+		}
+		s.set(keyButton(t.Keysym.Sym), t.State == sdl.PRESSED)
+	case *sdl.MouseButtonEvent:
+		s.set(mouseButton(t.Button), t.State == sdl.PRESSED)
+	case *sdl.MouseMotionEvent:
+		s.axes[input.MouseX] = float64(t.X)
+		s.axes[input.MouseY] = float64(t.Y)
+		s.axes[input.MouseDX] += float64(t.XRel)
+		s.axes[input.MouseDY] += float64(t.YRel)
+	case *sdl.MouseWheelEvent:
+		s.axes[input.WheelX] += float64(t.X)
+		s.axes[input.WheelY] += float64(t.Y)
+	case *sdl.ControllerDeviceEvent:
+		s.device(t)
+	case *sdl.ControllerButtonEvent:
+		name := sdl.GameControllerGetStringForButton(sdl.GameControllerButton(t.Button))
+		s.set(input.Button("pad:"+name), t.State == sdl.PRESSED)
+	case *sdl.ControllerAxisEvent:
+		name := sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(t.Axis))
+		s.axes[input.Axis("pad:"+name)] = float64(t.Value) / 32767
+	default:
+		return false
+	}
+	return true
+}
+
+// Next the state of everything since the last call
+func (s *sdlSource) Next() input.Frame {
+	held := []input.Button{}
+	for b, down := range s.held {
+		if down {
+			held = append(held, b)
+		}
+	}
+	axes := map[input.Axis]float64{}
+	for a, v := range s.axes {
+		axes[a] = v
+	}
+
+	// Movement is per frame
+	delete(s.axes, input.MouseDX)
+	delete(s.axes, input.MouseDY)
+	delete(s.axes, input.WheelX)
+	delete(s.axes, input.WheelY)
+
+	return input.NewFrame(held, axes)
+}
+
+// Close close any open controllers
+func (s *sdlSource) Close() {
+	for id, c := range s.controllers {
+		c.Close()
+		delete(s.controllers, id)
+	}
+}
+
+func (s *sdlSource) set(b input.Button, down bool) {
+	if down {
+		s.held[b] = true
+	} else {
+		delete(s.held, b)
+	}
+}
+
+func (s *sdlSource) device(t *sdl.ControllerDeviceEvent) {
+	switch t.Type {
+	case sdl.CONTROLLERDEVICEADDED:
+		// Which is the device index when added
+		if c := sdl.GameControllerOpen(int(t.Which)); c != nil {
+			s.controllers[c.Joystick().InstanceID()] = c
+		}
+	case sdl.CONTROLLERDEVICEREMOVED:
+		if c, ok := s.controllers[t.Which]; ok {
+			c.Close()
+			delete(s.controllers, t.Which)
+		}
+	}
+}
+
+func keyButton(key sdl.Keycode) input.Button {
+	name := strings.ToLower(sdl.GetKeyName(key))
+	return input.Button("key:" + strings.Replace(name, " ", "_", -1))
+}
+
+func mouseButton(button uint8) input.Button {
+	switch button {
+	case sdl.BUTTON_LEFT:
+		return "mouse:left"
+	case sdl.BUTTON_MIDDLE:
+		return "mouse:middle"
+	case sdl.BUTTON_RIGHT:
+		return "mouse:right"
+	case sdl.BUTTON_X1:
+		return "mouse:x1"
+	}
+	return "mouse:x2"
+}
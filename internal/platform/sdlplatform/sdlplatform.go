@@ -0,0 +1,126 @@
+// Package sdlplatform the platform on top of SDL2 and OpenGL
+package sdlplatform
+
+import (
+	"github.com/robrohan/mesh/internal/input"
+	"github.com/robrohan/mesh/internal/platform"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Platform SDL2 windows, events and timing. SDL has to be used from the
+// main thread, so call runtime.LockOSThread before New
+type Platform struct {
+	source    *sdlSource
+	window    *Window
+	start     uint64
+	frequency float64
+}
+
+// New start SDL
+func New() (*Platform, error) {
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		return nil, err
+	}
+	return &Platform{
+		source:    newSDLSource(),
+		start:     sdl.GetPerformanceCounter(),
+		frequency: float64(sdl.GetPerformanceFrequency()),
+	}, nil
+}
+
+// Open create a resizable, high-DPI aware OpenGL window
+func (p *Platform) Open(title string, width, height int32) (platform.Window, error) {
+	sdl.GLSetAttribute(sdl.GL_RED_SIZE, 5)
+	sdl.GLSetAttribute(sdl.GL_GREEN_SIZE, 5)
+	sdl.GLSetAttribute(sdl.GL_BLUE_SIZE, 5)
+	sdl.GLSetAttribute(sdl.GL_DEPTH_SIZE, 16)
+	sdl.GLSetAttribute(sdl.GL_DOUBLEBUFFER, 1)
+
+	window, err := sdl.CreateWindow(
+		title,
+		sdl.WINDOWPOS_UNDEFINED,
+		sdl.WINDOWPOS_UNDEFINED,
+		width, height,
+		sdl.WINDOW_OPENGL|sdl.WINDOW_RESIZABLE|sdl.WINDOW_ALLOW_HIGHDPI) // |sdl.WINDOW_FULLSCREEN)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := window.GLCreateContext()
+	if err != nil {
+		window.Destroy()
+		return nil, err
+	}
+
+	p.window = &Window{window: window, context: context}
+	return p.window, nil
+}
+
+// Poll the window events since the last call
+func (p *Platform) Poll() []platform.Event {
+	events := []platform.Event{}
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		if p.source.Handle(event) {
+			continue
+		}
+		switch t := event.(type) {
+		case *sdl.QuitEvent:
+			events = append(events, platform.QuitEvent{})
+		case *sdl.WindowEvent:
+			if t.Event == sdl.WINDOWEVENT_SIZE_CHANGED && p.window != nil {
+				w, h := p.window.Size()
+				dw, dh := p.window.DrawableSize()
+				events = append(events, platform.ResizeEvent{
+					Width:          w,
+					Height:         h,
+					DrawableWidth:  dw,
+					DrawableHeight: dh,
+				})
+			}
+		}
+	}
+	return events
+}
+
+// Input the keyboard, mouse and gamepads
+func (p *Platform) Input() input.Source {
+	return p.source
+}
+
+// Now seconds since New
+func (p *Platform) Now() float64 {
+	return float64(sdl.GetPerformanceCounter()-p.start) / p.frequency
+}
+
+// Close release any gamepads and shut SDL down
+func (p *Platform) Close() {
+	p.source.Close()
+	sdl.Quit()
+}
+
+// Window an SDL window with an OpenGL context
+type Window struct {
+	window  *sdl.Window
+	context sdl.GLContext
+}
+
+// Size of the window in screen units
+func (w *Window) Size() (int32, int32) {
+	return w.window.GetSize()
+}
+
+// DrawableSize of the window in pixels
+func (w *Window) DrawableSize() (int32, int32) {
+	return w.window.GLGetDrawableSize()
+}
+
+// Swap show the back buffer
+func (w *Window) Swap() {
+	w.window.GLSwap()
+}
+
+// Close delete the context and window
+func (w *Window) Close() {
+	sdl.GLDeleteContext(w.context)
+	w.window.Destroy()
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// LightType what kind of light a ComponentLight is
+type LightType uint8
+
+const (
+	// LightAmbient lights everything equally from everywhere
+	LightAmbient LightType = iota
+	// LightDirectional shines along the entity's -Z from infinitely far
+	// away, like the sun
+	LightDirectional
+	// LightPoint shines in every direction from the entity's position
+	LightPoint
+	// LightSpot shines a cone along the entity's -Z from its position
+	LightSpot
+)
+
+// ComponentLight lights the scene. Like cameras, lights point down the
+// entity's -Z axis
+type ComponentLight struct {
+	*core.Component
+	Type      LightType
+	Color     algebra.Vector
+	Intensity float64
+	// Range distance at which point and spot lights fade out completely,
+	// 0 for no limit
+	Range float64
+	// InnerAngle, OuterAngle the spot light cone (degrees from the center
+	// line). Full brightness inside InnerAngle fading to none at
+	// OuterAngle
+	InnerAngle float64
+	OuterAngle float64
+	// Environment lights PBR materials from a cubemap, for ambient
+	// lights
+	Environment *Environment
+
+	// CastShadows directional and spot lights are blocked by what's in
+	// the way. Only the first MaxShadows lights get shadows
+	CastShadows bool
+	// ShadowBias how far (world units) a surface has to be behind what
+	// the light sees to be in shadow, 0 means DefaultShadowBias. Too
+	// little and lit surfaces shadow themselves in stripes, too much and
+	// shadows come away from what casts them
+	ShadowBias float64
+	// ShadowNormalBias how many shadow map texels surfaces are pushed out
+	// along their normal before they are looked up, 0 means
+	// DefaultShadowNormalBias
+	ShadowNormalBias float64
+	// ShadowMapSize the width and height of the shadow map (of each
+	// cascade), 0 means DefaultShadowMapSize
+	ShadowMapSize int
+	// ShadowCascades how many pieces a directional light's shadow is
+	// split into down the camera's view, nearer ones being sharper. 0
+	// means DefaultShadowCascades, at most MaxCascades
+	ShadowCascades int
+	// ShadowDistance how far from the camera a directional light's
+	// shadows reach, and the farthest a spot light without a Range
+	// casts them. 0 means DefaultShadowDistance
+	ShadowDistance float64
+
+	// shadow kept from frame to frame so the GPU copy can be reused
+	shadow *ShadowMap
+}
+
+func newComponentLight(t LightType, color algebra.Vector, intensity float64) ComponentLight {
+	return ComponentLight{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Type:      t,
+		Color:     color,
+		Intensity: intensity,
+	}
+}
+
+// NewComponentAmbientLight create a light that lights everything evenly
+func NewComponentAmbientLight(color algebra.Vector, intensity float64) ComponentLight {
+	return newComponentLight(LightAmbient, color, intensity)
+}
+
+// NewComponentEnvironmentLight create an ambient light that comes from
+// the surroundings in env, which shiny PBR materials also reflect
+func NewComponentEnvironmentLight(env *Environment, intensity float64) ComponentLight {
+	l := newComponentLight(LightAmbient, algebra.Vector{X: 1, Y: 1, Z: 1}, intensity)
+	l.Environment = env
+	return l
+}
+
+// NewComponentDirectionalLight create a sun like light
+func NewComponentDirectionalLight(color algebra.Vector, intensity float64) ComponentLight {
+	return newComponentLight(LightDirectional, color, intensity)
+}
+
+// NewComponentPointLight create a light bulb like light
+func NewComponentPointLight(color algebra.Vector, intensity float64, lightRange float64) ComponentLight {
+	l := newComponentLight(LightPoint, color, intensity)
+	l.Range = lightRange
+	return l
+}
+
+// NewComponentSpotLight create a torch like light
+func NewComponentSpotLight(color algebra.Vector, intensity float64, lightRange float64, inner float64, outer float64) ComponentLight {
+	l := newComponentLight(LightSpot, color, intensity)
+	l.Range = lightRange
+	l.InnerAngle = inner
+	l.OuterAngle = outer
+	return l
+}
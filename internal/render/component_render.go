@@ -2,6 +2,7 @@ package render
 
 import (
 	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
 )
 
 // ComponentRender draw an object on screen
@@ -18,3 +19,8 @@ func NewComponentRender() ComponentRender {
 		},
 	}
 }
+
+// GetPolyhedron the geometry this component draws
+func (rc *ComponentRender) GetPolyhedron() *geometry.Polyhedron {
+	return &rc.Mesh.Poly
+}
@@ -0,0 +1,346 @@
+package render
+
+import (
+	"math"
+	"strings"
+
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+// sdfScene the line of the SDF fragment shader the generated distance
+// function goes in place of
+const sdfScene = "// SDF_SCENE"
+
+// ComponentSDF draw a shape described by a signed distance function
+// (see the sdf package) by ray marching it rather than from triangles.
+// The march writes depth, so the shape sits in among the meshes. Root is
+// in the entity's space, whose scale has to be the same along every
+// axis. The same tree answers Distance, Contact and Raycast on the CPU,
+// for collision and picking
+type ComponentSDF struct {
+	*core.Component
+	Root sdf.Node
+	// Material the colors the shape is lit with, as the Lit shader does
+	// but without maps or shadows. Its Shader isn't used, the program is
+	// generated from Root
+	Material Material
+	// Bounds half the size of a box around the entity's origin that the
+	// shape fits in. Only the pixels the box covers are marched, which
+	// is a lot cheaper. Zero marches the whole screen
+	Bounds algebra.Vector
+
+	// program, source the program generated for Root, and the distance
+	// function it was built from
+	program *Program
+	source  string
+}
+
+// NewComponentSDF create a component drawing root
+func NewComponentSDF(root sdf.Node) ComponentSDF {
+	return ComponentSDF{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Root: root,
+	}
+}
+
+// Distance the signed distance from a point in world space to the shape
+func (c *ComponentSDF) Distance(p algebra.Vector) float64 {
+	if c.Root == nil {
+		return math.Inf(1)
+	}
+	local, scale := c.toLocal(p)
+	return c.Root.Distance(local) * scale
+}
+
+// Normal which way is out from the shape at a point in world space
+func (c *ComponentSDF) Normal(p algebra.Vector) algebra.Vector {
+	if c.Root == nil {
+		return algebra.Vector{}
+	}
+	local, _ := c.toLocal(p)
+	return c.worldNormal(sdf.Normal(c.Root, local))
+}
+
+// Contact how far a sphere in world space overlaps the shape, and the
+// way out. ok is false when they don't touch
+func (c *ComponentSDF) Contact(center algebra.Vector, radius float64) (depth float64, normal algebra.Vector, ok bool) {
+	d := c.Distance(center)
+	if d >= radius {
+		return 0, algebra.Vector{}, false
+	}
+	return radius - d, c.Normal(center), true
+}
+
+// Raycast march a world space ray into the shape, see core.Raycaster
+func (c *ComponentSDF) Raycast(ray algebra.Ray, maxDistance float64) (core.RaycastHit, bool) {
+	if c.Root == nil {
+		return core.RaycastHit{}, false
+	}
+	end := algebra.Vector{}
+	ray.At(maxDistance, &end)
+	inverse := c.inverse()
+	origin := transformPoint(inverse, ray.Origin)
+	local, t, ok := c.march(origin, transformPoint(inverse, end))
+	if !ok {
+		return core.RaycastHit{}, false
+	}
+	hit := core.RaycastHit{Distance: t * maxDistance}
+	ray.At(hit.Distance, &hit.Point)
+	hit.Normal = c.worldNormal(sdf.Normal(c.Root, local))
+	return hit, true
+}
+
+// march the shape along the line from start to end, both in the
+// entity's space. The hit is in the entity's space too, with how far
+// along the line it is from 0 to 1
+func (c *ComponentSDF) march(start, end algebra.Vector) (hit algebra.Vector, t float64, ok bool) {
+	line := algebra.Vector{}
+	end.SubV(start, &line)
+	length := line.Length()
+	if length == 0 {
+		return hit, 0, false
+	}
+	direction := line.Scale(1 / length)
+	near, far := 0.0, length
+	if !c.Bounds.IsZero() {
+		if near, far, ok = boxSpan(start, direction, c.Bounds); !ok {
+			return hit, 0, false
+		}
+		near = math.Max(near, 0)
+		far = math.Min(far, length)
+	}
+	if near > far {
+		return hit, 0, false
+	}
+	from := direction.Scale(near)
+	from.AddV(start, &from)
+	d, ok := sdf.March(c.Root, from, direction, far-near)
+	if !ok {
+		return hit, 0, false
+	}
+	hit = direction.Scale(d)
+	hit.AddV(from, &hit)
+	hit.W = 1
+	return hit, (near + d) / length, true
+}
+
+// boxSpan how far along a ray it enters and leaves the box of half size
+// half around the origin. The SDF shader does the same
+func boxSpan(origin, direction, half algebra.Vector) (near, far float64, ok bool) {
+	near, far = math.Inf(-1), math.Inf(1)
+	o := [3]float64{origin.X, origin.Y, origin.Z}
+	d := [3]float64{direction.X, direction.Y, direction.Z}
+	h := [3]float64{half.X, half.Y, half.Z}
+	for i := 0; i < 3; i++ {
+		if d[i] == 0 {
+			if math.Abs(o[i]) > h[i] {
+				return 0, 0, false
+			}
+			continue
+		}
+		t1 := (-h[i] - o[i]) / d[i]
+		t2 := (h[i] - o[i]) / d[i]
+		near = math.Max(near, math.Min(t1, t2))
+		far = math.Min(far, math.Max(t1, t2))
+	}
+	return near, far, near <= far && far >= 0
+}
+
+// world the entity's model to world matrix, the identity when it has
+// no transform
+func (c *ComponentSDF) world() *algebra.Matrix {
+	e := c.GetParent()
+	if e == nil || e.Transform == nil {
+		m := algebra.Matrix{}
+		m.InitIdentity()
+		return &m
+	}
+	return e.Transform.GetTransformation()
+}
+
+func (c *ComponentSDF) inverse() *algebra.Matrix {
+	inverse := algebra.Matrix{}
+	c.world().Inverse(&inverse)
+	return &inverse
+}
+
+// toLocal a world space point in the entity's space, with how much the
+// entity is scaled by
+func (c *ComponentSDF) toLocal(p algebra.Vector) (algebra.Vector, float64) {
+	world := c.world()
+	across := algebra.Vector{}
+	world.Transform(algebra.Vector{X: 1}, &across)
+	inverse := algebra.Matrix{}
+	world.Inverse(&inverse)
+	return transformPoint(&inverse, p), across.Length()
+}
+
+// worldNormal a normal in the entity's space in world space
+func (c *ComponentSDF) worldNormal(n algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	c.world().Transform(algebra.Vector{X: n.X, Y: n.Y, Z: n.Z}, &out)
+	out.Normalized(&out)
+	out.W = 0
+	return out
+}
+
+// surface the material's colors, without its maps
+func (c *ComponentSDF) surface() Surface {
+	m := &c.Material
+	ambient := m.AmbientColor
+	if isZero(ambient) {
+		ambient = m.tint()
+	}
+	return Surface{
+		Illumination: m.Illumination,
+		Ambient:      ambient,
+		Diffuse:      m.tint(),
+		Specular:     m.SpecularColor,
+		Shininess:    math.Max(1, m.shininess()),
+		Alpha:        m.opacity(),
+	}
+}
+
+// transformPoint m applied to the point p, divided through by w
+func transformPoint(m *algebra.Matrix, p algebra.Vector) algebra.Vector {
+	out := algebra.Vector{}
+	m.Transform(algebra.Vector{X: p.X, Y: p.Y, Z: p.Z, W: 1}, &out)
+	if out.W != 0 && out.W != 1 {
+		out = out.Scale(1 / out.W)
+	}
+	out.W = 1
+	return out
+}
+
+// withoutShadows the lighting with the lights' shadow maps left off,
+// which ray marched shapes don't receive
+func (lighting Lighting) withoutShadows() Lighting {
+	lights := make([]Light, len(lighting.Lights))
+	copy(lights, lighting.Lights)
+	for i := 0; i < len(lights); i++ {
+		lights[i].Shadow = nil
+	}
+	lighting.Lights = lights
+	return lighting
+}
+
+// sdfCube the box bounded shapes are drawn with
+var sdfCube *Mesh
+
+// unitCube a box from -1 to 1, wound counter clockwise from outside
+func unitCube() geometry.Polyhedron {
+	vertices := make([]geometry.Vertex, 8)
+	for i := 0; i < 8; i++ {
+		corner := algebra.Vector{X: -1, Y: -1, Z: -1}
+		if i&1 != 0 {
+			corner.X = 1
+		}
+		if i&2 != 0 {
+			corner.Y = 1
+		}
+		if i&4 != 0 {
+			corner.Z = 1
+		}
+		vertices[i] = geometry.Vertex{Pos: corner}
+	}
+	return geometry.Polyhedron{
+		Vertices: vertices,
+		Indices: []uint16{
+			5, 1, 3, 5, 3, 7, // +x
+			0, 4, 6, 0, 6, 2, // -x
+			6, 7, 3, 6, 3, 2, // +y
+			0, 1, 5, 0, 5, 4, // -y
+			4, 5, 7, 4, 7, 6, // +z
+			1, 0, 2, 1, 2, 3, // -z
+		},
+	}
+}
+
+// drawGl ray march the shape on the GPU, generating its program the
+// first time and again whenever the shape of the tree changes
+func (c *ComponentSDF) drawGl(cc *core.ComponentCamera, lighting *Lighting) error {
+	if c.Root == nil {
+		return nil
+	}
+	source, params := sdf.Shader(c.Root)
+	if c.program == nil || source != c.source {
+		if c.program != nil {
+			gl.DeleteProgram(c.program.Program)
+			c.program = nil
+		}
+		fragment := strings.Replace(ReadFragmentShader(".", "SDF.glsl"), sdfScene, source, 1)
+		p, err := BuildProgram(ReadVertexShader(".", "SDF.glsl"), fragment)
+		if err != nil {
+			return err
+		}
+		c.program, c.source = &p, source
+	}
+	p := c.program
+
+	mesh := fullScreenMesh()
+	if !c.Bounds.IsZero() {
+		if sdfCube == nil {
+			cube := CreateMesh(unitCube())
+			sdfCube = &cube
+		}
+		mesh = sdfCube
+		// The back of the box, so it's still drawn from inside
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(gl.FRONT)
+		defer gl.Disable(gl.CULL_FACE)
+	}
+
+	gl.UseProgram(p.Program)
+	disableAttributesGl()
+	bindMeshGl(p, mesh)
+
+	world := c.world()
+	viewProjection := algebra.Matrix{}
+	cc.GetView().Mul(*cc.GetProjection(), &viewProjection)
+	inverseViewProjection := algebra.Matrix{}
+	viewProjection.Inverse(&inverseViewProjection)
+	matrices := []struct {
+		loc gl.Int
+		m   *algebra.Matrix
+	}{
+		{p.UniWorld, world},
+		{p.UniView, cc.GetView()},
+		{p.UniProject, cc.GetProjection()},
+		{findUniform(p.Program, "uInverseWorld"), c.inverse()},
+		{findUniform(p.Program, "uInverseViewProjection"), &inverseViewProjection},
+	}
+	for i := 0; i < len(matrices); i++ {
+		if matrices[i].loc < 0 {
+			continue
+		}
+		m := matrixAsArray(matrices[i].m)
+		gl.UniformMatrix4fv(matrices[i].loc, 1, gl.FALSE, &m[0])
+	}
+
+	viewport := [4]gl.Int{}
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	if loc := findUniform(p.Program, "uViewport"); loc >= 0 {
+		gl.Uniform4f(loc, gl.Float(viewport[0]), gl.Float(viewport[1]), gl.Float(viewport[2]), gl.Float(viewport[3]))
+	}
+	uniformVector(findUniform(p.Program, "uBounds"), c.Bounds)
+	if loc := findUniform(p.Program, sdf.ParamsUniform); loc >= 0 && len(params) > 0 {
+		values := make([]gl.Float, len(params))
+		for i := 0; i < len(params); i++ {
+			values[i] = gl.Float(params[i])
+		}
+		gl.Uniform1fv(loc, gl.Sizei(len(values)), &values[0])
+	}
+	uploadLighting(&p.Lighting, lighting)
+	if err := uploadMaterial(&p.Material, &c.Material); err != nil {
+		return err
+	}
+
+	return drawGl(mesh, &c.Material)
+}
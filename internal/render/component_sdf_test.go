@@ -0,0 +1,81 @@
+package render_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func mockSDFEntity(root sdf.Node, pos algebra.Vector, scale float64) (*core.Entity, *render.ComponentSDF) {
+	e := &core.Entity{Transform: core.NewTransform()}
+	e.Transform.Position = pos
+	e.Transform.Scale = algebra.Vector{X: scale, Y: scale, Z: scale}
+	sc := render.NewComponentSDF(root)
+	e.Attach(&sc)
+	return e, &sc
+}
+
+func TestComponentSDF(t *testing.T) {
+	e, sc := mockSDFEntity(sdf.Sphere{Radius: 1}, algebra.Vector{Z: -5}, 2)
+
+	if d := sc.Distance(algebra.Vector{}); math.Abs(d-3) > 1e-6 {
+		t.Errorf("distance %v should be 3", d)
+	}
+	out := algebra.Vector{Z: 1}
+	depth, normal, ok := sc.Contact(algebra.Vector{Z: -2.5}, 1)
+	if !ok || math.Abs(depth-0.5) > 1e-6 || !normal.AlmostEquals(&out) {
+		t.Errorf("contact %v %v %v should be 0.5 %v", depth, normal, ok, out)
+	}
+
+	// Picked through the scene like meshes are
+	scene := core.Scene{}
+	scene.Add(e)
+	ray := algebra.NewRay(algebra.Vector{}, algebra.Vector{Z: -1})
+	hit, ok := scene.Raycast(ray, 100)
+	if !ok || hit.Entity != e || hit.Triangle != -1 {
+		t.Fatalf("expected to hit the sdf got %v %v", hit, ok)
+	}
+	if math.Abs(hit.Distance-3) > sdf.SurfaceDistance*2 || !hit.Normal.AlmostEquals(&out) {
+		t.Errorf("hit at %v facing %v should be 3 facing %v", hit.Distance, hit.Normal, out)
+	}
+	if _, ok := scene.Raycast(ray, 2); ok {
+		t.Errorf("hit past the max distance")
+	}
+}
+
+func TestSoftwareSDF(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	red := mockQuad(-2, algebra.Vector{X: 1})
+	r.DrawMesh(&red, world, view, proj)
+
+	_, sc := mockSDFEntity(sdf.Sphere{Radius: 1.5}, algebra.Vector{Z: -4}, 1)
+	sc.Material = render.Material{Illumination: render.IllumColorOnAmbientOff, DiffuseColor: algebra.Vector{Z: 1}}
+	r.DrawSDF(sc, view, proj, nil)
+
+	// Behind the quad in the middle, showing above it
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("center should be the red quad got %v", c)
+	}
+	if c := r.Image.RGBAAt(20, 5); c != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("above the quad should be the blue sdf got %v", c)
+	}
+	if c := r.Image.RGBAAt(2, 2); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("corner should be clear got %v", c)
+	}
+
+	// Only what's inside the bounds is drawn
+	r.Clear()
+	sc.Bounds = algebra.Vector{X: 2, Y: 1, Z: 2}
+	r.DrawSDF(sc, view, proj, nil)
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("center should be the blue sdf got %v", c)
+	}
+	if c := r.Image.RGBAAt(20, 5); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("outside the bounds should be clear got %v", c)
+	}
+}
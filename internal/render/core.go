@@ -20,12 +20,87 @@ type Program struct {
 	TexCoordLoc gl.Uint
 	NormalLoc   gl.Uint
 	TangentLoc  gl.Uint
+	// JointsLoc, WeightsLoc the skinning attributes, which only skinned
+	// shaders have
+	JointsLoc  gl.Uint
+	WeightsLoc gl.Uint
 	// UniWorld the uniform world matrix
 	UniWorld gl.Int
 	// UniView the uniform view matrix
 	UniView gl.Int
 	// UniProject the uniform projection matrix
 	UniProject gl.Int
+	// UniJoints the uniform array of skinning matrices, -1 when the
+	// shader doesn't skin
+	UniJoints gl.Int
+	// Lighting the lighting uniforms, -1 for any the shader doesn't use
+	Lighting LightingUniforms
+	// Material the material uniforms, -1 for any the shader doesn't use
+	Material MaterialUniforms
+	// PBR the PBR material and environment uniforms, -1 for any the
+	// shader doesn't use
+	PBR PBRUniforms
+	// Shadow the shadow map uniforms, -1 for any the shader doesn't use
+	Shadow ShadowUniforms
+}
+
+// LightingUniforms uniform locations used by the Lit shader
+type LightingUniforms struct {
+	Eye            gl.Int
+	Ambient        gl.Int
+	LightCount     gl.Int
+	LightType      gl.Int
+	LightPosition  gl.Int
+	LightDirection gl.Int
+	LightColor     gl.Int
+	LightRange     gl.Int
+	LightCone      gl.Int
+}
+
+// MaterialUniforms uniform locations for a Material's values and maps
+type MaterialUniforms struct {
+	AmbientColor  gl.Int
+	DiffuseColor  gl.Int
+	SpecularColor gl.Int
+	Shininess     gl.Int
+	Alpha         gl.Int
+	Illum         gl.Int
+	BumpScale     gl.Int
+	TextureOrigin gl.Int
+	TextureScale  gl.Int
+	// Maps the samplers, by texture unit (AmbientTextureUnit...)
+	Maps [textureUnits]gl.Int
+}
+
+// PBRUniforms uniform locations for a PBRMaterial and the environment
+type PBRUniforms struct {
+	BaseColor         gl.Int
+	Metallic          gl.Int
+	Roughness         gl.Int
+	Emissive          gl.Int
+	NormalScale       gl.Int
+	OcclusionStrength gl.Int
+	// Maps the samplers, by texture unit (BaseColorTextureUnit...)
+	Maps              [pbrTextureUnits]gl.Int
+	Environment       gl.Int
+	EnvironmentColor  gl.Int
+	EnvironmentLevels gl.Int
+	Irradiance        gl.Int
+}
+
+// ShadowUniforms uniform locations for the lights' shadow maps
+type ShadowUniforms struct {
+	// Light which shadow map each light uses, by light
+	Light  gl.Int
+	Packed gl.Int
+	// Cascades, Settings by shadow map
+	Cascades gl.Int
+	Settings gl.Int
+	// Matrix, Cascade by shadow map then cascade
+	Matrix  gl.Int
+	Cascade gl.Int
+	// Maps the samplers, bound from ShadowTextureUnit on
+	Maps [MaxShadows]gl.Int
 }
 
 // ReadVertexShader read a vertex shader from disk
@@ -115,14 +190,48 @@ func compileStatus(shader gl.Uint) (gl.Uint, error) {
 	return gl.TRUE, nil
 }
 
-// UseProgram uses a program
+// UseProgram uses the Simple (unlit vertex color) program
 func UseProgram() Program {
-	program, err := CreateProgram(
-		ReadVertexShader(".", "Simple.glsl"),
-		ReadFragmentShader(".", "Simple.glsl"))
+	program, err := LoadProgram("Simple.glsl", "Simple.glsl")
 	if err != nil {
 		panic(err)
 	}
+	return program
+}
+
+// UseLitProgram uses the Lit (Blinn-Phong) program
+func UseLitProgram() Program {
+	program, err := LoadProgram("Lit.glsl", "Lit.glsl")
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// UsePBRProgram uses the PBR (Cook-Torrance) program
+func UsePBRProgram() Program {
+	program, err := LoadProgram("Lit.glsl", "PBR.glsl")
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// LoadProgram build a program from shaders in assets/shaders and look
+// up its attributes and uniforms
+func LoadProgram(vertex string, fragment string) (Program, error) {
+	return BuildProgram(
+		ReadVertexShader(".", vertex),
+		ReadFragmentShader(".", fragment))
+}
+
+// BuildProgram build a program from shader source and look up its
+// attributes and uniforms, for shaders made at run time
+func BuildProgram(vertexSource string, fragmentSource string) (Program, error) {
+	program, err := CreateProgram(vertexSource, fragmentSource)
+	if err != nil {
+		return Program{}, err
+	}
 
 	gl.UseProgram(program)
 
@@ -161,6 +270,9 @@ func UseProgram() Program {
 	if tangentLoc == -1 {
 		log.Printf("Tangent attribute not found.")
 	}
+	// Only skinned shaders have these
+	jointsLoc := gl.GetAttribLocation(program, gl.GLString("Joints"))
+	weightsLoc := gl.GetAttribLocation(program, gl.GLString("Weights"))
 
 	gl.EnableVertexAttribArray(gl.Uint(posLoc))
 	if gl.GetError() != gl.NO_ERROR {
@@ -212,8 +324,113 @@ func UseProgram() Program {
 		TexCoordLoc: gl.Uint(texCoordLoc),
 		NormalLoc:   gl.Uint(normalLoc),
 		TangentLoc:  gl.Uint(tangentLoc),
+		JointsLoc:   gl.Uint(jointsLoc),
+		WeightsLoc:  gl.Uint(weightsLoc),
 		UniWorld:    uniWorld,
 		UniView:     uniView,
 		UniProject:  uniProj,
+		UniJoints:   findUniform(program, "uJoints[0]"),
+		Lighting:    lightingUniforms(program),
+		Material:    materialUniforms(program),
+		PBR:         pbrUniforms(program),
+		Shadow:      shadowUniforms(program),
+	}, nil
+}
+
+// findUniform the location of an optional uniform, -1 when the program
+// doesn't have it
+func findUniform(program gl.Uint, name string) gl.Int {
+	s := gl.GLString(name)
+	defer gl.GLStringFree(s)
+	return gl.GetUniformLocation(program, s)
+}
+
+// lightingUniforms look up the lighting uniforms, which are optional
+func lightingUniforms(program gl.Uint) LightingUniforms {
+	find := func(name string) gl.Int {
+		return findUniform(program, name)
+	}
+	return LightingUniforms{
+		Eye:            find("uEye"),
+		Ambient:        find("uAmbient"),
+		LightCount:     find("uLightCount"),
+		LightType:      find("uLightType[0]"),
+		LightPosition:  find("uLightPosition[0]"),
+		LightDirection: find("uLightDirection[0]"),
+		LightColor:     find("uLightColor[0]"),
+		LightRange:     find("uLightRange[0]"),
+		LightCone:      find("uLightCone[0]"),
+	}
+}
+
+// materialUniforms look up the material uniforms, which are optional
+func materialUniforms(program gl.Uint) MaterialUniforms {
+	find := func(name string) gl.Int {
+		return findUniform(program, name)
+	}
+	return MaterialUniforms{
+		AmbientColor:  find("uAmbientColor"),
+		DiffuseColor:  find("uDiffuseColor"),
+		SpecularColor: find("uSpecularColor"),
+		Shininess:     find("uShininess"),
+		Alpha:         find("uAlpha"),
+		Illum:         find("uIllum"),
+		BumpScale:     find("uBumpScale"),
+		TextureOrigin: find("uTextureOrigin"),
+		TextureScale:  find("uTextureScale"),
+		Maps: [textureUnits]gl.Int{
+			find("uAmbientMap"),
+			find("uDiffuseMap"),
+			find("uSpecularMap"),
+			find("uShininessMap"),
+			find("uAlphaMap"),
+			find("uBumpMap"),
+		},
+	}
+}
+
+// pbrUniforms look up the PBR uniforms, which are optional
+func pbrUniforms(program gl.Uint) PBRUniforms {
+	find := func(name string) gl.Int {
+		return findUniform(program, name)
+	}
+	return PBRUniforms{
+		BaseColor:         find("uBaseColor"),
+		Metallic:          find("uMetallic"),
+		Roughness:         find("uRoughness"),
+		Emissive:          find("uEmissive"),
+		NormalScale:       find("uNormalScale"),
+		OcclusionStrength: find("uOcclusionStrength"),
+		Maps: [pbrTextureUnits]gl.Int{
+			find("uBaseColorMap"),
+			find("uMetallicMap"),
+			find("uRoughnessMap"),
+			find("uNormalMap"),
+			find("uOcclusionMap"),
+			find("uEmissiveMap"),
+		},
+		Environment:       find("uEnvironment"),
+		EnvironmentColor:  find("uEnvironmentColor"),
+		EnvironmentLevels: find("uEnvironmentLevels"),
+		Irradiance:        find("uIrradiance[0]"),
+	}
+}
+
+// shadowUniforms look up the shadow uniforms, which are optional
+func shadowUniforms(program gl.Uint) ShadowUniforms {
+	find := func(name string) gl.Int {
+		return findUniform(program, name)
+	}
+	u := ShadowUniforms{
+		Light:    find("uLightShadow[0]"),
+		Packed:   find("uShadowPacked"),
+		Cascades: find("uShadowCascades[0]"),
+		Settings: find("uShadowSettings[0]"),
+		Matrix:   find("uShadowMatrix[0]"),
+		Cascade:  find("uShadowCascade[0]"),
+	}
+	for i := 0; i < MaxShadows; i++ {
+		u.Maps[i] = find(fmt.Sprintf("uShadowMap%v", i))
 	}
+	return u
 }
@@ -0,0 +1,465 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// TonemapOperator the curve Tonemap squeezes light into 0..1 with
+type TonemapOperator uint8
+
+const (
+	// TonemapACES Narkowicz's fit of the ACES filmic curve, which
+	// desaturates and rolls off highlights like film
+	TonemapACES TonemapOperator = iota
+	// TonemapReinhard x / (1 + x), softer and flatter
+	TonemapReinhard
+)
+
+// Tonemap scale the scene's light by Exposure and bring it into the
+// range the screen can show
+type Tonemap struct {
+	// Exposure what light is multiplied by first, 0 means 1
+	Exposure float64
+	Operator TonemapOperator
+}
+
+// Apply tonemap the frame
+func (t Tonemap) Apply(f *Frame) {
+	exposure := t.exposure()
+	curve := aces
+	if t.Operator == TonemapReinhard {
+		curve = reinhard
+	}
+	f.each(func(c algebra.Vector) algebra.Vector {
+		return algebra.Vector{
+			X: curve(c.X * exposure),
+			Y: curve(c.Y * exposure),
+			Z: curve(c.Z * exposure),
+			W: c.W,
+		}
+	})
+}
+
+func (t Tonemap) passes() []postPass {
+	return []postPass{{shader: "PostTonemap.glsl", set: func(uniform func(string) gl.Int) error {
+		gl.Uniform1f(uniform("uExposure"), gl.Float(t.exposure()))
+		gl.Uniform1i(uniform("uOperator"), gl.Int(t.Operator))
+		return nil
+	}}}
+}
+
+func (t Tonemap) exposure() float64 {
+	if t.Exposure == 0 {
+		return 1
+	}
+	return t.Exposure
+}
+
+func aces(x float64) float64 {
+	x = math.Max(x, 0)
+	return clamp(x*(2.51*x+0.03)/(x*(2.43*x+0.59)+0.14), 0, 1)
+}
+
+func reinhard(x float64) float64 {
+	x = math.Max(x, 0)
+	return x / (1 + x)
+}
+
+// DefaultGamma what Gamma corrects for when it isn't given one, about
+// what sRGB screens want
+const DefaultGamma = 2.2
+
+// Gamma correct linear light for the screen. Lighting is worked out in
+// linear light, so this usually goes last, or before ColorGrade
+type Gamma struct {
+	// Gamma 0 means DefaultGamma
+	Gamma float64
+}
+
+// Apply gamma correct the frame
+func (g Gamma) Apply(f *Frame) {
+	inverse := 1 / g.gamma()
+	f.each(func(c algebra.Vector) algebra.Vector {
+		return algebra.Vector{
+			X: math.Pow(math.Max(c.X, 0), inverse),
+			Y: math.Pow(math.Max(c.Y, 0), inverse),
+			Z: math.Pow(math.Max(c.Z, 0), inverse),
+			W: c.W,
+		}
+	})
+}
+
+func (g Gamma) passes() []postPass {
+	return []postPass{{shader: "PostGamma.glsl", set: func(uniform func(string) gl.Int) error {
+		gl.Uniform1f(uniform("uGamma"), gl.Float(g.gamma()))
+		return nil
+	}}}
+}
+
+func (g Gamma) gamma() float64 {
+	if g.Gamma <= 0 {
+		return DefaultGamma
+	}
+	return g.Gamma
+}
+
+// Bloom make light brighter than Threshold glow into its surroundings.
+// The bright parts are blurred at half size, which also makes the glow
+// twice as wide for free. Put it before Tonemap, while there is still
+// light past white to find
+type Bloom struct {
+	// Threshold how bright (the brightest of red, green and blue) light
+	// has to be to glow, 0 means 1
+	Threshold float64
+	// Intensity how much of the glow is added back, 0 means 1
+	Intensity float64
+	// Radius how far the glow spreads, in half size pixels per blur
+	// step, 0 means 1
+	Radius float64
+}
+
+// bloomWeights a 9 tap Gaussian, the middle first
+var bloomWeights = [5]float64{0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216}
+
+// Apply add the glow to the frame
+func (b Bloom) Apply(f *Frame) {
+	threshold := b.threshold()
+	w, h := maxInt(f.Width/2, 1), maxInt(f.Height/2, 1)
+	// Bright pass, sampling between 4 pixels like the shader does
+	bright := NewFrame(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := f.sample((float64(x)+0.5)*float64(f.Width)/float64(w), (float64(y)+0.5)*float64(f.Height)/float64(h))
+			bright.Set(x, y, brightPass(c, threshold))
+		}
+	}
+	blurred := blur(blur(bright, b.radius(), 0), 0, b.radius())
+
+	intensity := b.intensity()
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			glow := blurred.sample((float64(x)+0.5)*float64(w)/float64(f.Width), (float64(y)+0.5)*float64(h)/float64(f.Height))
+			c := f.At(x, y)
+			c.X += glow.X * intensity
+			c.Y += glow.Y * intensity
+			c.Z += glow.Z * intensity
+			f.Set(x, y, c)
+		}
+	}
+}
+
+// brightPass how much of c is past threshold, keeping its hue
+func brightPass(c algebra.Vector, threshold float64) algebra.Vector {
+	brightest := math.Max(c.X, math.Max(c.Y, c.Z))
+	if brightest <= threshold {
+		return algebra.Vector{W: 1}
+	}
+	out := c.Scale((brightest - threshold) / brightest)
+	out.W = 1
+	return out
+}
+
+// blur a frame along (dx, dy) with bloomWeights
+func blur(f *Frame, dx, dy float64) *Frame {
+	out := NewFrame(f.Width, f.Height)
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			middle := f.sample(px, py)
+			sum := middle.Scale(bloomWeights[0])
+			for i := 1; i < len(bloomWeights); i++ {
+				o := float64(i)
+				a := f.sample(px+dx*o, py+dy*o)
+				b := f.sample(px-dx*o, py-dy*o)
+				a.AddV(b, &a)
+				sum.AddV(a.Scale(bloomWeights[i]), &sum)
+			}
+			sum.W = 1
+			out.Set(x, y, sum)
+		}
+	}
+	return out
+}
+
+func (b Bloom) passes() []postPass {
+	direction := func(x, y float64) func(func(string) gl.Int) error {
+		return func(uniform func(string) gl.Int) error {
+			gl.Uniform2f(uniform("uDirection"), gl.Float(x*b.radius()), gl.Float(y*b.radius()))
+			return nil
+		}
+	}
+	return []postPass{
+		{shader: "PostBright.glsl", scale: 2, set: func(uniform func(string) gl.Int) error {
+			gl.Uniform1f(uniform("uThreshold"), gl.Float(b.threshold()))
+			return nil
+		}},
+		{shader: "PostBlur.glsl", scale: 2, set: direction(1, 0)},
+		{shader: "PostBlur.glsl", scale: 2, set: direction(0, 1)},
+		{shader: "PostBloom.glsl", set: func(uniform func(string) gl.Int) error {
+			gl.Uniform1f(uniform("uIntensity"), gl.Float(b.intensity()))
+			return nil
+		}},
+	}
+}
+
+func (b Bloom) threshold() float64 {
+	if b.Threshold == 0 {
+		return 1
+	}
+	return b.Threshold
+}
+
+func (b Bloom) intensity() float64 {
+	if b.Intensity == 0 {
+		return 1
+	}
+	return b.Intensity
+}
+
+func (b Bloom) radius() float64 {
+	if b.Radius == 0 {
+		return 1
+	}
+	return b.Radius
+}
+
+// FXAA smooth jagged edges by blurring along them, Lottes' fast
+// approximate anti-aliasing. It wants colors the screen will show, so
+// it goes after Tonemap
+type FXAA struct {
+	// SpanMax the farthest (in pixels) an edge is searched along, 0
+	// means 8
+	SpanMax float64
+}
+
+const (
+	fxaaReduceMul = 1.0 / 8
+	fxaaReduceMin = 1.0 / 128
+)
+
+// Apply anti-alias the frame
+func (a FXAA) Apply(f *Frame) {
+	src := f.Copy()
+	span := a.spanMax()
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			nw := luma(src.At(x-1, y-1))
+			ne := luma(src.At(x+1, y-1))
+			sw := luma(src.At(x-1, y+1))
+			se := luma(src.At(x+1, y+1))
+			middle := src.At(x, y)
+			m := luma(middle)
+			lumaMin := math.Min(m, math.Min(math.Min(nw, ne), math.Min(sw, se)))
+			lumaMax := math.Max(m, math.Max(math.Max(nw, ne), math.Max(sw, se)))
+
+			dirX := -((nw + ne) - (sw + se))
+			dirY := (nw + sw) - (ne + se)
+			reduce := math.Max((nw+ne+sw+se)*0.25*fxaaReduceMul, fxaaReduceMin)
+			scale := 1 / (math.Min(math.Abs(dirX), math.Abs(dirY)) + reduce)
+			dirX = clamp(dirX*scale, -span, span)
+			dirY = clamp(dirY*scale, -span, span)
+
+			at := func(t float64) algebra.Vector {
+				return src.sample(px+dirX*t, py+dirY*t)
+			}
+			inner := at(1.0/3 - 0.5)
+			inner.AddV(at(2.0/3-0.5), &inner)
+			inner = inner.Scale(0.5)
+			outer := at(-0.5)
+			outer.AddV(at(0.5), &outer)
+			wide := inner.Scale(0.5)
+			wide.AddV(outer.Scale(0.25), &wide)
+
+			out := wide
+			if l := luma(wide); l < lumaMin || l > lumaMax {
+				out = inner
+			}
+			out.W = middle.W
+			f.Set(x, y, out)
+		}
+	}
+}
+
+func (a FXAA) passes() []postPass {
+	return []postPass{{shader: "PostFXAA.glsl", set: func(uniform func(string) gl.Int) error {
+		gl.Uniform1f(uniform("uSpanMax"), gl.Float(a.spanMax()))
+		return nil
+	}}}
+}
+
+func (a FXAA) spanMax() float64 {
+	if a.SpanMax <= 0 {
+		return 8
+	}
+	return a.SpanMax
+}
+
+// luma how bright a color looks
+func luma(c algebra.Vector) float64 {
+	return c.X*0.299 + c.Y*0.587 + c.Z*0.114
+}
+
+// Vignette darken the frame towards its edges
+type Vignette struct {
+	// Intensity how dark the edges get, 0 means 1 (all the way to Color)
+	Intensity float64
+	// Radius how far from the middle (0.5 is the middle of an edge) the
+	// darkening starts, 0 means 0.75
+	Radius float64
+	// Softness how far it takes to go dark, 0 means 0.45
+	Softness float64
+	// Color what the edges fade to, black when unset
+	Color algebra.Vector
+}
+
+// Apply darken the frame's edges
+func (v Vignette) Apply(f *Frame) {
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			u := (float64(x)+0.5)/float64(f.Width) - 0.5
+			w := (float64(y)+0.5)/float64(f.Height) - 0.5
+			c := f.At(x, y)
+			out := lerpVector(v.Color, c, v.amount(math.Sqrt(u*u+w*w)))
+			out.W = c.W
+			f.Set(x, y, out)
+		}
+	}
+}
+
+// amount how much of the frame is left at d from the middle
+func (v Vignette) amount(d float64) float64 {
+	radius, softness := v.radius(), v.softness()
+	return 1 - v.intensity()*(1-smoothstep(radius, radius-softness, d))
+}
+
+func (v Vignette) passes() []postPass {
+	return []postPass{{shader: "PostVignette.glsl", set: func(uniform func(string) gl.Int) error {
+		gl.Uniform3f(uniform("uVignette"), gl.Float(v.intensity()), gl.Float(v.radius()), gl.Float(v.softness()))
+		uniformVector(uniform("uVignetteColor"), v.Color)
+		return nil
+	}}}
+}
+
+func (v Vignette) intensity() float64 {
+	if v.Intensity == 0 {
+		return 1
+	}
+	return v.Intensity
+}
+
+func (v Vignette) radius() float64 {
+	if v.Radius == 0 {
+		return 0.75
+	}
+	return v.Radius
+}
+
+func (v Vignette) softness() float64 {
+	if v.Softness == 0 {
+		return 0.45
+	}
+	return v.Softness
+}
+
+// ColorGradeTextureUnit the texture unit ColorGrade's lookup table is
+// read from (uSource and uOriginal are on 0 and 1)
+const ColorGradeTextureUnit = 2
+
+// ColorGrade change the frame's colors with a lookup table: a strip of
+// square slices, one for each step of blue, red going across and green
+// going up each one. Grade NeutralLUT's image in a photo editor to make
+// one. The table expects colors the screen will show, so this goes last
+type ColorGrade struct {
+	LUT Texture
+	// Strength how much of the graded color is used, 0 means 1
+	Strength float64
+}
+
+// Apply grade the frame
+func (g ColorGrade) Apply(f *Frame) {
+	size := g.size()
+	if size < 2 {
+		return
+	}
+	strength := g.strength()
+	f.each(func(c algebra.Vector) algebra.Vector {
+		out := lerpVector(c, g.lookup(c, size), strength)
+		out.W = c.W
+		return out
+	})
+}
+
+// lookup the graded color, blending the two nearest slices like the
+// shader does
+func (g ColorGrade) lookup(c algebra.Vector, size int) algebra.Vector {
+	n := float64(size)
+	blue := clamp(c.Z, 0, 1) * (n - 1)
+	slice := math.Floor(blue)
+	at := func(slice float64) algebra.Vector {
+		return g.LUT.Sample(algebra.Vector{
+			X: (slice*n + clamp(c.X, 0, 1)*(n-1) + 0.5) / (n * n),
+			Y: (clamp(c.Y, 0, 1)*(n-1) + 0.5) / n,
+		})
+	}
+	return lerpVector(at(slice), at(math.Min(slice+1, n-1)), blue-slice)
+}
+
+func (g ColorGrade) passes() []postPass {
+	return []postPass{{shader: "PostColorGrade.glsl", set: func(uniform func(string) gl.Int) error {
+		if err := g.LUT.bind(ColorGradeTextureUnit, &whiteTexture); err != nil {
+			return err
+		}
+		gl.Uniform1i(uniform("uLUT"), ColorGradeTextureUnit)
+		gl.Uniform1f(uniform("uLUTSize"), gl.Float(g.size()))
+		strength := g.strength()
+		if g.size() < 2 {
+			strength = 0
+		}
+		gl.Uniform1f(uniform("uStrength"), gl.Float(strength))
+		return nil
+	}}}
+}
+
+// size how many steps each channel of the table has, its height
+func (g ColorGrade) size() int {
+	img := g.LUT.image()
+	if img == nil {
+		return 0
+	}
+	return img.Bounds().Dy()
+}
+
+func (g ColorGrade) strength() float64 {
+	if g.Strength == 0 {
+		return 1
+	}
+	return g.Strength
+}
+
+// NeutralLUT a lookup table for ColorGrade that changes nothing, size
+// steps a channel
+func NeutralLUT(size int) Texture {
+	img := image.NewNRGBA(image.Rect(0, 0, size*size, size))
+	step := 255 / float64(size-1)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				// Green goes up, image rows go down
+				img.SetNRGBA(b*size+r, size-1-g, color.NRGBA{
+					R: uint8(float64(r)*step + 0.5),
+					G: uint8(float64(g)*step + 0.5),
+					B: uint8(float64(b)*step + 0.5),
+					A: 255,
+				})
+			}
+		}
+	}
+	return NewTexture("neutral", img)
+}
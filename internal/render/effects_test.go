@@ -0,0 +1,152 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// mockFrame a frame filled with c
+func mockFrame(width, height int, c algebra.Vector) *render.Frame {
+	f := render.NewFrame(width, height)
+	for i := 0; i < len(f.Pix); i++ {
+		f.Pix[i] = c
+	}
+	return f
+}
+
+func TestTonemap(t *testing.T) {
+	bright := algebra.Vector{X: 0, Y: 0.5, Z: 8, W: 1}
+	for _, op := range []render.TonemapOperator{render.TonemapACES, render.TonemapReinhard} {
+		f := mockFrame(2, 2, bright)
+		render.Tonemap{Operator: op}.Apply(f)
+		c := f.At(1, 1)
+		if c.X != 0 || c.Z <= c.Y || c.Z > 1 || c.W != 1 {
+			t.Errorf("operator %v should squeeze light into 0..1 in order got %v", op, c)
+		}
+	}
+
+	f := mockFrame(1, 1, algebra.Vector{X: 1, Y: 1, Z: 1, W: 1})
+	render.Tonemap{Operator: render.TonemapReinhard, Exposure: 3}.Apply(f)
+	if c := f.At(0, 0); math.Abs(c.X-0.75) > algebra.Precision {
+		t.Errorf("exposure 3 should give 3 / 4 got %v", c)
+	}
+}
+
+func TestGamma(t *testing.T) {
+	f := mockFrame(1, 1, algebra.Vector{X: 0.25, Y: 1, W: 0.5})
+	render.Gamma{Gamma: 2}.Apply(f)
+	if c := f.At(0, 0); math.Abs(c.X-0.5) > algebra.Precision || c.Y != 1 || c.Z != 0 || c.W != 0.5 {
+		t.Errorf("gamma 2 should take the square root got %v", c)
+	}
+}
+
+func TestBloom(t *testing.T) {
+	// A bright dot in the middle of a grey frame
+	grey := algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5, W: 1}
+	f := mockFrame(32, 32, grey)
+	for y := 14; y < 18; y++ {
+		for x := 14; x < 18; x++ {
+			f.Set(x, y, algebra.Vector{X: 4, Y: 4, Z: 4, W: 1})
+		}
+	}
+	render.Bloom{}.Apply(f)
+
+	near, far := f.At(20, 16), f.At(0, 0)
+	if near.X <= grey.X {
+		t.Errorf("light should glow around the dot got %v", near)
+	}
+	if !far.AlmostEquals(&grey) {
+		t.Errorf("light under the threshold shouldn't glow got %v", far)
+	}
+	if middle := f.At(16, 16); middle.X <= 4 {
+		t.Errorf("the dot should get brighter got %v", middle)
+	}
+}
+
+func TestFXAA(t *testing.T) {
+	// A diagonal edge, black under white
+	f := render.NewFrame(16, 16)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			c := algebra.Vector{W: 1}
+			if x > y {
+				c = algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+			}
+			f.Set(x, y, c)
+		}
+	}
+	flat := mockFrame(16, 16, algebra.Vector{X: 0.3, Y: 0.6, Z: 0.9, W: 1})
+	before := flat.Copy()
+
+	render.FXAA{}.Apply(f)
+	render.FXAA{}.Apply(flat)
+	if c := f.At(8, 7); c.X >= 1 || c.X <= 0 {
+		t.Errorf("pixels along the edge should be blended got %v", c)
+	}
+	if c := f.At(12, 2); c.X != 1 {
+		t.Errorf("pixels away from the edge should be left alone got %v", c)
+	}
+	for i := 0; i < len(flat.Pix); i++ {
+		if !flat.Pix[i].AlmostEquals(&before.Pix[i]) {
+			t.Fatalf("a flat frame should be left alone got %v", flat.Pix[i])
+		}
+	}
+}
+
+func TestVignette(t *testing.T) {
+	white := algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	f := mockFrame(20, 20, white)
+	render.Vignette{Radius: 0.6, Softness: 0.3, Color: algebra.Vector{X: 1}}.Apply(f)
+	if c := f.At(10, 10); !c.AlmostEquals(&white) {
+		t.Errorf("the middle should be left alone got %v", c)
+	}
+	if c := f.At(0, 0); c.X != 1 || c.Y > 0.1 || c.W != 1 {
+		t.Errorf("the corners should fade to red got %v", c)
+	}
+}
+
+func TestColorGrade(t *testing.T) {
+	colors := []algebra.Vector{
+		{X: 0.2, Y: 0.4, Z: 0.6, W: 1},
+		{X: 1, Y: 0, Z: 0.5, W: 1},
+		{X: 0.9, Y: 0.9, Z: 0.1, W: 1},
+	}
+	f := render.NewFrame(len(colors), 1)
+	copy(f.Pix, colors)
+	render.ColorGrade{LUT: render.NeutralLUT(16)}.Apply(f)
+	for i := 0; i < len(colors); i++ {
+		d := algebra.Vector{}
+		f.Pix[i].SubV(colors[i], &d)
+		if d.Length() > 0.01 {
+			t.Errorf("a neutral table should change nothing, %v became %v", colors[i], f.Pix[i])
+		}
+	}
+
+	// A table with red and blue swapped, used half way
+	neutral := render.NeutralLUT(8)
+	src := neutral.Image.(*image.NRGBA)
+	swapped := image.NewNRGBA(src.Bounds())
+	for y := 0; y < src.Bounds().Dy(); y++ {
+		for x := 0; x < src.Bounds().Dx(); x++ {
+			c := src.NRGBAAt(x, y)
+			swapped.SetNRGBA(x, y, color.NRGBA{R: c.B, G: c.G, B: c.R, A: 255})
+		}
+	}
+	f = mockFrame(1, 1, algebra.Vector{X: 1, Z: 0, W: 1})
+	render.ColorGrade{LUT: render.NewTexture("swapped", swapped), Strength: 0.5}.Apply(f)
+	if c := f.At(0, 0); math.Abs(c.X-0.5) > 0.01 || math.Abs(c.Z-0.5) > 0.01 {
+		t.Errorf("red should be half way to blue got %v", c)
+	}
+
+	// Without a table nothing happens
+	f = mockFrame(1, 1, colors[0])
+	render.ColorGrade{}.Apply(f)
+	if c := f.At(0, 0); !c.AlmostEquals(&colors[0]) {
+		t.Errorf("no table should change nothing got %v", c)
+	}
+}
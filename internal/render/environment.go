@@ -0,0 +1,271 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Cube map faces in OpenGL's order
+const (
+	CubePositiveX = iota
+	CubeNegativeX
+	CubePositiveY
+	CubeNegativeY
+	CubePositiveZ
+	CubeNegativeZ
+)
+
+// Cubemap six square images around a point, like the inside of a box
+type Cubemap struct {
+	Name string
+	// Faces by CubePositiveX... Rows run top to bottom the way the
+	// images are usually drawn
+	Faces [6]Texture
+	// Handle the OpenGL texture, 0 until Upload
+	Handle gl.Uint
+}
+
+// NewCubemap create a cubemap from its six faces
+func NewCubemap(name string, faces [6]image.Image) *Cubemap {
+	c := &Cubemap{Name: name}
+	for i := 0; i < 6; i++ {
+		c.Faces[i] = NewTexture(name, faces[i])
+	}
+	return c
+}
+
+// LoadCubemap read six images from disk, in the order +X, -X, +Y, -Y,
+// +Z, -Z
+func LoadCubemap(paths [6]string) (*Cubemap, error) {
+	c := &Cubemap{Name: paths[0]}
+	for i := 0; i < 6; i++ {
+		t, err := LoadTexture(paths[i])
+		if err != nil {
+			return nil, err
+		}
+		c.Faces[i] = t
+	}
+	return c, c.check()
+}
+
+func (c *Cubemap) check() error {
+	size := c.Faces[0].Image.Bounds().Size()
+	for i := 0; i < 6; i++ {
+		s := c.Faces[i].Image.Bounds().Size()
+		if s.X != s.Y || s != size {
+			return fmt.Errorf("cubemap %q faces must be square and the same size", c.Name)
+		}
+	}
+	return nil
+}
+
+// Sample the color seen looking along dir
+func (c *Cubemap) Sample(dir algebra.Vector) algebra.Vector {
+	face, s, t := cubeFace(dir)
+	// Texture.Sample has V going up, cube maps go down
+	return c.Faces[face].Sample(algebra.Vector{X: s, Y: 1 - t})
+}
+
+// Levels how many mipmap levels there are past the first
+func (c *Cubemap) Levels() int {
+	size := c.Faces[0].Image.Bounds().Dx()
+	return int(math.Log2(float64(size)))
+}
+
+// cubeFace which face dir hits and where, the way OpenGL works it out
+func cubeFace(dir algebra.Vector) (face int, s, t float64) {
+	x, y, z := dir.X, dir.Y, dir.Z
+	ax, ay, az := math.Abs(x), math.Abs(y), math.Abs(z)
+	var sc, tc, ma float64
+	switch {
+	case ax >= ay && ax >= az:
+		ma = ax
+		if x >= 0 {
+			face, sc, tc = CubePositiveX, -z, -y
+		} else {
+			face, sc, tc = CubeNegativeX, z, -y
+		}
+	case ay >= az:
+		ma = ay
+		if y >= 0 {
+			face, sc, tc = CubePositiveY, x, z
+		} else {
+			face, sc, tc = CubeNegativeY, x, -z
+		}
+	default:
+		ma = az
+		if z >= 0 {
+			face, sc, tc = CubePositiveZ, x, -y
+		} else {
+			face, sc, tc = CubeNegativeZ, -x, -y
+		}
+	}
+	if ma == 0 {
+		return CubePositiveZ, 0.5, 0.5
+	}
+	return face, (sc/ma + 1) / 2, (tc/ma + 1) / 2
+}
+
+// cubeDirection the (unnormalized) direction through s, t on a face,
+// the inverse of cubeFace
+func cubeDirection(face int, s, t float64) algebra.Vector {
+	sc := 2*s - 1
+	tc := 2*t - 1
+	switch face {
+	case CubePositiveX:
+		return algebra.Vector{X: 1, Y: -tc, Z: -sc}
+	case CubeNegativeX:
+		return algebra.Vector{X: -1, Y: -tc, Z: sc}
+	case CubePositiveY:
+		return algebra.Vector{X: sc, Y: 1, Z: tc}
+	case CubeNegativeY:
+		return algebra.Vector{X: sc, Y: -1, Z: -tc}
+	case CubePositiveZ:
+		return algebra.Vector{X: sc, Y: -tc, Z: 1}
+	}
+	return algebra.Vector{X: -sc, Y: -tc, Z: -1}
+}
+
+// Upload send the faces to the GPU, with mipmaps for blurry
+// reflections
+func (c *Cubemap) Upload() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	if c.Handle == 0 {
+		gl.GenTextures(1, &c.Handle)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.Handle)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.GENERATE_MIPMAP, gl.TRUE)
+	for i := 0; i < 6; i++ {
+		img := c.Faces[i].Image
+		b := img.Bounds()
+		pix := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(pix, pix.Bounds(), img, b.Min, draw.Src)
+		gl.TexImage2D(gl.Enum(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gl.RGBA,
+			gl.Sizei(b.Dx()), gl.Sizei(b.Dy()), 0,
+			gl.RGBA, gl.UNSIGNED_BYTE, gl.Pointer(&pix.Pix[0]))
+	}
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		return fmt.Errorf("cubemap %q upload failed: %v", c.Name, err)
+	}
+	return nil
+}
+
+// Delete free the GPU copy of the cubemap
+func (c *Cubemap) Delete() {
+	if c.Handle != 0 {
+		gl.DeleteTextures(1, &c.Handle)
+		c.Handle = 0
+	}
+}
+
+// bind make the cubemap current on a texture unit, uploading it first
+// if needed
+func (c *Cubemap) bind(unit int) error {
+	if c.Handle == 0 {
+		if err := c.Upload(); err != nil {
+			return err
+		}
+	}
+	gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + unit))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, c.Handle)
+	return nil
+}
+
+// blackCubemap bound when there's no environment
+var blackCubemap = func() *Cubemap {
+	img := solidImage(color.NRGBA{A: 255})
+	return NewCubemap("black", [6]image.Image{img, img, img, img, img, img})
+}()
+
+// Environment image based ambient light. The cubemap is what shiny
+// surfaces reflect and Irradiance what rough ones are lit by
+type Environment struct {
+	Cubemap *Cubemap
+	// Irradiance the light reaching a surface facing each way, as the
+	// nine spherical harmonic terms Diffuse adds up
+	Irradiance [9]algebra.Vector
+}
+
+// NewEnvironment work out the irradiance of a cubemap
+func NewEnvironment(c *Cubemap) *Environment {
+	e := &Environment{Cubemap: c}
+	// Band weights of the cosine lobe (divided by pi so a plain white
+	// environment gives white)
+	bands := [9]float64{1, 2.0 / 3, 2.0 / 3, 2.0 / 3, 0.25, 0.25, 0.25, 0.25, 0.25}
+	for face := 0; face < 6; face++ {
+		img := c.Faces[face].Image
+		b := img.Bounds()
+		w, h := b.Dx(), b.Dy()
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				s := (float64(x) + 0.5) / float64(w)
+				t := (float64(y) + 0.5) / float64(h)
+				d := cubeDirection(face, s, t)
+				// Texels near the corners cover less of the sphere
+				l2 := d.X*d.X + d.Y*d.Y + d.Z*d.Z
+				weight := 4 / float64(w*h) / (l2 * math.Sqrt(l2))
+				n := algebra.Vector{}
+				d.Normalized(&n)
+				texel := c.Faces[face].Sample(algebra.Vector{X: s, Y: 1 - t})
+				terms := shTerms(n)
+				for i := 0; i < 9; i++ {
+					k := shConstants[i] * terms[i] * weight
+					e.Irradiance[i].X += texel.X * k
+					e.Irradiance[i].Y += texel.Y * k
+					e.Irradiance[i].Z += texel.Z * k
+				}
+			}
+		}
+	}
+	// Fold the basis constants in so Diffuse (and the shader) only need
+	// the plain polynomial
+	for i := 0; i < 9; i++ {
+		e.Irradiance[i] = e.Irradiance[i].Scale(shConstants[i] * bands[i])
+	}
+	return e
+}
+
+// shConstants the normalizing constants of the first nine real
+// spherical harmonics
+var shConstants = [9]float64{0.282095, 0.488603, 0.488603, 0.488603, 1.092548, 1.092548, 0.315392, 1.092548, 0.546274}
+
+// shTerms the polynomial part of each of the first nine real spherical
+// harmonics in direction n
+func shTerms(n algebra.Vector) [9]float64 {
+	x, y, z := n.X, n.Y, n.Z
+	return [9]float64{1, y, z, x, x * y, y * z, 3*z*z - 1, x * z, x*x - y*y}
+}
+
+// Diffuse the light reaching a surface facing n
+func (e *Environment) Diffuse(n algebra.Vector) algebra.Vector {
+	terms := shTerms(n)
+	out := algebra.Vector{}
+	for i := 0; i < 9; i++ {
+		out.X += e.Irradiance[i].X * terms[i]
+		out.Y += e.Irradiance[i].Y * terms[i]
+		out.Z += e.Irradiance[i].Z * terms[i]
+	}
+	out.X = math.Max(0, out.X)
+	out.Y = math.Max(0, out.Y)
+	out.Z = math.Max(0, out.Z)
+	return out
+}
+
+// Specular the light reflected along r by a surface as rough as
+// roughness. The rougher it is the more it looks like Diffuse
+func (e *Environment) Specular(r algebra.Vector, roughness float64) algebra.Vector {
+	return lerpVector(e.Cubemap.Sample(r), e.Diffuse(r), roughness)
+}
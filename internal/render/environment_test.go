@@ -0,0 +1,88 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// mockCubemap a cubemap with a plain color on each face
+func mockCubemap(size int, colors [6]algebra.Vector) *render.Cubemap {
+	faces := [6]image.Image{}
+	for i := 0; i < 6; i++ {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		c := color.NRGBA{
+			R: uint8(colors[i].X * 255),
+			G: uint8(colors[i].Y * 255),
+			B: uint8(colors[i].Z * 255),
+			A: 255,
+		}
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		faces[i] = img
+	}
+	return render.NewCubemap("test", faces)
+}
+
+func TestCubemapSample(t *testing.T) {
+	c := mockCubemap(4, [6]algebra.Vector{
+		{X: 1}, {Y: 1}, {Z: 1}, {X: 1, Y: 1}, {Y: 1, Z: 1}, {X: 1, Z: 1},
+	})
+	dirs := [6]algebra.Vector{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}}
+	for i := 0; i < 6; i++ {
+		got := c.Sample(dirs[i])
+		want := c.Faces[i].Sample(algebra.Vector{X: 0.5, Y: 0.5})
+		if !got.AlmostEquals(&want) {
+			t.Errorf("looking along %v should see face %v got %v", dirs[i], i, got)
+		}
+	}
+	if c.Levels() != 2 {
+		t.Errorf("a 4 pixel cubemap has 2 more mipmap levels got %v", c.Levels())
+	}
+}
+
+func TestEnvironmentDiffuse(t *testing.T) {
+	grey := algebra.Vector{X: 0.6, Y: 0.6, Z: 0.6}
+	even := render.NewEnvironment(mockCubemap(16, [6]algebra.Vector{grey, grey, grey, grey, grey, grey}))
+	dirs := []algebra.Vector{{X: 1}, {Y: -1}, {X: 0.6, Z: 0.8}}
+	for i := 0; i < len(dirs); i++ {
+		d := even.Diffuse(dirs[i])
+		if math.Abs(d.X-0.6) > 0.01 {
+			t.Errorf("an even environment should light %v the same got %v", dirs[i], d)
+		}
+	}
+
+	// Only the sky is bright
+	black := algebra.Vector{}
+	sky := render.NewEnvironment(mockCubemap(16, [6]algebra.Vector{
+		black, black, {X: 1, Y: 1, Z: 1}, black, black, black,
+	}))
+	up := sky.Diffuse(algebra.Vector{Y: 1})
+	down := sky.Diffuse(algebra.Vector{Y: -1})
+	if up.X < 0.5 || down.X > 0.1 {
+		t.Errorf("facing the sky %v should be much brighter than facing the ground %v", up, down)
+	}
+}
+
+func TestGatherEnvironment(t *testing.T) {
+	env := render.NewEnvironment(mockCubemap(1, [6]algebra.Vector{}))
+	s := &core.Scene{}
+	s.Add(mockLightEntity(render.NewComponentEnvironmentLight(env, 0.5), algebra.Vector{}))
+	s.Add(mockLightEntity(render.NewComponentAmbientLight(algebra.Vector{X: 1}, 0.2), algebra.Vector{}))
+
+	l := render.GatherLights(s)
+	if l.Environment != env || l.EnvironmentColor.X != 0.5 {
+		t.Errorf("environment not gathered %v %v", l.Environment, l.EnvironmentColor)
+	}
+	if l.Ambient.X != 0.2 {
+		t.Errorf("environment should not add to the plain ambient %v", l.Ambient)
+	}
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// MaxLights most lights (not counting ambient ones) used in a frame
+const MaxLights = 8
+
+// DefaultShininess specular exponent for materials that don't set one
+const DefaultShininess = 32
+
+// Light a light in world space, ready for shading
+type Light struct {
+	Type      LightType
+	Position  algebra.Vector
+	Direction algebra.Vector
+	// Color the light's color times its intensity
+	Color algebra.Vector
+	Range float64
+	// CosInner, CosOuter the cosines of the spot light cone angles
+	CosInner float64
+	CosOuter float64
+	// Shadow the light's shadow map, nil when it doesn't cast shadows
+	Shadow *ShadowMap
+}
+
+// Lighting everything lighting a frame
+type Lighting struct {
+	Ambient algebra.Vector
+	Lights  []Light
+	// Eye the camera's position, for highlights
+	Eye algebra.Vector
+	// Forward the way the camera looks, to pick shadow cascades by
+	Forward algebra.Vector
+	// shadowEye where the camera the shadow cascades were fitted to is,
+	// which stays put when Eye moves to draw a render target
+	shadowEye algebra.Vector
+	// Environment image based ambient light for PBR materials, nil for
+	// none
+	Environment *Environment
+	// EnvironmentColor what the environment is multiplied by
+	EnvironmentColor algebra.Vector
+}
+
+// GatherLights collect the lights in a scene. Ambient lights are added
+// together, only the first environment light is used, and past
+// MaxLights the rest are ignored. Shadow maps are placed for the active
+// camera but not drawn
+func GatherLights(s *core.Scene) Lighting {
+	out := Lighting{Forward: algebra.Vector{Z: -1}}
+	if s.ActiveCamera != nil && s.ActiveCamera.Transform != nil {
+		out.Eye = s.ActiveCamera.Transform.Position
+		out.Eye.W = 0
+		out.shadowEye = out.Eye
+		forward := algebra.Vector{}
+		s.ActiveCamera.Transform.GetTransformation().Transform(algebra.Vector{Z: -1}, &forward)
+		forward.Normalized(&out.Forward)
+		out.Forward.W = 0
+	}
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		gatherTree(entities[i], &out)
+	}
+	camera, _ := activeCamera(s)
+	out.fitShadows(camera)
+	return out
+}
+
+func gatherTree(e *core.Entity, out *Lighting) {
+	// An entity can have more than one light, e.g. a sun and the sky
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		if l, ok := components[i].(*ComponentLight); ok {
+			out.add(e, l)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		gatherTree(children[i], out)
+	}
+}
+
+func (out *Lighting) add(e *core.Entity, l *ComponentLight) {
+	color := l.Color.Scale(l.Intensity)
+	color.W = 0
+	if l.Type == LightAmbient && l.Environment != nil {
+		if out.Environment == nil {
+			out.Environment = l.Environment
+			out.EnvironmentColor = color
+		}
+		return
+	}
+	if l.Type == LightAmbient {
+		out.Ambient.AddV(color, &out.Ambient)
+		return
+	}
+	if len(out.Lights) >= MaxLights {
+		return
+	}
+
+	light := Light{
+		Type:      l.Type,
+		Color:     color,
+		Range:     l.Range,
+		Direction: algebra.Vector{Z: -1},
+		CosInner:  math.Cos(algebra.DegToRad(l.InnerAngle)),
+		CosOuter:  math.Cos(algebra.DegToRad(l.OuterAngle)),
+	}
+	if e.Transform != nil {
+		light.Position = e.Transform.Position
+		light.Position.W = 0
+		forward := algebra.Vector{}
+		e.Transform.GetTransformation().Transform(algebra.Vector{Z: -1}, &forward)
+		forward.Normalized(&light.Direction)
+		light.Direction.W = 0
+	}
+	if l.CastShadows && (l.Type == LightDirectional || l.Type == LightSpot) && out.shadows() < MaxShadows {
+		light.Shadow = l.shadowMap()
+	}
+	out.Lights = append(out.Lights, light)
+}
+
+// Shade the color of a surface point, the same Blinn-Phong model as the
+// Lit shader. The surface's Illumination picks what's used:
+// IllumColorOnAmbientOff is unlit, IllumColorOnAmbientOn ambient and
+// diffuse, and IllumHighlightOn (and above) adds specular highlights.
+// IllumCastsShadows surfaces are invisible apart from the shadows that
+// fall on them, which are black. Alpha comes back in W
+func Shade(s *Surface, pos algebra.Vector, normal algebra.Vector, lighting *Lighting) algebra.Vector {
+	if s.Illumination == IllumColorOnAmbientOff || lighting == nil {
+		out := s.Diffuse
+		out.W = s.Alpha
+		return out
+	}
+	if s.Illumination == IllumCastsShadows {
+		return algebra.Vector{W: s.Alpha * lighting.shadowed(pos, normal)}
+	}
+	n := algebra.Vector{}
+	normal.Normalized(&n)
+	view := algebra.Vector{}
+	toEye := algebra.Vector{}
+	lighting.Eye.SubV(pos, &toEye)
+	toEye.Normalized(&view)
+
+	highlight := s.Illumination >= IllumHighlightOn
+	depth := lighting.viewDepth(pos)
+
+	out := algebra.Vector{
+		X: s.Ambient.X * lighting.Ambient.X,
+		Y: s.Ambient.Y * lighting.Ambient.Y,
+		Z: s.Ambient.Z * lighting.Ambient.Z,
+	}
+	for i := 0; i < len(lighting.Lights); i++ {
+		l := &lighting.Lights[i]
+		dir, strength := l.incoming(pos)
+		nDotL := n.Dot(dir)
+		if strength <= 0 || nDotL <= 0 {
+			continue
+		}
+		strength *= l.visibility(pos, n, depth)
+		diffuse := nDotL * strength
+		out.X += s.Diffuse.X * l.Color.X * diffuse
+		out.Y += s.Diffuse.Y * l.Color.Y * diffuse
+		out.Z += s.Diffuse.Z * l.Color.Z * diffuse
+
+		if highlight {
+			half := algebra.Vector{}
+			sum := algebra.Vector{}
+			dir.AddV(view, &sum)
+			sum.Normalized(&half)
+			spec := math.Pow(math.Max(n.Dot(half), 0), s.Shininess) * strength
+			out.X += s.Specular.X * l.Color.X * spec
+			out.Y += s.Specular.Y * l.Color.Y * spec
+			out.Z += s.Specular.Z * l.Color.Z * spec
+		}
+	}
+	out.W = s.Alpha
+	return out
+}
+
+// incoming the direction from pos towards the light, and how much of the
+// light reaches pos (range and cone falloff)
+func (l *Light) incoming(pos algebra.Vector) (algebra.Vector, float64) {
+	dir := algebra.Vector{}
+	if l.Type == LightDirectional {
+		l.Direction.Negate(&dir)
+		dir.W = 0
+		return dir, 1
+	}
+
+	toLight := algebra.Vector{}
+	l.Position.SubV(pos, &toLight)
+	distance := toLight.Length()
+	toLight.Normalized(&dir)
+
+	strength := 1.0
+	if l.Range > 0 {
+		f := math.Max(0, 1-distance/l.Range)
+		strength = f * f
+	}
+	if l.Type == LightSpot {
+		cos := -dir.Dot(l.Direction)
+		strength *= smoothstep(l.CosOuter, l.CosInner, cos)
+	}
+	return dir, strength
+}
+
+// tint what the material multiplies vertex colors by. A material
+// without a DiffuseColor leaves them as they are
+func (m *Material) tint() algebra.Vector {
+	if isZero(m.DiffuseColor) {
+		return algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	}
+	return m.DiffuseColor
+}
+
+func smoothstep(edge0, edge1, x float64) float64 {
+	if edge0 == edge1 {
+		if x < edge0 {
+			return 0
+		}
+		return 1
+	}
+	t := math.Max(0, math.Min(1, (x-edge0)/(edge1-edge0)))
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,150 @@
+package render_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func mockLightEntity(l render.ComponentLight, pos algebra.Vector) *core.Entity {
+	e := &core.Entity{Transform: core.NewTransform()}
+	e.Transform.Position = pos
+	e.Attach(&l)
+	return e
+}
+
+func TestGatherLights(t *testing.T) {
+	s := &core.Scene{}
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	s.Add(mockLightEntity(render.NewComponentAmbientLight(white, 0.1), algebra.Vector{}))
+	s.Add(mockLightEntity(render.NewComponentAmbientLight(white, 0.2), algebra.Vector{}))
+	parent := mockLightEntity(render.NewComponentPointLight(white, 2, 10), algebra.Vector{Y: 3})
+	parent.Add(mockLightEntity(render.NewComponentSpotLight(white, 1, 5, 10, 20), algebra.Vector{}))
+	s.Add(parent)
+	for i := 0; i < render.MaxLights; i++ {
+		s.Add(mockLightEntity(render.NewComponentDirectionalLight(white, 1), algebra.Vector{}))
+	}
+
+	l := render.GatherLights(s)
+	if math.Abs(l.Ambient.X-0.3) > 1e-9 {
+		t.Errorf("ambient lights should add up to 0.3 got %v", l.Ambient)
+	}
+	if len(l.Lights) != render.MaxLights {
+		t.Fatalf("expected %v lights got %v", render.MaxLights, len(l.Lights))
+	}
+	point := l.Lights[0]
+	if point.Type != render.LightPoint || point.Position.Y != 3 || point.Color.X != 2 {
+		t.Errorf("point light %+v", point)
+	}
+	spot := l.Lights[1]
+	expected := algebra.Vector{Z: -1}
+	if spot.Type != render.LightSpot || !spot.Direction.AlmostEquals(&expected) {
+		t.Errorf("spot light should point down -Z %+v", spot)
+	}
+	if math.Abs(spot.CosOuter-math.Cos(algebra.DegToRad(20))) > 1e-9 {
+		t.Errorf("spot cone %v", spot.CosOuter)
+	}
+}
+
+// shade a point on m colored albedo
+func shade(m *render.Material, albedo, pos, normal algebra.Vector, lighting *render.Lighting) algebra.Vector {
+	s := m.SurfaceAt(albedo, algebra.Vector{})
+	return render.Shade(&s, pos, normal, lighting)
+}
+
+func TestShade(t *testing.T) {
+	albedo := algebra.Vector{X: 1, Y: 0.5, Z: 0.25, W: 1}
+	up := algebra.Vector{Y: 1}
+	sun := render.Light{Type: render.LightDirectional, Direction: algebra.Vector{Y: -1}, Color: algebra.Vector{X: 1, Y: 1, Z: 1}}
+	lighting := &render.Lighting{
+		Ambient: algebra.Vector{X: 0.1, Y: 0.1, Z: 0.1},
+		Lights:  []render.Light{sun},
+		Eye:     algebra.Vector{Y: 10},
+	}
+
+	unlit := render.Material{Illumination: render.IllumColorOnAmbientOff}
+	if c := shade(&unlit, albedo, algebra.Vector{}, up, lighting); c != albedo {
+		t.Errorf("unlit should be the albedo got %v", c)
+	}
+
+	diffuse := render.Material{Illumination: render.IllumColorOnAmbientOn}
+	c := shade(&diffuse, albedo, algebra.Vector{}, up, lighting)
+	expected := algebra.Vector{X: 1.1, Y: 0.55, Z: 0.275, W: 1}
+	if !c.AlmostEquals(&expected) {
+		t.Errorf("ambient + diffuse %v should be %v", c, expected)
+	}
+
+	// Facing away only gets ambient
+	down := algebra.Vector{Y: -1}
+	c = shade(&diffuse, albedo, algebra.Vector{}, down, lighting)
+	expected = algebra.Vector{X: 0.1, Y: 0.05, Z: 0.025, W: 1}
+	if !c.AlmostEquals(&expected) {
+		t.Errorf("facing away %v should be %v", c, expected)
+	}
+
+	shiny := render.Material{
+		Illumination:  render.IllumHighlightOn,
+		SpecularColor: algebra.Vector{X: 1, Y: 1, Z: 1},
+	}
+	c = shade(&shiny, albedo, algebra.Vector{}, up, lighting)
+	// Light, eye and normal all line up so the highlight is full
+	expected = algebra.Vector{X: 2.1, Y: 1.55, Z: 1.275, W: 1}
+	if !c.AlmostEquals(&expected) {
+		t.Errorf("with highlight %v should be %v", c, expected)
+	}
+}
+
+func TestShadeFalloff(t *testing.T) {
+	albedo := algebra.Vector{X: 1, Y: 1, Z: 1}
+	up := algebra.Vector{Y: 1}
+	m := render.Material{Illumination: render.IllumColorOnAmbientOn}
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+
+	point := render.Light{Type: render.LightPoint, Position: algebra.Vector{Y: 5}, Color: white, Range: 10}
+	lighting := &render.Lighting{Lights: []render.Light{point}}
+	near := shade(&m, albedo, algebra.Vector{}, up, lighting)
+	if math.Abs(near.X-0.25) > 1e-9 {
+		t.Errorf("half way to the range should be a quarter as bright got %v", near.X)
+	}
+	lighting.Lights[0].Position.Y = 20
+	if far := shade(&m, albedo, algebra.Vector{}, up, lighting); far.X != 0 {
+		t.Errorf("out of range should be dark got %v", far.X)
+	}
+
+	spot := render.Light{
+		Type:      render.LightSpot,
+		Position:  algebra.Vector{Y: 5},
+		Direction: algebra.Vector{Y: -1},
+		Color:     white,
+		CosInner:  math.Cos(algebra.DegToRad(10)),
+		CosOuter:  math.Cos(algebra.DegToRad(20)),
+	}
+	lighting = &render.Lighting{Lights: []render.Light{spot}}
+	if c := shade(&m, albedo, algebra.Vector{}, up, lighting); math.Abs(c.X-1) > 1e-9 {
+		t.Errorf("inside the cone should be fully lit got %v", c.X)
+	}
+	// 45 degrees off the center line
+	if c := shade(&m, albedo, algebra.Vector{X: 5}, up, lighting); c.X != 0 {
+		t.Errorf("outside the cone should be dark got %v", c.X)
+	}
+}
+
+func TestSoftwareLit(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	quad := mockQuad(-2, algebra.Vector{X: 1, Y: 1, Z: 1})
+	for i := 0; i < len(quad.Poly.Vertices); i++ {
+		quad.Poly.Vertices[i].Normal = algebra.Vector{Z: 1}
+	}
+	m := render.Material{Illumination: render.IllumColorOnAmbientOn, DiffuseColor: algebra.Vector{X: 1}}
+	lighting := &render.Lighting{
+		Lights: []render.Light{{Type: render.LightDirectional, Direction: algebra.Vector{Z: -1}, Color: algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5}}},
+	}
+	r.DrawShaded(&quad, &m, world, view, proj, lighting)
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 128, A: 255}) {
+		t.Errorf("lit quad should be half red got %v", c)
+	}
+}
@@ -1,6 +1,11 @@
 package render
 
-import "github.com/robrohan/mesh/internal/algebra"
+import (
+	"math"
+	"path/filepath"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
 
 const (
 	// IllumColorOnAmbientOff Color on and Ambient off
@@ -27,52 +32,227 @@ const (
 	IllumCastsShadows
 )
 
-// Material a skin for an entity
+// Material a skin for an entity. The fields follow the Wavefront MTL
+// statements they're read from (see LoadMTL). Zero colors and weights
+// are treated as unset so a Material{} still draws sensibly
 type Material struct {
 	Name string
-	// AmbientColor 'Ka' blender does not have per-object ambient color.
-	// AmbientColor algebra.Vector
+	// AmbientColor 'Ka' how much ambient light is reflected, unset
+	// follows the diffuse color (blender does not have per-object
+	// ambient color)
+	AmbientColor algebra.Vector
 	// DiffuseColor 'Kd' is the most instinctive meaning of the color of an object.
 	DiffuseColor algebra.Vector
 	// SpecularColor 'Ks' the color of highlights on a shiny surface
-	// SpecularColor algebra.Vector
-	// SpecularColorWeight 'Ns'
-	// SpecularColorWeight float32
-	// Transparent 'd' 1=opaque (or Tr - inverted d)
+	SpecularColor algebra.Vector
+	// SpecularColorWeight 'Ns' how tight the highlights are (shininess),
+	// 0 uses DefaultShininess
+	SpecularColorWeight float32
+	// Transparent 'd' 1=opaque (or Tr - inverted d), 0 (unset) is also
+	// opaque
 	Transparent float32
 	// Illumination specifies the illumination model to use in the material.
 	Illumination uint8
 
-	// Textures
-	// AmbientTextureName string
+	// Textures, each one multiplies the value it's named after. Missing
+	// ones change nothing
+	AmbientTextureName string
 	// AmbientTexture map_Ka
-	// AmbientTexture Texture
+	AmbientTexture Texture
 
 	DiffuseTextureName string
 	// DiffuseTexture map_Kd
 	DiffuseTexture Texture
 
-	// SpecularColorTextureName string
+	SpecularColorTextureName string
 	// SpecularColorTexture map_Ks
-	// SpecularColorTexture Texture
+	SpecularColorTexture Texture
 
-	// SpecularHighlightTextureName string
-	// SpecularHighlightTexture map_Ns
-	// SpecularHighlightTexture Texture
+	SpecularHighlightTextureName string
+	// SpecularHighlightTexture map_Ns, scales SpecularColorWeight
+	SpecularHighlightTexture Texture
 
-	// AlphaTextureName string
-	// AlphaTexture map_d
-	// AlphaTexture Texture
+	AlphaTextureName string
+	// AlphaTexture map_d, scales Transparent
+	AlphaTexture Texture
 
-	// BumpTextureName string
-	// BumpTexture map_bump or bump
-	// BumpTexture Texture
+	BumpTextureName string
+	// BumpTexture map_bump or bump, a tangent space normal map (height
+	// maps are turned into one when loaded)
+	BumpTexture Texture
+	// BumpMultiplier '-bm' how strong the bumps are, 0 means 1
+	BumpMultiplier float32
 
 	// TextureOrigin the origin of the texture
 	TextureOrigin algebra.Vector
 	// TextureOrigin the scale of the nexture
 	TextureScale algebra.Vector
 
+	// PBR the physically based variant of the material, drawn by the PBR
+	// shader. nil uses ToPBR when it's needed
+	PBR *PBRMaterial
+
 	// The shader will operate on the textures
 	Shader Shader
 }
+
+// Texture units the Lit shader reads each map from
+const (
+	AmbientTextureUnit = iota
+	DiffuseTextureUnit
+	SpecularColorTextureUnit
+	SpecularHighlightTextureUnit
+	AlphaTextureUnit
+	BumpTextureUnit
+	textureUnits
+)
+
+// Surface what a material looks like at one point once its textures
+// are applied
+type Surface struct {
+	Illumination uint8
+	Ambient      algebra.Vector
+	Diffuse      algebra.Vector
+	Specular     algebra.Vector
+	Shininess    float64
+	Alpha        float64
+}
+
+// SurfaceAt the surface at texture coordinate uv of a vertex colored
+// color
+func (m *Material) SurfaceAt(color algebra.Vector, uv algebra.Vector) Surface {
+	uv = m.textureCoord(uv)
+	tint := m.tint()
+
+	s := Surface{Illumination: m.Illumination}
+	color.MulV(tint, &s.Diffuse)
+	s.Diffuse.MulV(m.DiffuseTexture.Sample(uv), &s.Diffuse)
+
+	ambient := m.AmbientColor
+	if isZero(ambient) {
+		ambient = tint
+	}
+	color.MulV(ambient, &s.Ambient)
+	s.Ambient.MulV(m.AmbientTexture.Sample(uv), &s.Ambient)
+
+	m.SpecularColor.MulV(m.SpecularColorTexture.Sample(uv), &s.Specular)
+
+	s.Shininess = math.Max(1, m.shininess()*m.SpecularHighlightTexture.Sample(uv).X)
+	s.Alpha = m.opacity() * m.AlphaTexture.Sample(uv).X
+	return s
+}
+
+// textures the material's maps by texture unit, with the name each is
+// loaded from
+func (m *Material) textures() ([textureUnits]*Texture, [textureUnits]string) {
+	return [textureUnits]*Texture{
+		&m.AmbientTexture,
+		&m.DiffuseTexture,
+		&m.SpecularColorTexture,
+		&m.SpecularHighlightTexture,
+		&m.AlphaTexture,
+		&m.BumpTexture,
+	}, [textureUnits]string{
+		m.AmbientTextureName,
+		m.DiffuseTextureName,
+		m.SpecularColorTextureName,
+		m.SpecularHighlightTextureName,
+		m.AlphaTextureName,
+		m.BumpTextureName,
+	}
+}
+
+// LoadTextures read the material's named textures (and its PBR
+// variant's) that haven't been loaded yet, relative to dir
+func (m *Material) LoadTextures(dir string) error {
+	textures, names := m.textures()
+	for i := 0; i < textureUnits; i++ {
+		if names[i] == "" || textures[i].Image != nil {
+			continue
+		}
+		t, err := loadMap(dir, names[i])
+		if err != nil {
+			return err
+		}
+		switch i {
+		case SpecularHighlightTextureUnit:
+			t.Image = scalarImage(t.Image, false)
+		case AlphaTextureUnit:
+			t.Image = scalarImage(t.Image, true)
+		case BumpTextureUnit:
+			if isGray(t.Image) {
+				t.Image = heightToNormal(t.Image)
+			}
+		}
+		*textures[i] = t
+	}
+	if m.PBR == nil {
+		return nil
+	}
+	// Maps the PBR variant shares don't need reading twice
+	shared, sharedNames := m.PBR.textures()
+	for i := 0; i < pbrTextureUnits; i++ {
+		for j := 0; j < textureUnits; j++ {
+			if shared[i].Image == nil && sharedNames[i] != "" && sharedNames[i] == names[j] {
+				*shared[i] = *textures[j]
+			}
+		}
+	}
+	return m.PBR.LoadTextures(dir)
+}
+
+// loadMap read a texture named in a material, relative to dir
+func loadMap(dir string, name string) (Texture, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	t, err := LoadTexture(path)
+	t.Name = name
+	return t, err
+}
+
+// textureCoord apply TextureOrigin and TextureScale to uv
+func (m *Material) textureCoord(uv algebra.Vector) algebra.Vector {
+	scale := m.textureScale()
+	return algebra.Vector{
+		X: uv.X*scale.X + m.TextureOrigin.X,
+		Y: uv.Y*scale.Y + m.TextureOrigin.Y,
+	}
+}
+
+func (m *Material) textureScale() algebra.Vector {
+	scale := m.TextureScale
+	if scale.X == 0 {
+		scale.X = 1
+	}
+	if scale.Y == 0 {
+		scale.Y = 1
+	}
+	return scale
+}
+
+func (m *Material) shininess() float64 {
+	if m.SpecularColorWeight <= 0 {
+		return DefaultShininess
+	}
+	return float64(m.SpecularColorWeight)
+}
+
+func (m *Material) opacity() float64 {
+	if m.Transparent <= 0 {
+		return 1
+	}
+	return float64(m.Transparent)
+}
+
+func (m *Material) bumpMultiplier() float64 {
+	if m.BumpMultiplier == 0 {
+		return 1
+	}
+	return float64(m.BumpMultiplier)
+}
+
+func isZero(v algebra.Vector) bool {
+	return v.X == 0 && v.Y == 0 && v.Z == 0
+}
@@ -0,0 +1,53 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func TestSurfaceDefaults(t *testing.T) {
+	m := render.Material{}
+	color := algebra.Vector{X: 0.5, Y: 1, Z: 0.25}
+	s := m.SurfaceAt(color, algebra.Vector{})
+	if s.Diffuse != color || s.Ambient != color {
+		t.Errorf("unset colors should leave the vertex color %v %v", s.Diffuse, s.Ambient)
+	}
+	if s.Alpha != 1 || s.Shininess != render.DefaultShininess {
+		t.Errorf("unset should be opaque with the default shininess %v %v", s.Alpha, s.Shininess)
+	}
+}
+
+func TestSurfaceMaps(t *testing.T) {
+	m := render.Material{
+		AmbientColor:         algebra.Vector{X: 0.2, Y: 0.2, Z: 0.2},
+		DiffuseColor:         algebra.Vector{X: 1, Y: 0.5, Z: 1},
+		SpecularColor:        algebra.Vector{X: 1, Y: 1, Z: 1},
+		SpecularColorWeight:  64,
+		Transparent:          0.5,
+		DiffuseTexture:       mockTexture(),
+		SpecularColorTexture: mockTexture(),
+		// Move along half a texture, top left becomes top right
+		TextureOrigin: algebra.Vector{X: 0.5},
+	}
+	white := algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	s := m.SurfaceAt(white, algebra.Vector{X: 0.25, Y: 0.75})
+
+	// Top right is green
+	expected := algebra.Vector{Y: 0.5, W: 1}
+	if !s.Diffuse.AlmostEquals(&expected) {
+		t.Errorf("diffuse %v should be %v", s.Diffuse, expected)
+	}
+	expected = algebra.Vector{Y: 1}
+	if !s.Specular.AlmostEquals(&expected) {
+		t.Errorf("specular %v should be %v", s.Specular, expected)
+	}
+	expected = algebra.Vector{X: 0.2, Y: 0.2, Z: 0.2}
+	if !s.Ambient.AlmostEquals(&expected) {
+		t.Errorf("ambient %v should be Ka %v", s.Ambient, expected)
+	}
+	if s.Alpha != 0.5 || s.Shininess != 64 {
+		t.Errorf("alpha %v shininess %v", s.Alpha, s.Shininess)
+	}
+}
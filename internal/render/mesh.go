@@ -33,10 +33,14 @@ type Mesh struct {
 
 // CreateMesh send a polygon to the GPU
 func CreateMesh(p geometry.Polyhedron) Mesh {
-	indexLen := len(p.GetIndices())
+	return CreateMeshFromBuffers(p, VertexBuffer(p), IndexBuffer(p))
+}
 
-	verts := VertexBuffer(p)
-	index := IndexBuffer(p)
+// CreateMeshFromBuffers send buffers already in the VertexBuffer layout
+// to the GPU, skipping the conversion from p (e.g. when they were read
+// straight from a file). p is kept for anything that needs the geometry
+func CreateMeshFromBuffers(p geometry.Polyhedron, verts []float32, index []uint16) Mesh {
+	indexLen := len(index)
 
 	var vertexBuffer gl.Uint
 	gl.GenBuffers(1, &vertexBuffer)
@@ -100,6 +104,16 @@ func VertexBuffer(p geometry.Polyhedron) []float32 {
 		buffer[row+11] = float32(vertices[i].Tangent.X)
 		buffer[row+12] = float32(vertices[i].Tangent.Y)
 		buffer[row+13] = float32(vertices[i].Tangent.Z)
+
+		buffer[row+14] = float32(vertices[i].Joints.X)
+		buffer[row+15] = float32(vertices[i].Joints.Y)
+		buffer[row+16] = float32(vertices[i].Joints.Z)
+		buffer[row+17] = float32(vertices[i].Joints.W)
+
+		buffer[row+18] = float32(vertices[i].Weights.X)
+		buffer[row+19] = float32(vertices[i].Weights.Y)
+		buffer[row+20] = float32(vertices[i].Weights.Z)
+		buffer[row+21] = float32(vertices[i].Weights.W)
 	}
 	return buffer
 }
@@ -36,12 +36,13 @@ func makePolygon() geometry.Polyhedron {
 func TestVertexBuffer(t *testing.T) {
 	p := makePolygon()
 
+	p.Vertices[1].Joints = algebra.Vector{X: 1, Y: 2}
+	p.Vertices[1].Weights = algebra.Vector{X: 0.75, Y: 0.25}
+
 	expected := []float32{
-		1, 2, 3, 0.3, 0.3, 0.3, 0, 0, 0,
-		0, 0, 0, 0, 0, 4, 5, 6, 0.4, 0.4,
-		0.4, 0, 0, 0, 0, 0, 0, 0, 0, 7, 8,
-		9, 0.5, 0.5, 0.5, 0, 0, 0, 0, 0, 0,
-		0, 0}
+		1, 2, 3, 0.3, 0.3, 0.3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		4, 5, 6, 0.4, 0.4, 0.4, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 0, 0, 0.75, 0.25, 0, 0,
+		7, 8, 9, 0.5, 0.5, 0.5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
 
 	actual := render.VertexBuffer(p)
 
@@ -0,0 +1,73 @@
+package render
+
+import (
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// morphWeights the morph target weights of the entity's morph component,
+// nil when it doesn't have one
+func morphWeights(e *core.Entity) []float64 {
+	if m, ok := e.GetComponent(core.ComponentTypeMorph).(core.Morphed); ok {
+		return m.MorphWeights()
+	}
+	return nil
+}
+
+// morphedMesh the mesh blended toward its morph targets by the entity's
+// weights, or the mesh itself when they don't move it
+func morphedMesh(e *core.Entity, mesh *Mesh) *Mesh {
+	weights := morphWeights(e)
+	if !mesh.Poly.Morphed(weights) {
+		return mesh
+	}
+	out := *mesh
+	out.Poly = geometry.Polyhedron{}
+	mesh.Poly.Morph(weights, &out.Poly)
+	return &out
+}
+
+// sameWeights whether two sets of morph weights put the mesh in the same
+// shape, missing weights being 0
+func sameWeights(a, b []float64) bool {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := 0.0, 0.0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// morphUploads the morph weights each vertex buffer was last filled with.
+// Copies of a mesh (one per entity, from Assets or prefabs) share the
+// buffer, so this can't live on the Mesh. A buffer not in here holds the
+// mesh as modeled
+var morphUploads = map[gl.Uint][]float64{}
+
+// uploadMorphGl refill the mesh's vertex buffer blended toward its morph
+// targets, when the entity's weights aren't what the buffer was last
+// filled with (by any entity drawing the same mesh). Skinning on the GPU
+// then starts from the blended shape
+func uploadMorphGl(e *core.Entity, mesh *Mesh) {
+	if len(mesh.Poly.Targets) == 0 || len(mesh.Poly.Vertices) == 0 {
+		return
+	}
+	weights := morphWeights(e)
+	vbo := mesh.Resource.Vbo
+	if sameWeights(weights, morphUploads[vbo]) {
+		return
+	}
+	verts := VertexBuffer(morphedMesh(e, mesh).Poly)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, gl.Sizeiptr(len(verts)*SizeOfFloat), gl.Pointer(&verts[0]))
+	morphUploads[vbo] = append(morphUploads[vbo][:0], weights...)
+}
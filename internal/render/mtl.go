@@ -0,0 +1,284 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// mtlOptionArgs how many arguments each texture map option takes. -o,
+// -s and -t take one to three
+var mtlOptionArgs = map[string]int{
+	"-blendu":  1,
+	"-blendv":  1,
+	"-boost":   1,
+	"-cc":      1,
+	"-clamp":   1,
+	"-imfchan": 1,
+	"-texres":  1,
+	"-bm":      1,
+	"-mm":      2,
+	"-o":       3,
+	"-s":       3,
+	"-t":       3,
+}
+
+// mtlPBR the PBR extension statements (Pr, Pm, Ke, norm...) of a
+// material, applied over what ToPBR makes of the rest
+type mtlPBR struct {
+	used         bool
+	roughness    *float32
+	metallic     *float32
+	emissive     algebra.Vector
+	roughnessMap string
+	metallicMap  string
+	emissiveMap  string
+	normalMap    string
+	normalScale  float32
+}
+
+func (e *mtlPBR) apply(m *Material) {
+	p := m.ToPBR()
+	if e.roughness != nil {
+		p.Roughness = float64(*e.roughness)
+	}
+	if e.metallic != nil {
+		p.Metallic = float64(*e.metallic)
+	}
+	p.Emissive = e.emissive
+	p.RoughnessTextureName = e.roughnessMap
+	p.MetallicTextureName = e.metallicMap
+	p.EmissiveTextureName = e.emissiveMap
+	if e.normalMap != "" {
+		p.NormalTextureName = e.normalMap
+		p.NormalTexture = Texture{}
+		p.NormalScale = 1
+		if e.normalScale != 0 {
+			p.NormalScale = float64(e.normalScale)
+		}
+	}
+	m.PBR = &p
+}
+
+// LoadMTL read the materials in a Wavefront MTL file. Only the texture
+// names are read, see LoadTextures. Materials that don't say otherwise
+// are opaque and use IllumHighlightOn. Materials using the PBR
+// extension (Pr, Pm, Ke, map_Pr, map_Pm, map_Ke, norm) also get a PBR
+// variant
+func LoadMTL(r io.Reader) ([]Material, error) {
+	materials := []Material{}
+	extensions := []mtlPBR{}
+	var m *Material
+	var ext *mtlPBR
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		keyword := strings.ToLower(fields[0])
+		if keyword == "newmtl" {
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %v: newmtl needs a name", line)
+			}
+			materials = append(materials, Material{
+				Name:         strings.Join(fields[1:], " "),
+				Transparent:  1,
+				Illumination: IllumHighlightOn,
+			})
+			extensions = append(extensions, mtlPBR{})
+			m = &materials[len(materials)-1]
+			ext = &extensions[len(extensions)-1]
+			continue
+		}
+		if m == nil {
+			return nil, fmt.Errorf("line %v: %v before newmtl", line, fields[0])
+		}
+
+		var err error
+		switch keyword {
+		case "ka":
+			m.AmbientColor, err = parseMTLColor(fields[1:])
+		case "kd":
+			m.DiffuseColor, err = parseMTLColor(fields[1:])
+		case "ks":
+			m.SpecularColor, err = parseMTLColor(fields[1:])
+		case "ns":
+			m.SpecularColorWeight, err = parseMTLFloat(fields[1:])
+		case "d":
+			m.Transparent, err = parseMTLFloat(fields[1:])
+		case "tr":
+			var tr float32
+			tr, err = parseMTLFloat(fields[1:])
+			m.Transparent = 1 - tr
+		case "illum":
+			var n int
+			if len(fields) < 2 {
+				err = fmt.Errorf("illum needs a model")
+			} else if n, err = strconv.Atoi(fields[1]); err == nil {
+				m.Illumination = uint8(n)
+			}
+		case "map_ka":
+			m.AmbientTextureName, err = parseMTLMap(m, fields[1:])
+		case "map_kd":
+			m.DiffuseTextureName, err = parseMTLMap(m, fields[1:])
+		case "map_ks":
+			m.SpecularColorTextureName, err = parseMTLMap(m, fields[1:])
+		case "map_ns":
+			m.SpecularHighlightTextureName, err = parseMTLMap(m, fields[1:])
+		case "map_d":
+			m.AlphaTextureName, err = parseMTLMap(m, fields[1:])
+		case "map_bump", "bump":
+			m.BumpTextureName, err = parseMTLMap(m, fields[1:])
+		case "pr":
+			ext.roughness = new(float32)
+			*ext.roughness, err = parseMTLFloat(fields[1:])
+			ext.used = true
+		case "pm":
+			ext.metallic = new(float32)
+			*ext.metallic, err = parseMTLFloat(fields[1:])
+			ext.used = true
+		case "ke":
+			ext.emissive, err = parseMTLColor(fields[1:])
+			ext.used = ext.used || !isZero(ext.emissive)
+		case "map_pr":
+			ext.roughnessMap, err = parseMTLMap(m, fields[1:])
+			ext.used = true
+		case "map_pm":
+			ext.metallicMap, err = parseMTLMap(m, fields[1:])
+			ext.used = true
+		case "map_ke":
+			ext.emissiveMap, err = parseMTLMap(m, fields[1:])
+			ext.used = true
+		case "norm":
+			// -bm here is the normal map's scale, not the bump map's
+			bump := m.BumpMultiplier
+			m.BumpMultiplier = 0
+			ext.normalMap, err = parseMTLMap(m, fields[1:])
+			ext.normalScale, m.BumpMultiplier = m.BumpMultiplier, bump
+			ext.used = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(materials); i++ {
+		if extensions[i].used {
+			extensions[i].apply(&materials[i])
+		}
+	}
+	return materials, nil
+}
+
+// LoadMTLFile read an MTL file and the textures it names, which are
+// relative to the file
+func LoadMTLFile(path string) ([]Material, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	materials, err := LoadMTL(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	dir := filepath.Dir(path)
+	for i := 0; i < len(materials); i++ {
+		if err := materials[i].LoadTextures(dir); err != nil {
+			return nil, err
+		}
+	}
+	return materials, nil
+}
+
+func parseMTLColor(fields []string) (algebra.Vector, error) {
+	if len(fields) == 0 {
+		return algebra.Vector{}, fmt.Errorf("expected a color")
+	}
+	if fields[0] == "spectral" || fields[0] == "xyz" {
+		return algebra.Vector{}, fmt.Errorf("%v colors are not supported", fields[0])
+	}
+	f := [3]float64{}
+	for i := 0; i < 3; i++ {
+		// "Ka r" is short for "Ka r r r"
+		s := fields[0]
+		if i < len(fields) {
+			s = fields[i]
+		}
+		var err error
+		if f[i], err = strconv.ParseFloat(s, 64); err != nil {
+			return algebra.Vector{}, err
+		}
+	}
+	return algebra.Vector{X: f[0], Y: f[1], Z: f[2]}, nil
+}
+
+func parseMTLFloat(fields []string) (float32, error) {
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("expected a number")
+	}
+	f, err := strconv.ParseFloat(fields[0], 32)
+	return float32(f), err
+}
+
+// parseMTLMap read a texture statement's options and file name. -bm
+// sets the material's BumpMultiplier, and -o and -s its texture origin
+// and scale. Other options are skipped
+func parseMTLMap(m *Material, fields []string) (string, error) {
+	i := 0
+	for i < len(fields) && strings.HasPrefix(fields[i], "-") {
+		option := fields[i]
+		args, ok := mtlOptionArgs[option]
+		if !ok {
+			return "", fmt.Errorf("unknown texture option %v", option)
+		}
+		i++
+		values := []float64{}
+		for len(values) < args && i < len(fields) {
+			f, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				if len(values) == 0 && args == 1 {
+					// on / off and -imfchan's channel
+					i++
+				}
+				break
+			}
+			values = append(values, f)
+			i++
+		}
+		switch option {
+		case "-bm":
+			if len(values) == 1 {
+				m.BumpMultiplier = float32(values[0])
+			}
+		case "-o":
+			m.TextureOrigin = optionVector(values, 0)
+		case "-s":
+			m.TextureScale = optionVector(values, 1)
+		}
+	}
+	if i >= len(fields) {
+		return "", fmt.Errorf("expected a file name")
+	}
+	return strings.Join(fields[i:], " "), nil
+}
+
+// optionVector the u, v, w values of -o or -s, missing ones default to
+// def
+func optionVector(values []float64, def float64) algebra.Vector {
+	v := [3]float64{def, def, def}
+	copy(v[:], values)
+	return algebra.Vector{X: v[0], Y: v[1], Z: v[2]}
+}
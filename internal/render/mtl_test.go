@@ -0,0 +1,180 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/render"
+)
+
+const brickMTL = `# Blender MTL File
+newmtl Brick
+Ns 96.0
+Ka 0.1 0.1 0.1
+Kd 0.8 0.2 0.1
+Ks 0.5 0.5 0.5
+d 0.75
+illum 2
+map_Kd -s 2 2 -o 0.5 0.25 -clamp off brick wall.png
+map_Bump -bm 0.3 bump.png
+map_d -imfchan m alpha.png
+
+newmtl Glass
+Tr 0.9
+Kd 1
+`
+
+func TestLoadMTL(t *testing.T) {
+	materials, err := render.LoadMTL(strings.NewReader(brickMTL))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(materials) != 2 {
+		t.Fatalf("expected 2 materials got %v", len(materials))
+	}
+
+	brick := materials[0]
+	if brick.Name != "Brick" || brick.SpecularColorWeight != 96 || brick.Transparent != 0.75 {
+		t.Errorf("brick not read %+v", brick)
+	}
+	if brick.AmbientColor.X != 0.1 || brick.DiffuseColor.Y != 0.2 || brick.SpecularColor.Z != 0.5 {
+		t.Errorf("brick colors %v %v %v", brick.AmbientColor, brick.DiffuseColor, brick.SpecularColor)
+	}
+	if brick.DiffuseTextureName != "brick wall.png" || brick.BumpTextureName != "bump.png" || brick.AlphaTextureName != "alpha.png" {
+		t.Errorf("texture names %q %q %q", brick.DiffuseTextureName, brick.BumpTextureName, brick.AlphaTextureName)
+	}
+	if brick.BumpMultiplier != 0.3 {
+		t.Errorf("bump multiplier %v should be 0.3", brick.BumpMultiplier)
+	}
+	if brick.TextureScale.X != 2 || brick.TextureScale.Y != 2 || brick.TextureOrigin.Y != 0.25 {
+		t.Errorf("texture transform %v %v", brick.TextureOrigin, brick.TextureScale)
+	}
+
+	glass := materials[1]
+	if math.Abs(float64(glass.Transparent)-0.1) > 1e-6 {
+		t.Errorf("Tr 0.9 should be d 0.1 got %v", glass.Transparent)
+	}
+	if glass.DiffuseColor.Z != 1 || glass.Illumination != render.IllumHighlightOn {
+		t.Errorf("glass defaults %v %v", glass.DiffuseColor, glass.Illumination)
+	}
+}
+
+func TestLoadMTLPBR(t *testing.T) {
+	src := `newmtl Gold
+Kd 1 0.8 0.2
+illum 2
+Ke 0 0 0
+Pr 0.3
+Pm 1
+map_Pr rough.png
+norm -bm 0.5 gold_n.png
+
+newmtl Blender
+Kd 1 1 1
+Ke 0 0 0
+`
+	materials, err := render.LoadMTL(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gold := materials[0].PBR
+	if gold == nil {
+		t.Fatalf("gold should have a PBR variant")
+	}
+	if gold.Roughness != float64(float32(0.3)) || gold.Metallic != 1 || gold.BaseColor.Y != 0.8 {
+		t.Errorf("gold %v %v %v", gold.Roughness, gold.Metallic, gold.BaseColor)
+	}
+	if gold.RoughnessTextureName != "rough.png" || gold.NormalTextureName != "gold_n.png" || gold.NormalScale != 0.5 {
+		t.Errorf("gold maps %q %q %v", gold.RoughnessTextureName, gold.NormalTextureName, gold.NormalScale)
+	}
+	if materials[0].BumpMultiplier != 0 {
+		t.Errorf("norm's -bm should not change the bump map's %v", materials[0].BumpMultiplier)
+	}
+	// A black Ke on its own isn't the extension
+	if materials[1].PBR != nil {
+		t.Errorf("plain material should not have a PBR variant")
+	}
+}
+
+func TestLoadMTLErrors(t *testing.T) {
+	bad := map[string]string{
+		"before newmtl":  "Kd 1 0 0\n",
+		"bad color":      "newmtl a\nKd red\n",
+		"spectral color": "newmtl a\nKd spectral file.rfl\n",
+		"unknown option": "newmtl a\nmap_Kd -wat x.png\n",
+		"missing file":   "newmtl a\nmap_Kd -bm 0.5\n",
+	}
+	for name, src := range bad {
+		if _, err := render.LoadMTL(strings.NewReader(src)); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+func TestLoadMTLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtl")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	diffuse := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	diffuse.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	writePNG(t, filepath.Join(dir, "brick wall.png"), diffuse)
+	// Height rising along X
+	bump := image.NewGray(image.Rect(0, 0, 4, 1))
+	for x := 0; x < 4; x++ {
+		bump.SetGray(x, 0, color.Gray{Y: uint8(x * 60)})
+	}
+	writePNG(t, filepath.Join(dir, "bump.png"), bump)
+	alpha := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	alpha.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 64})
+	writePNG(t, filepath.Join(dir, "alpha.png"), alpha)
+	path := filepath.Join(dir, "brick.mtl")
+	if err := ioutil.WriteFile(path, []byte(brickMTL), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	materials, err := render.LoadMTLFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	brick := materials[0]
+	if brick.DiffuseTexture.Image == nil || brick.DiffuseTexture.Name != "brick wall.png" {
+		t.Fatalf("diffuse texture not loaded %v", brick.DiffuseTexture.Name)
+	}
+	// The height map becomes a normal map leaning away from the rise
+	r, _, b, _ := brick.BumpTexture.Image.At(1, 0).RGBA()
+	if r>>8 >= 128 || b>>8 < 128 {
+		t.Errorf("bump normal %v %v should lean towards -X", r>>8, b>>8)
+	}
+	// The alpha map's transparency is what's used, not its brightness
+	if g, ok := brick.AlphaTexture.Image.(*image.Gray); !ok || g.GrayAt(0, 0).Y != 64 {
+		t.Errorf("alpha map %v", brick.AlphaTexture.Image.At(0, 0))
+	}
+
+	if err := os.Remove(filepath.Join(dir, "bump.png")); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := render.LoadMTLFile(path); err == nil {
+		t.Errorf("missing texture should be an error")
+	}
+}
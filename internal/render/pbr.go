@@ -0,0 +1,309 @@
+package render
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// PBRMaterial a metal/roughness material, the way glTF and Blender's
+// Principled BSDF describe a surface. Each map multiplies the factor it
+// is named after, and missing maps change nothing
+type PBRMaterial struct {
+	// BaseColor the color of the surface, unset is white
+	BaseColor algebra.Vector
+	// Alpha 1=opaque, 0 (unset) is also opaque
+	Alpha float64
+	// Metallic 0 for plastic, wood, skin... 1 for bare metal
+	Metallic float64
+	// Roughness 0 for a mirror finish, 1 for chalk
+	Roughness float64
+	// Emissive light the surface gives off itself
+	Emissive algebra.Vector
+	// NormalScale how strong NormalTexture is, 0 means 1
+	NormalScale float64
+	// OcclusionStrength how much OcclusionTexture darkens ambient light,
+	// 0 means 1
+	OcclusionStrength float64
+
+	BaseColorTextureName string
+	BaseColorTexture     Texture
+
+	// MetallicTexture, RoughnessTexture read blue and green like glTF's
+	// metallicRoughnessTexture, which can be used for both. A grey map
+	// works for either
+	MetallicTextureName  string
+	MetallicTexture      Texture
+	RoughnessTextureName string
+	RoughnessTexture     Texture
+
+	NormalTextureName string
+	// NormalTexture a tangent space normal map (height maps are turned
+	// into one when loaded)
+	NormalTexture Texture
+
+	OcclusionTextureName string
+	// OcclusionTexture ambient occlusion in red
+	OcclusionTexture Texture
+
+	EmissiveTextureName string
+	EmissiveTexture     Texture
+}
+
+// Texture units the PBR shader reads each map from
+const (
+	BaseColorTextureUnit = iota
+	MetallicTextureUnit
+	RoughnessTextureUnit
+	NormalTextureUnit
+	OcclusionTextureUnit
+	EmissiveTextureUnit
+	// EnvironmentTextureUnit the environment cubemap
+	EnvironmentTextureUnit
+	pbrTextureUnits = EnvironmentTextureUnit
+)
+
+// PBRSurface what a PBR material looks like at one point once its
+// textures are applied
+type PBRSurface struct {
+	BaseColor algebra.Vector
+	Metallic  float64
+	Roughness float64
+	// Occlusion how much ambient light gets to the point, 0..1
+	Occlusion float64
+	Emissive  algebra.Vector
+	Alpha     float64
+}
+
+// Physical the material's PBR variant. A material without one gets one
+// converted from its MTL parameters (see ToPBR), which is kept
+func (m *Material) Physical() *PBRMaterial {
+	if m.PBR == nil {
+		p := m.ToPBR()
+		m.PBR = &p
+	}
+	return m.PBR
+}
+
+// ToPBR convert the material's MTL parameters: Kd and map_Kd become the
+// base color, Ns the roughness, d the alpha, map_Ka the occlusion and
+// bump the normal map. Only the reflective illumination models are
+// metallic, by as much as Ka says (which is how Blender writes it).
+// Ks is dropped, non metals all reflect about the same
+func (m *Material) ToPBR() PBRMaterial {
+	p := PBRMaterial{
+		BaseColor:            m.tint(),
+		Alpha:                m.opacity(),
+		Roughness:            1,
+		NormalScale:          m.bumpMultiplier(),
+		BaseColorTextureName: m.DiffuseTextureName,
+		BaseColorTexture:     m.DiffuseTexture,
+		NormalTextureName:    m.BumpTextureName,
+		NormalTexture:        m.BumpTexture,
+		OcclusionTextureName: m.AmbientTextureName,
+		OcclusionTexture:     m.AmbientTexture,
+	}
+	p.BaseColor.W = 0
+	if m.Illumination >= IllumHighlightOn {
+		// The inverse of Blender's Ns = (1 - roughness)^2 * 1000
+		p.Roughness = clamp(1-math.Sqrt(m.shininess()/1000), 0, 1)
+	}
+	switch m.Illumination {
+	case IllumReflectionOn, IllumReflectionFresnel, IllumReflectionNoRay:
+		p.Metallic = 1
+		if !isZero(m.AmbientColor) {
+			p.Metallic = (m.AmbientColor.X + m.AmbientColor.Y + m.AmbientColor.Z) / 3
+		}
+		// Ka is the metalness here, not an occlusion map's strength
+		p.OcclusionTextureName = ""
+		p.OcclusionTexture = Texture{}
+	}
+	return p
+}
+
+// PBRSurfaceAt the PBR surface at texture coordinate uv of a vertex
+// colored color. Material's TextureOrigin and TextureScale apply
+func (m *Material) PBRSurfaceAt(color algebra.Vector, uv algebra.Vector) PBRSurface {
+	p := m.Physical()
+	uv = m.textureCoord(uv)
+
+	s := PBRSurface{}
+	base := p.baseColor()
+	color.MulV(base, &s.BaseColor)
+	s.BaseColor.MulV(p.BaseColorTexture.Sample(uv), &s.BaseColor)
+	s.Metallic = p.Metallic * p.MetallicTexture.Sample(uv).Z
+	s.Roughness = p.Roughness * p.RoughnessTexture.Sample(uv).Y
+	ao := p.OcclusionTexture.Sample(uv).X
+	s.Occlusion = 1 + p.occlusionStrength()*(ao-1)
+	p.Emissive.MulV(p.EmissiveTexture.Sample(uv), &s.Emissive)
+	s.Alpha = p.alpha()
+	return s
+}
+
+// textures the material's maps by texture unit, with the name each is
+// loaded from
+func (p *PBRMaterial) textures() ([pbrTextureUnits]*Texture, [pbrTextureUnits]string) {
+	return [pbrTextureUnits]*Texture{
+		&p.BaseColorTexture,
+		&p.MetallicTexture,
+		&p.RoughnessTexture,
+		&p.NormalTexture,
+		&p.OcclusionTexture,
+		&p.EmissiveTexture,
+	}, [pbrTextureUnits]string{
+		p.BaseColorTextureName,
+		p.MetallicTextureName,
+		p.RoughnessTextureName,
+		p.NormalTextureName,
+		p.OcclusionTextureName,
+		p.EmissiveTextureName,
+	}
+}
+
+// LoadTextures read the material's named textures that haven't been
+// loaded yet, relative to dir
+func (p *PBRMaterial) LoadTextures(dir string) error {
+	textures, names := p.textures()
+	for i := 0; i < pbrTextureUnits; i++ {
+		if names[i] == "" || textures[i].Image != nil {
+			continue
+		}
+		t, err := loadMap(dir, names[i])
+		if err != nil {
+			return err
+		}
+		if i == NormalTextureUnit && isGray(t.Image) {
+			t.Image = heightToNormal(t.Image)
+		}
+		*textures[i] = t
+	}
+	return nil
+}
+
+func (p *PBRMaterial) baseColor() algebra.Vector {
+	if isZero(p.BaseColor) {
+		return algebra.Vector{X: 1, Y: 1, Z: 1}
+	}
+	return p.BaseColor
+}
+
+func (p *PBRMaterial) alpha() float64 {
+	if p.Alpha <= 0 {
+		return 1
+	}
+	return p.Alpha
+}
+
+func (p *PBRMaterial) normalScale() float64 {
+	if p.NormalScale == 0 {
+		return 1
+	}
+	return p.NormalScale
+}
+
+func (p *PBRMaterial) occlusionStrength() float64 {
+	if p.OcclusionStrength == 0 {
+		return 1
+	}
+	return p.OcclusionStrength
+}
+
+// ShadePBR the color of a surface point with the same Cook-Torrance
+// model (GGX, Smith and Schlick) as the PBR shader. Lights are as
+// bright as they are in Shade: a white light straight onto a white
+// rough surface gives white. Ambient light and the environment are
+// reflected as well, dimmed by the surface's occlusion. Alpha comes
+// back in W
+func ShadePBR(s *PBRSurface, pos algebra.Vector, normal algebra.Vector, lighting *Lighting) algebra.Vector {
+	if lighting == nil {
+		lighting = &Lighting{}
+	}
+	n := algebra.Vector{}
+	normal.Normalized(&n)
+	view := algebra.Vector{}
+	toEye := algebra.Vector{}
+	lighting.Eye.SubV(pos, &toEye)
+	toEye.Normalized(&view)
+	nDotV := math.Max(n.Dot(view), 1e-4)
+
+	roughness := clamp(s.Roughness, 0.04, 1)
+	alpha2 := roughness * roughness * roughness * roughness
+	k := (roughness + 1) * (roughness + 1) / 8
+	diffuse := s.BaseColor.Scale(1 - s.Metallic)
+	f0 := lerpVector(algebra.Vector{X: 0.04, Y: 0.04, Z: 0.04}, s.BaseColor, s.Metallic)
+	depth := lighting.viewDepth(pos)
+
+	out := algebra.Vector{}
+	for i := 0; i < len(lighting.Lights); i++ {
+		l := &lighting.Lights[i]
+		dir, strength := l.incoming(pos)
+		nDotL := n.Dot(dir)
+		if strength <= 0 || nDotL <= 0 {
+			continue
+		}
+		strength *= l.visibility(pos, n, depth)
+		half := algebra.Vector{}
+		sum := algebra.Vector{}
+		dir.AddV(view, &sum)
+		sum.Normalized(&half)
+		nDotH := math.Max(n.Dot(half), 0)
+		vDotH := math.Max(view.Dot(half), 0)
+
+		d := nDotH*nDotH*(alpha2-1) + 1
+		distribution := alpha2 / (math.Pi * d * d)
+		geometry := nDotV / (nDotV*(1-k) + k) * nDotL / (nDotL*(1-k) + k)
+		fresnel := math.Pow(1-vDotH, 5)
+		spec := distribution * geometry / (4 * nDotL * nDotV)
+
+		light := l.Color.Scale(nDotL * strength)
+		channel := func(base, f0, light float64) float64 {
+			f := f0 + (1-f0)*fresnel
+			// Times pi to match Shade's brightness
+			return ((1-f)*base + math.Pi*spec*f) * light
+		}
+		out.X += channel(diffuse.X, f0.X, light.X)
+		out.Y += channel(diffuse.Y, f0.Y, light.Y)
+		out.Z += channel(diffuse.Z, f0.Z, light.Z)
+	}
+
+	reflected := algebra.Vector{}
+	mirror := n.Scale(2 * n.Dot(view))
+	mirror.SubV(view, &reflected)
+	irradiance, prefiltered := lighting.Ambient, lighting.Ambient
+	if e := lighting.Environment; e != nil {
+		tint := lighting.EnvironmentColor
+		var lit algebra.Vector
+		fromEnv := e.Diffuse(n)
+		fromEnv.MulV(tint, &lit)
+		irradiance.AddV(lit, &irradiance)
+		fromEnv = e.Specular(reflected, roughness)
+		fromEnv.MulV(tint, &lit)
+		prefiltered.AddV(lit, &prefiltered)
+	}
+	a, b := envBRDF(roughness, nDotV)
+	out.X += (diffuse.X*irradiance.X + (f0.X*a+b)*prefiltered.X) * s.Occlusion
+	out.Y += (diffuse.Y*irradiance.Y + (f0.Y*a+b)*prefiltered.Y) * s.Occlusion
+	out.Z += (diffuse.Z*irradiance.Z + (f0.Z*a+b)*prefiltered.Z) * s.Occlusion
+
+	out.AddV(s.Emissive, &out)
+	out.W = s.Alpha
+	return out
+}
+
+// envBRDF the scale and bias to F0 of light reflected from the
+// environment, Karis' fit of the split sum lookup table so no texture is
+// needed
+func envBRDF(roughness, nDotV float64) (float64, float64) {
+	r := [4]float64{
+		roughness*-1 + 1,
+		roughness*-0.0275 + 0.0425,
+		roughness*-0.572 + 1.04,
+		roughness*0.022 - 0.04,
+	}
+	a004 := math.Min(r[0]*r[0], math.Exp2(-9.28*nDotV))*r[0] + r[1]
+	return a004*-1.04 + r[2], a004*1.04 + r[3]
+}
+
+func clamp(f, min, max float64) float64 {
+	return math.Max(min, math.Min(max, f))
+}
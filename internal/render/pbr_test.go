@@ -0,0 +1,114 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func TestToPBR(t *testing.T) {
+	plastic := render.Material{
+		DiffuseColor:        algebra.Vector{X: 0.8, Y: 0.2, Z: 0.1},
+		SpecularColorWeight: 250,
+		Transparent:         0.5,
+		Illumination:        render.IllumHighlightOn,
+		DiffuseTextureName:  "plastic.png",
+	}
+	p := plastic.ToPBR()
+	if p.BaseColor.X != 0.8 || p.Alpha != 0.5 || p.Metallic != 0 || p.BaseColorTextureName != "plastic.png" {
+		t.Errorf("plastic %+v", p)
+	}
+	// Ns 250 is what Blender writes for roughness 0.5
+	if math.Abs(p.Roughness-0.5) > 1e-9 {
+		t.Errorf("roughness %v should be 0.5", p.Roughness)
+	}
+
+	matte := render.Material{Illumination: render.IllumColorOnAmbientOn}
+	if p := matte.ToPBR(); p.Roughness != 1 {
+		t.Errorf("no highlights should be fully rough got %v", p.Roughness)
+	}
+
+	metal := render.Material{
+		AmbientColor: algebra.Vector{X: 0.6, Y: 0.6, Z: 0.6},
+		Illumination: render.IllumReflectionOn,
+	}
+	if p := metal.ToPBR(); math.Abs(p.Metallic-0.6) > 1e-9 {
+		t.Errorf("reflective metallic %v should follow Ka", p.Metallic)
+	}
+
+	if metal.Physical() != metal.Physical() || metal.PBR == nil {
+		t.Errorf("the converted material should be kept")
+	}
+}
+
+func TestShadePBR(t *testing.T) {
+	up := algebra.Vector{Y: 1}
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	lighting := &render.Lighting{
+		Lights: []render.Light{{Type: render.LightDirectional, Direction: algebra.Vector{Y: -1}, Color: white}},
+		Eye:    algebra.Vector{Y: 10},
+	}
+
+	// Straight on a rough white surface is about as bright as the light,
+	// less what's reflected (F0 0.04) plus the highlight
+	rough := render.PBRSurface{BaseColor: white, Roughness: 1, Occlusion: 1, Alpha: 1}
+	c := render.ShadePBR(&rough, algebra.Vector{}, up, lighting)
+	if math.Abs(c.X-0.97) > 1e-6 || c.W != 1 {
+		t.Errorf("rough white %v should be 0.97", c)
+	}
+
+	// Metals have no diffuse and highlights in their own color
+	gold := render.PBRSurface{BaseColor: algebra.Vector{X: 1}, Metallic: 1, Roughness: 0.2, Occlusion: 1}
+	c = render.ShadePBR(&gold, algebra.Vector{}, up, lighting)
+	if c.X <= 1 || c.Y != 0 || c.Z != 0 {
+		t.Errorf("red metal highlight %v", c)
+	}
+
+	glow := render.PBRSurface{Emissive: algebra.Vector{Z: 0.5}, Occlusion: 1}
+	c = render.ShadePBR(&glow, algebra.Vector{}, algebra.Vector{Y: -1}, lighting)
+	if c.Z != 0.5 {
+		t.Errorf("facing away should only glow got %v", c)
+	}
+}
+
+func TestShadePBREnvironment(t *testing.T) {
+	env := render.NewEnvironment(mockCubemap(16, [6]algebra.Vector{
+		{X: 1, Y: 1, Z: 1}, {X: 1, Y: 1, Z: 1}, {X: 1, Y: 1, Z: 1},
+		{X: 1, Y: 1, Z: 1}, {X: 1, Y: 1, Z: 1}, {X: 1, Y: 1, Z: 1},
+	}))
+	lighting := &render.Lighting{
+		Environment:      env,
+		EnvironmentColor: algebra.Vector{X: 1, Y: 1, Z: 1},
+		Eye:              algebra.Vector{Y: 10},
+	}
+	s := render.PBRSurface{BaseColor: algebra.Vector{X: 0.5, Y: 0.5, Z: 0.5}, Roughness: 1, Occlusion: 1}
+	lit := render.ShadePBR(&s, algebra.Vector{}, algebra.Vector{Y: 1}, lighting)
+	if lit.X < 0.5 || lit.X > 0.6 {
+		t.Errorf("grey in a white room %v should be a little over 0.5", lit)
+	}
+
+	s.Occlusion = 0.5
+	dim := render.ShadePBR(&s, algebra.Vector{}, algebra.Vector{Y: 1}, lighting)
+	if math.Abs(dim.X-lit.X/2) > 1e-9 {
+		t.Errorf("half occluded %v should be half of %v", dim.X, lit.X)
+	}
+}
+
+func TestSoftwarePBR(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	quad := mockQuad(-2, algebra.Vector{X: 1, Y: 1, Z: 1})
+	for i := 0; i < len(quad.Poly.Vertices); i++ {
+		quad.Poly.Vertices[i].Normal = algebra.Vector{Z: 1}
+	}
+	m := render.Material{PBR: &render.PBRMaterial{BaseColor: algebra.Vector{X: 1}, Roughness: 1}}
+	lighting := &render.Lighting{
+		Lights: []render.Light{{Type: render.LightDirectional, Direction: algebra.Vector{Z: -1}, Color: algebra.Vector{X: 1, Y: 1, Z: 1}}},
+		Eye:    algebra.Vector{Z: 10},
+	}
+	r.DrawShaded(&quad, &m, world, view, proj, lighting)
+	if c := r.Image.RGBAAt(20, 20); c.R != 247 || c.G != 3 || c.B != 3 {
+		t.Errorf("rough red quad got %v", c)
+	}
+}
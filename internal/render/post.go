@@ -0,0 +1,312 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/chsc/gogl/ext"
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// ComponentPostProcess full screen effects run, in order, on what the
+// camera on the same entity sees before it reaches the window (or the
+// camera's render target). On the GPU the scene is drawn into a half
+// float target first, so effects like Tonemap get light brighter than
+// white to work with
+type ComponentPostProcess struct {
+	*core.Component
+	Effects []Effect
+
+	// scene, targets what the scene and each shader pass are drawn into,
+	// kept from frame to frame
+	scene   *RenderTarget
+	targets []*RenderTarget
+}
+
+// NewComponentPostProcess create a post processing stack that runs
+// effects in the order given
+func NewComponentPostProcess(effects ...Effect) ComponentPostProcess {
+	return ComponentPostProcess{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Effects: effects,
+	}
+}
+
+// Effect one step of a post processing stack
+type Effect interface {
+	// Apply the effect to a frame on the CPU
+	Apply(f *Frame)
+	// passes the full screen shader passes that do the same on the GPU
+	passes() []postPass
+}
+
+// postPass one full screen draw on the GPU. Every pass's shader gets
+// uSource (what the pass before drew), uOriginal (what the effect
+// started from) and uTexel (the size of one of uSource's pixels)
+type postPass struct {
+	// shader the fragment shader, drawn with the Post vertex shader
+	shader string
+	// scale how many times smaller than the frame the pass draws, 0
+	// means 1. The last pass of the stack is always full size
+	scale int
+	// set upload the pass's own uniforms, nil for none
+	set func(uniform func(name string) gl.Int) error
+}
+
+// Apply run the effects on a frame on the CPU, which is what the
+// Software renderer does
+func (pp *ComponentPostProcess) Apply(f *Frame) {
+	for i := 0; i < len(pp.Effects); i++ {
+		pp.Effects[i].Apply(f)
+	}
+}
+
+// postProcess the effects on a camera's entity, nil when there are
+// none
+func postProcess(cc *core.ComponentCamera) *ComponentPostProcess {
+	e := cc.GetParent()
+	if e == nil {
+		return nil
+	}
+	pp, ok := e.GetComponent(core.ComponentTypePostProcess).(*ComponentPostProcess)
+	if !ok || len(pp.Effects) == 0 {
+		return nil
+	}
+	return pp
+}
+
+// Frame an image in floating point for effects to work on on the CPU.
+// Rows go down like they do in image.RGBA, and alpha is in W
+type Frame struct {
+	Width  int
+	Height int
+	Pix    []algebra.Vector
+}
+
+// NewFrame a black, transparent frame
+func NewFrame(width, height int) *Frame {
+	return &Frame{Width: width, Height: height, Pix: make([]algebra.Vector, width*height)}
+}
+
+// FrameFromImage a frame with the image's colors
+func FrameFromImage(img *image.RGBA) *Frame {
+	b := img.Bounds()
+	f := NewFrame(b.Dx(), b.Dy())
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			c := img.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			f.Pix[y*f.Width+x] = algebra.Vector{
+				X: float64(c.R) / 255,
+				Y: float64(c.G) / 255,
+				Z: float64(c.B) / 255,
+				W: float64(c.A) / 255,
+			}
+		}
+	}
+	return f
+}
+
+// ToImage write the frame into an image the same size, clamping colors
+// to 0..1
+func (f *Frame) ToImage(img *image.RGBA) {
+	b := img.Bounds()
+	for y := 0; y < f.Height && y < b.Dy(); y++ {
+		for x := 0; x < f.Width && x < b.Dx(); x++ {
+			c := f.Pix[y*f.Width+x]
+			img.SetRGBA(b.Min.X+x, b.Min.Y+y, color.RGBA{
+				R: toByte(c.X),
+				G: toByte(c.Y),
+				B: toByte(c.Z),
+				A: toByte(c.W),
+			})
+		}
+	}
+}
+
+// At the color of a pixel, the nearest edge pixel outside the frame
+func (f *Frame) At(x, y int) algebra.Vector {
+	x = clampInt(x, 0, f.Width-1)
+	y = clampInt(y, 0, f.Height-1)
+	return f.Pix[y*f.Width+x]
+}
+
+// Set the color of a pixel
+func (f *Frame) Set(x, y int, c algebra.Vector) {
+	f.Pix[y*f.Width+x] = c
+}
+
+// Copy a frame the same as f
+func (f *Frame) Copy() *Frame {
+	out := NewFrame(f.Width, f.Height)
+	copy(out.Pix, f.Pix)
+	return out
+}
+
+// sample the color at (x, y) in pixels, bilinear filtered and clamped
+// to the edges like a render target's textures. Pixel centers are at
+// .5
+func (f *Frame) sample(x, y float64) algebra.Vector {
+	x -= 0.5
+	y -= 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	top := lerpVector(f.At(ix, iy), f.At(ix+1, iy), fx)
+	bottom := lerpVector(f.At(ix, iy+1), f.At(ix+1, iy+1), fx)
+	return lerpVector(top, bottom, fy)
+}
+
+// each replace every pixel with fn of it
+func (f *Frame) each(fn func(c algebra.Vector) algebra.Vector) {
+	for i := 0; i < len(f.Pix); i++ {
+		f.Pix[i] = fn(f.Pix[i])
+	}
+}
+
+// postQuad the full screen quad passes are drawn with
+var postQuad *Mesh
+
+// fullScreenMesh postQuad, sent to the GPU the first time it's needed
+func fullScreenMesh() *Mesh {
+	if postQuad == nil {
+		quad := CreateMesh(fullScreenQuad())
+		postQuad = &quad
+	}
+	return postQuad
+}
+
+// postPrograms the programs passes are drawn with, by fragment shader
+var postPrograms = map[string]*Program{}
+
+// postProgram load a pass's program the first time it's needed
+func postProgram(shader string) (*Program, error) {
+	if p, ok := postPrograms[shader]; ok {
+		return p, nil
+	}
+	p, err := LoadProgram("Post.glsl", shader)
+	if err != nil {
+		return nil, err
+	}
+	postPrograms[shader] = &p
+	return &p, nil
+}
+
+// fullScreenQuad clip space corners with texture coordinates from 0 to 1
+func fullScreenQuad() geometry.Polyhedron {
+	v := func(x, y float64) geometry.Vertex {
+		return geometry.Vertex{
+			Pos:      algebra.Vector{X: x, Y: y},
+			TexCoord: algebra.Vector{X: (x + 1) / 2, Y: (y + 1) / 2},
+		}
+	}
+	return geometry.Polyhedron{
+		Vertices: []geometry.Vertex{v(-1, -1), v(1, -1), v(1, 1), v(-1, 1)},
+		Indices:  []uint16{0, 1, 2, 0, 2, 3},
+	}
+}
+
+// sceneTarget the target the scene is drawn into before the effects,
+// made again when the size changes
+func (pp *ComponentPostProcess) sceneTarget(width, height int, clear color.RGBA) *RenderTarget {
+	if pp.scene == nil {
+		pp.scene = NewRenderTarget("post scene", width, height, TargetRGBA16F, 1, true)
+	} else if pp.scene.Width != width || pp.scene.Height != height {
+		pp.scene.Resize(width, height)
+	}
+	pp.scene.ClearColor = clear
+	return pp.scene
+}
+
+// passTarget the target the i'th pass draws into
+func (pp *ComponentPostProcess) passTarget(i, width, height int) *RenderTarget {
+	for len(pp.targets) <= i {
+		pp.targets = append(pp.targets, NewRenderTarget("post pass", width, height, TargetRGBA16F, 1, false))
+	}
+	t := pp.targets[i]
+	if t.Width != width || t.Height != height {
+		t.Resize(width, height)
+	}
+	return t
+}
+
+// drawGl run the effects' passes on the GPU, from scene into the
+// framebuffer dest at viewport
+func (pp *ComponentPostProcess) drawGl(scene *RenderTarget, dest ext.Uint, viewport [4]gl.Int) error {
+	quad := fullScreenMesh()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	defer func() {
+		gl.Enable(gl.DEPTH_TEST)
+		gl.Enable(gl.BLEND)
+	}()
+	disableAttributesGl()
+
+	source, original := scene, scene
+	n := 0
+	for e := 0; e < len(pp.Effects); e++ {
+		original = source
+		passes := pp.Effects[e].passes()
+		for i := 0; i < len(passes); i++ {
+			pass := passes[i]
+			p, err := postProgram(pass.shader)
+			if err != nil {
+				return err
+			}
+			gl.UseProgram(p.Program)
+			bindMeshGl(p, quad)
+
+			var out *RenderTarget
+			if e == len(pp.Effects)-1 && i == len(passes)-1 {
+				ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, dest)
+				gl.Viewport(viewport[0], viewport[1], gl.Sizei(viewport[2]), gl.Sizei(viewport[3]))
+			} else {
+				scale := pass.scale
+				if scale < 1 {
+					scale = 1
+				}
+				out = pp.passTarget(n, maxInt(scene.Width/scale, 1), maxInt(scene.Height/scale, 1))
+				if err := out.bind(); err != nil {
+					return err
+				}
+			}
+
+			gl.ActiveTexture(gl.TEXTURE0)
+			gl.BindTexture(gl.TEXTURE_2D, source.handle(0))
+			gl.ActiveTexture(gl.TEXTURE1)
+			gl.BindTexture(gl.TEXTURE_2D, original.handle(0))
+			uniform := func(name string) gl.Int {
+				return findUniform(p.Program, name)
+			}
+			gl.Uniform1i(uniform("uSource"), 0)
+			gl.Uniform1i(uniform("uOriginal"), 1)
+			gl.Uniform2f(uniform("uTexel"), gl.Float(1/float64(source.Width)), gl.Float(1/float64(source.Height)))
+			if pass.set != nil {
+				if err := pass.set(uniform); err != nil {
+					return err
+				}
+			}
+			gl.DrawElements(gl.TRIANGLES, gl.Sizei(quad.Resource.Size), gl.UNSIGNED_SHORT, gl.Offset(nil, 0))
+
+			if out != nil {
+				source = out
+			}
+			n++
+		}
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	return nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,86 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func TestFrameImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.SetRGBA(2, 1, color.RGBA{R: 255, G: 51, A: 255})
+	f := render.FrameFromImage(img)
+	if c := f.At(2, 1); c.X != 1 || c.Y != 0.2 || c.W != 1 {
+		t.Errorf("frame pixel %v", c)
+	}
+	if c := f.At(5, 9); c.X != 1 {
+		t.Errorf("pixels past the edge should be the edge's got %v", c)
+	}
+
+	f.Set(0, 0, algebra.Vector{X: 2, Y: -1, Z: 0.5, W: 1})
+	f.ToImage(img)
+	if c := img.RGBAAt(0, 0); c != (color.RGBA{R: 255, B: 128, A: 255}) {
+		t.Errorf("colors should be clamped into the image got %v", c)
+	}
+}
+
+func TestPostProcessOrder(t *testing.T) {
+	// Gamma then tonemapping isn't tonemapping then gamma
+	grey := algebra.Vector{X: 0.25, Y: 0.25, Z: 0.25, W: 1}
+	a := mockFrame(1, 1, grey)
+	b := mockFrame(1, 1, grey)
+	first := render.NewComponentPostProcess(render.Gamma{}, render.Tonemap{Operator: render.TonemapReinhard})
+	second := render.NewComponentPostProcess(render.Tonemap{Operator: render.TonemapReinhard}, render.Gamma{})
+	first.Apply(a)
+	second.Apply(b)
+	ca, cb := a.At(0, 0), b.At(0, 0)
+	if ca.AlmostEquals(&cb) {
+		t.Errorf("effects should run in order got %v both ways", ca)
+	}
+	c := mockFrame(1, 1, grey)
+	render.Gamma{}.Apply(c)
+	render.Tonemap{Operator: render.TonemapReinhard}.Apply(c)
+	if cc := c.At(0, 0); !ca.AlmostEquals(&cc) {
+		t.Errorf("the stack should run its effects one after the other got %v not %v", ca, cc)
+	}
+}
+
+func TestSoftwarePostProcess(t *testing.T) {
+	s := &core.Scene{}
+	camera, _ := mockCameraEntity(40, 40)
+	s.Add(camera)
+	s.ActiveCamera = camera
+	pp := render.NewComponentPostProcess(render.Vignette{Color: algebra.Vector{Z: 1}})
+	camera.Attach(&pp)
+
+	r := &render.Software{}
+	r.Initialize(core.Settings{Width: 40, Height: 40})
+	if err := r.RenderScene(s); err != nil {
+		t.Fatal(err)
+	}
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("the middle should be the clear color got %v", c)
+	}
+	if c := r.Image.RGBAAt(0, 0); c.R > 20 || c.B < 250 {
+		t.Errorf("the corners should be vignetted blue got %v", c)
+	}
+
+	// Effects on a camera with a target go into the target
+	target := render.NewRenderTarget("screen", 16, 16, render.TargetRGBA8, 1, false)
+	target.ClearColor = color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	security, cc := mockCameraEntity(16, 16)
+	cc.Target = target
+	squared := render.NewComponentPostProcess(render.Gamma{Gamma: 0.5})
+	security.Attach(&squared)
+	s.Add(security)
+	if err := r.RenderScene(s); err != nil {
+		t.Fatal(err)
+	}
+	if c := target.Image(0).(*image.RGBA).RGBAAt(8, 8); c.R < 60 || c.R > 68 {
+		t.Errorf("the target should be squared got %v", c)
+	}
+}
@@ -3,11 +3,14 @@ package render
 import (
 	"errors"
 	"fmt"
+	"image/color"
 	"log"
 
 	"github.com/robrohan/mesh/internal/algebra"
 	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
 
+	"github.com/chsc/gogl/ext"
 	gl "github.com/chsc/gogl/gl21"
 )
 
@@ -17,10 +20,13 @@ type RenderCommand struct {
 	// ModelToWorld *algebra.Matrix
 	Render *ComponentRender
 	Camera *core.ComponentCamera
+	// Lighting the frame's lights, nil for none
+	Lighting *Lighting
 }
 
 // RenderInitializer initialize the render framework (opengl)
 type RenderInitializer func(width int32, height int32) error
+type RenderResizer func(width int32, height int32) error
 type RenderDrawer func(mesh *Mesh, material *Material) error
 
 //////////////////////////////////////////////////////////////////////////////////////
@@ -42,41 +48,154 @@ func (r *System) Configure(s core.Settings) {
 
 // InitSystem configure and startup the system
 func (r *System) InitSystem(s core.Settings, fn RenderInitializer) {
-	err := fn(s.Width, s.Height)
+	err := fn(s.DrawableSize())
 	if err != nil {
 		panic("Initialize render system failed")
 	}
 }
 
+// Resize match the viewport to a new window size
+func (r *System) Resize(s core.Settings) error {
+	return r.ResizeSystem(s, resizeOpenGl)
+}
+
+// ResizeSystem match the viewport to the drawable size in s
+func (r *System) ResizeSystem(s core.Settings, fn RenderResizer) error {
+	w, h := s.DrawableSize()
+	if w <= 0 || h <= 0 {
+		// Minimized, nothing to draw into
+		return nil
+	}
+	return fn(w, h)
+}
+
+// RenderScene draw every render component from the active camera, after
+// the shadow maps and the cameras that draw into render targets
 func (r *System) RenderScene(s *core.Scene) error {
 	// log.Printf("Start render scene...\n")
 
-	camera := s.ActiveCamera
-	if camera == nil {
-		panic("Scene has no active camera")
+	cc, err := activeCamera(s)
+	if err != nil {
+		return err
 	}
-	ccomp := s.ActiveCamera.GetComponent(core.ComponentTypeCamera)
-	cc, ok := ccomp.(*core.ComponentCamera)
-	if !ok {
-		panic("Camera has no camera component")
+	lighting := GatherLights(s)
+	entities := s.All()
+	if err := drawShadowsGl(entities, &lighting); err != nil {
+		return err
+	}
+	if err := r.drawTargets(s, entities, &lighting); err != nil {
+		return err
+	}
+	return r.drawCamera(cc, entities, &lighting, nil)
+}
+
+// drawCamera draw a camera's view into target, or the window when it's
+// nil, through the camera's post processing effects if it has any.
+// Without framebuffers the effects are skipped
+func (r *System) drawCamera(cc *core.ComponentCamera, entities []*core.Entity, lighting *Lighting, target *RenderTarget) error {
+	var framebuffer ext.Uint
+	viewport := [4]gl.Int{}
+	clear := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if target != nil {
+		if err := target.bind(); err != nil {
+			return err
+		}
+		framebuffer = target.framebuffer
+		viewport = [4]gl.Int{0, 0, gl.Int(target.Width), gl.Int(target.Height)}
+		clear = target.ClearColor
+	} else {
+		gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
 	}
 
-	entities := s.All()
+	pp := postProcess(cc)
+	if pp == nil || !framebuffers || viewport[2] <= 0 || viewport[3] <= 0 {
+		if target == nil {
+			clearGl()
+		}
+		return r.drawView(cc, entities, lighting, target)
+	}
+	scene := pp.sceneTarget(int(viewport[2]), int(viewport[3]), clear)
+	if err := scene.bind(); err != nil {
+		return err
+	}
+	if err := r.drawView(cc, entities, lighting, target); err != nil {
+		return err
+	}
+	return pp.drawGl(scene, framebuffer, viewport)
+}
+
+// drawView draw the entities' render components and SDFs from a camera,
+// leaving out any that show target (a framebuffer can't be drawn with
+// its own texture)
+func (r *System) drawView(cc *core.ComponentCamera, entities []*core.Entity, lighting *Lighting, target *RenderTarget) error {
 	for t := 0; t < len(entities); t++ {
 		e := entities[t]
 
 		comp := e.GetComponent(core.ComponentTypeRender)
 
 		if rc, ok := comp.(*ComponentRender); ok {
-			r.Render(RenderCommand{
-				Render: rc,
-				Camera: cc,
-			})
+			if target != nil && rc.Material.showsTarget(target) {
+				continue
+			}
+			if err := r.Render(RenderCommand{
+				Render:   rc,
+				Camera:   cc,
+				Lighting: lighting,
+			}); err != nil {
+				return err
+			}
+		}
+		if sc, ok := e.GetComponent(core.ComponentTypeSDF).(*ComponentSDF); ok {
+			if err := sc.drawGl(cc, lighting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// drawTargets draw the views of the cameras that have render targets.
+// Without framebuffers the targets are left empty, and materials using
+// them get white
+func (r *System) drawTargets(s *core.Scene, entities []*core.Entity, lighting *Lighting) error {
+	cameras := targetCameras(s)
+	if len(cameras) == 0 || !framebuffers {
+		return nil
+	}
+	viewport := [4]gl.Int{}
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	defer func() {
+		ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, 0)
+		gl.Viewport(viewport[0], viewport[1], gl.Sizei(viewport[2]), gl.Sizei(viewport[3]))
+	}()
+
+	for i := 0; i < len(cameras); i++ {
+		cc := cameras[i]
+		t, ok := cc.Target.(*RenderTarget)
+		if !ok || t.Width <= 0 || t.Height <= 0 {
+			continue
+		}
+		cc.UpdateViewMatrix()
+		view := lighting.from(cc)
+		if err := r.drawCamera(cc, entities, &view, t); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// activeCamera the camera component on the scene's active camera
+func activeCamera(s *core.Scene) (*core.ComponentCamera, error) {
+	if s.ActiveCamera == nil {
+		return nil, errors.New("Scene has no active camera")
+	}
+	cc, ok := s.ActiveCamera.GetComponent(core.ComponentTypeCamera).(*core.ComponentCamera)
+	if !ok {
+		return nil, errors.New("Camera has no camera component")
+	}
+	return cc, nil
+}
+
 // Render render a mesh
 func (r *System) Render(command RenderCommand) error {
 	mesh := &command.Render.Mesh
@@ -97,9 +216,24 @@ func (r *System) Render(command RenderCommand) error {
 	viewa := matrixAsArray(view)
 	proja := matrixAsArray(proj)
 
+	// The shadow pass leaves its own program and buffers bound
+	gl.UseProgram(material.Shader.Program.Program)
+	uploadMorphGl(entity, mesh)
+	bindMeshGl(&material.Shader.Program, mesh)
 	gl.UniformMatrix4fv(material.Shader.Program.UniWorld, gl.Sizei(1), gl.FALSE, &mtw[0])
 	gl.UniformMatrix4fv(material.Shader.Program.UniView, gl.Sizei(1), gl.FALSE, &viewa[0])
 	gl.UniformMatrix4fv(material.Shader.Program.UniProject, gl.Sizei(1), gl.FALSE, &proja[0])
+	if err := uploadJoints(material.Shader.Program.UniJoints, entity); err != nil {
+		return err
+	}
+	uploadLighting(&material.Shader.Program.Lighting, command.Lighting)
+	if err := uploadMaterial(&material.Shader.Program.Material, material); err != nil {
+		return err
+	}
+	if err := uploadPBR(&material.Shader.Program.PBR, material, command.Lighting); err != nil {
+		return err
+	}
+	uploadShadows(&material.Shader.Program.Shadow, command.Lighting)
 
 	return r.Draw(mesh, material, drawGl)
 }
@@ -122,6 +256,248 @@ func matrixAsArray(matrix *algebra.Matrix) [16]gl.Float {
 	return asArray
 }
 
+// uploadLighting set the lighting uniforms the program has
+func uploadLighting(u *LightingUniforms, lighting *Lighting) {
+	if lighting == nil {
+		lighting = &Lighting{}
+	}
+	count := len(lighting.Lights)
+	types := make([]gl.Int, MaxLights)
+	positions := make([]gl.Float, MaxLights*3)
+	directions := make([]gl.Float, MaxLights*3)
+	colors := make([]gl.Float, MaxLights*3)
+	ranges := make([]gl.Float, MaxLights)
+	cones := make([]gl.Float, MaxLights*2)
+	for i := 0; i < count; i++ {
+		l := &lighting.Lights[i]
+		types[i] = gl.Int(l.Type)
+		putVector(positions[i*3:], l.Position)
+		putVector(directions[i*3:], l.Direction)
+		putVector(colors[i*3:], l.Color)
+		ranges[i] = gl.Float(l.Range)
+		cones[i*2] = gl.Float(l.CosInner)
+		cones[i*2+1] = gl.Float(l.CosOuter)
+	}
+
+	uniformVector(u.Eye, lighting.Eye)
+	uniformVector(u.Ambient, lighting.Ambient)
+	if u.LightCount >= 0 {
+		gl.Uniform1i(u.LightCount, gl.Int(count))
+	}
+	if count == 0 {
+		return
+	}
+	if u.LightType >= 0 {
+		gl.Uniform1iv(u.LightType, MaxLights, &types[0])
+	}
+	if u.LightPosition >= 0 {
+		gl.Uniform3fv(u.LightPosition, MaxLights, &positions[0])
+	}
+	if u.LightDirection >= 0 {
+		gl.Uniform3fv(u.LightDirection, MaxLights, &directions[0])
+	}
+	if u.LightColor >= 0 {
+		gl.Uniform3fv(u.LightColor, MaxLights, &colors[0])
+	}
+	if u.LightRange >= 0 {
+		gl.Uniform1fv(u.LightRange, MaxLights, &ranges[0])
+	}
+	if u.LightCone >= 0 {
+		gl.Uniform2fv(u.LightCone, MaxLights, &cones[0])
+	}
+}
+
+// uploadMaterial set the material uniforms the program has and bind its
+// maps, or stand ins for the missing ones, to their texture units
+func uploadMaterial(u *MaterialUniforms, m *Material) error {
+	ambient := m.AmbientColor
+	if isZero(ambient) {
+		ambient = m.tint()
+	}
+	uniformVector(u.AmbientColor, ambient)
+	uniformVector(u.DiffuseColor, m.tint())
+	uniformVector(u.SpecularColor, m.SpecularColor)
+	uniformFloat(u.Shininess, m.shininess())
+	uniformFloat(u.Alpha, m.opacity())
+	uniformFloat(u.BumpScale, m.bumpMultiplier())
+	if u.Illum >= 0 {
+		gl.Uniform1i(u.Illum, gl.Int(m.Illumination))
+	}
+	if u.TextureOrigin >= 0 {
+		gl.Uniform2f(u.TextureOrigin, gl.Float(m.TextureOrigin.X), gl.Float(m.TextureOrigin.Y))
+	}
+	if u.TextureScale >= 0 {
+		scale := m.textureScale()
+		gl.Uniform2f(u.TextureScale, gl.Float(scale.X), gl.Float(scale.Y))
+	}
+
+	textures, _ := m.textures()
+	for i := 0; i < textureUnits; i++ {
+		if u.Maps[i] < 0 {
+			continue
+		}
+		fallback := &whiteTexture
+		if i == BumpTextureUnit {
+			fallback = &flatTexture
+		}
+		if err := textures[i].bind(i, fallback); err != nil {
+			return err
+		}
+		gl.Uniform1i(u.Maps[i], gl.Int(i))
+	}
+	return nil
+}
+
+// uploadPBR set the PBR uniforms the program has, if it has any, and
+// bind the maps and environment. Materials without a PBR variant are
+// converted
+func uploadPBR(u *PBRUniforms, m *Material, lighting *Lighting) error {
+	if u.BaseColor < 0 {
+		return nil
+	}
+	p := m.Physical()
+	uniformVector(u.BaseColor, p.baseColor())
+	uniformFloat(u.Metallic, p.Metallic)
+	uniformFloat(u.Roughness, p.Roughness)
+	uniformVector(u.Emissive, p.Emissive)
+	uniformFloat(u.NormalScale, p.normalScale())
+	uniformFloat(u.OcclusionStrength, p.occlusionStrength())
+	// Alpha and the texture transform are shared with the MTL uniforms
+	uniformFloat(m.Shader.Program.Material.Alpha, p.alpha())
+
+	textures, _ := p.textures()
+	for i := 0; i < pbrTextureUnits; i++ {
+		if u.Maps[i] < 0 {
+			continue
+		}
+		fallback := &whiteTexture
+		if i == NormalTextureUnit {
+			fallback = &flatTexture
+		}
+		if err := textures[i].bind(i, fallback); err != nil {
+			return err
+		}
+		gl.Uniform1i(u.Maps[i], gl.Int(i))
+	}
+
+	env := &Environment{Cubemap: blackCubemap}
+	color := algebra.Vector{}
+	if lighting != nil && lighting.Environment != nil {
+		env = lighting.Environment
+		color = lighting.EnvironmentColor
+	}
+	if u.Environment >= 0 {
+		if err := env.Cubemap.bind(EnvironmentTextureUnit); err != nil {
+			return err
+		}
+		gl.Uniform1i(u.Environment, EnvironmentTextureUnit)
+	}
+	uniformVector(u.EnvironmentColor, color)
+	uniformFloat(u.EnvironmentLevels, float64(env.Cubemap.Levels()))
+	if u.Irradiance >= 0 {
+		irradiance := make([]gl.Float, 9*3)
+		for i := 0; i < 9; i++ {
+			putVector(irradiance[i*3:], env.Irradiance[i])
+		}
+		gl.Uniform3fv(u.Irradiance, 9, &irradiance[0])
+	}
+	return nil
+}
+
+// uploadShadows set the shadow uniforms the program has and bind the
+// shadow maps drawn this frame
+func uploadShadows(u *ShadowUniforms, lighting *Lighting) {
+	if lighting == nil {
+		lighting = &Lighting{}
+	}
+	lights := make([]gl.Int, MaxLights)
+	for i := 0; i < MaxLights; i++ {
+		lights[i] = -1
+	}
+	cascades := make([]gl.Int, MaxShadows)
+	settings := make([]gl.Float, MaxShadows*3)
+	matrices := make([]gl.Float, MaxShadows*MaxCascades*16)
+	ranges := make([]gl.Float, MaxShadows*MaxCascades*4)
+	packed := gl.Int(0)
+	count := 0
+	for i := 0; i < len(lighting.Lights); i++ {
+		m := lighting.Lights[i].Shadow
+		if m == nil || m.Handle == 0 || count >= MaxShadows {
+			continue
+		}
+		lights[i] = gl.Int(count)
+		cascades[count] = gl.Int(len(m.Cascades))
+		settings[count*3] = gl.Float(m.Size)
+		settings[count*3+1] = gl.Float(m.Bias)
+		settings[count*3+2] = gl.Float(m.NormalBias)
+		for c := 0; c < len(m.Cascades); c++ {
+			cascade := &m.Cascades[c]
+			tile := count*MaxCascades + c
+			matrix := matrixAsArray(&cascade.ViewProjection)
+			copy(matrices[tile*16:], matrix[:])
+			ranges[tile*4] = gl.Float(cascade.Near)
+			ranges[tile*4+1] = gl.Float(cascade.Far)
+			ranges[tile*4+2] = gl.Float(cascade.Split)
+			ranges[tile*4+3] = gl.Float(cascade.Texel)
+		}
+		if m.Packed {
+			packed = 1
+		}
+		if u.Maps[count] >= 0 {
+			gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + ShadowTextureUnit + count))
+			gl.BindTexture(gl.TEXTURE_2D, m.Handle)
+		}
+		count++
+	}
+
+	for i := 0; i < MaxShadows; i++ {
+		if u.Maps[i] >= 0 {
+			gl.Uniform1i(u.Maps[i], gl.Int(ShadowTextureUnit+i))
+		}
+	}
+	if u.Light >= 0 {
+		gl.Uniform1iv(u.Light, MaxLights, &lights[0])
+	}
+	if u.Packed >= 0 {
+		gl.Uniform1i(u.Packed, packed)
+	}
+	if count == 0 {
+		return
+	}
+	if u.Cascades >= 0 {
+		gl.Uniform1iv(u.Cascades, MaxShadows, &cascades[0])
+	}
+	if u.Settings >= 0 {
+		gl.Uniform3fv(u.Settings, MaxShadows, &settings[0])
+	}
+	if u.Matrix >= 0 {
+		gl.UniformMatrix4fv(u.Matrix, MaxShadows*MaxCascades, gl.FALSE, &matrices[0])
+	}
+	if u.Cascade >= 0 {
+		gl.Uniform4fv(u.Cascade, MaxShadows*MaxCascades, &ranges[0])
+	}
+}
+
+func uniformVector(loc gl.Int, v algebra.Vector) {
+	if loc >= 0 {
+		f := [3]gl.Float{}
+		putVector(f[:], v)
+		gl.Uniform3fv(loc, 1, &f[0])
+	}
+}
+
+func uniformFloat(loc gl.Int, f float64) {
+	if loc >= 0 {
+		gl.Uniform1f(loc, gl.Float(f))
+	}
+}
+
+func putVector(out []gl.Float, v algebra.Vector) {
+	out[0] = gl.Float(v.X)
+	out[1] = gl.Float(v.Y)
+	out[2] = gl.Float(v.Z)
+}
+
 // InitOpenGl startup OpenGl
 func initOpenGl(width, height int32) error {
 	gl.Init()
@@ -139,15 +515,211 @@ func initOpenGl(width, height int32) error {
 		return errors.New("Initialsation failed")
 	}
 
+	if err := ext.InitExtFramebufferObject(); err != nil {
+		log.Println("Shadows, render targets and post processing are off:", err)
+	} else {
+		framebuffers = true
+	}
+
 	return nil
 }
 
-// DrawGl draw gl
-func drawGl(mesh *Mesh, material *Material) error {
+// resizeOpenGl point the viewport at the whole drawable
+func resizeOpenGl(width, height int32) error {
+	gl.Viewport(0, 0, gl.Sizei(width), gl.Sizei(height))
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		return fmt.Errorf("Viewport failed: %v", err)
+	}
+	return nil
+}
+
+// clearGl clear the frame, once before anything is drawn
+func clearGl() {
 	gl.ClearColor(1, 1, 1, 1)
-	// Swap program if needed...
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
 
+// framebuffers the driver can draw into textures, which shadows need
+var framebuffers bool
+
+// depthProgram, skinnedDepthProgram the programs the shadow pass draws
+// with, loaded the first time there are shadows. Entities with an
+// animator are drawn with the skinned one, loaded once there are some
+var depthProgram, skinnedDepthProgram *Program
+
+// drawShadowsGl draw everything that casts shadows into the lights'
+// shadow maps, cascade by cascade. Surfaces that only show shadows
+// (IllumCastsShadows) don't cast any. Without framebuffers the lights
+// lose their shadows
+func drawShadowsGl(entities []*core.Entity, lighting *Lighting) error {
+	if lighting.shadows() == 0 {
+		return nil
+	}
+	if !framebuffers {
+		for i := 0; i < len(lighting.Lights); i++ {
+			lighting.Lights[i].Shadow = nil
+		}
+		return nil
+	}
+	programs := []struct {
+		p      **Program
+		vertex string
+	}{
+		{&depthProgram, "Depth.glsl"},
+		{&skinnedDepthProgram, "DepthSkinned.glsl"},
+	}
+	skinned := false
+	for t := 0; t < len(entities) && !skinned; t++ {
+		skinned = jointMatrices(entities[t]) != nil
+	}
+	for i := 0; i < len(programs); i++ {
+		if *programs[i].p != nil || (i == 1 && !skinned) {
+			continue
+		}
+		p, err := LoadProgram(programs[i].vertex, "Depth.glsl")
+		if err != nil {
+			return err
+		}
+		*programs[i].p = &p
+	}
+
+	viewport := [4]gl.Int{}
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	defer func() {
+		ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, 0)
+		gl.Viewport(viewport[0], viewport[1], gl.Sizei(viewport[2]), gl.Sizei(viewport[3]))
+		gl.Enable(gl.BLEND)
+	}()
+	// Packed depth would be blended otherwise
+	gl.Disable(gl.BLEND)
+	disableAttributesGl()
+
+	for i := 0; i < len(lighting.Lights); i++ {
+		l := &lighting.Lights[i]
+		m := l.Shadow
+		if m == nil {
+			continue
+		}
+		if err := m.allocate(); err != nil {
+			return err
+		}
+		ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, m.framebuffer)
+		gl.ClearColor(1, 1, 1, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		for c := 0; c < len(m.Cascades); c++ {
+			gl.Viewport(gl.Int(c*m.Size), 0, gl.Sizei(m.Size), gl.Sizei(m.Size))
+			for k := 0; k < len(programs); k++ {
+				if *programs[k].p == nil {
+					continue
+				}
+				if err := drawDepthGl(*programs[k].p, k == 1, entities, m, c); err != nil {
+					return err
+				}
+			}
+		}
+		if err := gl.GetError(); err != gl.NO_ERROR {
+			return fmt.Errorf("Shadow pass failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// drawDepthGl draw the shadow casters into one cascade of a shadow map
+// with a depth program, either the ones with an animator (skinned) or
+// the ones without
+func drawDepthGl(p *Program, skinned bool, entities []*core.Entity, m *ShadowMap, c int) error {
+	used := false
+	for t := 0; t < len(entities); t++ {
+		rc, ok := entities[t].GetComponent(core.ComponentTypeRender).(*ComponentRender)
+		if !ok || rc.Material.Illumination == IllumCastsShadows {
+			continue
+		}
+		if (jointMatrices(entities[t]) != nil) != skinned {
+			continue
+		}
+		if !used {
+			used = true
+			useDepthProgramGl(p, m, c)
+		}
+		world := matrixAsArray(entities[t].Transform.GetTransformation())
+		gl.UniformMatrix4fv(p.UniWorld, 1, gl.FALSE, &world[0])
+		if err := uploadJoints(p.UniJoints, entities[t]); err != nil {
+			return err
+		}
+		uploadMorphGl(entities[t], &rc.Mesh)
+		bindMeshGl(p, &rc.Mesh)
+		gl.DrawElements(gl.TRIANGLES, gl.Sizei(rc.Mesh.Resource.Size), gl.UNSIGNED_SHORT, gl.Offset(nil, 0))
+	}
+	return nil
+}
+
+// useDepthProgramGl switch to a depth program set up for one cascade of
+// a shadow map
+func useDepthProgramGl(p *Program, m *ShadowMap, c int) {
+	gl.UseProgram(p.Program)
+	packed := gl.Int(0)
+	if m.Packed {
+		packed = 1
+	}
+	gl.Uniform1i(findUniform(p.Program, "uShadowPacked"), packed)
+
+	cascade := &m.Cascades[c]
+	matrix := matrixAsArray(&cascade.ViewProjection)
+	gl.UniformMatrix4fv(findUniform(p.Program, "uShadowMatrix"), 1, gl.FALSE, &matrix[0])
+	perspective := gl.Float(0)
+	if cascade.Perspective {
+		perspective = 1
+	}
+	gl.Uniform3f(findUniform(p.Program, "uShadowRange"), gl.Float(cascade.Near), gl.Float(cascade.Far), perspective)
+}
+
+// noAttribute what a missing attribute's location is stored as
+const noAttribute = ^gl.Uint(0)
+
+// bindMeshGl bind the mesh's buffers and point the program's attributes
+// at them
+func bindMeshGl(p *Program, mesh *Mesh) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, mesh.Resource.Vbo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.Resource.Ibo)
+	stride := gl.Sizei(geometry.VertexSize * 4)
+	attributes := []struct {
+		loc        gl.Uint
+		size       gl.Int
+		normalized gl.Boolean
+		offset     uintptr
+	}{
+		{p.PosLoc, 3, gl.FALSE, 0},
+		{p.ColorLoc, 3, gl.FALSE, 3 * 4},
+		{p.TexCoordLoc, 2, gl.TRUE, 6 * 4},
+		{p.NormalLoc, 3, gl.TRUE, 8 * 4},
+		{p.TangentLoc, 3, gl.TRUE, 11 * 4},
+		{p.JointsLoc, 4, gl.FALSE, 14 * 4},
+		{p.WeightsLoc, 4, gl.FALSE, 18 * 4},
+	}
+	for i := 0; i < len(attributes); i++ {
+		a := attributes[i]
+		if a.loc == noAttribute {
+			continue
+		}
+		gl.EnableVertexAttribArray(a.loc)
+		gl.VertexAttribPointer(a.loc, a.size, gl.FLOAT, a.normalized, stride, gl.Offset(nil, a.offset))
+	}
+}
+
+// disableAttributesGl stop reading every vertex attribute, so ones the
+// next program doesn't use don't read past the end of its buffers
+func disableAttributesGl() {
+	var count gl.Int
+	gl.GetIntegerv(gl.MAX_VERTEX_ATTRIBS, &count)
+	for i := gl.Int(0); i < count; i++ {
+		gl.DisableVertexAttribArray(gl.Uint(i))
+	}
+}
+
+// DrawGl draw gl
+func drawGl(mesh *Mesh, material *Material) error {
+	// Swap program if needed...
 	err := gl.GetError()
 	if err != gl.NO_ERROR {
 		return fmt.Errorf("Uniform failed: %v", err)
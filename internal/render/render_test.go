@@ -45,3 +45,30 @@ func TestRenderDraw(t *testing.T) {
 	}
 
 }
+
+func TestRenderResize(t *testing.T) {
+	rs := InitMockRenderSystem(t)
+
+	s := core.Settings{}
+	s.SetWindowSize(400, 300, 800, 600)
+	called := false
+	err := rs.ResizeSystem(s, func(w, h int32) error {
+		called = true
+		if w != 800 || h != 600 {
+			t.Errorf("ResizeSystem: viewport %v x %v should be the drawable 800 x 600", w, h)
+		}
+		return nil
+	})
+	if err != nil || !called {
+		t.Errorf("ResizeSystem failed")
+	}
+
+	s.SetWindowSize(0, 0, 0, 0)
+	err = rs.ResizeSystem(s, func(w, h int32) error {
+		t.Errorf("ResizeSystem: minimized windows should not resize")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ResizeSystem failed")
+	}
+}
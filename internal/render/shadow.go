@@ -0,0 +1,547 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chsc/gogl/ext"
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+const (
+	// MaxShadows most shadow casting lights in a frame, lights past it
+	// light without shadows
+	MaxShadows = 4
+	// MaxCascades most pieces a directional light's shadow is split into
+	MaxCascades = 4
+	// ShadowTextureUnit the first of the MaxShadows texture units the
+	// shadow maps are bound to, after the material's maps
+	ShadowTextureUnit = 8
+
+	// DefaultShadowMapSize texels across each cascade
+	DefaultShadowMapSize = 1024
+	// DefaultShadowBias in world units
+	DefaultShadowBias = 0.05
+	// DefaultShadowNormalBias in shadow map texels
+	DefaultShadowNormalBias = 1.5
+	// DefaultShadowCascades pieces a directional light's shadow is split
+	// into
+	DefaultShadowCascades = 3
+	// DefaultShadowDistance how far from the camera shadows reach
+	DefaultShadowDistance = 50
+)
+
+// cascadeSplitBlend how much the cascade splits follow a logarithmic
+// spread (texels the same size on screen all the way out) rather than
+// an even one (which wastes fewer texels close up)
+const cascadeSplitBlend = 0.75
+
+// spotShadowNear how close to a spot light its shadow map starts
+const spotShadowNear = 0.1
+
+// noSplit the Split of cascades that aren't picked by distance
+const noSplit = math.MaxFloat32
+
+// ShadowMap the depth of what a light sees, which anything farther away
+// along the same line is shadowed by. A directional light's map is
+// split into cascades down the camera's view, laid side by side; a spot
+// light's has just one
+type ShadowMap struct {
+	// Size the width and height of each cascade in texels
+	Size int
+	// Bias how far (world units) behind what the light sees a surface
+	// has to be to be shadowed
+	Bias float64
+	// NormalBias how many texels surfaces are pushed out along their
+	// normal before they are looked up
+	NormalBias float64
+	// Distance how far down the camera's view the cascades reach
+	Distance float64
+	Cascades []ShadowCascade
+	// Depth the software renderer's copy, Size * len(Cascades) wide and
+	// Size high with the bottom row first like the GPU's. Depths go from
+	// 0 at a cascade's Near to 1 at its Far
+	Depth []float64
+
+	// Handle the OpenGL texture, 0 until the first shadow pass
+	Handle gl.Uint
+	// Packed depth is packed into the texture's RGBA rather than kept in
+	// a depth texture, for drivers that can't draw into those
+	Packed       bool
+	framebuffer  ext.Uint
+	renderbuffer ext.Uint
+	// width, height what the texture was made at
+	width, height int
+}
+
+// ShadowCascade one piece of a shadow map and where the light sees it
+// from
+type ShadowCascade struct {
+	// ViewProjection world space to the light's clip space
+	ViewProjection algebra.Matrix
+	// Near, Far the light's depth range in world units. Depth in the
+	// map goes evenly from one to the other, even for spot lights
+	Near float64
+	Far  float64
+	// Split how far down the camera's view the cascade reaches. The
+	// first cascade that reaches a point shadows it
+	Split float64
+	// Texel world units across a texel, at a distance of 1 from spot
+	// lights
+	Texel float64
+	// Perspective the light spreads out from a point (spot lights)
+	Perspective bool
+}
+
+// shadowMap the light's shadow map brought up to date with its
+// settings. Cascades are placed later, by fitShadows
+func (l *ComponentLight) shadowMap() *ShadowMap {
+	if l.shadow == nil {
+		l.shadow = &ShadowMap{}
+	}
+	m := l.shadow
+	m.Size = l.ShadowMapSize
+	if m.Size <= 0 {
+		m.Size = DefaultShadowMapSize
+	}
+	m.Bias = l.ShadowBias
+	if m.Bias == 0 {
+		m.Bias = DefaultShadowBias
+	}
+	m.NormalBias = l.ShadowNormalBias
+	if m.NormalBias == 0 {
+		m.NormalBias = DefaultShadowNormalBias
+	}
+	m.Distance = l.ShadowDistance
+	if m.Distance <= 0 {
+		m.Distance = DefaultShadowDistance
+	}
+	count := 1
+	if l.Type == LightDirectional {
+		count = l.ShadowCascades
+		if count <= 0 {
+			count = DefaultShadowCascades
+		}
+		if count > MaxCascades {
+			count = MaxCascades
+		}
+	}
+	if len(m.Cascades) != count {
+		m.Cascades = make([]ShadowCascade, count)
+	}
+	return m
+}
+
+// shadows how many of the lights cast shadows
+func (out *Lighting) shadows() int {
+	n := 0
+	for i := 0; i < len(out.Lights); i++ {
+		if out.Lights[i].Shadow != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// fitShadows place the lights' shadow cascades. Directional lights need
+// the camera to fit theirs to, without one they lose their shadows
+func (out *Lighting) fitShadows(camera *core.ComponentCamera) {
+	for i := 0; i < len(out.Lights); i++ {
+		l := &out.Lights[i]
+		switch {
+		case l.Shadow == nil:
+		case l.Type == LightSpot:
+			l.Shadow.fitSpot(l)
+		case camera != nil && camera.View != nil && camera.Projection != nil:
+			l.Shadow.fitDirectional(l.Direction, camera)
+		default:
+			l.Shadow = nil
+		}
+	}
+}
+
+// fitSpot look out from the spot light over its whole cone
+func (m *ShadowMap) fitSpot(l *Light) {
+	far := l.Range
+	if far <= 0 {
+		far = m.Distance
+	}
+	// A little wider than the cone so PCF has texels to read at its edge
+	fov := math.Min(2*math.Acos(clamp(l.CosOuter, -1, 1))*1.1, algebra.DegToRad(170))
+
+	view := algebra.Matrix{}
+	target := algebra.Vector{}
+	l.Position.AddV(l.Direction, &target)
+	up := shadowUp(l.Direction)
+	view.InitLookAt(&l.Position, &target, &up)
+	proj := algebra.Matrix{}
+	proj.InitPerspective(algebra.PerspectiveOptions{
+		Fov:         fov,
+		AspectRatio: 1,
+		Near:        spotShadowNear,
+		Far:         far,
+	})
+
+	c := &m.Cascades[0]
+	view.Mul(proj, &c.ViewProjection)
+	c.Near = spotShadowNear
+	c.Far = far
+	c.Split = noSplit
+	c.Texel = 2 * math.Tan(fov/2) / float64(m.Size)
+	c.Perspective = true
+}
+
+// fitDirectional split the camera's view into cascades and fit each one
+// around its piece of the view. The cascades are kept the same size and
+// moved a whole texel at a time so the shadows' edges don't crawl as
+// the camera moves
+func (m *ShadowMap) fitDirectional(dir algebra.Vector, camera *core.ComponentCamera) {
+	viewProj := algebra.Matrix{}
+	camera.View.Mul(*camera.Projection, &viewProj)
+	inv := algebra.Matrix{}
+	viewProj.Inverse(&inv)
+
+	// The view's corners on the near and far planes
+	corners := [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	var near, far [4]algebra.Vector
+	for i := 0; i < 4; i++ {
+		near[i] = unprojectPoint(&inv, corners[i][0], corners[i][1], -1)
+		far[i] = unprojectPoint(&inv, corners[i][0], corners[i][1], 1)
+	}
+	depth := func(p algebra.Vector) float64 {
+		v := algebra.Vector{}
+		camera.View.Transform(p, &v)
+		return -v.Z
+	}
+	nearDepth, farDepth := depth(near[0]), depth(far[0])
+	reach := math.Min(farDepth, m.Distance)
+	count := len(m.Cascades)
+
+	lightView := algebra.Matrix{}
+	origin := algebra.Vector{W: 1}
+	up := shadowUp(dir)
+	forward := dir
+	forward.W = 1
+	lightView.InitLookAt(&origin, &forward, &up)
+
+	from := nearDepth
+	for c := 0; c < count; c++ {
+		f := float64(c+1) / float64(count)
+		split := nearDepth + (reach-nearDepth)*f
+		if nearDepth > 0 {
+			split = cascadeSplitBlend*nearDepth*math.Pow(reach/nearDepth, f) + (1-cascadeSplitBlend)*split
+		}
+
+		// Bounding sphere of this piece of the view, which stays the same
+		// size however the camera turns
+		var slice [8]algebra.Vector
+		center := algebra.Vector{}
+		for i := 0; i < 4; i++ {
+			slice[i] = lerpVector(near[i], far[i], (from-nearDepth)/(farDepth-nearDepth))
+			slice[i+4] = lerpVector(near[i], far[i], (split-nearDepth)/(farDepth-nearDepth))
+		}
+		for i := 0; i < 8; i++ {
+			center.AddV(slice[i], &center)
+		}
+		center = center.Scale(1.0 / 8)
+		radius := 0.0
+		for i := 0; i < 8; i++ {
+			radius = math.Max(radius, distance(center, slice[i]))
+		}
+		radius = math.Ceil(radius*16) / 16
+		texel := 2 * radius / float64(m.Size)
+
+		inLight := algebra.Vector{}
+		center.W = 1
+		lightView.Transform(center, &inLight)
+		inLight.X = math.Floor(inLight.X/texel) * texel
+		inLight.Y = math.Floor(inLight.Y/texel) * texel
+		// Anything up to Distance towards the light still casts into it
+		nearPlane := -inLight.Z - radius - m.Distance
+		farPlane := -inLight.Z + radius
+
+		proj := algebra.Matrix{}
+		proj.InitOrthographicBox(
+			inLight.X-radius, inLight.X+radius,
+			inLight.Y-radius, inLight.Y+radius,
+			nearPlane, farPlane)
+		cascade := &m.Cascades[c]
+		lightView.Mul(proj, &cascade.ViewProjection)
+		cascade.Near = nearPlane
+		cascade.Far = farPlane
+		cascade.Split = split
+		cascade.Texel = texel
+		cascade.Perspective = false
+		from = split
+	}
+}
+
+// shadowUp an up direction for a light looking along dir that isn't
+// along dir itself
+func shadowUp(dir algebra.Vector) algebra.Vector {
+	if math.Abs(dir.Y) > 0.99 {
+		return algebra.Vector{Z: -1}
+	}
+	return algebra.Up
+}
+
+func unprojectPoint(inv *algebra.Matrix, x, y, z float64) algebra.Vector {
+	out := algebra.Vector{}
+	inv.Transform(algebra.Vector{X: x, Y: y, Z: z, W: 1}, &out)
+	out.Div(out.W, &out)
+	out.W = 1
+	return out
+}
+
+// project where pos lands in the cascade: its map coordinates 0..1 and
+// its depth, 0 at Near to 1 at Far
+func (c *ShadowCascade) project(pos algebra.Vector) (u, v, depth float64) {
+	clip := algebra.Vector{}
+	pos.W = 1
+	c.ViewProjection.Transform(pos, &clip)
+	u = (clip.X/clip.W + 1) / 2
+	v = (clip.Y/clip.W + 1) / 2
+	if c.Perspective {
+		return u, v, (clip.W - c.Near) / (c.Far - c.Near)
+	}
+	return u, v, (clip.Z + 1) / 2
+}
+
+// cascade the cascade that covers a point viewDepth down the camera's
+// view, -1 when none do
+func (m *ShadowMap) cascade(viewDepth float64) int {
+	for i := 0; i < len(m.Cascades); i++ {
+		if viewDepth <= m.Cascades[i].Split {
+			return i
+		}
+	}
+	return -1
+}
+
+// viewDepth how far pos is down the view of the camera the shadows were
+// fitted to
+func (lighting *Lighting) viewDepth(pos algebra.Vector) float64 {
+	toPos := algebra.Vector{}
+	pos.SubV(lighting.shadowEye, &toPos)
+	return toPos.Dot(lighting.Forward)
+}
+
+// visibility how much of the light reaches pos, 0 in full shadow to 1,
+// from a 3x3 block of shadow map texels (percentage closer filtering)
+// so the edges are soft. Without a software shadow map it's all of it
+func (l *Light) visibility(pos, normal algebra.Vector, viewDepth float64) float64 {
+	m := l.Shadow
+	if m == nil || len(m.Depth) == 0 {
+		return 1
+	}
+	c := m.cascade(viewDepth)
+	if c < 0 {
+		return 1
+	}
+	cascade := &m.Cascades[c]
+
+	// Pushed out along the normal by about a texel, more where the
+	// surface is edge on to the light
+	texel := cascade.Texel
+	if cascade.Perspective {
+		texel *= distance(l.Position, pos)
+	}
+	n := algebra.Vector{}
+	normal.Normalized(&n)
+	dir, _ := l.incoming(pos)
+	push := n.Scale(m.NormalBias * texel * (1 - math.Max(0, n.Dot(dir))*0.5))
+	pos.AddV(push, &pos)
+
+	u, v, depth := cascade.project(pos)
+	if u < 0 || u > 1 || v < 0 || v > 1 || depth > 1 {
+		return 1
+	}
+	bias := m.Bias / (cascade.Far - cascade.Near)
+	size := m.Size
+	width := size * len(m.Cascades)
+	x0 := int(u * float64(size))
+	y0 := int(v * float64(size))
+	lit := 0.0
+	for y := y0 - 1; y <= y0+1; y++ {
+		for x := x0 - 1; x <= x0+1; x++ {
+			// Kept inside the cascade, the next one over is elsewhere
+			tx := c*size + clampInt(x, 0, size-1)
+			ty := clampInt(y, 0, size-1)
+			if depth-bias <= m.Depth[ty*width+tx] {
+				lit++
+			}
+		}
+	}
+	return lit / 9
+}
+
+// shadowed how much of the lights that cast shadows is blocked at pos,
+// for IllumCastsShadows surfaces which only show the shadows on them
+func (lighting *Lighting) shadowed(pos, normal algebra.Vector) float64 {
+	depth := lighting.viewDepth(pos)
+	out := 0.0
+	for i := 0; i < len(lighting.Lights); i++ {
+		l := &lighting.Lights[i]
+		if l.Shadow == nil {
+			continue
+		}
+		_, strength := l.incoming(pos)
+		out = math.Max(out, (1-l.visibility(pos, normal, depth))*math.Min(strength, 1))
+	}
+	return out
+}
+
+func distance(a, b algebra.Vector) float64 {
+	d := algebra.Vector{}
+	a.SubV(b, &d)
+	return d.Length()
+}
+
+func clampInt(i, min, max int) int {
+	if i < min {
+		return min
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
+
+// clearDepth make the software map the right size, with nothing in it
+func (m *ShadowMap) clearDepth() {
+	size := m.Size * m.Size * len(m.Cascades)
+	if len(m.Depth) != size {
+		m.Depth = make([]float64, size)
+	}
+	for i := 0; i < size; i++ {
+		m.Depth[i] = 1
+	}
+}
+
+// drawDepth draw a mesh's triangles into one of the software map's
+// cascades
+func (m *ShadowMap) drawDepth(c int, mesh *Mesh, world *algebra.Matrix) {
+	cascade := &m.Cascades[c]
+	mvp := algebra.Matrix{}
+	world.Mul(cascade.ViewProjection, &mvp)
+
+	size := float64(m.Size)
+	width := m.Size * len(m.Cascades)
+	poly := &mesh.Poly
+	vertex := func(index uint16) clipVertex {
+		return toClip(&mvp, poly.Vertices[index])
+	}
+	for i := 0; i+2 < len(poly.Indices); i += 3 {
+		clipped := clipNear([]clipVertex{
+			vertex(poly.Indices[i]),
+			vertex(poly.Indices[i+1]),
+			vertex(poly.Indices[i+2]),
+		})
+		screen := make([]softVertex, len(clipped))
+		for j := 0; j < len(clipped); j++ {
+			p := clipped[j].pos
+			invW := 1 / p.W
+			depth := (p.Z*invW + 1) / 2
+			if cascade.Perspective {
+				depth = (p.W - cascade.Near) / (cascade.Far - cascade.Near)
+			}
+			// Y up, the map's rows go bottom first
+			screen[j] = softVertex{
+				x:    (p.X*invW + 1) * 0.5 * size,
+				y:    (p.Y*invW + 1) * 0.5 * size,
+				z:    depth,
+				invW: invW,
+			}
+		}
+		for j := 2; j < len(screen); j++ {
+			a, b, d := screen[0], screen[j-1], screen[j]
+			scanTriangle(a, b, d, m.Size, m.Size, func(x, y int, w0, w1, w2 float64) {
+				// Depth is even across the light's view, so it's
+				// interpolated like any other perspective correct value
+				p0, p1, p2 := w0*a.invW, w1*b.invW, w2*d.invW
+				z := (p0*a.z + p1*b.z + p2*d.z) / (p0 + p1 + p2)
+				i := y*width + c*m.Size + x
+				if z >= 0 && z < m.Depth[i] {
+					m.Depth[i] = z
+				}
+			})
+		}
+	}
+}
+
+// allocate create the texture and framebuffer the GPU draws the map
+// into, or recreate them when the map has changed size. A depth texture
+// is tried first, then depth packed into RGBA
+func (m *ShadowMap) allocate() error {
+	width, height := m.Size*len(m.Cascades), m.Size
+	if m.Handle != 0 && m.width == width && m.height == height {
+		return nil
+	}
+	m.Delete()
+	m.width, m.height = width, height
+
+	gl.GenTextures(1, &m.Handle)
+	gl.BindTexture(gl.TEXTURE_2D, m.Handle)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	ext.GenFramebuffersEXT(1, &m.framebuffer)
+	ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, m.framebuffer)
+	defer ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, 0)
+
+	if !m.Packed {
+		// The shader compares depths itself, PCF needs the values
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24,
+			gl.Sizei(width), gl.Sizei(height), 0,
+			gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, nil)
+		ext.FramebufferTexture2DEXT(ext.FRAMEBUFFER_EXT, ext.DEPTH_ATTACHMENT_EXT,
+			ext.Enum(gl.TEXTURE_2D), ext.Uint(m.Handle), 0)
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+		gl.GetError()
+		if ext.CheckFramebufferStatusEXT(ext.FRAMEBUFFER_EXT) == ext.FRAMEBUFFER_COMPLETE_EXT {
+			return nil
+		}
+		m.Delete()
+		m.Packed = true
+		return m.allocate()
+	}
+
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA,
+		gl.Sizei(width), gl.Sizei(height), 0,
+		gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	ext.FramebufferTexture2DEXT(ext.FRAMEBUFFER_EXT, ext.COLOR_ATTACHMENT0_EXT,
+		ext.Enum(gl.TEXTURE_2D), ext.Uint(m.Handle), 0)
+	ext.GenRenderbuffersEXT(1, &m.renderbuffer)
+	ext.BindRenderbufferEXT(ext.RENDERBUFFER_EXT, m.renderbuffer)
+	ext.RenderbufferStorageEXT(ext.RENDERBUFFER_EXT, ext.Enum(gl.DEPTH_COMPONENT24),
+		ext.Sizei(width), ext.Sizei(height))
+	ext.FramebufferRenderbufferEXT(ext.FRAMEBUFFER_EXT, ext.DEPTH_ATTACHMENT_EXT,
+		ext.RENDERBUFFER_EXT, m.renderbuffer)
+	if status := ext.CheckFramebufferStatusEXT(ext.FRAMEBUFFER_EXT); status != ext.FRAMEBUFFER_COMPLETE_EXT {
+		m.Delete()
+		return fmt.Errorf("shadow map framebuffer incomplete: %x", status)
+	}
+	return nil
+}
+
+// Delete free the GPU copy of the map
+func (m *ShadowMap) Delete() {
+	if m.Handle != 0 {
+		gl.DeleteTextures(1, &m.Handle)
+		m.Handle = 0
+	}
+	if m.renderbuffer != 0 {
+		ext.DeleteRenderbuffersEXT(1, &m.renderbuffer)
+		m.renderbuffer = 0
+	}
+	if m.framebuffer != 0 {
+		ext.DeleteFramebuffersEXT(1, &m.framebuffer)
+		m.framebuffer = 0
+	}
+}
@@ -0,0 +1,123 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// mockShadowScene a camera at the origin looking down -Z, a small quad
+// 2 units away and a big one 6 units away that its shadow falls on
+func mockShadowScene(light render.ComponentLight) (*core.Scene, render.Lighting) {
+	s := &core.Scene{}
+	camera := &core.Entity{Transform: core.NewTransform()}
+	cc := core.NewComponentCamera()
+	camera.Attach(&cc)
+	cc.UpdatePerspective(100, 100, algebra.PerspectiveOptions{Fov: 90, Near: 0.1, Far: 100, PixelRatio: 1})
+	cc.UpdateViewMatrix()
+	s.Add(camera)
+	s.ActiveCamera = camera
+
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	for _, z := range []float64{-2, -6} {
+		e := &core.Entity{Transform: core.NewTransform()}
+		if z == -6 {
+			e.Transform.Scale = algebra.Vector{X: 5, Y: 5, Z: 1}
+		}
+		rc := render.NewComponentRender()
+		rc.Mesh = mockQuad(z, white)
+		e.Attach(&rc)
+		s.Add(e)
+	}
+
+	light.CastShadows = true
+	light.ShadowMapSize = 64
+	s.Add(mockLightEntity(light, algebra.Vector{}))
+
+	lighting := render.GatherLights(s)
+	r := &render.Software{}
+	r.DrawShadows(s.All(), &lighting)
+	return s, lighting
+}
+
+func TestGatherShadows(t *testing.T) {
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	sun := render.NewComponentDirectionalLight(white, 1)
+	sun.ShadowCascades = 3
+	sun.ShadowDistance = 40
+	s, l := mockShadowScene(sun)
+
+	m := l.Lights[0].Shadow
+	if m == nil || len(m.Cascades) != 3 {
+		t.Fatalf("the sun should have 3 shadow cascades %+v", m)
+	}
+	if m.Bias != render.DefaultShadowBias || m.Size != 64 {
+		t.Errorf("shadow settings %+v", m)
+	}
+	for i := 1; i < 3; i++ {
+		if m.Cascades[i].Split <= m.Cascades[i-1].Split || m.Cascades[i].Texel <= m.Cascades[i-1].Texel {
+			t.Errorf("cascades should get farther and coarser %+v", m.Cascades)
+		}
+	}
+	if last := m.Cascades[2].Split; last < 39.99 || last > 40.01 {
+		t.Errorf("cascades should reach ShadowDistance got %v", last)
+	}
+
+	// Point lights don't cast shadows, and without a camera the sun
+	// has nothing to fit its cascades to
+	point := render.NewComponentPointLight(white, 1, 10)
+	point.CastShadows = true
+	s.Add(mockLightEntity(point, algebra.Vector{}))
+	s.ActiveCamera = nil
+	for _, light := range render.GatherLights(s).Lights {
+		if light.Shadow != nil {
+			t.Errorf("%v light should have no shadow", light.Type)
+		}
+	}
+}
+
+func TestSoftwareShadows(t *testing.T) {
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	lights := map[string]render.ComponentLight{
+		"directional": render.NewComponentDirectionalLight(white, 1),
+		"spot":        render.NewComponentSpotLight(white, 1, 0, 40, 45),
+	}
+	m := render.Material{Illumination: render.IllumColorOnAmbientOn}
+	normal := algebra.Vector{Z: 1}
+	for name, light := range lights {
+		_, l := mockShadowScene(light)
+
+		behind := shade(&m, white, algebra.Vector{Z: -6}, normal, &l)
+		if behind.X > 0.01 {
+			t.Errorf("%v: the middle of the far quad should be in shadow got %v", name, behind)
+		}
+		unshadowed := l
+		unshadowed.Lights = []render.Light{l.Lights[0]}
+		unshadowed.Lights[0].Shadow = nil
+		for _, pos := range []algebra.Vector{{X: 4, Z: -6}, {Z: -2}} {
+			lit := shade(&m, white, pos, normal, &l)
+			expected := shade(&m, white, pos, normal, &unshadowed)
+			if lit.X < 0.1 || !lit.AlmostEquals(&expected) {
+				t.Errorf("%v: %v should be lit got %v", name, pos, lit)
+			}
+		}
+	}
+}
+
+func TestShadowCatcher(t *testing.T) {
+	white := algebra.Vector{X: 1, Y: 1, Z: 1}
+	_, l := mockShadowScene(render.NewComponentDirectionalLight(white, 1))
+
+	m := render.Material{Illumination: render.IllumCastsShadows}
+	normal := algebra.Vector{Z: 1}
+	shadowed := shade(&m, white, algebra.Vector{Z: -6}, normal, &l)
+	if shadowed.X != 0 || shadowed.W < 0.99 {
+		t.Errorf("a catcher should be black where shadowed got %v", shadowed)
+	}
+	lit := shade(&m, white, algebra.Vector{X: 4, Z: -6}, normal, &l)
+	if lit.W > 0.01 {
+		t.Errorf("a catcher should be invisible where lit got %v", lit)
+	}
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"fmt"
+
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// MaxJoints the most joints a skeleton can have to be skinned on the
+// GPU. Each takes four uniforms, and with the other matrices 60 just
+// fits the 256 older GL 2.1 cards give a vertex shader
+const MaxJoints = 60
+
+// UseSkinnedProgram uses the Lit (Blinn-Phong) program, with its
+// vertices moved by the skeleton of the entity's anim.ComponentAnimator
+func UseSkinnedProgram() Program {
+	program, err := LoadProgram("Skinned.glsl", "Lit.glsl")
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// UseSkinnedPBRProgram uses the PBR (Cook-Torrance) program, skinned
+// like UseSkinnedProgram
+func UseSkinnedPBRProgram() Program {
+	program, err := LoadProgram("Skinned.glsl", "PBR.glsl")
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// jointMatrices the skinning matrices of the entity's animator, nil when
+// it doesn't have one
+func jointMatrices(e *core.Entity) []algebra.Matrix {
+	if s, ok := e.GetComponent(core.ComponentTypeAnimator).(core.Skinned); ok {
+		return s.JointMatrices()
+	}
+	return nil
+}
+
+// posedMesh the mesh blended by the entity's morph targets and then
+// posed by its animator, or the mesh itself when nothing moves it
+func posedMesh(e *core.Entity, mesh *Mesh) *Mesh {
+	mesh = morphedMesh(e, mesh)
+	joints := jointMatrices(e)
+	if joints == nil {
+		return mesh
+	}
+	out := *mesh
+	out.Poly = geometry.Polyhedron{}
+	mesh.Poly.Skin(joints, &out.Poly)
+	return &out
+}
+
+// uploadJoints set a skinned program's joint matrices from the entity's
+// animator. Without one every joint is left where it was bound
+func uploadJoints(loc gl.Int, e *core.Entity) error {
+	if loc < 0 {
+		return nil
+	}
+	joints := jointMatrices(e)
+	if len(joints) > MaxJoints {
+		return fmt.Errorf("skeleton has %v joints, the GPU can skin %v", len(joints), MaxJoints)
+	}
+	values := make([]gl.Float, MaxJoints*16)
+	identity := algebra.Matrix{}
+	identity.InitIdentity()
+	for i := 0; i < MaxJoints; i++ {
+		m := &identity
+		if i < len(joints) {
+			m = &joints[i]
+		}
+		a := matrixAsArray(m)
+		copy(values[i*16:], a[:])
+	}
+	gl.UniformMatrix4fv(loc, MaxJoints, gl.FALSE, &values[0])
+	return nil
+}
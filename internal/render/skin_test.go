@@ -0,0 +1,67 @@
+package render_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func TestSoftwareSkinned(t *testing.T) {
+	s := &core.Scene{}
+	camera, _ := mockCameraEntity(40, 40)
+	s.Add(camera)
+	s.ActiveCamera = camera
+
+	// A small quad in the middle, all of it bound to the one joint
+	v := func(x, y float64) geometry.Vertex {
+		return geometry.Vertex{
+			Pos:     algebra.Vector{X: x, Y: y, Z: -2},
+			Weights: algebra.Vector{X: 1},
+		}
+	}
+	e := &core.Entity{Transform: core.NewTransform()}
+	rc := render.NewComponentRender()
+	rc.Mesh = render.Mesh{Poly: geometry.Polyhedron{
+		Vertices: []geometry.Vertex{v(-0.5, -0.5), v(0.5, -0.5), v(0.5, 0.5), v(-0.5, 0.5)},
+		Indices:  []uint16{0, 1, 2, 0, 2, 3},
+	}}
+	e.Attach(&rc)
+	s.Add(e)
+
+	r := &render.Software{}
+	r.Initialize(core.Settings{Width: 40, Height: 40})
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if err := r.RenderScene(s); err != nil {
+		t.Fatal(err)
+	}
+	if c := r.Image.RGBAAt(20, 20); c == white {
+		t.Errorf("without an animator the quad should stay in the middle got %v", c)
+	}
+
+	// The joint moves one unit right, and the quad with it
+	animator := anim.NewComponentAnimator(&anim.Skeleton{Joints: []anim.Joint{{Name: "root", Parent: -1}}})
+	e.Attach(&animator)
+	animator.Play(&anim.Clip{Tracks: []anim.Track{{
+		Property: anim.Translation,
+		Times:    []float64{0},
+		Values:   []algebra.Vector{{X: 1}},
+	}}})
+	animator.Update(0)
+	if err := r.RenderScene(s); err != nil {
+		t.Fatal(err)
+	}
+	if c := r.Image.RGBAAt(20, 20); c != white {
+		t.Errorf("the middle should be clear once the quad moves got %v", c)
+	}
+	if c := r.Image.RGBAAt(30, 20); c == white {
+		t.Errorf("the quad should follow the joint right got %v", c)
+	}
+	if rc.Mesh.Poly.Vertices[0].Pos.X != -0.5 {
+		t.Errorf("drawing skinned should leave the mesh alone")
+	}
+}
@@ -0,0 +1,404 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+// Software draws scenes on the CPU into an image, the same way the
+// Simple, Lit and PBR shaders do on the GPU (though lighting and material
+// maps are worked out per vertex rather than per pixel, shadows
+// included, and bump maps are left out). It is slow but needs no GPU or
+// window, so it works in tests and on servers
+type Software struct {
+	Image      *image.RGBA
+	ClearColor color.RGBA
+	depth      []float64
+}
+
+// softVertex a vertex after projection
+type softVertex struct {
+	// Screen position in pixels, depth 0 (near) to 1 (far)
+	x, y, z float64
+	// invW 1 / clip w for perspective correct interpolation
+	invW  float64
+	color algebra.Vector
+}
+
+// Initialize create the image at the drawable size
+func (r *Software) Initialize(s core.Settings) {
+	r.ClearColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	r.Resize(s)
+}
+
+// Resize recreate the image at the new drawable size
+func (r *Software) Resize(s core.Settings) error {
+	w, h := s.DrawableSize()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	r.Image = image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	r.depth = make([]float64, int(w)*int(h))
+	return nil
+}
+
+// RenderScene clear the image and draw every render component, after
+// the shadow maps and the cameras that draw into render targets
+func (r *Software) RenderScene(s *core.Scene) error {
+	cc, err := activeCamera(s)
+	if err != nil {
+		return err
+	}
+	lighting := GatherLights(s)
+	entities := s.All()
+	r.DrawShadows(entities, &lighting)
+	cameras := targetCameras(s)
+	for i := 0; i < len(cameras); i++ {
+		r.drawTarget(cameras[i], entities, &lighting)
+	}
+
+	r.Clear()
+	r.drawView(cc, entities, &lighting, nil)
+	r.postProcess(cc)
+	return nil
+}
+
+// drawView draw the entities' render components and SDFs from a camera,
+// leaving out any that show target like the GPU has to
+func (r *Software) drawView(cc *core.ComponentCamera, entities []*core.Entity, lighting *Lighting, target *RenderTarget) {
+	for t := 0; t < len(entities); t++ {
+		comp := entities[t].GetComponent(core.ComponentTypeRender)
+		if rc, ok := comp.(*ComponentRender); ok {
+			if target != nil && rc.Material.showsTarget(target) {
+				continue
+			}
+			r.DrawShaded(posedMesh(entities[t], &rc.Mesh), &rc.Material, entities[t].Transform.GetTransformation(),
+				cc.GetView(), cc.GetProjection(), lighting)
+		}
+		if sc, ok := entities[t].GetComponent(core.ComponentTypeSDF).(*ComponentSDF); ok {
+			r.DrawSDF(sc, cc.GetView(), cc.GetProjection(), lighting)
+		}
+	}
+}
+
+// postProcess run the camera's effects, if it has any, on the image
+func (r *Software) postProcess(cc *core.ComponentCamera) {
+	pp := postProcess(cc)
+	if pp == nil {
+		return
+	}
+	f := FrameFromImage(r.Image)
+	pp.Apply(f)
+	f.ToImage(r.Image)
+}
+
+// drawTarget draw a camera's view into its render target, by pointing
+// the renderer at the target's images for a while
+func (r *Software) drawTarget(cc *core.ComponentCamera, entities []*core.Entity, lighting *Lighting) {
+	t, ok := cc.Target.(*RenderTarget)
+	if !ok || t.Width <= 0 || t.Height <= 0 {
+		return
+	}
+	img, depth, clear := r.Image, r.depth, r.ClearColor
+	defer func() {
+		r.Image, r.depth, r.ClearColor = img, depth, clear
+	}()
+	r.Image, r.depth, r.ClearColor = t.canvas(), t.depth, t.ClearColor
+
+	r.Clear()
+	cc.UpdateViewMatrix()
+	view := lighting.from(cc)
+	r.drawView(cc, entities, &view, t)
+	r.postProcess(cc)
+	for i := 1; i < len(t.images); i++ {
+		copy(t.images[i].Pix, r.Image.Pix)
+	}
+	t.storeDepth(t.depth)
+}
+
+// DrawShadows draw the entities' render components into the shadow maps
+// of the lights that cast shadows. Surfaces that only show shadows
+// (IllumCastsShadows) don't cast any
+func (r *Software) DrawShadows(entities []*core.Entity, lighting *Lighting) {
+	for i := 0; i < len(lighting.Lights); i++ {
+		m := lighting.Lights[i].Shadow
+		if m == nil {
+			continue
+		}
+		m.clearDepth()
+		for t := 0; t < len(entities); t++ {
+			rc, ok := entities[t].GetComponent(core.ComponentTypeRender).(*ComponentRender)
+			if !ok || rc.Material.Illumination == IllumCastsShadows {
+				continue
+			}
+			world := entities[t].Transform.GetTransformation()
+			mesh := posedMesh(entities[t], &rc.Mesh)
+			for c := 0; c < len(m.Cascades); c++ {
+				m.drawDepth(c, mesh, world)
+			}
+		}
+	}
+}
+
+// Clear fill the image with ClearColor and reset the depth buffer
+func (r *Software) Clear() {
+	pix := r.Image.Pix
+	for i := 0; i < len(pix); i += 4 {
+		pix[i] = r.ClearColor.R
+		pix[i+1] = r.ClearColor.G
+		pix[i+2] = r.ClearColor.B
+		pix[i+3] = r.ClearColor.A
+	}
+	for i := 0; i < len(r.depth); i++ {
+		r.depth[i] = math.Inf(1)
+	}
+}
+
+// DrawMesh draw a mesh's triangles in their vertex colors with depth
+// testing
+func (r *Software) DrawMesh(mesh *Mesh, world, view, proj *algebra.Matrix) {
+	r.DrawShaded(mesh, nil, world, view, proj, nil)
+}
+
+// DrawShaded draw a mesh's triangles lit by lighting as material says
+func (r *Software) DrawShaded(mesh *Mesh, material *Material, world, view, proj *algebra.Matrix, lighting *Lighting) {
+	mvp := algebra.Matrix{}
+	wv := algebra.Matrix{}
+	world.Mul(*view, &wv)
+	wv.Mul(*proj, &mvp)
+
+	poly := &mesh.Poly
+	vertex := func(index uint16) clipVertex {
+		v := poly.Vertices[index]
+		out := toClip(&mvp, v)
+		if material != nil {
+			out.color = shadeVertex(v, material, world, lighting)
+		}
+		return out
+	}
+	for i := 0; i+2 < len(poly.Indices); i += 3 {
+		clipped := clipNear([]clipVertex{
+			vertex(poly.Indices[i]),
+			vertex(poly.Indices[i+1]),
+			vertex(poly.Indices[i+2]),
+		})
+		// Fan the clipped polygon back into triangles
+		for j := 2; j < len(clipped); j++ {
+			r.rasterize(
+				r.toScreen(clipped[0]),
+				r.toScreen(clipped[j-1]),
+				r.toScreen(clipped[j]))
+		}
+	}
+}
+
+// DrawSDF ray march an SDF component through every pixel, depth tested
+// against what's been drawn, like the SDF shader
+func (r *Software) DrawSDF(c *ComponentSDF, view, proj *algebra.Matrix, lighting *Lighting) {
+	// Surfaces that only show shadows have nothing to show, as the
+	// shapes don't get any
+	if c.Root == nil || c.Material.Illumination == IllumCastsShadows {
+		return
+	}
+	viewProjection := algebra.Matrix{}
+	view.Mul(*proj, &viewProjection)
+	inverseViewProjection := algebra.Matrix{}
+	viewProjection.Inverse(&inverseViewProjection)
+	world := c.world()
+	toLocal := algebra.Matrix{}
+	// Screen to the entity's space in one go
+	inverseViewProjection.Mul(*c.inverse(), &toLocal)
+
+	unshadowed := Lighting{}
+	if lighting != nil {
+		unshadowed = lighting.withoutShadows()
+		lighting = &unshadowed
+	}
+	surface := c.surface()
+	size := r.Image.Bounds().Size()
+	for y := 0; y < size.Y; y++ {
+		ndcY := 1 - (float64(y)+0.5)/float64(size.Y)*2
+		for x := 0; x < size.X; x++ {
+			ndcX := (float64(x)+0.5)/float64(size.X)*2 - 1
+			near := transformPoint(&toLocal, algebra.Vector{X: ndcX, Y: ndcY, Z: -1})
+			far := transformPoint(&toLocal, algebra.Vector{X: ndcX, Y: ndcY, Z: 1})
+			local, _, ok := c.march(near, far)
+			if !ok {
+				continue
+			}
+
+			pos := transformPoint(world, local)
+			clip := algebra.Vector{}
+			viewProjection.Transform(pos, &clip)
+			z := (clip.Z/clip.W + 1) * 0.5
+			i := y*size.X + x
+			if z < 0 || z > 1 || z >= r.depth[i] {
+				continue
+			}
+			r.depth[i] = z
+			normal := c.worldNormal(sdf.Normal(c.Root, local))
+			r.blend(x, y, Shade(&surface, pos, normal, lighting))
+		}
+	}
+}
+
+// clipVertex a vertex in clip space
+type clipVertex struct {
+	pos   algebra.Vector
+	color algebra.Vector
+}
+
+func toClip(mvp *algebra.Matrix, v geometry.Vertex) clipVertex {
+	out := clipVertex{color: v.Color}
+	out.color.W = 1
+	mvp.Transform(algebra.Vector{X: v.Pos.X, Y: v.Pos.Y, Z: v.Pos.Z, W: 1}, &out.pos)
+	return out
+}
+
+// shadeVertex light a vertex in world space, with the PBR model when
+// the material has a PBR variant
+func shadeVertex(v geometry.Vertex, m *Material, world *algebra.Matrix, lighting *Lighting) algebra.Vector {
+	pos := algebra.Vector{}
+	normal := algebra.Vector{}
+	world.Transform(algebra.Vector{X: v.Pos.X, Y: v.Pos.Y, Z: v.Pos.Z, W: 1}, &pos)
+	world.Transform(algebra.Vector{X: v.Normal.X, Y: v.Normal.Y, Z: v.Normal.Z}, &normal)
+	if m.PBR != nil {
+		surface := m.PBRSurfaceAt(v.Color, v.TexCoord)
+		return ShadePBR(&surface, pos, normal, lighting)
+	}
+	surface := m.SurfaceAt(v.Color, v.TexCoord)
+	return Shade(&surface, pos, normal, lighting)
+}
+
+// clipNear clip a polygon to the near plane (z >= -w). Anything off the
+// sides is taken care of when rasterizing
+func clipNear(in []clipVertex) []clipVertex {
+	out := []clipVertex{}
+	for i := 0; i < len(in); i++ {
+		a := in[i]
+		b := in[(i+1)%len(in)]
+		da := a.pos.Z + a.pos.W
+		db := b.pos.Z + b.pos.W
+		if da >= 0 {
+			out = append(out, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			t := da / (da - db)
+			out = append(out, clipVertex{
+				pos:   lerpVector(a.pos, b.pos, t),
+				color: lerpVector(a.color, b.color, t),
+			})
+		}
+	}
+	return out
+}
+
+func lerpVector(a, b algebra.Vector, t float64) algebra.Vector {
+	return algebra.Vector{
+		X: a.X + (b.X-a.X)*t,
+		Y: a.Y + (b.Y-a.Y)*t,
+		Z: a.Z + (b.Z-a.Z)*t,
+		W: a.W + (b.W-a.W)*t,
+	}
+}
+
+// toScreen perspective divide and viewport transform. Y goes down the
+// image like the window
+func (r *Software) toScreen(v clipVertex) softVertex {
+	size := r.Image.Bounds().Size()
+	invW := 1 / v.pos.W
+	return softVertex{
+		x:     (v.pos.X*invW + 1) * 0.5 * float64(size.X),
+		y:     (1 - v.pos.Y*invW) * 0.5 * float64(size.Y),
+		z:     (v.pos.Z*invW + 1) * 0.5,
+		invW:  invW,
+		color: v.color,
+	}
+}
+
+// scanTriangle call fn for every pixel of a width x height image whose
+// center is inside a triangle, with its barycentric weights
+func scanTriangle(a, b, c softVertex, width, height int, fn func(x, y int, w0, w1, w2 float64)) {
+	area := edge(a, b, c.x, c.y)
+	if area == 0 {
+		return
+	}
+	minX := int(math.Max(0, math.Floor(math.Min(a.x, math.Min(b.x, c.x)))))
+	maxX := int(math.Min(float64(width-1), math.Ceil(math.Max(a.x, math.Max(b.x, c.x)))))
+	minY := int(math.Max(0, math.Floor(math.Min(a.y, math.Min(b.y, c.y)))))
+	maxY := int(math.Min(float64(height-1), math.Ceil(math.Max(a.y, math.Max(b.y, c.y)))))
+
+	for y := minY; y <= maxY; y++ {
+		py := float64(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float64(x) + 0.5
+			// Both windings are drawn, like OpenGL without culling
+			w0 := edge(b, c, px, py) / area
+			w1 := edge(c, a, px, py) / area
+			w2 := edge(a, b, px, py) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			fn(x, y, w0, w1, w2)
+		}
+	}
+}
+
+// rasterize fill a triangle, sampling at pixel centers
+func (r *Software) rasterize(a, b, c softVertex) {
+	size := r.Image.Bounds().Size()
+	scanTriangle(a, b, c, size.X, size.Y, func(x, y int, w0, w1, w2 float64) {
+		z := w0*a.z + w1*b.z + w2*c.z
+		if z < 0 || z > 1 {
+			return
+		}
+		i := y*size.X + x
+		if z >= r.depth[i] {
+			return
+		}
+		r.depth[i] = z
+
+		// Perspective correct color
+		p0, p1, p2 := w0*a.invW, w1*b.invW, w2*c.invW
+		sum := p0 + p1 + p2
+		src := algebra.Vector{
+			X: (p0*a.color.X + p1*b.color.X + p2*c.color.X) / sum,
+			Y: (p0*a.color.Y + p1*b.color.Y + p2*c.color.Y) / sum,
+			Z: (p0*a.color.Z + p1*b.color.Z + p2*c.color.Z) / sum,
+		}
+		src.W = (p0*a.color.W + p1*b.color.W + p2*c.color.W) / sum
+		r.blend(x, y, src)
+	})
+}
+
+// blend put a color in a pixel, blended over what's there by its alpha
+// (W) like SRC_ALPHA, ONE_MINUS_SRC_ALPHA
+func (r *Software) blend(x, y int, src algebra.Vector) {
+	alpha := math.Max(0, math.Min(1, src.W))
+	if alpha < 1 {
+		dst := r.Image.RGBAAt(x, y)
+		src.X = src.X*alpha + float64(dst.R)/255*(1-alpha)
+		src.Y = src.Y*alpha + float64(dst.G)/255*(1-alpha)
+		src.Z = src.Z*alpha + float64(dst.B)/255*(1-alpha)
+	}
+	r.Image.SetRGBA(x, y, color.RGBA{
+		R: toByte(src.X),
+		G: toByte(src.Y),
+		B: toByte(src.Z),
+		A: 255,
+	})
+}
+
+func edge(a, b softVertex, x, y float64) float64 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+func toByte(f float64) uint8 {
+	return uint8(math.Max(0, math.Min(1, f))*255 + 0.5)
+}
@@ -0,0 +1,74 @@
+package render_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// mockQuad a square facing the camera, 2 units across
+func mockQuad(z float64, c algebra.Vector) render.Mesh {
+	v := func(x, y float64) geometry.Vertex {
+		return geometry.Vertex{Pos: algebra.Vector{X: x, Y: y, Z: z}, Color: c}
+	}
+	return render.Mesh{Poly: geometry.Polyhedron{
+		Vertices: []geometry.Vertex{v(-1, -1), v(1, -1), v(1, 1), v(-1, 1)},
+		Indices:  []uint16{0, 1, 2, 0, 2, 3},
+	}}
+}
+
+func mockSoftware() (*render.Software, *algebra.Matrix, *algebra.Matrix, *algebra.Matrix) {
+	r := &render.Software{}
+	r.Initialize(core.Settings{Width: 40, Height: 40})
+	r.Clear()
+
+	world := algebra.Matrix{}
+	world.InitIdentity()
+	view := algebra.Matrix{}
+	view.InitIdentity()
+	proj := algebra.Matrix{}
+	proj.InitOrthographic(algebra.OrthographicOptions{Size: 2, AspectRatio: 1, Near: 0.1, Far: 10})
+	return r, &world, &view, &proj
+}
+
+func TestSoftwareDepth(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	red := mockQuad(-2, algebra.Vector{X: 1})
+	blue := mockQuad(-4, algebra.Vector{Z: 1})
+
+	// Blue is further away so red stays in front whatever the order
+	r.DrawMesh(&red, world, view, proj)
+	r.DrawMesh(&blue, world, view, proj)
+
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("center should be red got %v", c)
+	}
+	// The quad covers the middle half of the view
+	if c := r.Image.RGBAAt(2, 2); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("corner should be clear got %v", c)
+	}
+}
+
+func TestSoftwareNearClip(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	behind := mockQuad(1, algebra.Vector{Y: 1})
+	r.DrawMesh(&behind, world, view, proj)
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("a quad behind the camera should not be drawn got %v", c)
+	}
+}
+
+func TestSoftwareTransparent(t *testing.T) {
+	r, world, view, proj := mockSoftware()
+	quad := mockQuad(-2, algebra.Vector{X: 1, Y: 1, Z: 1})
+	m := render.Material{Illumination: render.IllumColorOnAmbientOff, DiffuseColor: algebra.Vector{Z: 1}, Transparent: 0.25}
+	r.DrawShaded(&quad, &m, world, view, proj, nil)
+	// A quarter blue over white
+	if c := r.Image.RGBAAt(20, 20); c != (color.RGBA{R: 191, G: 191, B: 255, A: 255}) {
+		t.Errorf("transparent quad should be pale blue got %v", c)
+	}
+}
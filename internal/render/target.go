@@ -0,0 +1,325 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/chsc/gogl/ext"
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// TargetFormat how a render target's color attachments store their
+// pixels
+type TargetFormat uint8
+
+const (
+	// TargetRGBA8 8 bits a channel, 0 to 1
+	TargetRGBA8 TargetFormat = iota
+	// TargetRGBA16F half floats, which can go past 1 (on the GPU, the
+	// software renderer keeps 8 bits)
+	TargetRGBA16F
+)
+
+// rgba16f GL_RGBA16F_ARB from ARB_texture_float, which gl21 doesn't have
+const rgba16f = 0x881A
+
+// DepthAttachment the attachment index of a target's depth
+const DepthAttachment = -1
+
+// RenderTarget an off screen image a camera can draw into (see
+// core.ComponentCamera's Target), which materials can then use as a
+// texture: mirrors, screens, minimaps... On the GPU it's a framebuffer
+// object with a texture for each attachment
+type RenderTarget struct {
+	Name string
+	// Width, Height the size in pixels, see Resize
+	Width  int
+	Height int
+	Format TargetFormat
+	// Colors how many color attachments there are. Shaders write the
+	// same color to all of them unless they use gl_FragData
+	Colors int
+	// Depth whether there is a depth attachment, without one nothing is
+	// depth tested on the GPU
+	Depth bool
+	// ClearColor what the target is cleared to before each frame
+	ClearColor color.RGBA
+
+	// images, depth the software renderer's copies, scratch what it
+	// draws into when there are no color attachments
+	images     []*image.RGBA
+	scratch    *image.RGBA
+	depth      []float64
+	depthImage *image.Gray16
+
+	handles      []gl.Uint
+	depthHandle  gl.Uint
+	renderbuffer ext.Uint
+	framebuffer  ext.Uint
+	// width, height what the GPU copy was made at
+	width, height int
+}
+
+// NewRenderTarget create a target with colors color attachments, and a
+// depth attachment if depth is set. It's cleared to opaque black
+func NewRenderTarget(name string, width, height int, format TargetFormat, colors int, depth bool) *RenderTarget {
+	t := &RenderTarget{
+		Name:       name,
+		Format:     format,
+		Colors:     colors,
+		Depth:      depth,
+		ClearColor: color.RGBA{A: 255},
+	}
+	t.Resize(width, height)
+	return t
+}
+
+// TargetSize the size in pixels
+func (t *RenderTarget) TargetSize() (int32, int32) {
+	return int32(t.Width), int32(t.Height)
+}
+
+// Resize change the target's size. The GPU copy is made again the next
+// time it's drawn into, and textures from it follow along
+func (t *RenderTarget) Resize(width, height int) {
+	t.Width, t.Height = width, height
+	t.images = make([]*image.RGBA, t.Colors)
+	for i := 0; i < t.Colors; i++ {
+		t.images[i] = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	t.scratch = nil
+	t.depth = make([]float64, width*height)
+	t.depthImage = image.NewGray16(image.Rect(0, 0, width, height))
+}
+
+// Texture color attachment i (or DepthAttachment) as a texture for a
+// material. It shows whatever was last drawn into the target
+func (t *RenderTarget) Texture(i int) Texture {
+	return Texture{Name: t.Name, target: t, attachment: i}
+}
+
+// Image the software renderer's copy of color attachment i (or
+// DepthAttachment, where white is far), nil when there isn't one
+func (t *RenderTarget) Image(i int) image.Image {
+	if i == DepthAttachment {
+		if !t.Depth {
+			return nil
+		}
+		return t.depthImage
+	}
+	if i < 0 || i >= len(t.images) {
+		return nil
+	}
+	return t.images[i]
+}
+
+// handle the GPU texture of an attachment, 0 before the target is first
+// drawn into
+func (t *RenderTarget) handle(i int) gl.Uint {
+	if i == DepthAttachment {
+		return t.depthHandle
+	}
+	if i < 0 || i >= len(t.handles) {
+		return 0
+	}
+	return t.handles[i]
+}
+
+// canvas the image the software renderer draws into
+func (t *RenderTarget) canvas() *image.RGBA {
+	if len(t.images) > 0 {
+		return t.images[0]
+	}
+	if t.scratch == nil {
+		t.scratch = image.NewRGBA(image.Rect(0, 0, t.Width, t.Height))
+	}
+	return t.scratch
+}
+
+// storeDepth keep a software depth buffer (0 near to 1 far, infinity
+// where nothing was drawn) as the depth attachment's image
+func (t *RenderTarget) storeDepth(depth []float64) {
+	if !t.Depth {
+		return
+	}
+	for i := 0; i < len(depth) && i < t.Width*t.Height; i++ {
+		d := math.Min(depth[i], 1)
+		t.depthImage.SetGray16(i%t.Width, i/t.Width, color.Gray16{Y: uint16(d*65535 + 0.5)})
+	}
+}
+
+// bind make the target what the GPU draws into, creating it first if
+// needed, and clear it
+func (t *RenderTarget) bind() error {
+	if err := t.allocate(); err != nil {
+		return err
+	}
+	ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, t.framebuffer)
+	gl.Viewport(0, 0, gl.Sizei(t.Width), gl.Sizei(t.Height))
+	c := t.ClearColor
+	gl.ClearColor(gl.Float(c.R)/255, gl.Float(c.G)/255, gl.Float(c.B)/255, gl.Float(c.A)/255)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	return nil
+}
+
+// allocate create the framebuffer and its textures, or make them again
+// when the target has changed size. The depth attachment is a texture
+// when the driver can draw into one, a renderbuffer (which materials
+// can't use) when it can't
+func (t *RenderTarget) allocate() error {
+	if t.framebuffer != 0 && t.width == t.Width && t.height == t.Height {
+		return nil
+	}
+	t.Delete()
+	t.width, t.height = t.Width, t.Height
+	w, h := gl.Sizei(t.Width), gl.Sizei(t.Height)
+
+	ext.GenFramebuffersEXT(1, &t.framebuffer)
+	ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, t.framebuffer)
+	defer ext.BindFramebufferEXT(ext.FRAMEBUFFER_EXT, 0)
+
+	internal, kind := gl.Int(gl.RGBA8), gl.Enum(gl.UNSIGNED_BYTE)
+	if t.Format == TargetRGBA16F {
+		internal, kind = rgba16f, gl.FLOAT
+	}
+	t.handles = make([]gl.Uint, t.Colors)
+	buffers := make([]gl.Enum, t.Colors)
+	for i := 0; i < t.Colors; i++ {
+		t.handles[i] = targetTexture()
+		gl.TexImage2D(gl.TEXTURE_2D, 0, internal, w, h, 0, gl.RGBA, kind, nil)
+		attachment := ext.Enum(ext.COLOR_ATTACHMENT0_EXT + i)
+		ext.FramebufferTexture2DEXT(ext.FRAMEBUFFER_EXT, attachment,
+			ext.Enum(gl.TEXTURE_2D), ext.Uint(t.handles[i]), 0)
+		buffers[i] = gl.Enum(attachment)
+	}
+	switch {
+	case t.Colors == 0:
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	case t.Colors == 1:
+		gl.DrawBuffer(buffers[0])
+	default:
+		gl.DrawBuffers(gl.Sizei(t.Colors), &buffers[0])
+	}
+
+	if t.Depth {
+		t.depthHandle = targetTexture()
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, w, h, 0,
+			gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, nil)
+		ext.FramebufferTexture2DEXT(ext.FRAMEBUFFER_EXT, ext.DEPTH_ATTACHMENT_EXT,
+			ext.Enum(gl.TEXTURE_2D), ext.Uint(t.depthHandle), 0)
+		if ext.CheckFramebufferStatusEXT(ext.FRAMEBUFFER_EXT) != ext.FRAMEBUFFER_COMPLETE_EXT {
+			ext.FramebufferTexture2DEXT(ext.FRAMEBUFFER_EXT, ext.DEPTH_ATTACHMENT_EXT,
+				ext.Enum(gl.TEXTURE_2D), 0, 0)
+			gl.DeleteTextures(1, &t.depthHandle)
+			t.depthHandle = 0
+			ext.GenRenderbuffersEXT(1, &t.renderbuffer)
+			ext.BindRenderbufferEXT(ext.RENDERBUFFER_EXT, t.renderbuffer)
+			ext.RenderbufferStorageEXT(ext.RENDERBUFFER_EXT, ext.Enum(gl.DEPTH_COMPONENT24),
+				ext.Sizei(w), ext.Sizei(h))
+			ext.FramebufferRenderbufferEXT(ext.FRAMEBUFFER_EXT, ext.DEPTH_ATTACHMENT_EXT,
+				ext.RENDERBUFFER_EXT, t.renderbuffer)
+		}
+	}
+
+	if status := ext.CheckFramebufferStatusEXT(ext.FRAMEBUFFER_EXT); status != ext.FRAMEBUFFER_COMPLETE_EXT {
+		t.Delete()
+		return fmt.Errorf("render target %q incomplete: %x", t.Name, status)
+	}
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		t.Delete()
+		return fmt.Errorf("render target %q failed: %v", t.Name, err)
+	}
+	return nil
+}
+
+// targetTexture a new texture, bound, that's filtered and clamped the
+// way attachments want
+func targetTexture() gl.Uint {
+	var handle gl.Uint
+	gl.GenTextures(1, &handle)
+	gl.BindTexture(gl.TEXTURE_2D, handle)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	return handle
+}
+
+// Delete free the GPU copy of the target
+func (t *RenderTarget) Delete() {
+	for i := 0; i < len(t.handles); i++ {
+		if t.handles[i] != 0 {
+			gl.DeleteTextures(1, &t.handles[i])
+		}
+	}
+	t.handles = nil
+	if t.depthHandle != 0 {
+		gl.DeleteTextures(1, &t.depthHandle)
+		t.depthHandle = 0
+	}
+	if t.renderbuffer != 0 {
+		ext.DeleteRenderbuffersEXT(1, &t.renderbuffer)
+		t.renderbuffer = 0
+	}
+	if t.framebuffer != 0 {
+		ext.DeleteFramebuffersEXT(1, &t.framebuffer)
+		t.framebuffer = 0
+	}
+}
+
+// targetCameras the cameras in the scene that draw into render targets
+func targetCameras(s *core.Scene) []*core.ComponentCamera {
+	out := []*core.ComponentCamera{}
+	var walk func(e *core.Entity)
+	walk = func(e *core.Entity) {
+		components := e.Components()
+		for i := 0; i < len(components); i++ {
+			if c, ok := components[i].(*core.ComponentCamera); ok && c.Target != nil {
+				out = append(out, c)
+			}
+		}
+		children := e.Children()
+		for i := 0; i < len(children); i++ {
+			walk(children[i])
+		}
+	}
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		walk(entities[i])
+	}
+	return out
+}
+
+// from the lighting as seen by camera, whose position highlights are
+// worked out from. Shadow cascades stay fitted to the active camera
+func (lighting Lighting) from(camera *core.ComponentCamera) Lighting {
+	lighting.Eye = camera.GetParent().Transform.Position
+	lighting.Eye.W = 0
+	return lighting
+}
+
+// showsTarget whether any of the material's maps come from target
+func (m *Material) showsTarget(target *RenderTarget) bool {
+	textures, _ := m.textures()
+	for i := 0; i < len(textures); i++ {
+		if textures[i].target == target {
+			return true
+		}
+	}
+	if m.PBR == nil {
+		return false
+	}
+	pbr, _ := m.PBR.textures()
+	for i := 0; i < len(pbr); i++ {
+		if pbr[i].target == target {
+			return true
+		}
+	}
+	return false
+}
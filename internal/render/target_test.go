@@ -0,0 +1,108 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// mockCameraEntity a camera at the origin looking down -Z that shows a
+// 4 unit square
+func mockCameraEntity(width, height int32) (*core.Entity, *core.ComponentCamera) {
+	e := &core.Entity{Transform: core.NewTransform()}
+	cc := core.NewComponentCamera()
+	e.Attach(&cc)
+	cc.UpdateOrthographic(width, height, algebra.OrthographicOptions{Size: 2, Near: 0.1, Far: 10, PixelRatio: 1})
+	cc.UpdateViewMatrix()
+	return e, &cc
+}
+
+func TestTargetTexture(t *testing.T) {
+	target := render.NewRenderTarget("screen", 16, 16, render.TargetRGBA8, 2, true)
+	if w, h := target.TargetSize(); w != 16 || h != 16 {
+		t.Errorf("target size %vx%v", w, h)
+	}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			target.Image(0).(*image.RGBA).SetRGBA(x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+	tex := target.Texture(0)
+	if c := tex.Sample(algebra.Vector{X: 0.5, Y: 0.5}); c.X != 0 || c.Y != 1 {
+		t.Errorf("the texture should show the target got %v", c)
+	}
+	if target.Image(2) != nil || target.Image(render.DepthAttachment) == nil {
+		t.Errorf("the target should have 2 colors and a depth")
+	}
+
+	// Resizing makes new images that the texture follows
+	target.Resize(8, 4)
+	if b := target.Image(1).Bounds(); b.Dx() != 8 || b.Dy() != 4 {
+		t.Errorf("resized image %v", b)
+	}
+	if c := tex.Sample(algebra.Vector{X: 0.5, Y: 0.5}); c.Y != 0 || c.W != 0 {
+		t.Errorf("the texture should show the resized target got %v", c)
+	}
+}
+
+func TestSoftwareTarget(t *testing.T) {
+	s := &core.Scene{}
+	main, _ := mockCameraEntity(40, 40)
+	s.Add(main)
+	s.ActiveCamera = main
+
+	// A second camera in the same place draws into the target. The screen
+	// in front of both shows the target, but can't show itself, so the
+	// target sees the red quad behind it
+	target := render.NewRenderTarget("screen", 16, 16, render.TargetRGBA8, 2, true)
+	target.ClearColor = color.RGBA{B: 255, A: 255}
+	security, cc := mockCameraEntity(16, 16)
+	cc.Target = target
+	s.Add(security)
+
+	screen := &core.Entity{Transform: core.NewTransform()}
+	rc := render.NewComponentRender()
+	rc.Mesh = mockQuad(-2, algebra.Vector{X: 1, Y: 1, Z: 1})
+	rc.Material = render.Material{
+		Illumination:   render.IllumColorOnAmbientOff,
+		DiffuseColor:   algebra.Vector{X: 1, Y: 1, Z: 1},
+		DiffuseTexture: target.Texture(0),
+	}
+	screen.Attach(&rc)
+	s.Add(screen)
+
+	wall := &core.Entity{Transform: core.NewTransform()}
+	wall.Transform.Scale = algebra.Vector{X: 4, Y: 4, Z: 1}
+	wc := render.NewComponentRender()
+	wc.Mesh = mockQuad(-4, algebra.Vector{X: 1})
+	wc.Material = render.Material{Illumination: render.IllumColorOnAmbientOff, DiffuseColor: algebra.Vector{X: 1, Y: 1, Z: 1}}
+	wall.Attach(&wc)
+	s.Add(wall)
+
+	r := &render.Software{}
+	r.Initialize(core.Settings{Width: 40, Height: 40})
+	if err := r.RenderScene(s); err != nil {
+		t.Fatal(err)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	for i := 0; i < 2; i++ {
+		if c := target.Image(i).At(8, 8); c != red {
+			t.Errorf("target color %v should be red got %v", i, c)
+		}
+	}
+	depth := target.Image(render.DepthAttachment).(*image.Gray16).Gray16At(8, 8).Y
+	if depth == 0 || depth == 65535 {
+		t.Errorf("target depth should be the wall's got %v", depth)
+	}
+	if b := r.Image.Bounds(); b.Dx() != 40 {
+		t.Errorf("the renderer should go back to its own image got %v", b)
+	}
+	if c := r.Image.RGBAAt(20, 20); c != red {
+		t.Errorf("the screen should show the target got %v", c)
+	}
+}
@@ -1,9 +1,267 @@
 package render
 
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	// Decoders for LoadTexture
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+
+	gl "github.com/chsc/gogl/gl21"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
 // Texture an image that can be used on a model
 type Texture struct {
 	Name string
-	// Image: HTMLImageElement;
-	Spot uint16
-	// glTexture: WebGLTexture;
+	// Image the decoded image, nil when the texture isn't loaded
+	Image image.Image
+	Spot  uint16
+	// Handle the OpenGL texture, 0 until Upload
+	Handle gl.Uint
+
+	// target, attachment what the texture shows when it comes from a
+	// render target, see RenderTarget.Texture
+	target     *RenderTarget
+	attachment int
+}
+
+var (
+	// whiteTexture bound in place of missing color maps
+	whiteTexture = NewTexture("white", solidImage(color.NRGBA{R: 255, G: 255, B: 255, A: 255}))
+	// flatTexture bound in place of a missing bump map, a normal
+	// pointing straight out
+	flatTexture = NewTexture("flat", solidImage(color.NRGBA{R: 128, G: 128, B: 255, A: 255}))
+)
+
+// NewTexture create a texture from an image
+func NewTexture(name string, img image.Image) Texture {
+	return Texture{Name: name, Image: img}
+}
+
+// LoadTexture read a PNG or JPEG from disk
+func LoadTexture(path string) (Texture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Texture{}, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Texture{}, fmt.Errorf("%v: %v", path, err)
+	}
+	return NewTexture(path, img), nil
+}
+
+// Sample the color at texture coordinate uv, bilinear filtered and
+// repeating. V goes up the image like in OpenGL. A texture without an
+// image is white
+func (t *Texture) Sample(uv algebra.Vector) algebra.Vector {
+	img := t.image()
+	if img == nil {
+		return algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	}
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	x := uv.X*w - 0.5
+	y := (1-uv.Y)*h - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+
+	texel := func(x, y float64) algebra.Vector {
+		px := b.Min.X + int(wrap(x, w))
+		py := b.Min.Y + int(wrap(y, h))
+		c := color.NRGBAModel.Convert(img.At(px, py)).(color.NRGBA)
+		return algebra.Vector{
+			X: float64(c.R) / 255,
+			Y: float64(c.G) / 255,
+			Z: float64(c.B) / 255,
+			W: float64(c.A) / 255,
+		}
+	}
+	top := lerpVector(texel(x0, y0), texel(x0+1, y0), fx)
+	bottom := lerpVector(texel(x0, y0+1), texel(x0+1, y0+1), fx)
+	return lerpVector(top, bottom, fy)
+}
+
+// image the texture's image, or its render target's software copy
+func (t *Texture) image() image.Image {
+	if t.target != nil {
+		return t.target.Image(t.attachment)
+	}
+	return t.Image
+}
+
+func wrap(f, size float64) float64 {
+	f = math.Mod(f, size)
+	if f < 0 {
+		f += size
+	}
+	return f
+}
+
+// Upload send the image to the GPU, with mipmaps
+func (t *Texture) Upload() error {
+	if t.Image == nil {
+		return fmt.Errorf("texture %q has no image", t.Name)
+	}
+	pix := flipped(t.Image)
+	size := pix.Bounds().Size()
+	if t.Handle == 0 {
+		gl.GenTextures(1, &t.Handle)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.Handle)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.GENERATE_MIPMAP, gl.TRUE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA,
+		gl.Sizei(size.X), gl.Sizei(size.Y), 0,
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Pointer(&pix.Pix[0]))
+	if err := gl.GetError(); err != gl.NO_ERROR {
+		return fmt.Errorf("texture %q upload failed: %v", t.Name, err)
+	}
+	return nil
+}
+
+// Delete free the GPU copy of the texture
+func (t *Texture) Delete() {
+	if t.Handle != 0 {
+		gl.DeleteTextures(1, &t.Handle)
+		t.Handle = 0
+	}
+}
+
+// bind make the texture current on a texture unit, uploading it first
+// if needed. Without an image fallback is bound instead, as it is for a
+// render target that hasn't been drawn into yet
+func (t *Texture) bind(unit int, fallback *Texture) error {
+	if t.target != nil {
+		handle := t.target.handle(t.attachment)
+		if handle == 0 {
+			return fallback.bind(unit, fallback)
+		}
+		gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + unit))
+		gl.BindTexture(gl.TEXTURE_2D, handle)
+		return nil
+	}
+	if t.Image == nil {
+		t = fallback
+	}
+	if t.Handle == 0 {
+		if err := t.Upload(); err != nil {
+			return err
+		}
+	}
+	gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + unit))
+	gl.BindTexture(gl.TEXTURE_2D, t.Handle)
+	return nil
+}
+
+// flipped the image as non premultiplied RGBA with the bottom row
+// first, which is the order OpenGL wants it in
+func flipped(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	stride := out.Stride
+	row := make([]uint8, stride)
+	for y := 0; y < b.Dy()/2; y++ {
+		top := out.Pix[y*stride : (y+1)*stride]
+		bottom := out.Pix[(b.Dy()-1-y)*stride : (b.Dy()-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+	return out
+}
+
+func solidImage(c color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return img
+}
+
+// scalarImage a single channel version of img for maps that only need
+// one value (map_Ns, map_d). With useAlpha an image that has any
+// transparency gives its alpha, otherwise it's the brightness
+func scalarImage(img image.Image, useAlpha bool) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	alpha := useAlpha && !isOpaque(img)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.At(x, y)
+			var g uint8
+			if alpha {
+				_, _, _, a := c.RGBA()
+				g = uint8(a >> 8)
+			} else {
+				g = color.GrayModel.Convert(c).(color.Gray).Y
+			}
+			out.SetGray(x-b.Min.X, y-b.Min.Y, color.Gray{Y: g})
+		}
+	}
+	return out
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
+
+// isGray every pixel is a shade of grey, so a bump map is a height map
+// rather than a normal map
+func isGray(img image.Image) bool {
+	switch img.(type) {
+	case *image.Gray, *image.Gray16:
+		return true
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r != g || g != bl {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// heightToNormal turn a height map (white is high) into a tangent space
+// normal map, using the slope between each pixel's neighbours
+func heightToNormal(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	height := func(x, y int) float64 {
+		x = (x + w) % w
+		y = (y + h) % h
+		return float64(color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y) / 255
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Image rows go down but V goes up
+			du := height(x+1, y) - height(x-1, y)
+			dv := height(x, y-1) - height(x, y+1)
+			n := algebra.Vector{}
+			(&algebra.Vector{X: -du, Y: -dv, Z: 1}).Normalized(&n)
+			out.SetNRGBA(x, y, color.NRGBA{
+				R: toByte(n.X*0.5 + 0.5),
+				G: toByte(n.Y*0.5 + 0.5),
+				B: toByte(n.Z*0.5 + 0.5),
+				A: 255,
+			})
+		}
+	}
+	return out
 }
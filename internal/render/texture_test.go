@@ -0,0 +1,48 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+func mockTexture() render.Texture {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{G: 255, A: 255})
+	img.SetNRGBA(0, 1, color.NRGBA{B: 255, A: 255})
+	img.SetNRGBA(1, 1, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	return render.NewTexture("test", img)
+}
+
+func TestTextureSample(t *testing.T) {
+	tex := mockTexture()
+
+	// V runs up so the top row of the image is at v = 1
+	red := algebra.Vector{X: 1, W: 1}
+	if c := tex.Sample(algebra.Vector{X: 0.25, Y: 0.75}); !c.AlmostEquals(&red) {
+		t.Errorf("top left should be red got %v", c)
+	}
+	blue := algebra.Vector{Z: 1, W: 1}
+	if c := tex.Sample(algebra.Vector{X: 0.25, Y: 0.25}); !c.AlmostEquals(&blue) {
+		t.Errorf("bottom left should be blue got %v", c)
+	}
+	// Repeats
+	if c := tex.Sample(algebra.Vector{X: 1.25, Y: -0.25}); !c.AlmostEquals(&red) {
+		t.Errorf("repeated top left should be red got %v", c)
+	}
+	// Half way between red and green
+	expected := algebra.Vector{X: 0.5, Y: 0.5, W: 1}
+	if c := tex.Sample(algebra.Vector{X: 0.5, Y: 0.75}); !c.AlmostEquals(&expected) {
+		t.Errorf("filtered %v should be %v", c, expected)
+	}
+
+	missing := render.Texture{}
+	white := algebra.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	if c := missing.Sample(algebra.Vector{}); c != white {
+		t.Errorf("a texture without an image should be white got %v", c)
+	}
+}
@@ -0,0 +1,42 @@
+package replay
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// HashScene a hash of the name and transform of every entity in the
+// scene. Any change to a position, rotation or scale, however small,
+// changes the hash
+func HashScene(scene *core.Scene) uint64 {
+	h := fnv.New64a()
+	entities := scene.All()
+	for i := 0; i < len(entities); i++ {
+		hashEntity(h, entities[i])
+	}
+	return h.Sum64()
+}
+
+func hashEntity(h hash.Hash64, e *core.Entity) {
+	h.Write([]byte(e.Name))
+	if t := e.Transform; t != nil {
+		values := []float64{
+			t.Position.X, t.Position.Y, t.Position.Z,
+			t.Rotation.X, t.Rotation.Y, t.Rotation.Z, t.Rotation.W,
+			t.Scale.X, t.Scale.Y, t.Scale.Z,
+		}
+		buf := make([]byte, 8)
+		for i := 0; i < len(values); i++ {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(values[i]))
+			h.Write(buf)
+		}
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		hashEntity(h, children[i])
+	}
+}
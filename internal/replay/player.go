@@ -0,0 +1,87 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+)
+
+// DivergenceError a replay stopped matching its recording
+type DivergenceError struct {
+	// Frame the first frame that differed, counting from 0
+	Frame    int
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("replay diverged at frame %v: expected hash %x got %x",
+		e.Frame, e.Expected, e.Actual)
+}
+
+// Player an input.Source that plays back a recording
+type Player struct {
+	Recording *Recording
+	frame     int
+}
+
+// NewPlayer play back rec from the start
+func NewPlayer(rec *Recording) *Player {
+	return &Player{Recording: rec, frame: -1}
+}
+
+// Next the next recorded frame, empty once the recording is over
+func (p *Player) Next() input.Frame {
+	p.frame++
+	if p.frame >= len(p.Recording.Frames) {
+		return input.Frame{}
+	}
+	return p.Recording.Frames[p.frame].Input
+}
+
+// Frame the frame last returned by Next, -1 before the first
+func (p *Player) Frame() int {
+	return p.frame
+}
+
+// Done whether every recorded frame has been played
+func (p *Player) Done() bool {
+	return p.frame >= len(p.Recording.Frames)-1
+}
+
+// Check compare the scene to the recording for the current frame.
+// Frames recorded without a hash always pass
+func (p *Player) Check(scene *core.Scene) error {
+	if p.frame < 0 || p.frame >= len(p.Recording.Frames) {
+		return nil
+	}
+	expected := p.Recording.Frames[p.frame].Hash
+	if expected == 0 {
+		return nil
+	}
+	if actual := HashScene(scene); actual != expected {
+		return &DivergenceError{Frame: p.frame, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// StepFunc advance the simulation one frame of dt seconds reading from
+// in, which has already been updated for the frame
+type StepFunc func(in *input.System, dt float64)
+
+// Verify replay a whole recording through step and check the scene
+// after every frame. Returns a *DivergenceError for the first frame
+// that doesn't match
+func Verify(rec *Recording, m *input.Map, scene *core.Scene, step StepFunc) error {
+	player := NewPlayer(rec)
+	in := input.NewSystem(player, m)
+	for !player.Done() {
+		in.Update()
+		step(in, rec.FixedStep)
+		if err := player.Check(scene); err != nil {
+			return err
+		}
+	}
+	return nil
+}
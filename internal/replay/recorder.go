@@ -0,0 +1,42 @@
+package replay
+
+import (
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+)
+
+// Recorder an input.Source that passes through another source while
+// keeping a copy of every frame
+type Recorder struct {
+	Recording *Recording
+	source    input.Source
+}
+
+// NewRecorder record what source produces. The simulation must advance
+// by exactly fixedStep each frame for the recording to replay
+func NewRecorder(source input.Source, fixedStep float64) *Recorder {
+	return &Recorder{
+		Recording: &Recording{
+			Version:   Version,
+			FixedStep: fixedStep,
+		},
+		source: source,
+	}
+}
+
+// Next the next frame from the source
+func (r *Recorder) Next() input.Frame {
+	f := r.source.Next()
+	r.Recording.Frames = append(r.Recording.Frames, Frame{Input: f})
+	return f
+}
+
+// Checkpoint remember the state of the scene after the current frame
+// so a replay can be checked against it
+func (r *Recorder) Checkpoint(scene *core.Scene) {
+	n := len(r.Recording.Frames)
+	if n == 0 {
+		return
+	}
+	r.Recording.Frames[n-1].Hash = HashScene(scene)
+}
@@ -0,0 +1,73 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robrohan/mesh/internal/input"
+)
+
+// Version the recording format written by this package
+const Version = 1
+
+// Recording the input for every frame of a run and the fixed step it was
+// simulated with, plus a hash of the scene after each frame
+type Recording struct {
+	Version int `json:"version"`
+	// FixedStep seconds simulated per frame
+	FixedStep float64 `json:"fixedStep"`
+	Frames    []Frame `json:"frames"`
+}
+
+// Frame one recorded frame
+type Frame struct {
+	Input input.Frame `json:"input"`
+	// Hash the scene's transforms after the frame was simulated, 0 when
+	// it wasn't checked
+	Hash uint64 `json:"hash,omitempty"`
+}
+
+// Save write the recording as JSON
+func (r *Recording) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// SaveFile write the recording to a file
+func (r *Recording) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load read a recording written by Save
+func Load(rd io.Reader) (*Recording, error) {
+	r := &Recording{}
+	if err := json.NewDecoder(rd).Decode(r); err != nil {
+		return nil, err
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %v", r.Version)
+	}
+	if r.FixedStep <= 0 {
+		return nil, fmt.Errorf("recording has no fixed step")
+	}
+	return r, nil
+}
+
+// LoadFile read a recording from a file
+func LoadFile(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
@@ -0,0 +1,142 @@
+package replay_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/input"
+	"github.com/robrohan/mesh/internal/physics"
+	"github.com/robrohan/mesh/internal/replay"
+)
+
+var inputMap = &input.Map{
+	Actions: map[string][]input.Button{"push": {"key:space"}},
+}
+
+// mockGame a ball on the ground that gets pushed while space is held
+type mockGame struct {
+	scene   *core.Scene
+	physics *physics.System
+	ball    *physics.ComponentRigidBody
+}
+
+func newMockGame() *mockGame {
+	g := &mockGame{
+		scene:   &core.Scene{},
+		physics: physics.NewSystem(),
+	}
+
+	ground := core.Entity{Name: "ground", Transform: core.NewTransform()}
+	groundCollider := physics.NewComponentCollider(&physics.PlaneShape{Normal: algebra.Up})
+	ground.Attach(&groundCollider)
+
+	ball := core.Entity{Name: "ball", Transform: core.NewTransform()}
+	ball.Transform.Position.Y = 0.5
+	ballCollider := physics.NewComponentCollider(&physics.SphereShape{Radius: 0.5})
+	ball.Attach(&ballCollider)
+	rb := physics.NewComponentRigidBody(1)
+	ball.Attach(&rb)
+	g.ball = &rb
+
+	g.scene.Add(&ground)
+	g.scene.Add(&ball)
+	g.physics.AddScene(g.scene)
+	return g
+}
+
+func (g *mockGame) step(in *input.System, dt float64) {
+	if in.Held("push") {
+		g.ball.AddForce(algebra.Vector{X: 10})
+	}
+	g.physics.Update(dt)
+}
+
+func record(t *testing.T) *replay.Recording {
+	script := []input.Frame{}
+	for i := 0; i < 60; i++ {
+		f := input.Frame{}
+		if i >= 10 && i < 30 {
+			f.Held = []input.Button{"key:space"}
+		}
+		script = append(script, f)
+	}
+
+	g := newMockGame()
+	recorder := replay.NewRecorder(input.NewFakeSource(script...), g.physics.FixedStep)
+	in := input.NewSystem(recorder, inputMap)
+	for i := 0; i < len(script); i++ {
+		in.Update()
+		g.step(in, recorder.Recording.FixedStep)
+		recorder.Checkpoint(g.scene)
+	}
+	return recorder.Recording
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	rec := record(t)
+	if len(rec.Frames) != 60 {
+		t.Fatalf("expected 60 recorded frames got %v", len(rec.Frames))
+	}
+
+	// Round trip through the file format
+	buf := bytes.Buffer{}
+	if err := rec.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := replay.Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	g := newMockGame()
+	if err := replay.Verify(loaded, inputMap, g.scene, g.step); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if g.ball.GetParent().Transform.Position.X < 0.5 {
+		t.Errorf("the replayed pushes should have moved the ball %v", g.ball.GetParent().Transform.Position)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	rec := record(t)
+
+	// Hold space for one frame longer than the recording did
+	rec.Frames[30].Input.Held = []input.Button{"key:space"}
+
+	g := newMockGame()
+	err := replay.Verify(rec, inputMap, g.scene, g.step)
+	div, ok := err.(*replay.DivergenceError)
+	if !ok {
+		t.Fatalf("expected a DivergenceError got %v", err)
+	}
+	if div.Frame != 30 {
+		t.Errorf("expected the first divergent frame to be 30 got %v", div.Frame)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := replay.Load(strings.NewReader(`{"version": 99, "fixedStep": 0.1}`)); err == nil {
+		t.Errorf("expected an error for an unknown version")
+	}
+	if _, err := replay.Load(strings.NewReader(`{"version": 1}`)); err == nil {
+		t.Errorf("expected an error for a missing fixed step")
+	}
+	if _, err := replay.LoadFile("testdata/missing.json"); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestHashScene(t *testing.T) {
+	g := newMockGame()
+	before := replay.HashScene(g.scene)
+	if before != replay.HashScene(g.scene) {
+		t.Errorf("hash should be stable")
+	}
+	g.ball.GetParent().Transform.Position.X += 1e-12
+	if before == replay.HashScene(g.scene) {
+		t.Errorf("hash should change when a transform does")
+	}
+}
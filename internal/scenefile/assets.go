@@ -0,0 +1,151 @@
+package scenefile
+
+import (
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/render"
+)
+
+// Assets the meshes, materials, skeletons and clips a scene file can
+// refer to. Files store an asset's ID (its Name) rather than the asset
+// itself
+type Assets struct {
+	// LoadMesh called for mesh IDs that haven't been added
+	LoadMesh func(id string) (render.Mesh, error)
+	// LoadMaterial called for material IDs that haven't been added
+	LoadMaterial func(id string) (render.Material, error)
+	// LoadPrefab called for prefab IDs that haven't been added
+	LoadPrefab func(id string) (*Prefab, error)
+	// LoadSkeleton called for skeleton IDs that haven't been added
+	LoadSkeleton func(id string) (*anim.Skeleton, error)
+	// LoadClip called for clip IDs that haven't been added
+	LoadClip func(id string) (*anim.Clip, error)
+
+	meshes    map[string]render.Mesh
+	materials map[string]render.Material
+	prefabs   map[string]*Prefab
+	skeletons map[string]*anim.Skeleton
+	clips     map[string]*anim.Clip
+}
+
+// NewAssets create an empty asset store
+func NewAssets() *Assets {
+	return &Assets{
+		meshes:    map[string]render.Mesh{},
+		materials: map[string]render.Material{},
+		prefabs:   map[string]*Prefab{},
+		skeletons: map[string]*anim.Skeleton{},
+		clips:     map[string]*anim.Clip{},
+	}
+}
+
+// AddMesh make a mesh available under its Name
+func (a *Assets) AddMesh(m render.Mesh) {
+	a.meshes[m.Name] = m
+}
+
+// AddMaterial make a material available under its Name
+func (a *Assets) AddMaterial(m render.Material) {
+	a.materials[m.Name] = m
+}
+
+// Mesh the mesh with the given ID, loading it if needed
+func (a *Assets) Mesh(id string) (render.Mesh, error) {
+	if m, ok := a.meshes[id]; ok {
+		return m, nil
+	}
+	if a.LoadMesh == nil {
+		return render.Mesh{}, fmt.Errorf("unknown mesh %q", id)
+	}
+	m, err := a.LoadMesh(id)
+	if err != nil {
+		return render.Mesh{}, err
+	}
+	m.Name = id
+	a.meshes[id] = m
+	return m, nil
+}
+
+// Material the material with the given ID, loading it if needed
+func (a *Assets) Material(id string) (render.Material, error) {
+	if m, ok := a.materials[id]; ok {
+		return m, nil
+	}
+	if a.LoadMaterial == nil {
+		return render.Material{}, fmt.Errorf("unknown material %q", id)
+	}
+	m, err := a.LoadMaterial(id)
+	if err != nil {
+		return render.Material{}, err
+	}
+	m.Name = id
+	a.materials[id] = m
+	return m, nil
+}
+
+// AddPrefab make a prefab available under its ID
+func (a *Assets) AddPrefab(p *Prefab) {
+	a.prefabs[p.ID] = p
+}
+
+// Prefab the prefab with the given ID, loading it if needed
+func (a *Assets) Prefab(id string) (*Prefab, error) {
+	if p, ok := a.prefabs[id]; ok {
+		return p, nil
+	}
+	if a.LoadPrefab == nil {
+		return nil, fmt.Errorf("unknown prefab %q", id)
+	}
+	p, err := a.LoadPrefab(id)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	a.prefabs[id] = p
+	return p, nil
+}
+
+// AddSkeleton make a skeleton available under its Name
+func (a *Assets) AddSkeleton(s *anim.Skeleton) {
+	a.skeletons[s.Name] = s
+}
+
+// Skeleton the skeleton with the given ID, loading it if needed
+func (a *Assets) Skeleton(id string) (*anim.Skeleton, error) {
+	if s, ok := a.skeletons[id]; ok {
+		return s, nil
+	}
+	if a.LoadSkeleton == nil {
+		return nil, fmt.Errorf("unknown skeleton %q", id)
+	}
+	s, err := a.LoadSkeleton(id)
+	if err != nil {
+		return nil, err
+	}
+	s.Name = id
+	a.skeletons[id] = s
+	return s, nil
+}
+
+// AddClip make a clip available under its Name
+func (a *Assets) AddClip(c *anim.Clip) {
+	a.clips[c.Name] = c
+}
+
+// Clip the clip with the given ID, loading it if needed
+func (a *Assets) Clip(id string) (*anim.Clip, error) {
+	if c, ok := a.clips[id]; ok {
+		return c, nil
+	}
+	if a.LoadClip == nil {
+		return nil, fmt.Errorf("unknown clip %q", id)
+	}
+	c, err := a.LoadClip(id)
+	if err != nil {
+		return nil, err
+	}
+	c.Name = id
+	a.clips[id] = c
+	return c, nil
+}
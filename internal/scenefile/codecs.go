@@ -0,0 +1,975 @@
+package scenefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/physics"
+	"github.com/robrohan/mesh/internal/render"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+// vec3 a vector written as [x, y, z]
+type vec3 [3]float64
+
+// quat a quaternion written as [x, y, z, w]
+type quat [4]float64
+
+func toVec3(v algebra.Vector) vec3 {
+	return vec3{v.X, v.Y, v.Z}
+}
+
+func (v vec3) vector() algebra.Vector {
+	return algebra.Vector{X: v[0], Y: v[1], Z: v[2]}
+}
+
+func toQuat(q algebra.Quaternion) quat {
+	return quat{q.X, q.Y, q.Z, q.W}
+}
+
+func (q quat) quaternion() algebra.Quaternion {
+	return algebra.Quaternion{X: q[0], Y: q[1], Z: q[2], W: q[3]}
+}
+
+func registerBuiltins(r *Registry) {
+	r.Register(core.ComponentTypeCamera, "camera", encodeCamera, decodeCamera)
+	r.Register(core.ComponentTypeOrbitController, "orbitController", encodeOrbit, decodeOrbit)
+	r.Register(core.ComponentTypeFlyController, "flyController", encodeFly, decodeFly)
+	r.Register(core.ComponentTypeFollowController, "followController", encodeFollow, decodeFollow)
+	r.Register(core.ComponentTypeRender, "render", encodeRender, decodeRender)
+	r.Register(core.ComponentTypeSDF, "sdf", encodeSDF, decodeSDF)
+	r.Register(core.ComponentTypeAnimator, "animator", encodeAnimator, decodeAnimator)
+	r.Register(core.ComponentTypeMorph, "morph", encodeMorph, decodeMorph)
+	r.Register(core.ComponentTypeLight, "light", encodeLight, decodeLight)
+	r.Register(core.ComponentTypePostProcess, "postProcess", encodePostProcess, decodePostProcess)
+	r.Register(core.ComponentTypeRigidBody, "rigidBody", encodeRigidBody, decodeRigidBody)
+	r.Register(core.ComponentTypeCollider, "collider", encodeCollider, decodeCollider)
+	r.Register(core.ComponentTypeCharacter, "characterController", encodeCharacter, decodeCharacter)
+	// Tweens are closures over what they move, so they're set up again
+	// by code
+	r.Skip(core.ComponentTypeTween)
+}
+
+// decode unmarshal data over the defaults already in v
+func decode(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+//////////////////////////////////////////////////////////////
+// Camera
+
+type cameraData struct {
+	Perspective  *perspectiveData  `json:"perspective,omitempty"`
+	Orthographic *orthographicData `json:"orthographic,omitempty"`
+}
+
+type perspectiveData struct {
+	// Fov field of view in degrees
+	Fov  float64 `json:"fov"`
+	Near float64 `json:"near"`
+	Far  float64 `json:"far"`
+}
+
+type orthographicData struct {
+	Size float64 `json:"size"`
+	Near float64 `json:"near"`
+	Far  float64 `json:"far"`
+}
+
+func encodeCamera(c core.Componenter, ctx *Context) (interface{}, error) {
+	cc := c.(*core.ComponentCamera)
+	out := cameraData{}
+	if o, ok := cc.PerspectiveOptions(); ok {
+		out.Perspective = &perspectiveData{Fov: o.Fov, Near: o.Near, Far: o.Far}
+	}
+	if o, ok := cc.OrthographicOptions(); ok {
+		out.Orthographic = &orthographicData{Size: o.Size, Near: o.Near, Far: o.Far}
+	}
+	return out, nil
+}
+
+func decodeCamera(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	in := cameraData{}
+	if err := decode(data, &in); err != nil {
+		return nil, err
+	}
+	cc := core.NewComponentCamera()
+	s := ctx.Settings
+	if s.Width <= 0 || s.Height <= 0 {
+		// Something sensible until the first Resize
+		s = core.Settings{Width: 1, Height: 1}
+	}
+	switch {
+	case in.Perspective != nil:
+		cc.UpdatePerspective(s.Width, s.Height, algebra.PerspectiveOptions{
+			Fov:        in.Perspective.Fov,
+			Near:       in.Perspective.Near,
+			Far:        in.Perspective.Far,
+			PixelRatio: s.GetPixelRatio(),
+		})
+	case in.Orthographic != nil:
+		cc.UpdateOrthographic(s.Width, s.Height, algebra.OrthographicOptions{
+			Size:       in.Orthographic.Size,
+			Near:       in.Orthographic.Near,
+			Far:        in.Orthographic.Far,
+			PixelRatio: s.GetPixelRatio(),
+		})
+	}
+	return &cc, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Controllers
+
+type orbitData struct {
+	Target      vec3    `json:"target"`
+	Distance    float64 `json:"distance"`
+	Yaw         float64 `json:"yaw"`
+	Pitch       float64 `json:"pitch"`
+	MinDistance float64 `json:"minDistance"`
+	MaxDistance float64 `json:"maxDistance"`
+	MinPitch    float64 `json:"minPitch"`
+	MaxPitch    float64 `json:"maxPitch"`
+	RotateSpeed float64 `json:"rotateSpeed"`
+	ZoomSpeed   float64 `json:"zoomSpeed"`
+	PanSpeed    float64 `json:"panSpeed"`
+}
+
+func encodeOrbit(c core.Componenter, ctx *Context) (interface{}, error) {
+	o := c.(*core.ComponentOrbitController)
+	return orbitData{
+		Target:      toVec3(o.Target),
+		Distance:    o.Distance,
+		Yaw:         o.Yaw,
+		Pitch:       o.Pitch,
+		MinDistance: o.MinDistance,
+		MaxDistance: o.MaxDistance,
+		MinPitch:    o.MinPitch,
+		MaxPitch:    o.MaxPitch,
+		RotateSpeed: o.RotateSpeed,
+		ZoomSpeed:   o.ZoomSpeed,
+		PanSpeed:    o.PanSpeed,
+	}, nil
+}
+
+func decodeOrbit(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	o := core.NewComponentOrbitController(algebra.Vector{}, 0)
+	in, _ := encodeOrbit(&o, ctx)
+	d := in.(orbitData)
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	o.Target = d.Target.vector()
+	o.Distance = d.Distance
+	o.Yaw = d.Yaw
+	o.Pitch = d.Pitch
+	o.MinDistance = d.MinDistance
+	o.MaxDistance = d.MaxDistance
+	o.MinPitch = d.MinPitch
+	o.MaxPitch = d.MaxPitch
+	o.RotateSpeed = d.RotateSpeed
+	o.ZoomSpeed = d.ZoomSpeed
+	o.PanSpeed = d.PanSpeed
+	return &o, nil
+}
+
+type flyData struct {
+	Yaw       float64 `json:"yaw"`
+	Pitch     float64 `json:"pitch"`
+	LookSpeed float64 `json:"lookSpeed"`
+	MoveSpeed float64 `json:"moveSpeed"`
+}
+
+func encodeFly(c core.Componenter, ctx *Context) (interface{}, error) {
+	f := c.(*core.ComponentFlyController)
+	return flyData{Yaw: f.Yaw, Pitch: f.Pitch, LookSpeed: f.LookSpeed, MoveSpeed: f.MoveSpeed}, nil
+}
+
+func decodeFly(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	f := core.NewComponentFlyController()
+	d := flyData{Yaw: f.Yaw, Pitch: f.Pitch, LookSpeed: f.LookSpeed, MoveSpeed: f.MoveSpeed}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	f.Yaw = d.Yaw
+	f.Pitch = d.Pitch
+	f.LookSpeed = d.LookSpeed
+	f.MoveSpeed = d.MoveSpeed
+	return &f, nil
+}
+
+type followData struct {
+	// Target the ID of the followed entity
+	Target       string  `json:"target,omitempty"`
+	Offset       vec3    `json:"offset"`
+	Damping      float64 `json:"damping"`
+	LookAtTarget bool    `json:"lookAtTarget"`
+}
+
+func encodeFollow(c core.Componenter, ctx *Context) (interface{}, error) {
+	f := c.(*core.ComponentFollowController)
+	out := followData{
+		Offset:       toVec3(f.Offset),
+		Damping:      f.Damping,
+		LookAtTarget: f.LookAtTarget,
+	}
+	if f.Target != nil {
+		out.Target = ctx.EntityID(f.Target)
+		if out.Target == "" {
+			return nil, errors.New("follow target is not in the scene")
+		}
+	}
+	return out, nil
+}
+
+func decodeFollow(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	f := core.NewComponentFollowController(nil, algebra.Vector{})
+	d := followData{Damping: f.Damping, LookAtTarget: f.LookAtTarget}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	f.Offset = d.Offset.vector()
+	f.Damping = d.Damping
+	f.LookAtTarget = d.LookAtTarget
+	follow := &f
+	ctx.Link(d.Target, func(e *core.Entity) { follow.Target = e })
+	return follow, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Render
+
+type renderData struct {
+	// Mesh the mesh's asset ID
+	Mesh string `json:"mesh,omitempty"`
+	// Material the material's asset ID
+	Material string `json:"material,omitempty"`
+	// Color the diffuse color, when it differs from the material asset's
+	Color *vec3 `json:"color,omitempty"`
+}
+
+func encodeRender(c core.Componenter, ctx *Context) (interface{}, error) {
+	rc := c.(*render.ComponentRender)
+	if rc.Mesh.Name == "" && len(rc.Mesh.Poly.Vertices) > 0 {
+		return nil, errors.New("mesh has no asset id (Name)")
+	}
+	out := renderData{Mesh: rc.Mesh.Name, Material: rc.Material.Name}
+	color := toVec3(rc.Material.DiffuseColor)
+	if rc.Material.Name != "" {
+		asset, err := ctx.Assets.Material(rc.Material.Name)
+		if err != nil || toVec3(asset.DiffuseColor) != color {
+			out.Color = &color
+		}
+	}
+	return out, nil
+}
+
+func decodeRender(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	d := renderData{}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	rc := render.NewComponentRender()
+	var err error
+	if d.Mesh != "" {
+		if rc.Mesh, err = ctx.Assets.Mesh(d.Mesh); err != nil {
+			return nil, err
+		}
+	}
+	if d.Material != "" {
+		if rc.Material, err = ctx.Assets.Material(d.Material); err != nil {
+			return nil, err
+		}
+	}
+	if d.Color != nil {
+		rc.Material.DiffuseColor = d.Color.vector()
+	}
+	return &rc, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Signed distance functions
+
+// nodeData one node of an SDF tree, settings left at 0 use the defaults
+type nodeData struct {
+	Type        string     `json:"type"`
+	Radius      float64    `json:"radius,omitempty"`
+	HalfExtents *vec3      `json:"halfExtents,omitempty"`
+	Rounding    float64    `json:"rounding,omitempty"`
+	A           *vec3      `json:"a,omitempty"`
+	B           *vec3      `json:"b,omitempty"`
+	Major       float64    `json:"major,omitempty"`
+	Minor       float64    `json:"minor,omitempty"`
+	Normal      *vec3      `json:"normal,omitempty"`
+	Offset      float64    `json:"offset,omitempty"`
+	Nodes       []nodeData `json:"nodes,omitempty"`
+	From        *nodeData  `json:"from,omitempty"`
+	Cut         *nodeData  `json:"cut,omitempty"`
+	Smooth      float64    `json:"smooth,omitempty"`
+	Position    *vec3      `json:"position,omitempty"`
+	Rotation    *quat      `json:"rotation,omitempty"`
+	Scale       float64    `json:"scale,omitempty"`
+	Node        *nodeData  `json:"node,omitempty"`
+}
+
+type sdfData struct {
+	Root *nodeData `json:"root,omitempty"`
+	// Material the material's asset ID
+	Material string `json:"material,omitempty"`
+	// Color the diffuse color, when it differs from the material asset's
+	Color  *vec3 `json:"color,omitempty"`
+	Bounds vec3  `json:"bounds"`
+}
+
+func encodeNodes(nodes []sdf.Node) ([]nodeData, error) {
+	out := make([]nodeData, len(nodes))
+	for i := 0; i < len(nodes); i++ {
+		n, err := encodeNode(nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = *n
+	}
+	return out, nil
+}
+
+func encodeNode(node sdf.Node) (*nodeData, error) {
+	switch n := node.(type) {
+	case sdf.Sphere:
+		return &nodeData{Type: "sphere", Radius: n.Radius}, nil
+	case sdf.Box:
+		he := toVec3(n.HalfExtents)
+		return &nodeData{Type: "box", HalfExtents: &he, Rounding: n.Rounding}, nil
+	case sdf.Capsule:
+		a, b := toVec3(n.A), toVec3(n.B)
+		return &nodeData{Type: "capsule", A: &a, B: &b, Radius: n.Radius}, nil
+	case sdf.Cylinder:
+		a, b := toVec3(n.A), toVec3(n.B)
+		return &nodeData{Type: "cylinder", A: &a, B: &b, Radius: n.Radius}, nil
+	case sdf.Torus:
+		return &nodeData{Type: "torus", Major: n.Major, Minor: n.Minor}, nil
+	case sdf.Plane:
+		normal := toVec3(n.Normal)
+		return &nodeData{Type: "plane", Normal: &normal, Offset: n.Offset}, nil
+	case sdf.Union:
+		nodes, err := encodeNodes(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return &nodeData{Type: "union", Nodes: nodes, Smooth: n.Smooth}, nil
+	case sdf.Intersect:
+		nodes, err := encodeNodes(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return &nodeData{Type: "intersect", Nodes: nodes, Smooth: n.Smooth}, nil
+	case sdf.Subtract:
+		from, err := encodeNode(n.From)
+		if err != nil {
+			return nil, err
+		}
+		cut, err := encodeNode(n.Cut)
+		if err != nil {
+			return nil, err
+		}
+		return &nodeData{Type: "subtract", From: from, Cut: cut, Smooth: n.Smooth}, nil
+	case sdf.Transform:
+		child, err := encodeNode(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		position, rotation := toVec3(n.Position), toQuat(n.Rotation)
+		return &nodeData{Type: "transform", Position: &position, Rotation: &rotation, Scale: n.Scale, Node: child}, nil
+	}
+	return nil, fmt.Errorf("can't save sdf node %T", node)
+}
+
+func decodeNodes(data []nodeData) ([]sdf.Node, error) {
+	nodes := make([]sdf.Node, len(data))
+	for i := 0; i < len(data); i++ {
+		n, err := decodeNode(&data[i])
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func decodeNode(d *nodeData) (sdf.Node, error) {
+	if d == nil {
+		return nil, errors.New("missing sdf node")
+	}
+	vector := func(v *vec3) algebra.Vector {
+		if v == nil {
+			return algebra.Vector{}
+		}
+		return v.vector()
+	}
+	switch d.Type {
+	case "sphere":
+		return sdf.Sphere{Radius: d.Radius}, nil
+	case "box":
+		return sdf.Box{HalfExtents: vector(d.HalfExtents), Rounding: d.Rounding}, nil
+	case "capsule":
+		return sdf.Capsule{A: vector(d.A), B: vector(d.B), Radius: d.Radius}, nil
+	case "cylinder":
+		return sdf.Cylinder{A: vector(d.A), B: vector(d.B), Radius: d.Radius}, nil
+	case "torus":
+		return sdf.Torus{Major: d.Major, Minor: d.Minor}, nil
+	case "plane":
+		if d.Normal == nil {
+			return nil, errors.New("plane has no normal")
+		}
+		return sdf.Plane{Normal: d.Normal.vector(), Offset: d.Offset}, nil
+	case "union":
+		nodes, err := decodeNodes(d.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return sdf.Union{Nodes: nodes, Smooth: d.Smooth}, nil
+	case "intersect":
+		nodes, err := decodeNodes(d.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return sdf.Intersect{Nodes: nodes, Smooth: d.Smooth}, nil
+	case "subtract":
+		from, err := decodeNode(d.From)
+		if err != nil {
+			return nil, err
+		}
+		cut, err := decodeNode(d.Cut)
+		if err != nil {
+			return nil, err
+		}
+		return sdf.Subtract{From: from, Cut: cut, Smooth: d.Smooth}, nil
+	case "transform":
+		child, err := decodeNode(d.Node)
+		if err != nil {
+			return nil, err
+		}
+		t := sdf.Transform{Position: vector(d.Position), Scale: d.Scale, Node: child}
+		if d.Rotation != nil {
+			t.Rotation = d.Rotation.quaternion()
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown sdf node %q", d.Type)
+}
+
+func encodeSDF(c core.Componenter, ctx *Context) (interface{}, error) {
+	sc := c.(*render.ComponentSDF)
+	out := sdfData{Material: sc.Material.Name, Bounds: toVec3(sc.Bounds)}
+	if sc.Root != nil {
+		root, err := encodeNode(sc.Root)
+		if err != nil {
+			return nil, err
+		}
+		out.Root = root
+	}
+	color := toVec3(sc.Material.DiffuseColor)
+	out.Color = &color
+	if sc.Material.Name != "" {
+		asset, err := ctx.Assets.Material(sc.Material.Name)
+		if err == nil && toVec3(asset.DiffuseColor) == color {
+			out.Color = nil
+		}
+	}
+	return out, nil
+}
+
+func decodeSDF(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	d := sdfData{}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	sc := render.NewComponentSDF(nil)
+	if d.Root != nil {
+		root, err := decodeNode(d.Root)
+		if err != nil {
+			return nil, err
+		}
+		sc.Root = root
+	}
+	if d.Material != "" {
+		m, err := ctx.Assets.Material(d.Material)
+		if err != nil {
+			return nil, err
+		}
+		sc.Material = m
+	}
+	if d.Color != nil {
+		sc.Material.DiffuseColor = d.Color.vector()
+	}
+	sc.Bounds = d.Bounds.vector()
+	return &sc, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Animation
+
+// animatorData a playing clip. A running state machine isn't saved, Run
+// it again once the scene has loaded
+type animatorData struct {
+	// Skeleton the skeleton's asset ID
+	Skeleton string `json:"skeleton,omitempty"`
+	// Clip the playing clip's asset ID
+	Clip       string             `json:"clip,omitempty"`
+	Time       float64            `json:"time"`
+	Speed      float64            `json:"speed"`
+	Loop       bool               `json:"loop"`
+	Parameters map[string]float64 `json:"parameters,omitempty"`
+}
+
+func encodeAnimator(c core.Componenter, ctx *Context) (interface{}, error) {
+	a := c.(*anim.ComponentAnimator)
+	out := animatorData{
+		Time:       a.Time,
+		Speed:      a.Speed,
+		Loop:       a.Loop,
+		Parameters: a.Parameters,
+	}
+	if a.Skeleton != nil {
+		if a.Skeleton.Name == "" {
+			return nil, errors.New("skeleton has no asset id (Name)")
+		}
+		out.Skeleton = a.Skeleton.Name
+	}
+	if a.Clip != nil {
+		if a.Clip.Name == "" {
+			return nil, errors.New("clip has no asset id (Name)")
+		}
+		out.Clip = a.Clip.Name
+	}
+	return out, nil
+}
+
+func decodeAnimator(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	a := anim.NewComponentAnimator(nil)
+	d := animatorData{Speed: a.Speed, Loop: a.Loop}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	if d.Skeleton != "" {
+		s, err := ctx.Assets.Skeleton(d.Skeleton)
+		if err != nil {
+			return nil, err
+		}
+		a = anim.NewComponentAnimator(s)
+	}
+	if d.Clip != "" {
+		clip, err := ctx.Assets.Clip(d.Clip)
+		if err != nil {
+			return nil, err
+		}
+		a.Clip = clip
+	}
+	a.Time = d.Time
+	a.Speed = d.Speed
+	a.Loop = d.Loop
+	a.Parameters = d.Parameters
+	return &a, nil
+}
+
+// morphData the weight of each of the mesh's morph targets
+type morphData struct {
+	Names   []string  `json:"names,omitempty"`
+	Weights []float64 `json:"weights"`
+}
+
+func encodeMorph(c core.Componenter, ctx *Context) (interface{}, error) {
+	m := c.(*anim.ComponentMorph)
+	if len(m.Names) > 0 && len(m.Names) != len(m.Weights) {
+		return nil, errors.New("morph has a different number of names and weights")
+	}
+	return morphData{Names: m.Names, Weights: m.Weights}, nil
+}
+
+func decodeMorph(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	d := morphData{}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	if len(d.Names) > 0 && len(d.Names) != len(d.Weights) {
+		return nil, errors.New("morph has a different number of names and weights")
+	}
+	m := anim.NewComponentMorph(&geometry.Polyhedron{})
+	m.Names = d.Names
+	m.Weights = d.Weights
+	return &m, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Lights
+
+var lightTypes = []string{"ambient", "directional", "point", "spot"}
+
+type lightData struct {
+	Type       string  `json:"type"`
+	Color      vec3    `json:"color"`
+	Intensity  float64 `json:"intensity"`
+	Range      float64 `json:"range,omitempty"`
+	InnerAngle float64 `json:"innerAngle,omitempty"`
+	OuterAngle float64 `json:"outerAngle,omitempty"`
+	// Shadow settings left at 0 use the defaults
+	CastShadows      bool    `json:"castShadows,omitempty"`
+	ShadowBias       float64 `json:"shadowBias,omitempty"`
+	ShadowNormalBias float64 `json:"shadowNormalBias,omitempty"`
+	ShadowMapSize    int     `json:"shadowMapSize,omitempty"`
+	ShadowCascades   int     `json:"shadowCascades,omitempty"`
+	ShadowDistance   float64 `json:"shadowDistance,omitempty"`
+}
+
+func encodeLight(c core.Componenter, ctx *Context) (interface{}, error) {
+	l := c.(*render.ComponentLight)
+	if int(l.Type) >= len(lightTypes) {
+		return nil, fmt.Errorf("unknown light type %v", l.Type)
+	}
+	if l.Environment != nil {
+		return nil, errors.New("environment lights can't be saved")
+	}
+	return lightData{
+		Type:       lightTypes[l.Type],
+		Color:      toVec3(l.Color),
+		Intensity:  l.Intensity,
+		Range:      l.Range,
+		InnerAngle: l.InnerAngle,
+		OuterAngle: l.OuterAngle,
+
+		CastShadows:      l.CastShadows,
+		ShadowBias:       l.ShadowBias,
+		ShadowNormalBias: l.ShadowNormalBias,
+		ShadowMapSize:    l.ShadowMapSize,
+		ShadowCascades:   l.ShadowCascades,
+		ShadowDistance:   l.ShadowDistance,
+	}, nil
+}
+
+func decodeLight(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	d := lightData{Type: "point", Color: vec3{1, 1, 1}, Intensity: 1}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	t := -1
+	for i := 0; i < len(lightTypes); i++ {
+		if lightTypes[i] == d.Type {
+			t = i
+		}
+	}
+	if t < 0 {
+		return nil, fmt.Errorf("unknown light type %q", d.Type)
+	}
+	l := render.NewComponentSpotLight(d.Color.vector(), d.Intensity, d.Range, d.InnerAngle, d.OuterAngle)
+	l.Type = render.LightType(t)
+	l.CastShadows = d.CastShadows
+	l.ShadowBias = d.ShadowBias
+	l.ShadowNormalBias = d.ShadowNormalBias
+	l.ShadowMapSize = d.ShadowMapSize
+	l.ShadowCascades = d.ShadowCascades
+	l.ShadowDistance = d.ShadowDistance
+	return &l, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Post processing
+
+var tonemapOperators = []string{"aces", "reinhard"}
+
+// effectData one effect, settings left at 0 use the defaults
+type effectData struct {
+	Type      string  `json:"type"`
+	Exposure  float64 `json:"exposure,omitempty"`
+	Operator  string  `json:"operator,omitempty"`
+	Gamma     float64 `json:"gamma,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Intensity float64 `json:"intensity,omitempty"`
+	Radius    float64 `json:"radius,omitempty"`
+	SpanMax   float64 `json:"spanMax,omitempty"`
+	Softness  float64 `json:"softness,omitempty"`
+	Color     *vec3   `json:"color,omitempty"`
+	// LUT the path of a color grading table
+	LUT      string  `json:"lut,omitempty"`
+	Strength float64 `json:"strength,omitempty"`
+}
+
+type postProcessData struct {
+	Effects []effectData `json:"effects"`
+}
+
+func encodeEffect(effect render.Effect) (effectData, error) {
+	switch e := effect.(type) {
+	case render.Tonemap:
+		if int(e.Operator) >= len(tonemapOperators) {
+			return effectData{}, fmt.Errorf("unknown tonemap operator %v", e.Operator)
+		}
+		return effectData{Type: "tonemap", Exposure: e.Exposure, Operator: tonemapOperators[e.Operator]}, nil
+	case render.Gamma:
+		return effectData{Type: "gamma", Gamma: e.Gamma}, nil
+	case render.Bloom:
+		return effectData{Type: "bloom", Threshold: e.Threshold, Intensity: e.Intensity, Radius: e.Radius}, nil
+	case render.FXAA:
+		return effectData{Type: "fxaa", SpanMax: e.SpanMax}, nil
+	case render.Vignette:
+		color := toVec3(e.Color)
+		return effectData{Type: "vignette", Intensity: e.Intensity, Radius: e.Radius, Softness: e.Softness, Color: &color}, nil
+	case render.ColorGrade:
+		if e.LUT.Name == "" {
+			return effectData{}, errors.New("color grading table has no path (Name)")
+		}
+		return effectData{Type: "colorGrade", LUT: e.LUT.Name, Strength: e.Strength}, nil
+	}
+	return effectData{}, fmt.Errorf("can't save effect %T", effect)
+}
+
+func decodeEffect(d effectData) (render.Effect, error) {
+	switch d.Type {
+	case "tonemap":
+		e := render.Tonemap{Exposure: d.Exposure}
+		if d.Operator != "" {
+			op := -1
+			for i := 0; i < len(tonemapOperators); i++ {
+				if tonemapOperators[i] == d.Operator {
+					op = i
+				}
+			}
+			if op < 0 {
+				return nil, fmt.Errorf("unknown tonemap operator %q", d.Operator)
+			}
+			e.Operator = render.TonemapOperator(op)
+		}
+		return e, nil
+	case "gamma":
+		return render.Gamma{Gamma: d.Gamma}, nil
+	case "bloom":
+		return render.Bloom{Threshold: d.Threshold, Intensity: d.Intensity, Radius: d.Radius}, nil
+	case "fxaa":
+		return render.FXAA{SpanMax: d.SpanMax}, nil
+	case "vignette":
+		e := render.Vignette{Intensity: d.Intensity, Radius: d.Radius, Softness: d.Softness}
+		if d.Color != nil {
+			e.Color = d.Color.vector()
+		}
+		return e, nil
+	case "colorGrade":
+		lut, err := render.LoadTexture(d.LUT)
+		if err != nil {
+			return nil, err
+		}
+		return render.ColorGrade{LUT: lut, Strength: d.Strength}, nil
+	}
+	return nil, fmt.Errorf("unknown effect %q", d.Type)
+}
+
+func encodePostProcess(c core.Componenter, ctx *Context) (interface{}, error) {
+	pp := c.(*render.ComponentPostProcess)
+	out := postProcessData{Effects: make([]effectData, len(pp.Effects))}
+	for i := 0; i < len(pp.Effects); i++ {
+		e, err := encodeEffect(pp.Effects[i])
+		if err != nil {
+			return nil, err
+		}
+		out.Effects[i] = e
+	}
+	return out, nil
+}
+
+func decodePostProcess(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	d := postProcessData{}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	pp := render.NewComponentPostProcess()
+	for i := 0; i < len(d.Effects); i++ {
+		e, err := decodeEffect(d.Effects[i])
+		if err != nil {
+			return nil, err
+		}
+		pp.Effects = append(pp.Effects, e)
+	}
+	return &pp, nil
+}
+
+//////////////////////////////////////////////////////////////
+// Physics
+
+type rigidBodyData struct {
+	Mass            float64 `json:"mass"`
+	Velocity        vec3    `json:"velocity"`
+	AngularVelocity vec3    `json:"angularVelocity"`
+	LinearDamping   float64 `json:"linearDamping"`
+	AngularDamping  float64 `json:"angularDamping"`
+	UseGravity      bool    `json:"useGravity"`
+}
+
+func encodeRigidBody(c core.Componenter, ctx *Context) (interface{}, error) {
+	rb := c.(*physics.ComponentRigidBody)
+	return rigidBodyData{
+		Mass:            rb.Mass,
+		Velocity:        toVec3(rb.Velocity),
+		AngularVelocity: toVec3(rb.AngularVelocity),
+		LinearDamping:   rb.LinearDamping,
+		AngularDamping:  rb.AngularDamping,
+		UseGravity:      rb.UseGravity,
+	}, nil
+}
+
+func decodeRigidBody(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	rb := physics.NewComponentRigidBody(0)
+	in, _ := encodeRigidBody(&rb, ctx)
+	d := in.(rigidBodyData)
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	rb.Mass = d.Mass
+	rb.Velocity = d.Velocity.vector()
+	rb.AngularVelocity = d.AngularVelocity.vector()
+	rb.LinearDamping = d.LinearDamping
+	rb.AngularDamping = d.AngularDamping
+	rb.UseGravity = d.UseGravity
+	return &rb, nil
+}
+
+type shapeData struct {
+	Type        string  `json:"type"`
+	Radius      float64 `json:"radius,omitempty"`
+	HalfHeight  float64 `json:"halfHeight,omitempty"`
+	HalfExtents *vec3   `json:"halfExtents,omitempty"`
+	Normal      *vec3   `json:"normal,omitempty"`
+	Offset      float64 `json:"offset,omitempty"`
+	Points      []vec3  `json:"points,omitempty"`
+}
+
+type colliderData struct {
+	Shape       shapeData `json:"shape"`
+	Friction    float64   `json:"friction"`
+	Restitution float64   `json:"restitution"`
+	IsTrigger   bool      `json:"isTrigger"`
+	Layer       uint8     `json:"layer"`
+}
+
+func encodeShape(shape physics.Shape) (shapeData, error) {
+	switch s := shape.(type) {
+	case *physics.SphereShape:
+		return shapeData{Type: "sphere", Radius: s.Radius}, nil
+	case *physics.BoxShape:
+		he := toVec3(s.HalfExtents)
+		return shapeData{Type: "box", HalfExtents: &he}, nil
+	case *physics.CapsuleShape:
+		return shapeData{Type: "capsule", Radius: s.Radius, HalfHeight: s.HalfHeight}, nil
+	case *physics.PlaneShape:
+		n := toVec3(s.Normal)
+		return shapeData{Type: "plane", Normal: &n, Offset: s.Offset}, nil
+	case *physics.HullShape:
+		points := make([]vec3, len(s.Points))
+		for i := 0; i < len(s.Points); i++ {
+			points[i] = toVec3(s.Points[i])
+		}
+		return shapeData{Type: "hull", Points: points}, nil
+	}
+	return shapeData{}, fmt.Errorf("can't save shape %T", shape)
+}
+
+func decodeShape(d shapeData) (physics.Shape, error) {
+	switch d.Type {
+	case "sphere":
+		return &physics.SphereShape{Radius: d.Radius}, nil
+	case "box":
+		if d.HalfExtents == nil {
+			return nil, errors.New("box has no halfExtents")
+		}
+		return &physics.BoxShape{HalfExtents: d.HalfExtents.vector()}, nil
+	case "capsule":
+		return &physics.CapsuleShape{Radius: d.Radius, HalfHeight: d.HalfHeight}, nil
+	case "plane":
+		if d.Normal == nil {
+			return nil, errors.New("plane has no normal")
+		}
+		return &physics.PlaneShape{Normal: d.Normal.vector(), Offset: d.Offset}, nil
+	case "hull":
+		points := make([]algebra.Vector, len(d.Points))
+		for i := 0; i < len(d.Points); i++ {
+			points[i] = d.Points[i].vector()
+		}
+		return &physics.HullShape{Points: points}, nil
+	}
+	return nil, fmt.Errorf("unknown shape %q", d.Type)
+}
+
+func encodeCollider(c core.Componenter, ctx *Context) (interface{}, error) {
+	cc := c.(*physics.ComponentCollider)
+	shape, err := encodeShape(cc.Shape)
+	if err != nil {
+		return nil, err
+	}
+	return colliderData{
+		Shape:       shape,
+		Friction:    cc.Friction,
+		Restitution: cc.Restitution,
+		IsTrigger:   cc.IsTrigger,
+		Layer:       cc.Layer,
+	}, nil
+}
+
+func decodeCollider(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	cc := physics.NewComponentCollider(nil)
+	d := colliderData{Friction: cc.Friction}
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	shape, err := decodeShape(d.Shape)
+	if err != nil {
+		return nil, err
+	}
+	cc.Shape = shape
+	cc.Friction = d.Friction
+	cc.Restitution = d.Restitution
+	cc.IsTrigger = d.IsTrigger
+	cc.Layer = d.Layer
+	return &cc, nil
+}
+
+type characterData struct {
+	Radius     float64 `json:"radius"`
+	HalfHeight float64 `json:"halfHeight"`
+	StepOffset float64 `json:"stepOffset"`
+	MaxSlope   float64 `json:"maxSlope"`
+	SkinWidth  float64 `json:"skinWidth"`
+	Layer      uint8   `json:"layer"`
+}
+
+func encodeCharacter(c core.Componenter, ctx *Context) (interface{}, error) {
+	cc := c.(*physics.ComponentCharacterController)
+	return characterData{
+		Radius:     cc.Radius,
+		HalfHeight: cc.HalfHeight,
+		StepOffset: cc.StepOffset,
+		MaxSlope:   cc.MaxSlope,
+		SkinWidth:  cc.SkinWidth,
+		Layer:      cc.Layer,
+	}, nil
+}
+
+func decodeCharacter(data json.RawMessage, ctx *Context) (core.Componenter, error) {
+	cc := physics.NewComponentCharacterController(0, 0)
+	in, _ := encodeCharacter(&cc, ctx)
+	d := in.(characterData)
+	if err := decode(data, &d); err != nil {
+		return nil, err
+	}
+	cc.Radius = d.Radius
+	cc.HalfHeight = d.HalfHeight
+	cc.StepOffset = d.StepOffset
+	cc.MaxSlope = d.MaxSlope
+	cc.SkinWidth = d.SkinWidth
+	cc.Layer = d.Layer
+	return &cc, nil
+}
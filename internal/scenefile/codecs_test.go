@@ -0,0 +1,144 @@
+package scenefile_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/render"
+	"github.com/robrohan/mesh/internal/scenefile"
+	"github.com/robrohan/mesh/internal/sdf"
+	"github.com/robrohan/mesh/internal/tween"
+)
+
+// roundTrip save a scene holding e and load it back, returning the
+// loaded copy of e
+func roundTrip(t *testing.T, e *core.Entity, assets *scenefile.Assets) *core.Entity {
+	s := &core.Scene{}
+	s.Add(e)
+	ctx := scenefile.NewContext(assets, core.Settings{})
+	buf := bytes.Buffer{}
+	if err := scenefile.Save(&buf, s, ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := scenefile.Load(&buf, scenefile.NewContext(assets, core.Settings{}))
+	if err != nil {
+		t.Fatalf("Load: %v\n%v", err, buf.String())
+	}
+	return loaded.All()[0]
+}
+
+func vectorsEqual(a, b algebra.Vector) bool {
+	return a.X == b.X && a.Y == b.Y && a.Z == b.Z
+}
+
+func TestSDFCodec(t *testing.T) {
+	root := sdf.Subtract{
+		From: sdf.Union{Nodes: []sdf.Node{
+			sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 1, Z: 1}, Rounding: 0.1},
+			sdf.Transform{Position: algebra.Vector{Y: 1.5}, Scale: 2, Node: sdf.Sphere{Radius: 0.5}},
+		}, Smooth: 0.2},
+		Cut: sdf.Capsule{A: algebra.Vector{Y: -2}, B: algebra.Vector{Y: 2}, Radius: 0.3},
+	}
+	sc := render.NewComponentSDF(root)
+	sc.Material = render.Material{Name: "red", DiffuseColor: algebra.Vector{X: 0.5}}
+	sc.Bounds = algebra.Vector{X: 2, Y: 3, Z: 2}
+	e := &core.Entity{Name: "Blob", Transform: core.NewTransform()}
+	e.Attach(&sc)
+
+	loaded := roundTrip(t, e, mockAssets())
+	out, ok := loaded.GetComponent(core.ComponentTypeSDF).(*render.ComponentSDF)
+	if !ok {
+		t.Fatalf("expected an sdf component")
+	}
+	if !reflect.DeepEqual(out.Root, sc.Root) {
+		t.Errorf("root %+v should be %+v", out.Root, sc.Root)
+	}
+	if out.Material.Name != "red" || out.Material.DiffuseColor.X != 0.5 {
+		t.Errorf("material %+v", out.Material)
+	}
+	if !vectorsEqual(out.Bounds, sc.Bounds) {
+		t.Errorf("bounds %v should be %v", out.Bounds, sc.Bounds)
+	}
+}
+
+func TestAnimatorCodec(t *testing.T) {
+	skeleton := &anim.Skeleton{Name: "arm", Joints: []anim.Joint{{Name: "root", Parent: -1}}}
+	walk := &anim.Clip{Name: "walk", Duration: 2}
+	assets := mockAssets()
+	assets.AddSkeleton(skeleton)
+	assets.AddClip(walk)
+
+	a := anim.NewComponentAnimator(skeleton)
+	a.Play(walk)
+	a.Time = 0.5
+	a.Speed = 1.5
+	a.Loop = false
+	a.SetParameter("speed", 2)
+	e := &core.Entity{Name: "Arm", Transform: core.NewTransform()}
+	e.Attach(&a)
+
+	loaded := roundTrip(t, e, assets)
+	out, ok := loaded.GetComponent(core.ComponentTypeAnimator).(*anim.ComponentAnimator)
+	if !ok {
+		t.Fatalf("expected an animator")
+	}
+	if out.Skeleton != skeleton || out.Clip != walk {
+		t.Errorf("skeleton %v and clip %v should come from the assets", out.Skeleton, out.Clip)
+	}
+	if out.Time != 0.5 || out.Speed != 1.5 || out.Loop || out.Parameters["speed"] != 2 {
+		t.Errorf("animator %+v", out)
+	}
+
+	// Assets have to be known by their ID
+	a.Clip = &anim.Clip{}
+	s := &core.Scene{}
+	s.Add(e)
+	if err := scenefile.Save(&bytes.Buffer{}, s, scenefile.NewContext(assets, core.Settings{})); err == nil {
+		t.Errorf("expected an error for a clip without a name")
+	}
+}
+
+func TestTweenNotSaved(t *testing.T) {
+	tw := tween.NewComponentTween()
+	e := &core.Entity{Name: "Door", Transform: core.NewTransform()}
+	e.Attach(&tw)
+	tw.Play(tween.Position(e.Transform, algebra.Vector{X: 2}, 1))
+
+	loaded := roundTrip(t, e, mockAssets())
+	if loaded.Name != "Door" || loaded.GetComponent(core.ComponentTypeTween) != nil {
+		t.Errorf("the door should load without its tween %+v", loaded)
+	}
+
+	// Types of your own can be left out too
+	r := scenefile.NewRegistry()
+	r.Skip("*scenefile_test.mockComponent")
+	m := &core.Entity{Name: "Mock", Transform: core.NewTransform()}
+	m.Attach(&mockComponent{Component: &core.Component{}})
+	s := &core.Scene{}
+	s.Add(m)
+	if err := r.Save(&bytes.Buffer{}, s, nil); err != nil {
+		t.Errorf("skipped components shouldn't stop a save: %v", err)
+	}
+}
+
+func TestMorphCodec(t *testing.T) {
+	poly := geometry.Polyhedron{Targets: []geometry.MorphTarget{{Name: "smile"}, {Name: "blink", Weight: 0.25}}}
+	m := anim.NewComponentMorph(&poly)
+	m.SetWeight("smile", 0.75)
+	e := &core.Entity{Name: "Face", Transform: core.NewTransform()}
+	e.Attach(&m)
+
+	loaded := roundTrip(t, e, mockAssets())
+	out, ok := loaded.GetComponent(core.ComponentTypeMorph).(*anim.ComponentMorph)
+	if !ok {
+		t.Fatalf("expected a morph component")
+	}
+	if !reflect.DeepEqual(out.Names, m.Names) || !reflect.DeepEqual(out.Weights, m.Weights) {
+		t.Errorf("morph %v %v should be %v %v", out.Names, out.Weights, m.Names, m.Weights)
+	}
+}
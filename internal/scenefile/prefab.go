@@ -0,0 +1,485 @@
+package scenefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// Prefab a reusable entity, with its children and components, that can
+// be instantiated into scenes many times. Prefab files are scene files
+// holding a single entity
+type Prefab struct {
+	ID string
+	// data the entity in its file form, decoded into generic maps
+	data map[string]interface{}
+}
+
+// Overrides per instance values keyed by path. A path is a field such
+// as "transform.position", "name" or "render.color" (starting with a
+// component's type), optionally prefixed by the names of the child it
+// applies to, e.g. "Arm/Hand/transform.scale". Values are anything that
+// encodes to the same JSON the field is saved as
+type Overrides map[string]interface{}
+
+// ComponentPrefab marks an entity as an instance of a prefab. Saving
+// the scene writes only the prefab's ID and the overrides
+type ComponentPrefab struct {
+	*core.Component
+	Prefab    *Prefab
+	Overrides Overrides
+
+	// applied what the instance was last built from
+	applied entityData
+}
+
+// Override set a field on this instance only. Call Registry.Sync to
+// apply it
+func (c *ComponentPrefab) Override(path string, value interface{}) error {
+	v, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	if c.Overrides == nil {
+		c.Overrides = Overrides{}
+	}
+	c.Overrides[path] = v
+	return nil
+}
+
+// Revert go back to the prefab's value for a field. Call Registry.Sync
+// to apply it
+func (c *ComponentPrefab) Revert(path string) {
+	delete(c.Overrides, path)
+}
+
+// Set change a field of the prefab. Instances pick it up on the next
+// Registry.Sync unless they override it
+func (p *Prefab) Set(path string, value interface{}) error {
+	v, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	return setPath(p.data, path, v)
+}
+
+// NewPrefab make a prefab from an entity and its children
+func (r *Registry) NewPrefab(id string, e *core.Entity, ctx *Context) (*Prefab, error) {
+	if ctx == nil {
+		ctx = NewContext(nil, core.Settings{})
+	}
+	local := ctx.sub()
+	assignIDs(e, local)
+	data, err := r.encodeEntity(e, local)
+	if err != nil {
+		return nil, err
+	}
+	generic := map[string]interface{}{}
+	if err := remarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return &Prefab{ID: id, data: generic}, nil
+}
+
+// LoadPrefab read a prefab, migrating older versions
+func (r *Registry) LoadPrefab(id string, rd io.Reader) (*Prefab, error) {
+	raw := map[string]interface{}{}
+	if err := json.NewDecoder(rd).Decode(&raw); err != nil {
+		return nil, err
+	}
+	doc := document{}
+	if err := r.migrate(raw, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Entities) != 1 {
+		return nil, fmt.Errorf("prefab %q should have one entity, has %v", id, len(doc.Entities))
+	}
+	generic := map[string]interface{}{}
+	if err := remarshal(doc.Entities[0], &generic); err != nil {
+		return nil, err
+	}
+	return &Prefab{ID: id, data: generic}, nil
+}
+
+// LoadPrefabFile read a prefab from a file
+func (r *Registry) LoadPrefabFile(id string, path string) (*Prefab, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return r.LoadPrefab(id, f)
+}
+
+// SavePrefab write a prefab as JSON
+func (r *Registry) SavePrefab(w io.Writer, p *Prefab) error {
+	e := entityData{}
+	if err := remarshal(p.data, &e); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(document{Version: r.Version, Entities: []entityData{e}})
+}
+
+// Instantiate build a new entity from a prefab. The instance and its
+// children get IDs starting with id so other entities can refer to them
+func (r *Registry) Instantiate(p *Prefab, id string, overrides Overrides, ctx *Context) (*core.Entity, error) {
+	if ctx == nil {
+		ctx = NewContext(nil, core.Settings{})
+	}
+	if overrides == nil {
+		overrides = Overrides{}
+	}
+	data, err := p.merge(overrides)
+	if err != nil {
+		return nil, err
+	}
+	local := ctx.sub()
+	e, err := r.decodeEntity(&data, local)
+	if err != nil {
+		return nil, fmt.Errorf("prefab %q: %v", p.ID, err)
+	}
+	if err := local.resolve(); err != nil {
+		return nil, fmt.Errorf("prefab %q: %v", p.ID, err)
+	}
+
+	e.ID = id
+	prefixIDs(e.Children(), id)
+	if err := register(e, ctx); err != nil {
+		return nil, err
+	}
+	e.Attach(&ComponentPrefab{
+		Component: &core.Component{Parent: &core.Entity{}},
+		Prefab:    p,
+		Overrides: overrides,
+		applied:   data,
+	})
+	return e, nil
+}
+
+// Sync bring every prefab instance in the scene up to date with its
+// prefab and overrides. Only fields that changed since the last Sync
+// are touched, so runtime state (a body's velocity, say) is kept
+func (r *Registry) Sync(s *core.Scene, ctx *Context) error {
+	if ctx == nil {
+		ctx = NewContext(nil, core.Settings{})
+	}
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		if err := r.syncTree(entities[i], ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) syncTree(e *core.Entity, ctx *Context) error {
+	if c, ok := e.GetComponent(core.ComponentTypePrefab).(*ComponentPrefab); ok {
+		data, err := c.Prefab.merge(c.Overrides)
+		if err != nil {
+			return err
+		}
+		local := ctx.sub()
+		mapIDs(e, &data, local)
+		if err := r.apply(e, &c.applied, &data, local); err != nil {
+			return fmt.Errorf("prefab %q: %v", c.Prefab.ID, err)
+		}
+		if err := local.resolve(); err != nil {
+			return fmt.Errorf("prefab %q: %v", c.Prefab.ID, err)
+		}
+		prefixIDs(e.Children(), e.ID)
+		c.applied = data
+		return nil
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		if err := r.syncTree(children[i], ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply update e, last built from old, to match data
+func (r *Registry) apply(e *core.Entity, old, data *entityData, ctx *Context) error {
+	if old.Name != data.Name {
+		e.Name = data.Name
+	}
+	if t := data.Transform; t != nil {
+		if e.Transform == nil {
+			e.Transform = core.NewTransform()
+		}
+		was := old.Transform
+		if was == nil {
+			was = &transformData{}
+		}
+		if was.Position != t.Position {
+			e.Transform.Position = t.Position.vector()
+		}
+		if was.Scale != t.Scale {
+			e.Transform.Scale = t.Scale.vector()
+		}
+		if was.Rotation != t.Rotation {
+			e.Transform.Rotation = t.Rotation.quaternion()
+			e.Transform.RotationMatrix(&e.Transform.Rotation)
+		}
+	}
+
+	for i := 0; i < len(data.Components); i++ {
+		cd := data.Components[i]
+		if prev := findComponentData(old.Components, cd.Type); prev != nil && bytes.Equal(prev.Data, cd.Data) {
+			continue
+		}
+		c, ok := r.codecs[cd.Type]
+		if !ok {
+			return fmt.Errorf("unknown component type %q", cd.Type)
+		}
+		fresh, err := c.decode(cd.Data, ctx)
+		if err != nil {
+			return fmt.Errorf("%v: %v", cd.Type, err)
+		}
+		if existing := r.findComponent(e, cd.Type); existing != nil {
+			copyComponent(existing, fresh)
+		} else {
+			e.Attach(fresh)
+		}
+	}
+	for i := 0; i < len(old.Components); i++ {
+		if findComponentData(data.Components, old.Components[i].Type) != nil {
+			continue
+		}
+		if existing := r.findComponent(e, old.Components[i].Type); existing != nil {
+			e.Detach(existing)
+		}
+	}
+
+	for i := 0; i < len(data.Children); i++ {
+		cd := &data.Children[i]
+		existing := findChild(e, cd.Name)
+		if existing == nil {
+			child, err := r.decodeEntity(cd, ctx)
+			if err != nil {
+				return err
+			}
+			e.Add(child)
+			continue
+		}
+		prev := findChildData(old.Children, cd.Name)
+		if prev == nil {
+			prev = &entityData{}
+		}
+		if err := r.apply(existing, prev, cd, ctx); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(old.Children); i++ {
+		if findChildData(data.Children, old.Children[i].Name) != nil {
+			continue
+		}
+		if existing := findChild(e, old.Children[i].Name); existing != nil {
+			e.Remove(existing)
+		}
+	}
+	return nil
+}
+
+// merge the prefab's data with overrides applied
+func (p *Prefab) merge(overrides Overrides) (entityData, error) {
+	data := map[string]interface{}{}
+	if err := remarshal(p.data, &data); err != nil {
+		return entityData{}, err
+	}
+	// Sorted so overlapping paths always apply the same way
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for i := 0; i < len(paths); i++ {
+		v, err := normalize(overrides[paths[i]])
+		if err != nil {
+			return entityData{}, err
+		}
+		if err := setPath(data, paths[i], v); err != nil {
+			return entityData{}, fmt.Errorf("prefab %q: %v", p.ID, err)
+		}
+	}
+	out := entityData{}
+	err := remarshal(data, &out)
+	return out, err
+}
+
+// setPath set the field at path (see Overrides) in generic entity data
+func setPath(e map[string]interface{}, path string, value interface{}) error {
+	field := path
+	if slash := strings.LastIndex(path, "/"); slash >= 0 {
+		names := strings.Split(path[:slash], "/")
+		for i := 0; i < len(names); i++ {
+			e = findGenericChild(e, names[i])
+			if e == nil {
+				return fmt.Errorf("%v: no child %q", path, names[i])
+			}
+		}
+		field = path[slash+1:]
+	}
+
+	segments := strings.Split(field, ".")
+	parent, key := e, segments[0]
+	if key != "name" && key != "transform" {
+		parent = findGenericComponent(e, key)
+		if parent == nil {
+			return fmt.Errorf("%v: no %q component", path, key)
+		}
+		key = "data"
+	}
+	for i := 1; i < len(segments); i++ {
+		next, ok := parent[key].(map[string]interface{})
+		if !ok {
+			if parent[key] != nil {
+				return fmt.Errorf("%v: %q is not an object", path, key)
+			}
+			next = map[string]interface{}{}
+			parent[key] = next
+		}
+		parent, key = next, segments[i]
+	}
+	parent[key] = value
+	return nil
+}
+
+func findGenericChild(e map[string]interface{}, name string) map[string]interface{} {
+	children, _ := e["children"].([]interface{})
+	for i := 0; i < len(children); i++ {
+		if c, ok := children[i].(map[string]interface{}); ok && c["name"] == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func findGenericComponent(e map[string]interface{}, name string) map[string]interface{} {
+	components, _ := e["components"].([]interface{})
+	for i := 0; i < len(components); i++ {
+		if c, ok := components[i].(map[string]interface{}); ok && c["type"] == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func findComponentData(components []componentData, name string) *componentData {
+	for i := 0; i < len(components); i++ {
+		if components[i].Type == name {
+			return &components[i]
+		}
+	}
+	return nil
+}
+
+func findChildData(children []entityData, name string) *entityData {
+	for i := 0; i < len(children); i++ {
+		if children[i].Name == name {
+			return &children[i]
+		}
+	}
+	return nil
+}
+
+func findChild(e *core.Entity, name string) *core.Entity {
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		if children[i].Name == name {
+			return children[i]
+		}
+	}
+	return nil
+}
+
+// findComponent the component on e saved under name
+func (r *Registry) findComponent(e *core.Entity, name string) core.Componenter {
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		if r.names[fmt.Sprintf("%T", components[i])] == name {
+			return components[i]
+		}
+	}
+	return nil
+}
+
+// copyComponent overwrite dst with src, keeping dst's place on its
+// entity so anything holding on to it sees the new values
+func copyComponent(dst, src core.Componenter) {
+	parent := dst.GetParent()
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+	dst.SetParent(parent)
+}
+
+// mapIDs point the local IDs in data at the entities already built from
+// them, so references resolve to the existing entities
+func mapIDs(e *core.Entity, data *entityData, ctx *Context) {
+	if data.ID != "" {
+		ctx.entities[data.ID] = e
+	}
+	for i := 0; i < len(data.Children); i++ {
+		if child := findChild(e, data.Children[i].Name); child != nil {
+			mapIDs(child, &data.Children[i], ctx)
+		}
+	}
+}
+
+// prefixIDs give an instance's children IDs under the instance's own
+func prefixIDs(children []*core.Entity, id string) {
+	for i := 0; i < len(children); i++ {
+		c := children[i]
+		local := c.ID
+		if slash := strings.LastIndex(local, "/"); slash >= 0 {
+			local = local[slash+1:]
+		}
+		if id == "" || local == "" {
+			c.ID = ""
+		} else {
+			c.ID = id + "/" + local
+		}
+		prefixIDs(c.Children(), id)
+	}
+}
+
+// register add an entity and its children to the context's IDs
+func register(e *core.Entity, ctx *Context) error {
+	if e.ID != "" {
+		if ctx.entities[e.ID] != nil {
+			return fmt.Errorf("duplicate entity id %q", e.ID)
+		}
+		ctx.entities[e.ID] = e
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		if err := register(children[i], ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize turn a value into the generic form encoding/json decodes to
+func normalize(value interface{}) (interface{}, error) {
+	var out interface{}
+	err := remarshal(value, &out)
+	return out, err
+}
+
+func remarshal(in interface{}, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
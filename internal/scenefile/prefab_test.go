@@ -0,0 +1,196 @@
+package scenefile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/physics"
+	"github.com/robrohan/mesh/internal/render"
+	"github.com/robrohan/mesh/internal/scenefile"
+)
+
+func mockPrefab(t *testing.T) *scenefile.Prefab {
+	p, err := scenefile.Default.LoadPrefabFile("tree", "testdata/tree.json")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return p
+}
+
+func leaves(e *core.Entity) (*core.Entity, *render.ComponentRender) {
+	l := e.Children()[0]
+	return l, l.GetComponent(core.ComponentTypeRender).(*render.ComponentRender)
+}
+
+func TestInstantiate(t *testing.T) {
+	ctx := scenefile.NewContext(mockAssets(), core.Settings{})
+	p := mockPrefab(t)
+
+	a, err := scenefile.Default.Instantiate(p, "a", nil, ctx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	b, err := scenefile.Default.Instantiate(p, "b", scenefile.Overrides{
+		"transform.position":  []float64{5, 0, 0},
+		"Leaves/render.color": []float64{0, 1, 0},
+	}, ctx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if a.ID != "a" || a.Children()[0].ID != "a/leaves" {
+		t.Errorf("instance ids %v %v", a.ID, a.Children()[0].ID)
+	}
+	if a.Transform.Position.X != 0 || b.Transform.Position.X != 5 {
+		t.Errorf("position override %v %v", a.Transform.Position, b.Transform.Position)
+	}
+	_, ra := leaves(a)
+	_, rb := leaves(b)
+	if ra.Material.DiffuseColor.Y != 0 || rb.Material.DiffuseColor.Y != 1 {
+		t.Errorf("color override %v %v", ra.Material.DiffuseColor, rb.Material.DiffuseColor)
+	}
+	if ra.Mesh.Name != "cube" {
+		t.Errorf("mesh should come from the assets %v", ra.Mesh.Name)
+	}
+
+	if _, err := scenefile.Default.Instantiate(p, "a", nil, ctx); err == nil {
+		t.Errorf("expected an error for a duplicate id")
+	}
+	bad := scenefile.Overrides{"Roots/transform.position": []float64{1, 2, 3}}
+	if _, err := scenefile.Default.Instantiate(p, "c", bad, ctx); err == nil {
+		t.Errorf("expected an error for an unknown child")
+	}
+	bad = scenefile.Overrides{"rigidBody.mass": 3}
+	if _, err := scenefile.Default.Instantiate(p, "d", bad, ctx); err == nil {
+		t.Errorf("expected an error for a missing component")
+	}
+}
+
+func TestPrefabPropagates(t *testing.T) {
+	ctx := scenefile.NewContext(mockAssets(), core.Settings{})
+	p := mockPrefab(t)
+	s := &core.Scene{}
+	a, _ := scenefile.Default.Instantiate(p, "a", nil, ctx)
+	b, _ := scenefile.Default.Instantiate(p, "b", scenefile.Overrides{
+		"Leaves/transform.scale": []float64{3, 3, 3},
+	}, ctx)
+	s.Add(a)
+	s.Add(b)
+
+	collider := a.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider)
+	// Runtime state that isn't in the prefab change should survive
+	a.Transform.Position.X = 7
+
+	if err := p.Set("Leaves/transform.scale", []float64{4, 4, 4}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := p.Set("collider.shape.radius", 1); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := scenefile.Default.Sync(s, ctx); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	la, _ := leaves(a)
+	lb, _ := leaves(b)
+	if la.Transform.Scale.X != 4 {
+		t.Errorf("prefab change should reach a %v", la.Transform.Scale)
+	}
+	if lb.Transform.Scale.X != 3 {
+		t.Errorf("b overrides the scale so should keep it %v", lb.Transform.Scale)
+	}
+	if a.Transform.Position.X != 7 {
+		t.Errorf("unchanged fields should be left alone %v", a.Transform.Position)
+	}
+	// Updated in place so systems holding the component see it
+	if a.GetComponent(core.ComponentTypeCollider) != collider || collider.GetParent() != a {
+		t.Errorf("collider should be updated in place")
+	}
+	if collider.Shape.(*physics.CapsuleShape).Radius != 1 {
+		t.Errorf("collider radius %v should be 1", collider.Shape)
+	}
+
+	// Overriding and reverting
+	instance := b.GetComponent(core.ComponentTypePrefab).(*scenefile.ComponentPrefab)
+	instance.Revert("Leaves/transform.scale")
+	instance.Override("name", "Oak")
+	scenefile.Default.Sync(s, ctx)
+	if lb.Transform.Scale.X != 4 || b.Name != "Oak" {
+		t.Errorf("b should follow the prefab again %v %v", lb.Transform.Scale, b.Name)
+	}
+}
+
+func TestOverrideWithoutOverrides(t *testing.T) {
+	instance := scenefile.ComponentPrefab{Component: &core.Component{}}
+	if err := instance.Override("name", "Oak"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if instance.Overrides["name"] != "Oak" {
+		t.Errorf("overrides %v should have the name", instance.Overrides)
+	}
+}
+
+func TestPrefabInScene(t *testing.T) {
+	assets := mockAssets()
+	assets.AddPrefab(mockPrefab(t))
+	ctx := scenefile.NewContext(assets, core.Settings{})
+	s := &core.Scene{}
+	a, err := scenefile.Default.Instantiate(mockPrefab(t), "a", scenefile.Overrides{
+		"transform.position": []float64{2, 0, 0},
+	}, ctx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	s.Add(a)
+	camera := &core.Entity{ID: "camera", Name: "Camera", Transform: core.NewTransform()}
+	follow := core.NewComponentFollowController(a.Children()[0], algebra.Vector{Z: -5})
+	camera.Attach(&follow)
+	s.Add(camera)
+
+	buf := bytes.Buffer{}
+	if err := scenefile.Save(&buf, s, nil); err != nil {
+		t.Fatalf("%v", err)
+	}
+	loaded, err := scenefile.Load(&buf, scenefile.NewContext(assets, core.Settings{}))
+	if err != nil {
+		t.Fatalf("%v\n%v", err, buf.String())
+	}
+
+	tree := loaded.All()[0]
+	if tree.Name != "Tree" || tree.Transform.Position.X != 2 || len(tree.Children()) != 1 {
+		t.Errorf("instance not rebuilt %v %v", tree.Name, tree.Transform.Position)
+	}
+	if tree.GetComponent(core.ComponentTypePrefab) == nil {
+		t.Errorf("loaded entity should still be an instance")
+	}
+	cam := loaded.All()[1]
+	f := cam.GetComponent(core.ComponentTypeFollowController).(*core.ComponentFollowController)
+	if f.Target != tree.Children()[0] {
+		t.Errorf("references into an instance should resolve")
+	}
+}
+
+func TestNewPrefab(t *testing.T) {
+	player := mockScene().All()[0]
+	p, err := scenefile.Default.NewPrefab("player", player, scenefile.NewContext(mockAssets(), core.Settings{}))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	buf := bytes.Buffer{}
+	if err := scenefile.Default.SavePrefab(&buf, p); err != nil {
+		t.Fatalf("%v", err)
+	}
+	loaded, err := scenefile.Default.LoadPrefab("player", &buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	e, err := scenefile.Default.Instantiate(loaded, "p1", nil, scenefile.NewContext(mockAssets(), core.Settings{}))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if e.Name != "Player" || e.Transform.Position.Z != 3 || len(e.Children()) != 1 {
+		t.Errorf("prefab should copy the entity %v %v", e.Name, e.Transform.Position)
+	}
+}
@@ -0,0 +1,432 @@
+// Package scenefile reads and writes scenes as JSON
+package scenefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// Version the scene format written by this package
+const Version = 1
+
+// EncodeFunc turn a component into something encoding/json can write
+type EncodeFunc func(c core.Componenter, ctx *Context) (interface{}, error)
+
+// DecodeFunc rebuild a component from what its EncodeFunc wrote
+type DecodeFunc func(data json.RawMessage, ctx *Context) (core.Componenter, error)
+
+// Migration upgrade a scene document, decoded into generic maps and
+// slices, by one version
+type Migration func(doc map[string]interface{}) error
+
+// Registry the component types and migrations a scene file can use
+type Registry struct {
+	// Version written to saved files, and what loaded files are
+	// migrated up to
+	Version int
+
+	codecs     map[string]codec
+	names      map[string]string
+	skipped    map[string]bool
+	migrations map[int]Migration
+}
+
+type codec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+// Context what codecs need beyond a component's own data
+type Context struct {
+	Assets *Assets
+	// Settings used to build camera projections
+	Settings core.Settings
+
+	ids      map[*core.Entity]string
+	entities map[string]*core.Entity
+	links    []link
+}
+
+type link struct {
+	id  string
+	set func(*core.Entity)
+}
+
+// NewContext create a context using assets
+func NewContext(assets *Assets, settings core.Settings) *Context {
+	if assets == nil {
+		assets = NewAssets()
+	}
+	return &Context{
+		Assets:   assets,
+		Settings: settings,
+		ids:      map[*core.Entity]string{},
+		entities: map[string]*core.Entity{},
+	}
+}
+
+// EntityID the ID an entity is saved under, "" for nil or entities
+// outside the scene
+func (ctx *Context) EntityID(e *core.Entity) string {
+	return ctx.ids[e]
+}
+
+// sub a context sharing this one's assets and settings but not its IDs
+func (ctx *Context) sub() *Context {
+	return NewContext(ctx.Assets, ctx.Settings)
+}
+
+// Link call set with the entity saved as id once the whole scene has
+// loaded
+func (ctx *Context) Link(id string, set func(*core.Entity)) {
+	if id == "" {
+		return
+	}
+	ctx.links = append(ctx.links, link{id: id, set: set})
+}
+
+// NewRegistry create a registry that knows all the engine's components
+func NewRegistry() *Registry {
+	r := &Registry{
+		Version:    Version,
+		codecs:     map[string]codec{},
+		names:      map[string]string{},
+		skipped:    map[string]bool{},
+		migrations: map[int]Migration{},
+	}
+	registerBuiltins(r)
+	return r
+}
+
+// Default the registry used by Save and Load
+var Default = NewRegistry()
+
+// Register declare how to save a component type. goType is the type's
+// name as printed by %T (see core.ComponentType...) and name is what is
+// written to the file
+func (r *Registry) Register(goType string, name string, encode EncodeFunc, decode DecodeFunc) {
+	r.codecs[name] = codec{encode: encode, decode: decode}
+	r.names[goType] = name
+}
+
+// Skip declare a component type that is left out of saved files, like
+// one only set up by code while the game runs. goType is as for Register
+func (r *Registry) Skip(goType string) {
+	r.skipped[goType] = true
+}
+
+// RegisterMigration upgrade files of version from to from+1
+func (r *Registry) RegisterMigration(from int, m Migration) {
+	r.migrations[from] = m
+}
+
+type document struct {
+	Version      int          `json:"version"`
+	ActiveCamera string       `json:"activeCamera,omitempty"`
+	Entities     []entityData `json:"entities"`
+}
+
+type entityData struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name,omitempty"`
+	Transform  *transformData  `json:"transform,omitempty"`
+	Components []componentData `json:"components,omitempty"`
+	Children   []entityData    `json:"children,omitempty"`
+	// Prefab the prefab this entity is an instance of, in which case
+	// the entity is built from the prefab and Overrides alone
+	Prefab    string    `json:"prefab,omitempty"`
+	Overrides Overrides `json:"overrides,omitempty"`
+}
+
+type transformData struct {
+	Position vec3 `json:"position"`
+	Rotation quat `json:"rotation"`
+	Scale    vec3 `json:"scale"`
+}
+
+type componentData struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Save write a scene with the Default registry
+func Save(w io.Writer, s *core.Scene, ctx *Context) error {
+	return Default.Save(w, s, ctx)
+}
+
+// Load read a scene with the Default registry
+func Load(rd io.Reader, ctx *Context) (*core.Scene, error) {
+	return Default.Load(rd, ctx)
+}
+
+// SaveFile write a scene to a file with the Default registry
+func SaveFile(path string, s *core.Scene, ctx *Context) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Save(f, s, ctx); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile read a scene from a file with the Default registry
+func LoadFile(path string, ctx *Context) (*core.Scene, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, ctx)
+}
+
+// Save write a scene as JSON. Entities without an ID are given one in
+// the file
+func (r *Registry) Save(w io.Writer, s *core.Scene, ctx *Context) error {
+	doc, err := r.encodeScene(s, ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Load read a scene written by Save, migrating older versions
+func (r *Registry) Load(rd io.Reader, ctx *Context) (*core.Scene, error) {
+	raw := map[string]interface{}{}
+	if err := json.NewDecoder(rd).Decode(&raw); err != nil {
+		return nil, err
+	}
+	doc := document{}
+	if err := r.migrate(raw, &doc); err != nil {
+		return nil, err
+	}
+	return r.decodeScene(&doc, ctx)
+}
+
+// migrate bring raw up to the current version and decode it into doc
+func (r *Registry) migrate(raw map[string]interface{}, doc *document) error {
+	v, ok := raw["version"].(float64)
+	if !ok {
+		return fmt.Errorf("scene has no version")
+	}
+	version := int(v)
+	if version > r.Version {
+		return fmt.Errorf("scene version %v is newer than %v", version, r.Version)
+	}
+	for ; version < r.Version; version++ {
+		m, ok := r.migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from scene version %v", version)
+		}
+		if err := m(raw); err != nil {
+			return fmt.Errorf("migrating scene from version %v: %v", version, err)
+		}
+	}
+	raw["version"] = version
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, doc)
+}
+
+func (r *Registry) encodeScene(s *core.Scene, ctx *Context) (*document, error) {
+	if ctx == nil {
+		ctx = NewContext(nil, core.Settings{})
+	}
+	// Every entity needs an ID before any component refers to one. The
+	// IDs entities already have go first, so generated ones can't take
+	// them
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		claimIDs(entities[i], ctx)
+	}
+	for i := 0; i < len(entities); i++ {
+		assignIDs(entities[i], ctx)
+	}
+
+	doc := &document{Version: r.Version}
+	for i := 0; i < len(entities); i++ {
+		e, err := r.encodeEntity(entities[i], ctx)
+		if err != nil {
+			return nil, err
+		}
+		doc.Entities = append(doc.Entities, e)
+	}
+	if s.ActiveCamera != nil {
+		doc.ActiveCamera = ctx.EntityID(s.ActiveCamera)
+	}
+	return doc, nil
+}
+
+// claimIDs keep the IDs the entity and its children have, the first
+// entity with an ID getting it
+func claimIDs(e *core.Entity, ctx *Context) {
+	if e.ID != "" && ctx.entities[e.ID] == nil {
+		ctx.ids[e] = e.ID
+		ctx.entities[e.ID] = e
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		claimIDs(children[i], ctx)
+	}
+}
+
+// assignIDs give the entity and its children that didn't get an ID from
+// claimIDs one nothing else has
+func assignIDs(e *core.Entity, ctx *Context) {
+	if _, ok := ctx.ids[e]; !ok {
+		n := len(ctx.ids) + 1
+		for ctx.entities["#"+strconv.Itoa(n)] != nil {
+			n++
+		}
+		id := "#" + strconv.Itoa(n)
+		ctx.ids[e] = id
+		ctx.entities[id] = e
+	}
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		assignIDs(children[i], ctx)
+	}
+}
+
+// encodeEntity turn an entity and its children into their file form
+func (r *Registry) encodeEntity(e *core.Entity, ctx *Context) (entityData, error) {
+	if p, ok := e.GetComponent(core.ComponentTypePrefab).(*ComponentPrefab); ok {
+		return entityData{ID: ctx.EntityID(e), Prefab: p.Prefab.ID, Overrides: p.Overrides}, nil
+	}
+
+	out := entityData{ID: ctx.EntityID(e), Name: e.Name}
+	if t := e.Transform; t != nil {
+		out.Transform = &transformData{
+			Position: toVec3(t.Position),
+			Rotation: toQuat(t.Rotation),
+			Scale:    toVec3(t.Scale),
+		}
+	}
+
+	components := e.Components()
+	for i := 0; i < len(components); i++ {
+		goType := fmt.Sprintf("%T", components[i])
+		if r.skipped[goType] {
+			continue
+		}
+		name, ok := r.names[goType]
+		if !ok {
+			return out, fmt.Errorf("entity %q: no codec registered for %v", e.Name, goType)
+		}
+		data, err := r.codecs[name].encode(components[i], ctx)
+		if err != nil {
+			return out, fmt.Errorf("entity %q: %v: %v", e.Name, name, err)
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return out, err
+		}
+		out.Components = append(out.Components, componentData{Type: name, Data: raw})
+	}
+
+	children := e.Children()
+	for i := 0; i < len(children); i++ {
+		child, err := r.encodeEntity(children[i], ctx)
+		if err != nil {
+			return out, err
+		}
+		out.Children = append(out.Children, child)
+	}
+	return out, nil
+}
+
+func (r *Registry) decodeScene(doc *document, ctx *Context) (*core.Scene, error) {
+	if ctx == nil {
+		ctx = NewContext(nil, core.Settings{})
+	}
+	s := &core.Scene{}
+	for i := 0; i < len(doc.Entities); i++ {
+		e, err := r.decodeEntity(&doc.Entities[i], ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.Add(e)
+	}
+	if err := ctx.resolve(); err != nil {
+		return nil, err
+	}
+	if doc.ActiveCamera != "" {
+		s.ActiveCamera = ctx.entities[doc.ActiveCamera]
+		if s.ActiveCamera == nil {
+			return nil, fmt.Errorf("active camera %q not found", doc.ActiveCamera)
+		}
+	}
+	return s, nil
+}
+
+func (r *Registry) decodeEntity(data *entityData, ctx *Context) (*core.Entity, error) {
+	if data.Prefab != "" {
+		p, err := ctx.Assets.Prefab(data.Prefab)
+		if err != nil {
+			return nil, err
+		}
+		return r.Instantiate(p, data.ID, data.Overrides, ctx)
+	}
+
+	e := &core.Entity{ID: data.ID, Name: data.Name}
+	if data.ID != "" {
+		if ctx.entities[data.ID] != nil {
+			return nil, fmt.Errorf("duplicate entity id %q", data.ID)
+		}
+		ctx.entities[data.ID] = e
+	}
+	if t := data.Transform; t != nil {
+		e.Transform = core.NewTransform()
+		e.Transform.Position = t.Position.vector()
+		e.Transform.Scale = t.Scale.vector()
+		e.Transform.Rotation = t.Rotation.quaternion()
+		e.Transform.RotationMatrix(&e.Transform.Rotation)
+	}
+
+	for i := 0; i < len(data.Components); i++ {
+		cd := data.Components[i]
+		c, ok := r.codecs[cd.Type]
+		if !ok {
+			return nil, fmt.Errorf("entity %q: unknown component type %q", data.Name, cd.Type)
+		}
+		cmp, err := c.decode(cd.Data, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("entity %q: %v: %v", data.Name, cd.Type, err)
+		}
+		e.Attach(cmp)
+	}
+
+	for i := 0; i < len(data.Children); i++ {
+		child, err := r.decodeEntity(&data.Children[i], ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.Add(child)
+	}
+	return e, nil
+}
+
+// resolve fill in the entity references once everything is loaded
+func (ctx *Context) resolve() error {
+	for i := 0; i < len(ctx.links); i++ {
+		e, ok := ctx.entities[ctx.links[i].id]
+		if !ok {
+			return fmt.Errorf("reference to unknown entity %q", ctx.links[i].id)
+		}
+		ctx.links[i].set(e)
+	}
+	ctx.links = nil
+	return nil
+}
@@ -0,0 +1,330 @@
+package scenefile_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/model"
+	"github.com/robrohan/mesh/internal/physics"
+	"github.com/robrohan/mesh/internal/render"
+	"github.com/robrohan/mesh/internal/scenefile"
+)
+
+func mockAssets() *scenefile.Assets {
+	poly, _ := model.CreateTestPoly()
+	mesh := render.Mesh{Name: "cube", Poly: poly}
+	assets := scenefile.NewAssets()
+	assets.AddMesh(mesh)
+	assets.AddMaterial(render.Material{Name: "red"})
+	return assets
+}
+
+func mockScene() *core.Scene {
+	s := &core.Scene{}
+
+	player := &core.Entity{ID: "player", Name: "Player", Transform: core.NewTransform()}
+	player.Transform.Position = algebra.Vector{X: 1, Y: 2, Z: 3}
+	player.Transform.Scale = algebra.Vector{X: 2, Y: 2, Z: 2}
+	rc := render.NewComponentRender()
+	rc.Mesh, _ = mockAssets().Mesh("cube")
+	rc.Material = render.Material{Name: "red"}
+	player.Attach(&rc)
+	collider := physics.NewComponentCollider(&physics.CapsuleShape{Radius: 0.5, HalfHeight: 1})
+	collider.Restitution = 0.25
+	player.Attach(&collider)
+	rb := physics.NewComponentRigidBody(3)
+	player.Attach(&rb)
+
+	hat := &core.Entity{Name: "Hat", Transform: core.NewTransform()}
+	hat.Transform.Position.Y = 1
+	lamp := render.NewComponentSpotLight(algebra.Vector{X: 1, Y: 0.5}, 2, 10, 15, 30)
+	lamp.CastShadows = true
+	lamp.ShadowBias = 0.02
+	hat.Attach(&lamp)
+	player.Add(hat)
+
+	camera := &core.Entity{ID: "camera", Name: "Camera", Transform: core.NewTransform()}
+	cc := core.NewComponentCamera()
+	cc.UpdatePerspective(640, 480, algebra.PerspectiveOptions{Fov: 60, Near: 0.1, Far: 100})
+	camera.Attach(&cc)
+	follow := core.NewComponentFollowController(player, algebra.Vector{Z: -5})
+	camera.Attach(&follow)
+	post := render.NewComponentPostProcess(
+		render.Bloom{Threshold: 0.8},
+		render.Tonemap{Operator: render.TonemapReinhard, Exposure: 2},
+		render.FXAA{})
+	camera.Attach(&post)
+
+	s.Add(player)
+	s.Add(camera)
+	s.ActiveCamera = camera
+	return s
+}
+
+func find(s *core.Scene, name string) *core.Entity {
+	var found *core.Entity
+	var walk func(e *core.Entity)
+	walk = func(e *core.Entity) {
+		if e.Name == name {
+			found = e
+		}
+		children := e.Children()
+		for i := 0; i < len(children); i++ {
+			walk(children[i])
+		}
+	}
+	entities := s.All()
+	for i := 0; i < len(entities); i++ {
+		walk(entities[i])
+	}
+	return found
+}
+
+func TestRoundTrip(t *testing.T) {
+	buf := bytes.Buffer{}
+	settings := core.Settings{Width: 640, Height: 480}
+	if err := scenefile.Save(&buf, mockScene(), scenefile.NewContext(nil, settings)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	s, err := scenefile.Load(&buf, scenefile.NewContext(mockAssets(), settings))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	player := find(s, "Player")
+	if player == nil || player.ID != "player" {
+		t.Fatalf("player not loaded %v", player)
+	}
+	expected := algebra.Vector{X: 1, Y: 2, Z: 3}
+	if !player.Transform.Position.AlmostEquals(&expected) {
+		t.Errorf("position %v should be %v", player.Transform.Position, expected)
+	}
+	if player.Transform.Scale.X != 2 {
+		t.Errorf("scale %v should be 2", player.Transform.Scale)
+	}
+
+	rc := player.GetComponent(core.ComponentTypeRender).(*render.ComponentRender)
+	if rc.Mesh.Name != "cube" || len(rc.Mesh.Poly.Vertices) == 0 {
+		t.Errorf("mesh should come from the assets %v", rc.Mesh.Name)
+	}
+	if rc.Material.Name != "red" {
+		t.Errorf("material %v should be red", rc.Material.Name)
+	}
+	collider := player.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider)
+	capsule, ok := collider.Shape.(*physics.CapsuleShape)
+	if !ok || capsule.HalfHeight != 1 || collider.Restitution != 0.25 {
+		t.Errorf("collider not restored %#v %v", collider.Shape, collider.Restitution)
+	}
+	rb := player.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	if rb.Mass != 3 || !rb.UseGravity {
+		t.Errorf("rigid body not restored %v", rb)
+	}
+
+	hat := find(s, "Hat")
+	if hat == nil || hat.ID == "" || hat.Transform.Position.Y != 1 {
+		t.Errorf("child not restored %v", hat)
+	}
+	lamp := hat.GetComponent(core.ComponentTypeLight).(*render.ComponentLight)
+	if lamp.Type != render.LightSpot || lamp.Color.Y != 0.5 || lamp.Range != 10 || lamp.OuterAngle != 30 ||
+		!lamp.CastShadows || lamp.ShadowBias != 0.02 {
+		t.Errorf("light not restored %v", lamp)
+	}
+
+	if s.ActiveCamera == nil || s.ActiveCamera.Name != "Camera" {
+		t.Fatalf("active camera not restored")
+	}
+	follow := s.ActiveCamera.GetComponent(core.ComponentTypeFollowController).(*core.ComponentFollowController)
+	if follow.Target != player {
+		t.Errorf("follow target should be the loaded player")
+	}
+	cc := s.ActiveCamera.GetComponent(core.ComponentTypeCamera).(*core.ComponentCamera)
+	if o, ok := cc.PerspectiveOptions(); !ok || o.Fov != 60 || o.Far != 100 {
+		t.Errorf("camera projection not restored %v", o)
+	}
+	post := s.ActiveCamera.GetComponent(core.ComponentTypePostProcess).(*render.ComponentPostProcess)
+	if len(post.Effects) != 3 {
+		t.Fatalf("post processing not restored %v", post.Effects)
+	}
+	bloom, _ := post.Effects[0].(render.Bloom)
+	tonemap, _ := post.Effects[1].(render.Tonemap)
+	_, fxaa := post.Effects[2].(render.FXAA)
+	if bloom.Threshold != 0.8 || tonemap.Operator != render.TonemapReinhard || tonemap.Exposure != 2 || !fxaa {
+		t.Errorf("effects should be restored in order %v", post.Effects)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	s, err := scenefile.LoadFile("testdata/scene.json", scenefile.NewContext(mockAssets(), core.Settings{}))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	player := find(s, "Player")
+	box, ok := player.GetComponent(core.ComponentTypeCollider).(*physics.ComponentCollider).Shape.(*physics.BoxShape)
+	if !ok || box.HalfExtents.X != 1 {
+		t.Errorf("expected a box collider")
+	}
+	rb := player.GetComponent(core.ComponentTypeRigidBody).(*physics.ComponentRigidBody)
+	if rb.Mass != 2 {
+		t.Errorf("mass %v should be 2", rb.Mass)
+	}
+	follow := s.ActiveCamera.GetComponent(core.ComponentTypeFollowController).(*core.ComponentFollowController)
+	if follow.Target != player {
+		t.Errorf("follow target should be resolved")
+	}
+}
+
+func TestAssetLoader(t *testing.T) {
+	assets := scenefile.NewAssets()
+	asked := []string{}
+	assets.LoadMesh = func(id string) (render.Mesh, error) {
+		asked = append(asked, id)
+		poly, _ := model.CreateTestPoly()
+		return render.Mesh{Poly: poly}, nil
+	}
+	assets.LoadMaterial = func(id string) (render.Material, error) {
+		return render.Material{}, nil
+	}
+	if _, err := scenefile.LoadFile("testdata/scene.json", scenefile.NewContext(assets, core.Settings{})); err != nil {
+		t.Fatalf("%v", err)
+	}
+	m, _ := assets.Mesh("cube")
+	if len(asked) != 1 || m.Name != "cube" {
+		t.Errorf("mesh should be loaded once by id %v %v", asked, m.Name)
+	}
+
+	missing := scenefile.NewAssets()
+	if _, err := missing.Mesh("cube"); err == nil {
+		t.Errorf("expected an error for an unknown mesh")
+	}
+}
+
+func TestGeneratedIDs(t *testing.T) {
+	// An ID that looks generated, then entities without one
+	s := &core.Scene{}
+	s.Add(&core.Entity{Name: "a"})
+	s.Add(&core.Entity{ID: "#2", Name: "b"})
+	s.Add(&core.Entity{Name: "c"})
+
+	buf := bytes.Buffer{}
+	if err := scenefile.Save(&buf, s, nil); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := scenefile.Load(&buf, nil)
+	if err != nil {
+		t.Fatalf("generated IDs should not clash with the scene's: %v", err)
+	}
+	if e := find(loaded, "b"); e == nil || e.ID != "#2" {
+		t.Errorf("an entity's own ID should be kept, got %+v", e)
+	}
+
+	// Deleting an entity after loading and adding another
+	edited := &core.Scene{}
+	for _, e := range loaded.All() {
+		if e.Name != "a" {
+			edited.Add(e)
+		}
+	}
+	edited.Add(&core.Entity{Name: "d"})
+	buf.Reset()
+	if err := scenefile.Save(&buf, edited, nil); err != nil {
+		t.Fatal(err)
+	}
+	again, err := scenefile.Load(&buf, nil)
+	if err != nil {
+		t.Fatalf("reload after an edit: %v", err)
+	}
+	if len(again.All()) != 3 {
+		t.Errorf("expected 3 entities got %v", len(again.All()))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"no version":     `{"entities": []}`,
+		"newer version":  `{"version": 99, "entities": []}`,
+		"unknown type":   `{"version": 1, "entities": [{"id": "a", "components": [{"type": "nope"}]}]}`,
+		"duplicate id":   `{"version": 1, "entities": [{"id": "a"}, {"id": "a"}]}`,
+		"missing target": `{"version": 1, "entities": [{"id": "a", "components": [{"type": "followController", "data": {"target": "b"}}]}]}`,
+		"missing camera": `{"version": 1, "activeCamera": "b", "entities": [{"id": "a"}]}`,
+		"bad shape":      `{"version": 1, "entities": [{"id": "a", "components": [{"type": "collider", "data": {"shape": {"type": "cone"}}}]}]}`,
+	}
+	for name, doc := range tests {
+		if _, err := scenefile.Load(strings.NewReader(doc), nil); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+type mockComponent struct {
+	*core.Component
+	Speed float64
+}
+
+func TestCustomComponentAndMigration(t *testing.T) {
+	r := scenefile.NewRegistry()
+	r.Version = 2
+	r.Register("*scenefile_test.mockComponent", "mock",
+		func(c core.Componenter, ctx *scenefile.Context) (interface{}, error) {
+			return map[string]float64{"speed": c.(*mockComponent).Speed}, nil
+		},
+		func(data json.RawMessage, ctx *scenefile.Context) (core.Componenter, error) {
+			in := map[string]float64{}
+			if err := json.Unmarshal(data, &in); err != nil {
+				return nil, err
+			}
+			return &mockComponent{Component: &core.Component{}, Speed: in["speed"]}, nil
+		})
+	// Version 1 files called it "velocity"
+	r.RegisterMigration(1, func(doc map[string]interface{}) error {
+		entities, _ := doc["entities"].([]interface{})
+		for i := 0; i < len(entities); i++ {
+			e := entities[i].(map[string]interface{})
+			components, _ := e["components"].([]interface{})
+			for c := 0; c < len(components); c++ {
+				data := components[c].(map[string]interface{})["data"].(map[string]interface{})
+				if v, ok := data["velocity"]; ok {
+					data["speed"] = v
+					delete(data, "velocity")
+				}
+			}
+		}
+		return nil
+	})
+
+	old := `{"version": 1, "entities": [{"id": "a", "name": "A", "components": [{"type": "mock", "data": {"velocity": 4}}]}]}`
+	s, err := r.Load(strings.NewReader(old), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	mock := s.All()[0].GetComponent("*scenefile_test.mockComponent").(*mockComponent)
+	if mock.Speed != 4 {
+		t.Errorf("migration should have renamed velocity to speed %v", mock.Speed)
+	}
+
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf, s, nil); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(buf.String(), `"version": 2`) || !strings.Contains(buf.String(), `"speed": 4`) {
+		t.Errorf("saved file should be the new version %v", buf.String())
+	}
+
+	// The default registry doesn't know about it
+	if err := scenefile.Save(&buf, s, nil); err == nil {
+		t.Errorf("expected an error for an unregistered component")
+	}
+
+	failing := scenefile.NewRegistry()
+	failing.Version = 2
+	failing.RegisterMigration(1, func(doc map[string]interface{}) error {
+		return errors.New("nope")
+	})
+	if _, err := failing.Load(strings.NewReader(`{"version": 1, "entities": []}`), nil); err == nil {
+		t.Errorf("expected the migration error")
+	}
+}
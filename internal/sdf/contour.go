@@ -0,0 +1,178 @@
+package sdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+)
+
+// refineSteps how many times a vertex is pulled towards the surface
+const refineSteps = 4
+
+// Mesh the surface of a field as triangles, sampling it every step
+// through the box from min to max. Leave a step or so of room around
+// the shape, or the mesh is left open where it's cut off
+func Mesh(f Field, min, max algebra.Vector, step float64) (geometry.Polyhedron, error) {
+	g, err := SampleGrid(f, min, max, step)
+	if err != nil {
+		return geometry.Polyhedron{}, err
+	}
+	return contour(g, f)
+}
+
+// Contour the surface of a grid's samples as triangles
+func Contour(g *Grid) (geometry.Polyhedron, error) {
+	return contour(g, g)
+}
+
+// contour dual contouring at its simplest (surface nets): one vertex in
+// every cell of the grid the surface goes through, pulled onto the
+// surface of f along its gradient, and a quad joining the four cells
+// around every edge the surface crosses. Normals come from the gradient
+// too and faces wind counter clockwise seen from outside
+func contour(g *Grid, f Field) (geometry.Polyhedron, error) {
+	poly := geometry.Polyhedron{}
+	size := [3]int{g.Width, g.Height, g.Depth}
+	cells := [3]int{size[0] - 1, size[1] - 1, size[2] - 1}
+	if cells[0] < 1 || cells[1] < 1 || cells[2] < 1 {
+		return poly, nil
+	}
+
+	// The vertex in each cell, -1 for none
+	vertices := make([]int, cells[0]*cells[1]*cells[2])
+	cellIndex := func(c [3]int) int {
+		return c[0] + cells[0]*(c[1]+cells[1]*c[2])
+	}
+	h := g.Step * 1e-3
+	for z := 0; z < cells[2]; z++ {
+		for y := 0; y < cells[1]; y++ {
+			for x := 0; x < cells[0]; x++ {
+				i := cellIndex([3]int{x, y, z})
+				vertices[i] = -1
+				p, ok := g.crossing(x, y, z)
+				if !ok {
+					continue
+				}
+				if len(poly.Vertices) > math.MaxUint16 {
+					return poly, fmt.Errorf("more than %v vertices, use a bigger step", math.MaxUint16+1)
+				}
+				p = refine(f, p, g.Point(x, y, z), g.Point(x+1, y+1, z+1), h)
+				vertices[i] = len(poly.Vertices)
+				poly.Vertices = append(poly.Vertices, geometry.Vertex{
+					Pos:    p,
+					Color:  algebra.Vector{X: 1, Y: 1, Z: 1, W: 1},
+					Normal: normalize(gradient(f, p, h)),
+				})
+			}
+		}
+	}
+
+	for z := 0; z < size[2]; z++ {
+		for y := 0; y < size[1]; y++ {
+			for x := 0; x < size[0]; x++ {
+				s := [3]int{x, y, z}
+				inside := g.At(x, y, z) < 0
+				for a := 0; a < 3; a++ {
+					// The edge from s along a, and the two other axes
+					// in the order that makes a quad face along a
+					b, c := (a+1)%3, (a+2)%3
+					if s[a]+1 >= size[a] || s[b] < 1 || s[b] >= size[b]-1 || s[c] < 1 || s[c] >= size[c]-1 {
+						continue
+					}
+					next := s
+					next[a]++
+					if (g.At(next[0], next[1], next[2]) < 0) == inside {
+						continue
+					}
+
+					quad := [4]uint16{}
+					for k, offset := range [4][2]int{{-1, -1}, {0, -1}, {0, 0}, {-1, 0}} {
+						cell := s
+						cell[b] += offset[0]
+						cell[c] += offset[1]
+						quad[k] = uint16(vertices[cellIndex(cell)])
+					}
+					if !inside {
+						// Outside first, so the surface faces back along a
+						quad[1], quad[3] = quad[3], quad[1]
+					}
+					poly.Indices = append(poly.Indices,
+						quad[0], quad[1], quad[2],
+						quad[0], quad[2], quad[3])
+				}
+			}
+		}
+	}
+	return poly, nil
+}
+
+// cubeEdges the corners (as X, Y, Z bits) at either end of each edge of
+// a cell
+var cubeEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7},
+	{0, 2}, {1, 3}, {4, 6}, {5, 7},
+	{0, 4}, {1, 5}, {2, 6}, {3, 7},
+}
+
+// crossing the average of where the surface crosses the edges of a
+// cell, false when it doesn't go through the cell
+func (g *Grid) crossing(x, y, z int) (algebra.Vector, bool) {
+	corners := [8]float64{}
+	points := [8]algebra.Vector{}
+	for k := 0; k < 8; k++ {
+		cx, cy, cz := x+k&1, y+(k>>1)&1, z+(k>>2)&1
+		corners[k] = g.At(cx, cy, cz)
+		points[k] = g.Point(cx, cy, cz)
+	}
+	sum := algebra.Vector{}
+	count := 0
+	for e := 0; e < len(cubeEdges); e++ {
+		i, j := cubeEdges[e][0], cubeEdges[e][1]
+		a, b := corners[i], corners[j]
+		if (a < 0) == (b < 0) {
+			continue
+		}
+		t := a / (a - b)
+		sum = add(sum, add(points[i], scale(sub(points[j], points[i]), t)))
+		count++
+	}
+	if count == 0 {
+		return sum, false
+	}
+	return scale(sum, 1/float64(count)), true
+}
+
+// refine pull p onto the surface along the gradient (Newton's method),
+// staying inside the cell from lo to hi
+func refine(f Field, p, lo, hi algebra.Vector, h float64) algebra.Vector {
+	for i := 0; i < refineSteps; i++ {
+		d := f.Distance(p)
+		n := gradient(f, p, h)
+		l := dot(n, n)
+		if l < 1e-12 || math.Abs(d) < 1e-9 {
+			break
+		}
+		p = sub(p, scale(n, d/l))
+		p = algebra.Vector{
+			X: clamp(p.X, lo.X, hi.X),
+			Y: clamp(p.Y, lo.Y, hi.Y),
+			Z: clamp(p.Z, lo.Z, hi.Z),
+		}
+	}
+	return p
+}
+
+// gradient how fast the field changes along each axis at p, by central
+// differences h apart
+func gradient(f Field, p algebra.Vector, h float64) algebra.Vector {
+	at := func(x, y, z float64) float64 {
+		return f.Distance(algebra.Vector{X: p.X + x, Y: p.Y + y, Z: p.Z + z})
+	}
+	return scale(algebra.Vector{
+		X: at(h, 0, 0) - at(-h, 0, 0),
+		Y: at(0, h, 0) - at(0, -h, 0),
+		Z: at(0, 0, h) - at(0, 0, -h),
+	}, 1/(2*h))
+}
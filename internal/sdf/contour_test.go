@@ -0,0 +1,124 @@
+package sdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/geometry"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+// checkClosed every edge of the mesh is shared by exactly two triangles
+// that run along it in opposite directions, so it's closed and wound
+// the same way throughout
+func checkClosed(t *testing.T, poly *geometry.Polyhedron) {
+	type edge struct{ a, b uint16 }
+	edges := map[edge]int{}
+	for i := 0; i+2 < len(poly.Indices); i += 3 {
+		for k := 0; k < 3; k++ {
+			edges[edge{poly.Indices[i+k], poly.Indices[i+(k+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[edge{e.b, e.a}] != 1 {
+			t.Fatalf("edge %v is used %v times and backwards %v times", e, n, edges[edge{e.b, e.a}])
+		}
+	}
+}
+
+// checkOutward every triangle faces away from center
+func checkOutward(t *testing.T, poly *geometry.Polyhedron, center algebra.Vector) {
+	for i := 0; i < poly.TriangleCount(); i++ {
+		a, b, c := poly.Triangle(i)
+		ab, ac, n, out := algebra.Vector{}, algebra.Vector{}, algebra.Vector{}, algebra.Vector{}
+		b.SubV(a, &ab)
+		c.SubV(a, &ac)
+		ab.Cross(ac, &n)
+		a.SubV(center, &out)
+		if n.Dot(out) <= 0 {
+			t.Fatalf("triangle %v faces inwards", i)
+		}
+	}
+}
+
+func TestMeshSphere(t *testing.T) {
+	center := algebra.Vector{X: 1, Y: 2, Z: 3}
+	ball := sdf.Transform{Position: center, Node: sdf.Sphere{Radius: 1}}
+	poly, err := sdf.Mesh(ball,
+		algebra.Vector{X: -0.5, Y: 0.5, Z: 1.5}, algebra.Vector{X: 2.5, Y: 3.5, Z: 4.5}, 0.2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if poly.TriangleCount() < 100 {
+		t.Fatalf("expected a sphere's worth of triangles got %v", poly.TriangleCount())
+	}
+	for i := 0; i < len(poly.Vertices); i++ {
+		v := poly.Vertices[i]
+		out := algebra.Vector{}
+		v.Pos.SubV(center, &out)
+		// Pulled onto the surface
+		if r := out.Length(); math.Abs(r-1) > 1e-3 {
+			t.Fatalf("vertex %v is %v from the center", v.Pos, r)
+		}
+		out.Normalized(&out)
+		if math.Abs(v.Normal.Dot(out)-1) > 1e-3 {
+			t.Fatalf("normal %v should be %v", v.Normal, out)
+		}
+	}
+	checkClosed(t, &poly)
+	checkOutward(t, &poly, center)
+}
+
+func TestContourVoxels(t *testing.T) {
+	// A 2x2x2 block of solid voxels in the middle of a 6x6x6 grid
+	g, err := sdf.NewGrid(algebra.Vector{}, 1, 6, 6, 6)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < len(g.Values); i++ {
+		g.Values[i] = 0.5
+	}
+	for z := 2; z < 4; z++ {
+		for y := 2; y < 4; y++ {
+			for x := 2; x < 4; x++ {
+				g.Set(x, y, z, -0.5)
+			}
+		}
+	}
+
+	poly, err := sdf.Contour(g)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if poly.TriangleCount() == 0 {
+		t.Fatalf("expected a mesh")
+	}
+	min, max := poly.Bounds()
+	if min.X < 1.5 || max.X > 3.5 || min.Y < 1.5 || max.Y > 3.5 {
+		t.Errorf("mesh %v to %v should wrap the block", min, max)
+	}
+	checkClosed(t, &poly)
+	checkOutward(t, &poly, algebra.Vector{X: 2.5, Y: 2.5, Z: 2.5})
+}
+
+func TestMeshLimits(t *testing.T) {
+	poly, err := sdf.Mesh(sdf.Sphere{Radius: 1}, algebra.Vector{X: 5}, algebra.Vector{X: 6, Y: 1, Z: 1}, 0.25)
+	if err != nil || len(poly.Vertices) != 0 {
+		t.Errorf("expected nothing away from the surface got %v %v", len(poly.Vertices), err)
+	}
+
+	// More vertices than 16 bit indices can reach
+	ground := sdf.Plane{Normal: algebra.Vector{Y: 1}, Offset: 0.05}
+	if _, err := sdf.Mesh(ground, algebra.Vector{X: -1, Y: -0.1, Z: -1}, algebra.Vector{X: 1, Y: 0.1, Z: 1}, 0.007); err == nil {
+		t.Errorf("expected too many vertices to fail")
+	}
+
+	// Steps that would never get anywhere, and boxes inside out
+	if _, err := sdf.Mesh(ground, algebra.Vector{}, algebra.Vector{X: 1, Y: 1, Z: 1}, 0); err == nil {
+		t.Errorf("expected a 0 step to fail")
+	}
+	if _, err := sdf.Mesh(ground, algebra.Vector{}, algebra.Vector{X: 1, Y: -1, Z: 1}, 0.25); err == nil {
+		t.Errorf("expected max below min to fail")
+	}
+}
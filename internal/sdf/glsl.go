@@ -0,0 +1,151 @@
+package sdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// ParamsUniform the float array the generated GLSL reads a tree's
+// numbers from
+const ParamsUniform = "uSDF"
+
+// Library the GLSL functions generated code calls, with Distance's
+// maths. Shader includes it
+const Library = `float sdfBox(vec3 p, vec3 b) {
+  vec3 q = abs(p) - b;
+  return length(max(q, 0.0)) + min(max(q.x, max(q.y, q.z)), 0.0);
+}
+
+float sdfCapsule(vec3 p, vec3 a, vec3 b, float r) {
+  vec3 ab = b - a;
+  vec3 ap = p - a;
+  float l = dot(ab, ab);
+  float t = l > 0.0 ? clamp(dot(ab, ap) / l, 0.0, 1.0) : 0.0;
+  return length(ap - ab * t) - r;
+}
+
+float sdfCylinder(vec3 p, vec3 a, vec3 b, float r) {
+  vec3 ab = b - a;
+  vec3 ap = p - a;
+  float l = dot(ab, ab);
+  if (l == 0.0) {
+    return length(ap) - r;
+  }
+  float t = dot(ab, ap) / l;
+  float x = length(ap - ab * t) - r;
+  float y = (abs(t - 0.5) - 0.5) * sqrt(l);
+  return length(max(vec2(x, y), 0.0)) + min(max(x, y), 0.0);
+}
+
+float sdfTorus(vec3 p, vec2 r) {
+  float x = length(p.xz) - r.x;
+  return length(vec2(x, p.y)) - r.y;
+}
+
+float sdfSmoothMin(float a, float b, float k) {
+  if (k <= 0.0) {
+    return min(a, b);
+  }
+  float h = clamp(0.5 + 0.5 * (b - a) / k, 0.0, 1.0);
+  return mix(b, a, h) - k * h * (1.0 - h);
+}
+
+float sdfSmoothMax(float a, float b, float k) {
+  return -sdfSmoothMin(-a, -b, k);
+}
+
+// sdfRotate turn v by the unit quaternion q
+vec3 sdfRotate(vec4 q, vec3 v) {
+  vec3 t = 2.0 * cross(q.xyz, v);
+  return v + q.w * t + cross(q.xyz, t);
+}
+`
+
+// Shader GLSL for a tree: Library, the uSDF uniform, and
+// "float sceneDistance(vec3 p)". The tree's numbers are read from uSDF
+// rather than written into the code, so the shader only has to be built
+// again when the shape of the tree changes. params is what to set uSDF
+// to, see Params
+func Shader(n Node) (source string, params []float64) {
+	g := &generator{}
+	d := "1e10"
+	if n != nil {
+		d = n.emit(g, "p")
+	}
+	size := len(g.params)
+	if size == 0 {
+		// GLSL has no empty arrays
+		size = 1
+	}
+	out := strings.Builder{}
+	fmt.Fprintf(&out, "uniform float %v[%v];\n\n", ParamsUniform, size)
+	out.WriteString(Library)
+	out.WriteString("\nfloat sceneDistance(vec3 p) {\n")
+	out.WriteString(g.code.String())
+	fmt.Fprintf(&out, "  return %v;\n}\n", d)
+	return out.String(), g.params
+}
+
+// Params the numbers to set uSDF to for a tree, in the order Shader
+// reads them. They can change from frame to frame without a new shader
+// as long as the tree keeps its shape
+func Params(n Node) []float64 {
+	_, params := Shader(n)
+	return params
+}
+
+// generator builds the body of sceneDistance
+type generator struct {
+	code   strings.Builder
+	params []float64
+	vars   int
+}
+
+// param a number, as a uSDF element
+func (g *generator) param(f float64) string {
+	g.params = append(g.params, f)
+	return fmt.Sprintf("%v[%v]", ParamsUniform, len(g.params)-1)
+}
+
+func (g *generator) vec3(v algebra.Vector) string {
+	return fmt.Sprintf("vec3(%v, %v, %v)", g.param(v.X), g.param(v.Y), g.param(v.Z))
+}
+
+func (g *generator) vec4(q algebra.Quaternion) string {
+	return fmt.Sprintf("vec4(%v, %v, %v, %v)", g.param(q.X), g.param(q.Y), g.param(q.Z), g.param(q.W))
+}
+
+// float declare a float variable set to the expression, and name it
+func (g *generator) float(format string, args ...interface{}) string {
+	return g.declare("float", format, args...)
+}
+
+// vec declare a vec3 variable set to the expression, and name it
+func (g *generator) vec(format string, args ...interface{}) string {
+	return g.declare("vec3", format, args...)
+}
+
+func (g *generator) declare(kind string, format string, args ...interface{}) string {
+	name := fmt.Sprintf("v%v", g.vars)
+	g.vars++
+	fmt.Fprintf(&g.code, "  %v %v = %v;\n", kind, name, fmt.Sprintf(format, args...))
+	return name
+}
+
+// fold combine nodes pairwise with a smooth min or max
+func (g *generator) fold(nodes []Node, p string, op string, smooth float64) string {
+	if len(nodes) == 0 {
+		return g.float("1e10")
+	}
+	d := nodes[0].emit(g, p)
+	if len(nodes) == 1 {
+		return d
+	}
+	k := g.param(smooth)
+	for i := 1; i < len(nodes); i++ {
+		d = g.float("%v(%v, %v, %v)", op, d, nodes[i].emit(g, p), k)
+	}
+	return d
+}
@@ -0,0 +1,69 @@
+package sdf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func TestShader(t *testing.T) {
+	tree := sdf.Subtract{
+		From: sdf.Union{
+			Nodes: []sdf.Node{
+				sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 2, Z: 3}},
+				sdf.Transform{Position: algebra.Vector{Y: 2}, Node: sdf.Sphere{Radius: 1.5}},
+			},
+			Smooth: 0.25,
+		},
+		Cut: sdf.Torus{Major: 1, Minor: 0.25},
+	}
+
+	source, params := sdf.Shader(tree)
+	for _, expected := range []string{
+		"uniform float uSDF[17];",
+		"float sdfSmoothMin(float a, float b, float k)",
+		"float sceneDistance(vec3 p) {",
+		"sdfBox(p, vec3(uSDF[0], uSDF[1], uSDF[2])) - uSDF[3]",
+		"sdfSmoothMin(",
+		"sdfTorus(p, vec2(",
+		"sdfSmoothMax(",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("shader is missing %q:\n%v", expected, source)
+		}
+	}
+
+	// Box and rounding, union smooth, scale, rotation (an unset one
+	// inverts to the identity), position, radius, torus, subtract smooth
+	expected := []float64{1, 2, 3, 0, 0.25, 1, 0, 0, 0, 1, 0, 2, 0, 1.5, 1, 0.25, 0}
+	if len(params) != 17 {
+		t.Fatalf("expected 17 params got %v", len(params))
+	}
+	for i := range expected {
+		if params[i] != expected[i] {
+			t.Errorf("param %v: %v should be %v (%v)", i, params[i], expected[i], params)
+		}
+	}
+}
+
+func TestShaderParams(t *testing.T) {
+	small := sdf.Sphere{Radius: 1}
+	big := sdf.Sphere{Radius: 5}
+
+	// Only the numbers change, so the same shader can be kept
+	a, _ := sdf.Shader(small)
+	b, _ := sdf.Shader(big)
+	if a != b {
+		t.Errorf("shaders for the same shaped tree should match")
+	}
+	if params := sdf.Params(big); len(params) != 1 || params[0] != 5 {
+		t.Errorf("params %v should be [5]", params)
+	}
+
+	empty, params := sdf.Shader(nil)
+	if !strings.Contains(empty, "uniform float uSDF[1];") || len(params) != 0 {
+		t.Errorf("an empty tree should still declare uSDF:\n%v", empty)
+	}
+}
@@ -0,0 +1,130 @@
+package sdf
+
+import (
+	"errors"
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Field a value at every point, negative inside and positive outside
+// with the surface where it's 0. Every Node is one, and so is a Grid
+type Field interface {
+	Distance(p algebra.Vector) float64
+}
+
+// Grid a field stored as samples on a regular lattice, like a voxel
+// grid. Densities that grow towards solid can be stored as threshold -
+// density, so the surface is where the density reaches the threshold
+type Grid struct {
+	// Min where the first sample is
+	Min algebra.Vector
+	// Step how far apart the samples are along each axis
+	Step float64
+	// Width, Height, Depth how many samples there are along X, Y and Z
+	Width  int
+	Height int
+	Depth  int
+	// Values the samples, X changing fastest then Y then Z
+	Values []float64
+}
+
+// NewGrid create a grid with every sample 0. The step and every size
+// must be above 0
+func NewGrid(min algebra.Vector, step float64, width, height, depth int) (*Grid, error) {
+	if !(step > 0) {
+		return nil, errors.New("grid step must be above 0")
+	}
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return nil, errors.New("grid width, height and depth must be above 0")
+	}
+	return &Grid{
+		Min:    min,
+		Step:   step,
+		Width:  width,
+		Height: height,
+		Depth:  depth,
+		Values: make([]float64, width*height*depth),
+	}, nil
+}
+
+// SampleGrid sample a field every step through the box from min to max
+// (rounded up to a whole number of steps)
+func SampleGrid(f Field, min, max algebra.Vector, step float64) (*Grid, error) {
+	if !(step > 0) {
+		return nil, errors.New("sample step must be above 0")
+	}
+	if max.X < min.X || max.Y < min.Y || max.Z < min.Z {
+		return nil, errors.New("sample box max is below min")
+	}
+	count := func(from, to float64) int {
+		return int(math.Ceil((to-from)/step-1e-9)) + 1
+	}
+	g, err := NewGrid(min, step, count(min.X, max.X), count(min.Y, max.Y), count(min.Z, max.Z))
+	if err != nil {
+		return nil, err
+	}
+	for z := 0; z < g.Depth; z++ {
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				g.Set(x, y, z, f.Distance(g.Point(x, y, z)))
+			}
+		}
+	}
+	return g, nil
+}
+
+// At the sample at x, y, z
+func (g *Grid) At(x, y, z int) float64 {
+	return g.Values[g.index(x, y, z)]
+}
+
+// Set the sample at x, y, z
+func (g *Grid) Set(x, y, z int, v float64) {
+	g.Values[g.index(x, y, z)] = v
+}
+
+// Point where the sample at x, y, z is
+func (g *Grid) Point(x, y, z int) algebra.Vector {
+	return algebra.Vector{
+		X: g.Min.X + float64(x)*g.Step,
+		Y: g.Min.Y + float64(y)*g.Step,
+		Z: g.Min.Z + float64(z)*g.Step,
+	}
+}
+
+// Distance the samples around p blended (trilinearly). Past the edges
+// of the grid the edge samples carry on
+func (g *Grid) Distance(p algebra.Vector) float64 {
+	x0, x1, fx := g.cell(p.X-g.Min.X, g.Width)
+	y0, y1, fy := g.cell(p.Y-g.Min.Y, g.Height)
+	z0, z1, fz := g.cell(p.Z-g.Min.Z, g.Depth)
+	lerp := func(a, b, t float64) float64 {
+		return a + (b-a)*t
+	}
+	plane := func(z int) float64 {
+		return lerp(
+			lerp(g.At(x0, y0, z), g.At(x1, y0, z), fx),
+			lerp(g.At(x0, y1, z), g.At(x1, y1, z), fx),
+			fy)
+	}
+	return lerp(plane(z0), plane(z1), fz)
+}
+
+// cell the samples either side of offset along an axis with count
+// samples, and how far between them it is
+func (g *Grid) cell(offset float64, count int) (int, int, float64) {
+	u := clamp(offset/g.Step, 0, float64(count-1))
+	i := int(math.Floor(u))
+	if i >= count-1 {
+		i = count - 2
+	}
+	if i < 0 {
+		return 0, 0, 0
+	}
+	return i, i + 1, u - float64(i)
+}
+
+func (g *Grid) index(x, y, z int) int {
+	return x + g.Width*(y+g.Height*z)
+}
@@ -0,0 +1,78 @@
+package sdf_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func TestGrid(t *testing.T) {
+	g, err := sdf.NewGrid(algebra.Vector{X: -1}, 0.5, 2, 2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	g.Set(1, 0, 0, 1)
+	g.Set(1, 1, 1, 3)
+	if g.At(1, 0, 0) != 1 || g.At(0, 0, 0) != 0 {
+		t.Errorf("samples %v", g.Values)
+	}
+	expected := algebra.Vector{X: -0.5, Y: 0.5, Z: 0.5}
+	if p := g.Point(1, 1, 1); p != expected {
+		t.Errorf("point %v should be %v", p, expected)
+	}
+
+	// Halfway along X on the first row, and in the middle of the cell
+	if d := g.Distance(algebra.Vector{X: -0.75}); !almost(d, 0.5) {
+		t.Errorf("distance %v should be 0.5", d)
+	}
+	if d := g.Distance(algebra.Vector{X: -0.75, Y: 0.25, Z: 0.25}); !almost(d, 0.5) {
+		t.Errorf("middle distance %v should be 0.5", d)
+	}
+	// Clamped past the edges
+	if d := g.Distance(algebra.Vector{X: 5}); !almost(d, 1) {
+		t.Errorf("outside distance %v should be 1", d)
+	}
+}
+
+func TestSampleGrid(t *testing.T) {
+	ground := sdf.Plane{Normal: algebra.Vector{Y: 1}}
+	g, err := sdf.SampleGrid(ground, algebra.Vector{X: -1, Y: -1, Z: -1}, algebra.Vector{X: 1, Y: 1, Z: 0.9}, 0.5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if g.Width != 5 || g.Height != 5 || g.Depth != 5 {
+		t.Errorf("expected 5x5x5 samples got %vx%vx%v", g.Width, g.Height, g.Depth)
+	}
+	if v := g.At(3, 3, 0); !almost(v, 0.5) {
+		t.Errorf("sample %v should be 0.5", v)
+	}
+	if d := g.Distance(algebra.Vector{Y: -0.2}); !almost(d, -0.2) {
+		t.Errorf("distance %v should be -0.2", d)
+	}
+}
+
+func TestGridBadSizes(t *testing.T) {
+	sizes := [][3]int{{0, 2, 2}, {2, -1, 2}, {2, 2, 0}}
+	for i := 0; i < len(sizes); i++ {
+		if _, err := sdf.NewGrid(algebra.Vector{}, 1, sizes[i][0], sizes[i][1], sizes[i][2]); err == nil {
+			t.Errorf("expected size %v to fail", sizes[i])
+		}
+	}
+	if _, err := sdf.NewGrid(algebra.Vector{}, 0, 2, 2, 2); err == nil {
+		t.Errorf("expected a 0 step to fail")
+	}
+
+	ground := sdf.Plane{Normal: algebra.Vector{Y: 1}}
+	for _, step := range []float64{0, -0.5} {
+		if _, err := sdf.SampleGrid(ground, algebra.Vector{}, algebra.Vector{X: 1, Y: 1, Z: 1}, step); err == nil {
+			t.Errorf("expected step %v to fail", step)
+		}
+	}
+	maxes := []algebra.Vector{{X: -1, Y: 1, Z: 1}, {X: 1, Y: -1, Z: 1}, {X: 1, Y: 1, Z: -1}}
+	for i := 0; i < len(maxes); i++ {
+		if _, err := sdf.SampleGrid(ground, algebra.Vector{}, maxes[i], 0.5); err == nil {
+			t.Errorf("expected max %v below min to fail", maxes[i])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+const (
+	// MaxSteps the most steps a ray takes before it gives up
+	MaxSteps = 100
+	// SurfaceDistance how close a ray has to get to count as a hit
+	SurfaceDistance = 0.001
+	// normalStep how far apart the distances Normal compares are
+	normalStep = 0.001
+)
+
+// Normal which way is out at p, the direction of the field's gradient
+func Normal(f Field, p algebra.Vector) algebra.Vector {
+	return normalize(gradient(f, p, normalStep))
+}
+
+// March step a ray (direction unit length) along by the distance to the
+// nearest surface until it hits one, or gets past maxDistance. The
+// distance along the ray comes back with whether it hit
+func March(n Node, origin, direction algebra.Vector, maxDistance float64) (float64, bool) {
+	t := 0.0
+	for i := 0; i < MaxSteps; i++ {
+		d := n.Distance(add(origin, scale(direction, t)))
+		if math.Abs(d) < SurfaceDistance {
+			return t, true
+		}
+		t += d
+		if t > maxDistance || t < 0 {
+			return maxDistance, false
+		}
+	}
+	return maxDistance, false
+}
+
+// Contact how far a sphere overlaps the shape, and the way out, for
+// collision. ok is false when they don't touch
+func Contact(n Node, center algebra.Vector, radius float64) (depth float64, normal algebra.Vector, ok bool) {
+	d := n.Distance(center)
+	if d >= radius {
+		return 0, algebra.Vector{}, false
+	}
+	return radius - d, Normal(n, center), true
+}
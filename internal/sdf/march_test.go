@@ -0,0 +1,40 @@
+package sdf_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func TestMarch(t *testing.T) {
+	ball := sdf.Transform{Position: algebra.Vector{Z: -10}, Node: sdf.Sphere{Radius: 2}}
+
+	distance, hit := sdf.March(ball, algebra.Vector{}, algebra.Vector{Z: -1}, 100)
+	if !hit || distance < 8-sdf.SurfaceDistance || distance > 8+sdf.SurfaceDistance {
+		t.Errorf("expected a hit at 8 got %v %v", distance, hit)
+	}
+
+	if _, hit := sdf.March(ball, algebra.Vector{}, algebra.Vector{Z: 1}, 100); hit {
+		t.Errorf("expected a miss looking away")
+	}
+	if _, hit := sdf.March(ball, algebra.Vector{}, algebra.Vector{Z: -1}, 5); hit {
+		t.Errorf("expected a miss past the max distance")
+	}
+}
+
+func TestNormalContact(t *testing.T) {
+	ground := sdf.Plane{Normal: algebra.Vector{Y: 1}}
+	expected := algebra.Vector{Y: 1}
+	if n := sdf.Normal(ground, algebra.Vector{X: 3, Y: 0.1}); !n.AlmostEquals(&expected) {
+		t.Errorf("normal %v should be %v", n, expected)
+	}
+
+	depth, normal, ok := sdf.Contact(ground, algebra.Vector{Y: 0.25}, 1)
+	if !ok || !almost(depth, 0.75) || !normal.AlmostEquals(&expected) {
+		t.Errorf("contact %v %v %v should be 0.75 %v", depth, normal, ok, expected)
+	}
+	if _, _, ok := sdf.Contact(ground, algebra.Vector{Y: 2}, 1); ok {
+		t.Errorf("expected no contact above the ground")
+	}
+}
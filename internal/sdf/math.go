@@ -0,0 +1,65 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Small value helpers, like the physics package's. Distance functions
+// read better returning by value than through out pointers
+
+func add(a, b algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func sub(a, b algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+func scale(a algebra.Vector, s float64) algebra.Vector {
+	return algebra.Vector{X: a.X * s, Y: a.Y * s, Z: a.Z * s}
+}
+
+func dot(a, b algebra.Vector) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func length(a algebra.Vector) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func normalize(a algebra.Vector) algebra.Vector {
+	l := length(a)
+	if l < 1e-12 {
+		return algebra.Vector{}
+	}
+	return scale(a, 1/l)
+}
+
+func abs(a algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: math.Abs(a.X), Y: math.Abs(a.Y), Z: math.Abs(a.Z)}
+}
+
+// maxZero each part of a, or 0 where it's negative
+func maxZero(a algebra.Vector) algebra.Vector {
+	return algebra.Vector{X: math.Max(a.X, 0), Y: math.Max(a.Y, 0), Z: math.Max(a.Z, 0)}
+}
+
+func clamp(f, min, max float64) float64 {
+	return math.Max(min, math.Min(max, f))
+}
+
+// smoothMin a minimum whose corner is rounded off over k, Quilez's
+// polynomial version. The shader library's sdfSmoothMin is the same
+func smoothMin(a, b, k float64) float64 {
+	if k <= 0 {
+		return math.Min(a, b)
+	}
+	h := clamp(0.5+0.5*(b-a)/k, 0, 1)
+	return b + (a-b)*h - k*h*(1-h)
+}
+
+func smoothMax(a, b, k float64) float64 {
+	return -smoothMin(-a, -b, k)
+}
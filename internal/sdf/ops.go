@@ -0,0 +1,118 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Union everything in any of the nodes
+type Union struct {
+	Nodes []Node
+	// Smooth how far apart shapes start to blend into each other, 0
+	// for a hard edge
+	Smooth float64
+}
+
+// Distance from the nearest node, blended
+func (u Union) Distance(p algebra.Vector) float64 {
+	if len(u.Nodes) == 0 {
+		return math.Inf(1)
+	}
+	d := u.Nodes[0].Distance(p)
+	for i := 1; i < len(u.Nodes); i++ {
+		d = smoothMin(d, u.Nodes[i].Distance(p), u.Smooth)
+	}
+	return d
+}
+
+func (u Union) emit(g *generator, p string) string {
+	return g.fold(u.Nodes, p, "sdfSmoothMin", u.Smooth)
+}
+
+// Intersect only what's in all of the nodes (nothing when there are
+// none)
+type Intersect struct {
+	Nodes []Node
+	// Smooth how far the edges where the nodes meet are rounded, 0 for
+	// a hard edge
+	Smooth float64
+}
+
+// Distance from the farthest node, blended
+func (in Intersect) Distance(p algebra.Vector) float64 {
+	if len(in.Nodes) == 0 {
+		return math.Inf(1)
+	}
+	d := in.Nodes[0].Distance(p)
+	for i := 1; i < len(in.Nodes); i++ {
+		d = smoothMax(d, in.Nodes[i].Distance(p), in.Smooth)
+	}
+	return d
+}
+
+func (in Intersect) emit(g *generator, p string) string {
+	return g.fold(in.Nodes, p, "sdfSmoothMax", in.Smooth)
+}
+
+// Subtract cut Cut out of From
+type Subtract struct {
+	From Node
+	Cut  Node
+	// Smooth how far the edges of the cut are rounded, 0 for a hard
+	// edge
+	Smooth float64
+}
+
+// Distance from what's left of From
+func (s Subtract) Distance(p algebra.Vector) float64 {
+	return smoothMax(s.From.Distance(p), -s.Cut.Distance(p), s.Smooth)
+}
+
+func (s Subtract) emit(g *generator, p string) string {
+	from := s.From.emit(g, p)
+	cut := s.Cut.emit(g, p)
+	return g.float("sdfSmoothMax(%v, -%v, %v)", from, cut, g.param(s.Smooth))
+}
+
+// Transform move, turn and scale a node
+type Transform struct {
+	Position algebra.Vector
+	// Rotation unit length, where unset means none
+	Rotation algebra.Quaternion
+	// Scale the same along every axis, so distances stay right. 0
+	// means 1
+	Scale float64
+	Node  Node
+}
+
+// Distance from the moved node
+func (t Transform) Distance(p algebra.Vector) float64 {
+	s := t.scale()
+	return t.Node.Distance(t.local(p)) * s
+}
+
+// local p in the node's space
+func (t Transform) local(p algebra.Vector) algebra.Vector {
+	inverse := algebra.Quaternion{}
+	t.Rotation.Inverse(&inverse)
+	out := algebra.Vector{}
+	inverse.Rotate(sub(p, t.Position), &out)
+	return scale(out, 1/t.scale())
+}
+
+func (t Transform) emit(g *generator, p string) string {
+	inverse := algebra.Quaternion{}
+	t.Rotation.Inverse(&inverse)
+	s := g.param(t.scale())
+	local := g.vec("sdfRotate(%v, %v - %v) / %v", g.vec4(inverse), p, g.vec3(t.Position), s)
+	d := t.Node.emit(g, local)
+	return g.float("%v * %v", d, s)
+}
+
+func (t Transform) scale() float64 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
@@ -0,0 +1,85 @@
+package sdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func TestUnion(t *testing.T) {
+	u := sdf.Union{Nodes: []sdf.Node{
+		sdf.Transform{Position: algebra.Vector{X: -2}, Node: sdf.Sphere{Radius: 1}},
+		sdf.Transform{Position: algebra.Vector{X: 2}, Node: sdf.Sphere{Radius: 1}},
+	}}
+	if d := u.Distance(algebra.Vector{X: 4}); !almost(d, 1) {
+		t.Errorf("union: %v should be 1", d)
+	}
+	if d := u.Distance(algebra.Vector{}); !almost(d, 1) {
+		t.Errorf("union between: %v should be 1", d)
+	}
+
+	// Blending pulls the surface in between the spheres
+	u.Smooth = 2
+	if d := u.Distance(algebra.Vector{}); d >= 1 {
+		t.Errorf("smooth union: %v should be less than 1", d)
+	}
+	// but not far away from where they meet
+	if d := u.Distance(algebra.Vector{X: 6}); !almost(d, 3) {
+		t.Errorf("smooth union far: %v should be 3", d)
+	}
+
+	if d := (sdf.Union{}).Distance(algebra.Vector{}); !math.IsInf(d, 1) {
+		t.Errorf("empty union: %v should be infinite", d)
+	}
+}
+
+func TestIntersectSubtract(t *testing.T) {
+	box := sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 1, Z: 1}}
+	ball := sdf.Sphere{Radius: 1.2}
+
+	in := sdf.Intersect{Nodes: []sdf.Node{box, ball}}
+	// The ball rounds off the box's corners
+	if d := in.Distance(algebra.Vector{X: 1, Y: 1, Z: 1}); !almost(d, math.Sqrt(3)-1.2) {
+		t.Errorf("intersect corner: %v should be %v", d, math.Sqrt(3)-1.2)
+	}
+	if d := in.Distance(algebra.Vector{X: 2}); !almost(d, 1) {
+		t.Errorf("intersect face: %v should be 1", d)
+	}
+
+	cut := sdf.Subtract{From: box, Cut: sdf.Sphere{Radius: 0.5}}
+	if d := cut.Distance(algebra.Vector{}); !almost(d, 0.5) {
+		t.Errorf("subtract hollow: %v should be 0.5", d)
+	}
+	if d := cut.Distance(algebra.Vector{X: 0.9}); !almost(d, -0.1) {
+		t.Errorf("subtract solid: %v should be -0.1", d)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	turn := algebra.Quaternion{}
+	turn.SetFromVector(&algebra.Vector{Z: 1}, math.Pi/2)
+	tr := sdf.Transform{
+		Position: algebra.Vector{Y: 5},
+		Rotation: turn,
+		Scale:    2,
+		// Long along X until it's turned onto Y
+		Node: sdf.Box{HalfExtents: algebra.Vector{X: 2, Y: 0.5, Z: 0.5}},
+	}
+
+	// 2 * 2 half length above the middle, plus 1 to go
+	if d := tr.Distance(algebra.Vector{Y: 10}); !almost(d, 1) {
+		t.Errorf("along: %v should be 1", d)
+	}
+	// 2 * 0.5 half width, plus 2 to go
+	if d := tr.Distance(algebra.Vector{X: 3, Y: 5}); !almost(d, 2) {
+		t.Errorf("across: %v should be 2", d)
+	}
+
+	// Unset rotation and scale leave the node as it is
+	moved := sdf.Transform{Position: algebra.Vector{Z: -3}, Node: sdf.Sphere{Radius: 1}}
+	if d := moved.Distance(algebra.Vector{}); !almost(d, 2) {
+		t.Errorf("moved: %v should be 2", d)
+	}
+}
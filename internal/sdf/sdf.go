@@ -0,0 +1,140 @@
+// Package sdf describes shapes by their signed distance functions: how
+// far a point is from the surface, negative inside. Trees of primitives
+// and CSG operations are evaluated on the CPU (for collision and
+// picking) and turned into GLSL for ray marching on the GPU, see
+// render.ComponentSDF. They, and voxel grids, can also be turned into
+// triangle meshes with Mesh and Contour
+package sdf
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+)
+
+// Node a shape in an SDF tree
+type Node interface {
+	// Distance the signed distance from p to the surface. It never
+	// overestimates, so marching a ray by it can't step through anything
+	Distance(p algebra.Vector) float64
+	// emit write GLSL working out the distance at the point in the vec3
+	// variable p, and return the float variable holding it
+	emit(g *generator, p string) string
+}
+
+// Sphere a ball around the origin
+type Sphere struct {
+	Radius float64
+}
+
+// Distance from the sphere
+func (s Sphere) Distance(p algebra.Vector) float64 {
+	return length(p) - s.Radius
+}
+
+func (s Sphere) emit(g *generator, p string) string {
+	return g.float("length(%v) - %v", p, g.param(s.Radius))
+}
+
+// Box a box around the origin
+type Box struct {
+	// HalfExtents half the box's size along each axis
+	HalfExtents algebra.Vector
+	// Rounding how far the corners are rounded, which also grows the
+	// box by as much
+	Rounding float64
+}
+
+// Distance from the box
+func (b Box) Distance(p algebra.Vector) float64 {
+	q := sub(abs(p), b.HalfExtents)
+	inside := math.Min(math.Max(q.X, math.Max(q.Y, q.Z)), 0)
+	return length(maxZero(q)) + inside - b.Rounding
+}
+
+func (b Box) emit(g *generator, p string) string {
+	return g.float("sdfBox(%v, %v) - %v", p, g.vec3(b.HalfExtents), g.param(b.Rounding))
+}
+
+// Capsule a line from A to B with rounded thickness
+type Capsule struct {
+	A      algebra.Vector
+	B      algebra.Vector
+	Radius float64
+}
+
+// Distance from the capsule
+func (c Capsule) Distance(p algebra.Vector) float64 {
+	ab := sub(c.B, c.A)
+	ap := sub(p, c.A)
+	t := 0.0
+	if l := dot(ab, ab); l > 0 {
+		t = clamp(dot(ab, ap)/l, 0, 1)
+	}
+	return length(sub(ap, scale(ab, t))) - c.Radius
+}
+
+func (c Capsule) emit(g *generator, p string) string {
+	return g.float("sdfCapsule(%v, %v, %v, %v)", p, g.vec3(c.A), g.vec3(c.B), g.param(c.Radius))
+}
+
+// Cylinder a flat ended cylinder from A to B
+type Cylinder struct {
+	A      algebra.Vector
+	B      algebra.Vector
+	Radius float64
+}
+
+// Distance from the cylinder
+func (c Cylinder) Distance(p algebra.Vector) float64 {
+	ab := sub(c.B, c.A)
+	ap := sub(p, c.A)
+	l := dot(ab, ab)
+	if l == 0 {
+		return length(ap) - c.Radius
+	}
+	t := dot(ab, ap) / l
+	x := length(sub(ap, scale(ab, t))) - c.Radius
+	y := (math.Abs(t-0.5) - 0.5) * math.Sqrt(l)
+	outside := math.Sqrt(math.Pow(math.Max(x, 0), 2) + math.Pow(math.Max(y, 0), 2))
+	return outside + math.Min(math.Max(x, y), 0)
+}
+
+func (c Cylinder) emit(g *generator, p string) string {
+	return g.float("sdfCylinder(%v, %v, %v, %v)", p, g.vec3(c.A), g.vec3(c.B), g.param(c.Radius))
+}
+
+// Torus a ring around the Y axis
+type Torus struct {
+	// Major the radius of the ring
+	Major float64
+	// Minor the radius of the tube
+	Minor float64
+}
+
+// Distance from the torus
+func (t Torus) Distance(p algebra.Vector) float64 {
+	x := math.Sqrt(p.X*p.X+p.Z*p.Z) - t.Major
+	return math.Sqrt(x*x+p.Y*p.Y) - t.Minor
+}
+
+func (t Torus) emit(g *generator, p string) string {
+	return g.float("sdfTorus(%v, vec2(%v, %v))", p, g.param(t.Major), g.param(t.Minor))
+}
+
+// Plane everything below a plane, like the ground
+type Plane struct {
+	// Normal which way is out, unit length
+	Normal algebra.Vector
+	// Offset how far along Normal from the origin the plane is
+	Offset float64
+}
+
+// Distance from the plane
+func (pl Plane) Distance(p algebra.Vector) float64 {
+	return dot(p, pl.Normal) - pl.Offset
+}
+
+func (pl Plane) emit(g *generator, p string) string {
+	return g.float("dot(%v, %v) - %v", p, g.vec3(pl.Normal), g.param(pl.Offset))
+}
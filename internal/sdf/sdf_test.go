@@ -0,0 +1,43 @@
+package sdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/sdf"
+)
+
+func almost(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestPrimitiveDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     sdf.Node
+		p        algebra.Vector
+		expected float64
+	}{
+		{"sphere outside", sdf.Sphere{Radius: 1}, algebra.Vector{X: 3}, 2},
+		{"sphere inside", sdf.Sphere{Radius: 1}, algebra.Vector{}, -1},
+		{"box face", sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 2, Z: 3}}, algebra.Vector{Y: 5}, 3},
+		{"box corner", sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 1, Z: 1}}, algebra.Vector{X: 4, Y: 5, Z: 1}, 5},
+		{"box inside", sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 2, Z: 3}}, algebra.Vector{X: 0.5}, -0.5},
+		{"rounded box", sdf.Box{HalfExtents: algebra.Vector{X: 1, Y: 1, Z: 1}, Rounding: 0.5}, algebra.Vector{X: 3}, 1.5},
+		{"capsule side", sdf.Capsule{B: algebra.Vector{Y: 2}, Radius: 0.5}, algebra.Vector{X: 2, Y: 1}, 1.5},
+		{"capsule end", sdf.Capsule{B: algebra.Vector{Y: 2}, Radius: 0.5}, algebra.Vector{Y: 4}, 1.5},
+		{"cylinder side", sdf.Cylinder{B: algebra.Vector{Y: 2}, Radius: 0.5}, algebra.Vector{X: 2, Y: 1}, 1.5},
+		{"cylinder cap", sdf.Cylinder{B: algebra.Vector{Y: 2}, Radius: 0.5}, algebra.Vector{Y: 4}, 2},
+		{"cylinder inside", sdf.Cylinder{B: algebra.Vector{Y: 2}, Radius: 0.5}, algebra.Vector{Y: 1.9}, -0.1},
+		{"torus", sdf.Torus{Major: 2, Minor: 0.5}, algebra.Vector{X: 2, Y: 1}, 0.5},
+		{"torus hole", sdf.Torus{Major: 2, Minor: 0.5}, algebra.Vector{}, 1.5},
+		{"plane", sdf.Plane{Normal: algebra.Vector{Y: 1}, Offset: -1}, algebra.Vector{X: 7, Y: 2}, 3},
+	}
+
+	for _, test := range tests {
+		if actual := test.node.Distance(test.p); !almost(actual, test.expected) {
+			t.Errorf("%v: %v should be %v", test.name, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tween
+
+import "github.com/robrohan/mesh/internal/core"
+
+// NewComponentTween create a component with nothing playing
+func NewComponentTween() ComponentTween {
+	return ComponentTween{
+		Component: &core.Component{
+			Parent: &core.Entity{},
+		},
+		Speed: 1,
+	}
+}
+
+// ComponentTween plays tweens, sequences and the like each update until
+// they finish
+type ComponentTween struct {
+	*core.Component
+	// Speed how fast everything plays, 1 as made and 0 frozen
+	Speed float64
+
+	playing []Animation
+}
+
+// Play start an animation from where it is, alongside anything already
+// playing
+func (c *ComponentTween) Play(a Animation) {
+	c.playing = append(c.playing, a)
+}
+
+// Stop an animation where it is, leaving what it moves there
+func (c *ComponentTween) Stop(a Animation) {
+	for i := 0; i < len(c.playing); i++ {
+		if c.playing[i] == a {
+			c.playing = append(c.playing[:i], c.playing[i+1:]...)
+			return
+		}
+	}
+}
+
+// StopAll stop everything
+func (c *ComponentTween) StopAll() {
+	c.playing = nil
+}
+
+// Playing how many animations haven't finished
+func (c *ComponentTween) Playing() int {
+	return len(c.playing)
+}
+
+// Update move everything on, dropping what finishes
+func (c *ComponentTween) Update(dt float64) {
+	dt *= c.Speed
+	if dt < 0 {
+		return
+	}
+	// Completion callbacks can play or stop more, so go by the
+	// animations as they were
+	playing := append([]Animation(nil), c.playing...)
+	for i := 0; i < len(playing); i++ {
+		if !c.has(playing[i]) {
+			continue
+		}
+		if _, done := playing[i].Step(dt); done {
+			c.Stop(playing[i])
+		}
+	}
+}
+
+// has whether an animation is still playing
+func (c *ComponentTween) has(a Animation) bool {
+	for i := 0; i < len(c.playing); i++ {
+		if c.playing[i] == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package tween_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/tween"
+)
+
+func TestComponentTween(t *testing.T) {
+	c := tween.NewComponentTween()
+	e := core.Entity{Transform: core.NewTransform()}
+	e.Attach(&c)
+	if _, ok := e.GetComponent(core.ComponentTypeTween).(*tween.ComponentTween); !ok {
+		t.Fatalf("tween component should be found by its type")
+	}
+	if _, ok := interface{}(&c).(core.Updater); !ok {
+		t.Fatalf("tween component should be updated by the scene")
+	}
+
+	color := algebra.Vector{X: 1}
+	fade := tween.Vector(&color, algebra.Vector{Z: 1}, 1)
+	// Playing something new when the first finishes
+	grow := tween.Scale(e.Transform, algebra.Vector{X: 2, Y: 2, Z: 2}, 1)
+	fade.OnComplete = func() { c.Play(grow) }
+	c.Play(fade)
+
+	c.Speed = 2
+	c.Update(0.25)
+	if color.X != 0.5 || color.Z != 0.5 {
+		t.Errorf("twice as fast: got %v", color)
+	}
+	c.Update(0.25)
+	if c.Playing() != 1 || color.Z != 1 {
+		t.Errorf("fade should finish and start the grow, %v playing", c.Playing())
+	}
+	c.Speed = 1
+	c.Update(0.5)
+	if e.Transform.Scale.X != 1.5 {
+		t.Errorf("grow: got %v", e.Transform.Scale)
+	}
+
+	c.StopAll()
+	c.Update(1)
+	if e.Transform.Scale.X != 1.5 || c.Playing() != 0 {
+		t.Errorf("stopped: got %v", e.Transform.Scale)
+	}
+}
@@ -0,0 +1,81 @@
+package tween
+
+import "math"
+
+// Ease shapes how a tween moves: takes how far through it is in time,
+// 0 to 1, and gives how far along the value is. 0 gives 0 and 1 gives 1,
+// but the value can overshoot in between (OutBack)
+type Ease func(t float64) float64
+
+// Linear constant speed
+func Linear(t float64) float64 {
+	return t
+}
+
+// InQuad start slow and speed up
+func InQuad(t float64) float64 {
+	return t * t
+}
+
+// OutQuad start fast and slow down
+func OutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+// InOutQuad slow at both ends
+func InOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+// InCubic start slower than InQuad and speed up
+func InCubic(t float64) float64 {
+	return t * t * t
+}
+
+// OutCubic start fast and slow down more than OutQuad
+func OutCubic(t float64) float64 {
+	t--
+	return t*t*t + 1
+}
+
+// InOutCubic slow at both ends, more so than InOutQuad
+func InOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t/2 + 1
+}
+
+// InOutSine slow at both ends following a sine wave, gentler than
+// InOutQuad
+func InOutSine(t float64) float64 {
+	return (1 - math.Cos(math.Pi*t)) / 2
+}
+
+// OutBack overshoot the end a little and settle back
+func OutBack(t float64) float64 {
+	const s = 1.70158
+	t--
+	return t*t*((s+1)*t+s) + 1
+}
+
+// OutBounce bounce to a stop at the end, like a dropped ball
+func OutBounce(t float64) float64 {
+	const n, d = 7.5625, 2.75
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	}
+	t -= 2.625 / d
+	return n*t*t + 0.984375
+}
@@ -0,0 +1,34 @@
+package tween_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/tween"
+)
+
+func TestEases(t *testing.T) {
+	eases := map[string]tween.Ease{
+		"Linear":     tween.Linear,
+		"InQuad":     tween.InQuad,
+		"OutQuad":    tween.OutQuad,
+		"InOutQuad":  tween.InOutQuad,
+		"InCubic":    tween.InCubic,
+		"OutCubic":   tween.OutCubic,
+		"InOutCubic": tween.InOutCubic,
+		"InOutSine":  tween.InOutSine,
+		"OutBack":    tween.OutBack,
+		"OutBounce":  tween.OutBounce,
+	}
+	for name, ease := range eases {
+		if math.Abs(ease(0)) > 1e-9 || math.Abs(ease(1)-1) > 1e-9 {
+			t.Errorf("%v: should go from 0 to 1, got %v to %v", name, ease(0), ease(1))
+		}
+	}
+	if tween.InQuad(0.5) != 0.25 || tween.OutQuad(0.5) != 0.75 || tween.InOutQuad(0.5) != 0.5 {
+		t.Errorf("quads at half way: %v %v %v", tween.InQuad(0.5), tween.OutQuad(0.5), tween.InOutQuad(0.5))
+	}
+	if !(tween.OutBack(0.8) > 1) {
+		t.Errorf("OutBack should overshoot, got %v", tween.OutBack(0.8))
+	}
+}
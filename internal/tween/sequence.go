@@ -0,0 +1,154 @@
+package tween
+
+// Sequence animations played one after the other
+type Sequence struct {
+	Steps []Animation
+	// OnComplete called when the last step finishes
+	OnComplete func()
+
+	current int
+	done    bool
+}
+
+// NewSequence play steps one after the other
+func NewSequence(steps ...Animation) *Sequence {
+	return &Sequence{Steps: steps}
+}
+
+// Step see Animation. Time left over from one step goes to the next
+func (s *Sequence) Step(dt float64) (float64, bool) {
+	if s.done {
+		return dt, true
+	}
+	for s.current < len(s.Steps) {
+		left, done := s.Steps[s.current].Step(dt)
+		if !done {
+			return 0, false
+		}
+		dt = left
+		s.current++
+	}
+	s.done = true
+	if s.OnComplete != nil {
+		s.OnComplete()
+	}
+	return dt, true
+}
+
+// Restart see Animation
+func (s *Sequence) Restart() {
+	s.current = 0
+	s.done = false
+	for i := 0; i < len(s.Steps); i++ {
+		s.Steps[i].Restart()
+	}
+}
+
+// Group animations played together
+type Group struct {
+	Animations []Animation
+	// OnComplete called when the last of them finishes
+	OnComplete func()
+
+	finished []bool
+	left     []float64
+	done     bool
+}
+
+// NewGroup play animations together
+func NewGroup(animations ...Animation) *Group {
+	return &Group{Animations: animations}
+}
+
+// Step see Animation. It finishes with the last of its animations
+func (g *Group) Step(dt float64) (float64, bool) {
+	if g.done {
+		return dt, true
+	}
+	if len(g.finished) != len(g.Animations) {
+		g.finished = make([]bool, len(g.Animations))
+		g.left = make([]float64, len(g.Animations))
+	}
+	all := true
+	for i := 0; i < len(g.Animations); i++ {
+		if g.finished[i] {
+			// Keeps track of how long ago it finished
+			g.left[i] += dt
+			continue
+		}
+		g.left[i], g.finished[i] = g.Animations[i].Step(dt)
+		all = all && g.finished[i]
+	}
+	if !all {
+		return 0, false
+	}
+	// The last to finish has the least left over
+	left := dt
+	for i := 0; i < len(g.left); i++ {
+		if g.left[i] < left {
+			left = g.left[i]
+		}
+	}
+	g.done = true
+	if g.OnComplete != nil {
+		g.OnComplete()
+	}
+	return left, true
+}
+
+// Restart see Animation
+func (g *Group) Restart() {
+	g.finished = nil
+	g.left = nil
+	g.done = false
+	for i := 0; i < len(g.Animations); i++ {
+		g.Animations[i].Restart()
+	}
+}
+
+// Repeat an animation, like a sequence, played over and over
+type Repeat struct {
+	Animation Animation
+	// Times how many times to play it, 0 is forever
+	Times int
+
+	count int
+	done  bool
+}
+
+// NewRepeat play an animation times times, 0 for forever
+func NewRepeat(a Animation, times int) *Repeat {
+	return &Repeat{Animation: a, Times: times}
+}
+
+// Step see Animation
+func (r *Repeat) Step(dt float64) (float64, bool) {
+	if r.done {
+		return dt, true
+	}
+	for {
+		left, done := r.Animation.Step(dt)
+		if !done {
+			return 0, false
+		}
+		r.count++
+		if r.Times > 0 && r.count >= r.Times {
+			r.done = true
+			return left, true
+		}
+		r.Animation.Restart()
+		// Something that takes no time would go round forever, so it
+		// goes round once a step
+		if left >= dt {
+			return 0, false
+		}
+		dt = left
+	}
+}
+
+// Restart see Animation
+func (r *Repeat) Restart() {
+	r.count = 0
+	r.done = false
+	r.Animation.Restart()
+}
@@ -0,0 +1,73 @@
+package tween_test
+
+import (
+	"testing"
+
+	"github.com/robrohan/mesh/internal/tween"
+)
+
+func TestSequence(t *testing.T) {
+	x, y := 0.0, 0.0
+	calls := 0
+	s := tween.NewSequence(
+		tween.Float(&x, 1, 1),
+		tween.Wait(1),
+		tween.Call(func() { calls++ }),
+		tween.Float(&y, 2, 1),
+	)
+	s.Step(1.5)
+	if x != 1 || y != 0 || calls != 0 {
+		t.Errorf("in the wait: x %v y %v calls %v", x, y, calls)
+	}
+	// What's left of the wait runs on into the last step
+	s.Step(1)
+	if calls != 1 || y != 1 {
+		t.Errorf("after the call: y %v calls %v", y, calls)
+	}
+	left, done := s.Step(1)
+	if !done || left != 0.5 || y != 2 {
+		t.Errorf("end: done %v left %v y %v", done, left, y)
+	}
+
+	s.Restart()
+	x, y = 0, 0
+	s.Step(0.5)
+	if x != 0.5 {
+		t.Errorf("restart: got %v", x)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	x, y := 0.0, 0.0
+	g := tween.NewGroup(tween.Float(&x, 1, 1), tween.Float(&y, 1, 2))
+	g.Step(1.5)
+	if x != 1 || y != 0.75 {
+		t.Errorf("together: x %v y %v", x, y)
+	}
+	left, done := g.Step(1)
+	if !done || left != 0.5 || y != 1 {
+		t.Errorf("finishes with the longest: done %v left %v", done, left)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	x := 0.0
+	up := tween.NewSequence(tween.Call(func() { x = 0 }), tween.Float(&x, 1, 1))
+	r := tween.NewRepeat(up, 3)
+	r.Step(2.5)
+	if x != 0.5 {
+		t.Errorf("third time through: got %v", x)
+	}
+	if _, done := r.Step(1); !done {
+		t.Errorf("should finish after three")
+	}
+
+	// Something that takes no time goes round once a step
+	calls := 0
+	forever := tween.NewRepeat(tween.Call(func() { calls++ }), 0)
+	forever.Step(1)
+	forever.Step(1)
+	if calls != 2 {
+		t.Errorf("instant repeat: %v calls, want 2", calls)
+	}
+}
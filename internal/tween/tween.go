@@ -0,0 +1,241 @@
+// Package tween simple animation for things that aren't skinned, like
+// doors, platforms and UI: tweens move a value (a position, a rotation,
+// a material's color) to a target over time with an easing curve, and
+// sequences and groups string them together. ComponentTween plays them
+// on an entity
+package tween
+
+import (
+	"math"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+)
+
+// Animation something that plays over time: a Tween, Sequence, Group or
+// Repeat
+type Animation interface {
+	// Step move on dt seconds. Once it's finished it returns true and
+	// the part of dt it didn't need, so what comes next can use it
+	Step(dt float64) (float64, bool)
+	// Restart go back to the beginning
+	Restart()
+}
+
+// Mode what a tween does when it gets to the end
+type Mode int
+
+const (
+	// Once stop at the end
+	Once Mode = iota
+	// Loop jump back to the start and go again
+	Loop
+	// PingPong go back to the start the way it came, then forward again
+	PingPong
+)
+
+// Tween one thing moving from where it is to somewhere else
+type Tween struct {
+	// Duration how long it takes to get there in seconds
+	Duration float64
+	// Delay how long to wait before starting
+	Delay float64
+	// Ease how it moves, nil is Linear
+	Ease Ease
+	Mode Mode
+	// Repeat how many times Loop and PingPong go through (there and back
+	// is 2) before finishing, 0 is forever
+	Repeat int
+	// OnComplete called when it finishes
+	OnComplete func()
+
+	// begin take where the value starts from
+	begin func()
+	// set put the value t (eased) of the way along
+	set     func(t float64)
+	elapsed float64
+	began   bool
+	done    bool
+}
+
+// Step see Animation
+func (t *Tween) Step(dt float64) (float64, bool) {
+	if t.done {
+		return dt, true
+	}
+	t.elapsed += dt
+	if t.elapsed < t.Delay {
+		return 0, false
+	}
+	if !t.began {
+		t.began = true
+		if t.begin != nil {
+			t.begin()
+		}
+	}
+
+	active := t.elapsed - t.Delay
+	end := t.Duration
+	if t.Mode != Once {
+		end = math.Inf(1)
+		if t.Repeat > 0 {
+			end = t.Duration * float64(t.Repeat)
+		}
+	}
+	if t.Duration <= 0 || active >= end {
+		t.apply(t.finalPosition())
+		t.done = true
+		if t.OnComplete != nil {
+			t.OnComplete()
+		}
+		if t.Duration <= 0 {
+			return active, true
+		}
+		return active - end, true
+	}
+
+	f := active / t.Duration
+	cycle := math.Floor(f)
+	f -= cycle
+	if t.Mode == PingPong && int(cycle)%2 == 1 {
+		f = 1 - f
+	}
+	t.apply(f)
+	return 0, false
+}
+
+// finalPosition where the tween ends: the far end, unless it ping-pongs
+// back to the start
+func (t *Tween) finalPosition() float64 {
+	if t.Mode == PingPong && t.Repeat%2 == 0 {
+		return 0
+	}
+	return 1
+}
+
+// apply set the value f of the way through, eased
+func (t *Tween) apply(f float64) {
+	if t.set == nil {
+		return
+	}
+	if t.Ease != nil {
+		f = t.Ease(f)
+	}
+	t.set(f)
+}
+
+// Restart see Animation. It starts again from wherever the value is then
+func (t *Tween) Restart() {
+	t.elapsed = 0
+	t.began = false
+	t.done = false
+}
+
+// Done whether it's finished
+func (t *Tween) Done() bool {
+	return t.done
+}
+
+// Vector tween a vector, like a position, a scale or a material color,
+// to another in a straight line
+func Vector(target *algebra.Vector, to algebra.Vector, duration float64) *Tween {
+	from := algebra.Vector{}
+	return &Tween{
+		Duration: duration,
+		begin:    func() { from = *target },
+		set: func(t float64) {
+			*target = algebra.Vector{
+				X: from.X + (to.X-from.X)*t,
+				Y: from.Y + (to.Y-from.Y)*t,
+				Z: from.Z + (to.Z-from.Z)*t,
+				W: from.W + (to.W-from.W)*t,
+			}
+		},
+	}
+}
+
+// Quaternion tween a rotation to another, slerping the shortest way
+// round. An unset rotation (all 0) is no rotation
+func Quaternion(target *algebra.Quaternion, to algebra.Quaternion, duration float64) *Tween {
+	from := algebra.Quaternion{}
+	return &Tween{
+		Duration: duration,
+		begin: func() {
+			from = *target
+			if from == (algebra.Quaternion{}) {
+				from = algebra.QuaternionIdentity
+			}
+		},
+		set: func(t float64) { from.Slerp(to, t, target) },
+	}
+}
+
+// Float tween a number
+func Float(target *float64, to float64, duration float64) *Tween {
+	from := 0.0
+	return &Tween{
+		Duration: duration,
+		begin:    func() { from = *target },
+		set:      func(t float64) { *target = from + (to-from)*t },
+	}
+}
+
+// Float32 tween a float32, like a material's Transparent
+func Float32(target *float32, to float32, duration float64) *Tween {
+	from := float32(0)
+	return &Tween{
+		Duration: duration,
+		begin:    func() { from = *target },
+		set:      func(t float64) { *target = from + (to-from)*float32(t) },
+	}
+}
+
+// Position tween a transform's position
+func Position(target *core.Transform, to algebra.Vector, duration float64) *Tween {
+	return Vector(&target.Position, to, duration)
+}
+
+// Rotation tween a transform's rotation
+func Rotation(target *core.Transform, to algebra.Quaternion, duration float64) *Tween {
+	return Quaternion(&target.Rotation, to, duration)
+}
+
+// Scale tween a transform's scale
+func Scale(target *core.Transform, to algebra.Vector, duration float64) *Tween {
+	return Vector(&target.Scale, to, duration)
+}
+
+// Keyframes play keyframe tracks on a transform: translation tracks move
+// its Position, rotation tracks its Rotation and scale tracks its Scale.
+// It lasts as long as the last key, and the tracks' Joint is ignored
+func Keyframes(target *core.Transform, tracks []anim.Track) *Tween {
+	clip := anim.Clip{Tracks: tracks}
+	length := clip.Length()
+	return &Tween{
+		Duration: length,
+		set: func(t float64) {
+			for i := 0; i < len(tracks); i++ {
+				v := tracks[i].Sample(t * length)
+				switch tracks[i].Property {
+				case anim.Translation:
+					target.Position = algebra.Vector{X: v.X, Y: v.Y, Z: v.Z}
+				case anim.Rotation:
+					target.Rotation = algebra.Quaternion(v)
+				case anim.Scale:
+					target.Scale = algebra.Vector{X: v.X, Y: v.Y, Z: v.Z}
+				}
+			}
+		},
+	}
+}
+
+// Wait do nothing for a while, to space out a sequence
+func Wait(seconds float64) *Tween {
+	return &Tween{Duration: seconds}
+}
+
+// Call call fn, at its place in a sequence
+func Call(fn func()) *Tween {
+	return &Tween{OnComplete: fn}
+}
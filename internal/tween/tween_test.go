@@ -0,0 +1,104 @@
+package tween_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/robrohan/mesh/internal/algebra"
+	"github.com/robrohan/mesh/internal/anim"
+	"github.com/robrohan/mesh/internal/core"
+	"github.com/robrohan/mesh/internal/tween"
+)
+
+func TestTween(t *testing.T) {
+	tr := core.NewTransform()
+	tr.Position = algebra.Vector{X: 1}
+	tw := tween.Position(tr, algebra.Vector{X: 3}, 2)
+	tw.Delay = 1
+	completed := 0
+	tw.OnComplete = func() { completed++ }
+
+	tw.Step(0.5)
+	if tr.Position.X != 1 {
+		t.Errorf("delay: moved to %v", tr.Position.X)
+	}
+	// Starts from where the position is when the delay is up
+	tr.Position.X = 0
+	tw.Step(1.5)
+	if tr.Position.X != 1.5 {
+		t.Errorf("half way: got %v, want 1.5", tr.Position.X)
+	}
+	left, done := tw.Step(1.5)
+	if !done || left != 0.5 || tr.Position.X != 3 || completed != 1 {
+		t.Errorf("end: left %v done %v at %v completed %v", left, done, tr.Position.X, completed)
+	}
+	if _, done := tw.Step(1); !done || completed != 1 {
+		t.Errorf("should stay done and complete once")
+	}
+
+	tw.Restart()
+	tw.Ease = tween.InQuad
+	tw.Step(2)
+	if tr.Position.X != 3 {
+		t.Errorf("restart starts from where it is, got %v", tr.Position.X)
+	}
+}
+
+func TestTweenModes(t *testing.T) {
+	x := 0.0
+	loop := tween.Float(&x, 1, 1)
+	loop.Mode = tween.Loop
+	loop.Step(2.25)
+	if x != 0.25 || loop.Done() {
+		t.Errorf("loop: got %v", x)
+	}
+
+	x = 0
+	pingPong := tween.Float(&x, 1, 1)
+	pingPong.Mode = tween.PingPong
+	pingPong.Repeat = 2
+	pingPong.Step(1.25)
+	if x != 0.75 {
+		t.Errorf("ping pong on the way back: got %v, want 0.75", x)
+	}
+	left, done := pingPong.Step(1)
+	if !done || x != 0 || left != 0.25 {
+		t.Errorf("there and back: done %v at %v left %v", done, x, left)
+	}
+
+	var f float32
+	tween.Float32(&f, 4, 0).Step(0)
+	if f != 4 {
+		t.Errorf("no duration goes straight there, got %v", f)
+	}
+}
+
+func TestTweenRotation(t *testing.T) {
+	tr := core.NewTransform()
+	axis := algebra.Vector{Y: 1}
+	to := algebra.Quaternion{}
+	to.SetFromVector(&axis, math.Pi/2)
+	tw := tween.Rotation(tr, to, 1)
+	tw.Step(0.5)
+
+	want := algebra.Quaternion{}
+	want.SetFromVector(&axis, math.Pi/4)
+	if math.Abs(math.Abs(tr.Rotation.Dot(want))-1) > 1e-9 {
+		t.Errorf("an unset rotation should slerp from none: got %v, want %v", tr.Rotation, want)
+	}
+}
+
+func TestKeyframes(t *testing.T) {
+	tr := core.NewTransform()
+	tw := tween.Keyframes(tr, []anim.Track{
+		{Property: anim.Translation, Times: []float64{0, 2}, Values: []algebra.Vector{{}, {Y: 4}}},
+		{Property: anim.Scale, Times: []float64{0, 1}, Values: []algebra.Vector{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 2, Z: 2}}},
+	})
+	if tw.Duration != 2 {
+		t.Fatalf("should last until the last key, got %v", tw.Duration)
+	}
+	tw.Step(1)
+	if tr.Position.Y != 2 || tr.Scale.X != 2 {
+		t.Errorf("at 1s: position %v scale %v", tr.Position, tr.Scale)
+	}
+}